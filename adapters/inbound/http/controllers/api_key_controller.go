@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/handlers"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/helpers"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/problems"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/requests"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/responses"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/services"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyController handles the HTTP requests managing the personal API keys of a user.
+type APIKeyController struct {
+	service *services.APIKeyService
+}
+
+// NewAPIKeyController creates and returns a new instance of APIKeyController.
+func NewAPIKeyController(a *services.APIKeyService) *APIKeyController {
+	return &APIKeyController{service: a}
+}
+
+// CreateAPIKey handles the HTTP request creating an API key for the user identified by
+// the "id" parameter. The response carries the full key in the "key" field; it is the
+// only time the key is shown.
+func (a *APIKeyController) CreateAPIKey(c *gin.Context) {
+	var input requests.CreateAPIKeyRequest
+
+	uid, err := helpers.ParseUUID(c.Param("id"))
+	if err != nil {
+		c.Error(problems.InvalidRequest(err.Error()))
+		return
+	}
+
+	if err := handlers.ShouldBindJSON(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	scopes := make([]domain.APIScope, len(input.Scopes))
+	for i, scope := range input.Scopes {
+		scopes[i] = domain.APIScope(scope)
+	}
+
+	key, secret, err := a.service.CreateAPIKey(c, uid, input.Name, scopes)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, responses.CreatedAPIKey{Key: secret, APIKey: responses.NewAPIKey(key)})
+}
+
+// FindUserAPIKeys handles the HTTP request listing the API keys of the user. Keys are
+// identified by their prefix; their secret cannot be retrieved.
+func (a *APIKeyController) FindUserAPIKeys(c *gin.Context) {
+	uid, err := helpers.ParseUUID(c.Param("id"))
+	if err != nil {
+		c.Error(problems.InvalidRequest(err.Error()))
+		return
+	}
+
+	keys, err := a.service.FindUserAPIKeys(c, uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.NewAPIKeys(keys))
+}
+
+// RevokeAPIKey handles the HTTP request revoking the API key identified by "key_id".
+func (a *APIKeyController) RevokeAPIKey(c *gin.Context) {
+	ids, ok := helpers.ValidateUUIDParams(c, "id", "key_id")
+	if !ok {
+		c.Error(problems.InvalidRequest("invalid user or api key ID"))
+		return
+	}
+
+	if err := a.service.RevokeAPIKey(c, ids[0], ids[1]); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/handlers"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/requests"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/responses"
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/services"
+	"github.com/gin-gonic/gin"
+)
+
+// AuthController handles the HTTP requests used to log in and to refresh tokens.
+type AuthController struct {
+	service *services.AuthService
+}
+
+// NewAuthController creates and returns a new instance of AuthController.
+func NewAuthController(a *services.AuthService) *AuthController {
+	return &AuthController{service: a}
+}
+
+// Login handles the HTTP request exchanging an email and a password for a pair of
+// access and refresh tokens. It responds with 422 when the payload is invalid and
+// with 401 when the credentials are wrong or the second factor is missing or wrong.
+// Users who did not confirm their email address yet get 403.
+func (a *AuthController) Login(c *gin.Context) {
+	var input requests.LoginRequest
+
+	if err := handlers.ShouldBindJSON(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	tokens, err := a.service.Login(c, input.Email, input.Password, input.OTP)
+	if err != nil {
+		c.Error(authenticationError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.NewTokens(tokens))
+}
+
+// Refresh handles the HTTP request exchanging a refresh token for a new pair of
+// tokens. It responds with 401 when the refresh token is invalid or expired.
+func (a *AuthController) Refresh(c *gin.Context) {
+	var input requests.RefreshTokenRequest
+
+	if err := handlers.ShouldBindJSON(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	tokens, err := a.service.Refresh(c, input.RefreshToken)
+	if err != nil {
+		c.Error(authenticationError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.NewTokens(tokens))
+}
+
+// authenticationError marks the invalid codes and tokens of a login or a refresh as
+// authentication failures, answered with 401, while the same errors are validation
+// errors of the other requests.
+func authenticationError(err error) error {
+	if errors.Is(err, core.ErrInvalidOTP) || errors.Is(err, core.ErrInvalidToken) {
+		return fmt.Errorf("%w: %w", core.ErrUnauthenticated, err)
+	}
+
+	return err
+}
@@ -0,0 +1,236 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/helpers"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/problems"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/responses"
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/services"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// resetEvent is the type of the message telling a client that its stream could not
+// resume after the last event it received, so it should reload its tasks.
+const resetEvent = "reset"
+
+// EventController streams the events of a user to their clients, as server-sent
+// events or over a WebSocket, so they follow the changes of their tasks without
+// polling.
+type EventController struct {
+	bus       *services.EventBus
+	heartbeat time.Duration
+	upgrader  websocket.Upgrader
+}
+
+// NewEventController creates and returns a new instance of EventController streaming
+// the events of the given EventBus. Idle streams get a heartbeat every heartbeat,
+// which also bounds how long a write to a client may take.
+func NewEventController(b *services.EventBus, heartbeat time.Duration) *EventController {
+	return &EventController{bus: b, heartbeat: heartbeat}
+}
+
+// StreamEvents handles HTTP GET requests streaming the events of a user as
+// server-sent events. Each event carries its ID, its type and the JSON of the event;
+// a client reconnecting with the Last-Event-ID header, or the last_event_id query
+// parameter, gets the events it missed first, or a "reset" event when they are no
+// longer available. Comments are sent as heartbeats. The stream ends when the client
+// falls too far behind or the server shuts down, and the client then reconnects.
+func (e *EventController) StreamEvents(c *gin.Context) {
+	sub, ok := e.subscribe(c, c.GetHeader("Last-Event-ID"))
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	// The stream outlives the read and write timeouts of the server; each write
+	// gets its own deadline instead.
+	rc := http.NewResponseController(c.Writer)
+	rc.SetReadDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	write := func(format string, args ...any) bool {
+		rc.SetWriteDeadline(time.Now().Add(e.heartbeat))
+		if _, err := fmt.Fprintf(c.Writer, format, args...); err != nil {
+			return false
+		}
+		c.Writer.Flush()
+		return true
+	}
+
+	if !write(": connected\n\n") {
+		return
+	}
+	if sub.Reset && !write("event: %s\ndata: {\"type\":%q}\n\n", resetEvent, resetEvent) {
+		return
+	}
+
+	send := func(event domain.Event) bool {
+		data, err := json.Marshal(responses.NewEvent(&event))
+		if err != nil {
+			return false
+		}
+		return write("id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	}
+
+	for _, event := range sub.Replay {
+		if !send(event) {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(e.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.Events:
+			if !ok || !send(event) {
+				return
+			}
+		case <-heartbeat.C:
+			if !write(": heartbeat\n\n") {
+				return
+			}
+		}
+	}
+}
+
+// StreamEventsWebSocket handles HTTP GET requests upgraded to a WebSocket streaming
+// the events of a user as JSON text messages. A client reconnecting with the
+// last_event_id query parameter gets the events it missed first, or a message of
+// type "reset" when they are no longer available. The server pings the client every
+// heartbeat and closes the connection when it stops answering, with the status
+// 1013 (try again later) when the client falls too far behind and 1001 (going away)
+// when the server shuts down. Messages sent by the client are ignored.
+func (e *EventController) StreamEventsWebSocket(c *gin.Context) {
+	if !websocket.IsWebSocketUpgrade(c.Request) {
+		c.Error(problems.InvalidRequest("expected a WebSocket upgrade"))
+		return
+	}
+
+	sub, ok := e.subscribe(c, "")
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	// Upgrade answers the failed handshakes itself.
+	conn, err := e.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+
+		conn.SetReadLimit(512)
+		conn.SetReadDeadline(time.Now().Add(2 * e.heartbeat))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * e.heartbeat))
+		})
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(message any) bool {
+		conn.SetWriteDeadline(time.Now().Add(e.heartbeat))
+		return conn.WriteJSON(message) == nil
+	}
+
+	if sub.Reset && !send(gin.H{"type": resetEvent}) {
+		return
+	}
+	for _, event := range sub.Replay {
+		if !send(responses.NewEvent(&event)) {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(e.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-gone:
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				e.closeWebSocket(conn, sub.Err())
+				return
+			}
+			if !send(responses.NewEvent(&event)) {
+				return
+			}
+		case <-heartbeat.C:
+			if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(e.heartbeat)) != nil {
+				return
+			}
+		}
+	}
+}
+
+// subscribe opens the subscription of the user of the request to the events,
+// resuming after the event ID in lastEventID or in the last_event_id query
+// parameter. It answers the request with the error and reports false when it fails.
+func (e *EventController) subscribe(c *gin.Context, lastEventID string) (*services.Subscription, bool) {
+	params, ok := helpers.ValidateUUIDParams(c, "id")
+	if !ok {
+		c.Error(problems.InvalidRequest("invalid user ID"))
+		return nil, false
+	}
+
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+
+	var after uint64
+	if lastEventID != "" {
+		id, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			c.Error(problems.InvalidRequest("invalid last event ID"))
+			return nil, false
+		}
+		after = id
+	}
+
+	sub, err := e.bus.Subscribe(c, params[0], after)
+	if err != nil {
+		c.Error(err)
+		return nil, false
+	}
+
+	return sub, true
+}
+
+// closeWebSocket closes the connection with the status telling the client why its
+// subscription ended.
+func (e *EventController) closeWebSocket(conn *websocket.Conn, err error) {
+	code, reason := websocket.CloseNormalClosure, ""
+	switch err {
+	case core.ErrEventSubscriberTooSlow:
+		code, reason = websocket.CloseTryAgainLater, err.Error()
+	case core.ErrEventBusClosed:
+		code, reason = websocket.CloseGoingAway, "server shutting down"
+	}
+
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(e.heartbeat))
+}
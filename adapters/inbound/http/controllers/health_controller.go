@@ -7,24 +7,69 @@ package controllers
 import (
 	"net/http"
 
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/services"
 	"github.com/gin-gonic/gin"
 )
 
 // HealthController is a struct that serves as a controller for handling
-// health check-related HTTP requests. It is typically used to provide
-// endpoints that allow clients to verify the application's availability
-// and operational status.
-type HealthController struct{}
-
-// NewHealthController creates and returns a new instance of HealthController.
-// This function initializes the HealthController struct and is typically used
-// to set up the controller for handling health-related HTTP requests.
-func NewHealthController() *HealthController {
-	return &HealthController{}
+// health check-related HTTP requests. It provides the liveness and readiness
+// probes used by the orchestrator to restart the application and to route
+// traffic to it.
+type HealthController struct {
+	HealthService *services.HealthService
 }
 
-// HealthCheck is a handler method for checking the health status of the application.
-// It responds with an HTTP 200 status code and a JSON message indicating that the service is operational.
-func (h *HealthController) HealthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "OK"})
+// dependencyHealthResponse is the status of a dependency with its latency in milliseconds.
+type dependencyHealthResponse struct {
+	domain.DependencyHealth
+	LatencyMS float64 `json:"latency_ms"`
+}
+
+// healthResponse is the JSON body of the probes.
+type healthResponse struct {
+	Status       domain.HealthStatus                 `json:"status"`
+	ShuttingDown bool                                `json:"shutting_down,omitempty"`
+	Checks       map[string]dependencyHealthResponse `json:"checks,omitempty"`
+}
+
+// NewHealthController creates and returns a new instance of HealthController
+// using the provided HealthService.
+func NewHealthController(h *services.HealthService) *HealthController {
+	return &HealthController{HealthService: h}
+}
+
+// Livez is a handler method for the liveness probe. It responds with an HTTP 200
+// status code as long as the application answers requests.
+func (h *HealthController) Livez(c *gin.Context) {
+	respondHealth(c, h.HealthService.Live(c.Request.Context()))
+}
+
+// Readyz is a handler method for the readiness probe. It responds with an HTTP 200
+// status code and the status and latency of each dependency when all of them are
+// up, and with 503 when one is down or the application is shutting down.
+func (h *HealthController) Readyz(c *gin.Context) {
+	respondHealth(c, h.HealthService.Ready(c.Request.Context()))
+}
+
+// respondHealth writes the health report, with 503 when the application is down.
+func respondHealth(c *gin.Context, report *domain.HealthReport) {
+	response := healthResponse{Status: report.Status, ShuttingDown: report.ShuttingDown}
+	if len(report.Checks) > 0 {
+		response.Checks = make(map[string]dependencyHealthResponse, len(report.Checks))
+		for name, check := range report.Checks {
+			response.Checks[name] = dependencyHealthResponse{
+				DependencyHealth: check,
+				LatencyMS:        float64(check.Latency.Microseconds()) / 1000,
+			}
+		}
+	}
+
+	status := http.StatusOK
+	if report.Status != domain.HealthStatusUp {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, response)
 }
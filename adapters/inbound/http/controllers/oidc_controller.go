@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/handlers"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/requests"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/responses"
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/auth"
+	"github.com/fabianoflorentino/gotostudy/core/services"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// oidcFlowCookie keeps the secrets of a login between the redirect to the
+	// identity provider and its callback.
+	oidcFlowCookie = "gts_oidc_flow"
+	// oidcFlowMaxAge is the time, in seconds, a user has to sign in with the provider.
+	oidcFlowMaxAge = 10 * 60
+)
+
+// OIDCController handles the single sign-on through the OpenID Connect provider.
+type OIDCController struct {
+	service *services.OIDCService
+}
+
+// NewOIDCController creates and returns a new instance of OIDCController.
+func NewOIDCController(o *services.OIDCService) *OIDCController {
+	return &OIDCController{service: o}
+}
+
+// Login handles the HTTP request starting a single sign-on in the workspace of the
+// request. The secrets of the flow are kept in an HttpOnly cookie and the browser
+// is redirected to the identity provider.
+func (o *OIDCController) Login(c *gin.Context) {
+	flow, err := o.service.StartLogin(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	value, err := json.Marshal(flow)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	o.setFlowCookie(c, base64.RawURLEncoding.EncodeToString(value), oidcFlowMaxAge)
+	c.Redirect(http.StatusFound, flow.URL)
+}
+
+// Callback handles the redirect back from the identity provider. It responds with
+// the token pair of the signed in user, with 401 when the flow is invalid or the
+// sign in failed, with 403 when the provider did not verify the email and with 409
+// when the email belongs to a user of another workspace. Users with two-factor
+// authentication get 202 and a challenge to complete with CompleteTwoFactor.
+func (o *OIDCController) Callback(c *gin.Context) {
+	flow := o.flowFromCookie(c)
+	o.setFlowCookie(c, "", -1)
+
+	if reason := c.Query("error"); reason != "" {
+		c.Error(fmt.Errorf("%w: %s", core.ErrSingleSignOn, reason))
+		return
+	}
+
+	tokens, challenge, err := o.service.CompleteLogin(c, flow, c.Query("state"), c.Query("code"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if challenge != nil {
+		c.JSON(http.StatusAccepted, responses.NewTwoFactorChallenge(challenge))
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.NewTokens(tokens))
+}
+
+// CompleteTwoFactor handles the HTTP request completing a single sign-on answered
+// with a two-factor challenge. It responds with the token pair of the user, with 422
+// when the payload is invalid and with 401 when the challenge is invalid or expired
+// or the code is wrong.
+func (o *OIDCController) CompleteTwoFactor(c *gin.Context) {
+	var input requests.TwoFactorChallengeRequest
+
+	if err := handlers.ShouldBindJSON(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	tokens, err := o.service.CompleteTwoFactor(c, input.ChallengeToken, input.OTP)
+	if err != nil {
+		c.Error(authenticationError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.NewTokens(tokens))
+}
+
+// flowFromCookie returns the flow stored by Login, or nil when the cookie is
+// missing or malformed.
+func (o *OIDCController) flowFromCookie(c *gin.Context) *auth.LoginFlow {
+	value, err := c.Cookie(oidcFlowCookie)
+	if err != nil {
+		return nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil
+	}
+
+	var flow auth.LoginFlow
+	if err := json.Unmarshal(raw, &flow); err != nil {
+		return nil
+	}
+
+	return &flow
+}
+
+// setFlowCookie stores the flow cookie, or deletes it when maxAge is negative. The
+// cookie is Lax so the browser sends it on the top-level redirect from the provider.
+// Its path is the one of the routes of the flow, registered next to each other under
+// each version of the API, so the callback of the version of the login receives it.
+func (o *OIDCController) setFlowCookie(c *gin.Context, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcFlowCookie, value, maxAge, path.Dir(c.FullPath()), "", c.Request.TLS != nil, true)
+}
@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPIController serves the OpenAPI document of the API and the page rendering
+// it.
+type OpenAPIController struct {
+	document []byte
+}
+
+// NewOpenAPIController creates an OpenAPIController, converting the OpenAPI
+// document to JSON once.
+func NewOpenAPIController() (*OpenAPIController, error) {
+	document, err := openapi.JSON()
+	if err != nil {
+		return nil, err
+	}
+
+	return &OpenAPIController{document: document}, nil
+}
+
+// Document handles the HTTP request for the OpenAPI document, answered as JSON.
+func (o *OpenAPIController) Document(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", o.document)
+}
+
+// Docs handles the HTTP request for the page rendering the OpenAPI document.
+func (o *OpenAPIController) Docs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", openapi.DocsPage)
+}
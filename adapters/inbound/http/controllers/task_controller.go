@@ -1,13 +1,19 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/handlers"
 	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/helpers"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/problems"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/requests"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/responses"
+	"github.com/fabianoflorentino/gotostudy/core"
 	"github.com/fabianoflorentino/gotostudy/core/domain"
 	"github.com/fabianoflorentino/gotostudy/core/services"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // TaskController handles HTTP requests related to task operations by interacting with the TaskService.
@@ -23,66 +29,105 @@ func NewTaskController(t *services.TaskService) *TaskController {
 
 // CreateTask handles the HTTP request to create a new task for a specific user.
 // It expects a JSON payload with the task details in the request body and a user ID as a URL parameter.
-// If the request body is invalid or the user ID is not a valid UUID, it responds with a 400 Bad Request.
-// If the task creation fails, it responds with a 422 Unprocessable Entity and the error message.
+// If the user ID is not a valid UUID, it responds with a 400 Bad Request, and with a 422 Unprocessable
+// Entity listing the invalid fields when the body is invalid.
+// If the task creation fails, it responds with the problem details of the error.
 // On success, it responds with a 201 Created status and the created task in the response body.
 func (t *TaskController) CreateTask(c *gin.Context) {
+	var input requests.TaskRequest
 
-	var task = &domain.Task{}
-
-	if err := handlers.ShouldBindJSON(c, &task); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request, title and description are required"})
+	if err := handlers.ShouldBindJSON(c, &input); err != nil {
+		c.Error(err)
 		return
 	}
 
 	params, ok := helpers.ValidateUUIDParams(c, "id")
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid userID"})
+		c.Error(problems.InvalidRequest("invalid user ID"))
 		return
 	}
 
 	userID := params[0]
+	task := &domain.Task{Title: input.Title, Description: input.Description, Completed: input.Completed}
 
 	if _, err := t.task.CreateTask(c, userID, task); err != nil {
-		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, task)
+	c.JSON(http.StatusCreated, responses.NewTask(task))
 }
 
 // FindUserTasks handles HTTP requests to retrieve all tasks associated with a specific user.
 // It parses the user ID from the request parameters, validates it, and then fetches the user's tasks.
-// If the user ID is invalid, it responds with HTTP 400 Bad Request.
-// If an error occurs while retrieving tasks, it responds with HTTP 422 Unprocessable Entity.
+// The optional "scope" query parameter selects which tasks are returned: "owned" (default) for the
+// tasks the user created, "shared" for the tasks other users shared with them, or "all" for both.
+// If the user ID or the scope is invalid, it responds with HTTP 400 Bad Request.
+// Users without tasks get an empty list; other errors are answered with problem details.
 // On success, it responds with HTTP 200 OK and the list of tasks.
 func (t *TaskController) FindUserTasks(c *gin.Context) {
 	params, ok := helpers.ValidateUUIDParams(c, "id")
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.Error(problems.InvalidRequest("invalid user ID"))
 		return
 	}
 
 	userID := params[0]
 
-	tasks, err := t.task.FindUserTasks(c, userID)
+	var (
+		tasks []*domain.Task
+		err   error
+	)
+
+	switch c.DefaultQuery("scope", "owned") {
+	case "owned":
+		tasks, err = t.task.FindUserTasks(c, userID)
+	case "shared":
+		tasks, err = t.task.FindSharedTasks(c, userID)
+	case "all":
+		tasks, err = t.findAllUserTasks(c, userID)
+	default:
+		c.Error(problems.InvalidRequest("invalid scope, use owned, shared or all"))
+		return
+	}
+
+	if errors.Is(err, core.ErrNoTasksFound) {
+		tasks, err = []*domain.Task{}, nil
+	}
 	if err != nil {
-		c.JSON(http.StatusOK, gin.H{"message": "user not have tasks"})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, tasks)
+	c.JSON(http.StatusOK, responses.NewTasks(tasks))
+}
+
+// findAllUserTasks combines the tasks owned by the user with the tasks shared with them.
+// A user without tasks in one of the lists is not an error.
+func (t *TaskController) findAllUserTasks(c *gin.Context, userID uuid.UUID) ([]*domain.Task, error) {
+	owned, err := t.task.FindUserTasks(c, userID)
+	if err != nil && !errors.Is(err, core.ErrNoTasksFound) {
+		return nil, err
+	}
+
+	shared, err := t.task.FindSharedTasks(c, userID)
+	if err != nil && !errors.Is(err, core.ErrNoTasksFound) {
+		return nil, err
+	}
+
+	return append(owned, shared...), nil
 }
 
 // FindTaskByID handles HTTP requests to retrieve a specific task by its ID for a given user.
 // It expects "id" (user ID) and "task_id" (task ID) as URL parameters.
 // If the parameters are invalid UUIDs, it responds with HTTP 400 Bad Request.
-// If the task cannot be found or another error occurs, it responds with HTTP 422 Unprocessable Entity.
+// If the task cannot be found, it responds with HTTP 404 Not Found, and with HTTP 403 Forbidden
+// when the user has no access to it.
 // On success, it responds with HTTP 200 OK and the task data in JSON format.
 func (t *TaskController) FindTaskByID(c *gin.Context) {
 	params, ok := helpers.ValidateUUIDParams(c, "id", "task_id")
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user or task ID"})
+		c.Error(problems.InvalidRequest("invalid user or task ID"))
 		return
 	}
 
@@ -91,38 +136,217 @@ func (t *TaskController) FindTaskByID(c *gin.Context) {
 
 	task, err := t.task.FindTaskByID(c, userID, taskID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "task not found"})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, task)
+	c.JSON(http.StatusOK, responses.NewTask(task))
 }
 
 // UpdateTask handles HTTP PUT requests to update an existing task for a specific user.
-// It parses the user ID and task ID from the URL parameters, binds the request body to a Task struct,
+// It parses the user ID and task ID from the URL parameters, binds the request body to a TaskRequest,
 // and calls the service layer to update the task. Returns appropriate HTTP status codes and error messages
 // for invalid input or update failures.
 func (t *TaskController) UpdateTask(c *gin.Context) {
-	var task domain.Task
+	var input requests.TaskRequest
 
 	params, ok := helpers.ValidateUUIDParams(c, "id", "task_id")
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user or task ID"})
+		c.Error(problems.InvalidRequest("invalid user or task ID"))
 		return
 	}
 
-	if err := handlers.ShouldBindJSON(c, &task); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request, title and description are required"})
+	if err := handlers.ShouldBindJSON(c, &input); err != nil {
+		c.Error(err)
 		return
 	}
 
 	userID := params[0]
 	taskID := params[1]
 
-	if err := t.task.UpdateTask(c, userID, taskID, &task); err != nil {
-		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	task := &domain.Task{Title: input.Title, Description: input.Description, Completed: input.Completed}
+
+	if err := t.task.UpdateTask(c, userID, taskID, task); err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusNoContent, gin.H{})
 }
+
+// PatchTask handles HTTP PATCH requests to update some fields of a task. The body is
+// a JSON merge patch or a JSON patch of the title, the description and the completion
+// of the task, as accepted by PUT, selected by the Content-Type header. The patch
+// applies to the current task and the result is validated as a PUT body before it
+// is saved, so either the whole patch is saved or nothing. On success, it responds
+// with HTTP 200 OK and the patched task.
+func (t *TaskController) PatchTask(c *gin.Context) {
+	params, ok := helpers.ValidateUUIDParams(c, "id", "task_id")
+	if !ok {
+		c.Error(problems.InvalidRequest("invalid user or task ID"))
+		return
+	}
+
+	userID := params[0]
+	taskID := params[1]
+
+	task, err := t.task.PatchTask(c, userID, taskID, func(task *domain.Task) error {
+		input := requests.TaskRequest{Title: task.Title, Description: task.Description, Completed: task.Completed}
+		if err := handlers.ShouldBindPatch(c, &input); err != nil {
+			return err
+		}
+
+		task.Title = input.Title
+		task.Description = input.Description
+		task.Completed = input.Completed
+		return nil
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.NewTask(task))
+}
+
+// DeleteTask handles HTTP DELETE requests to remove a task. Only the owner of the task
+// can delete it; collaborators receive HTTP 403 Forbidden.
+// On success, it responds with HTTP 204 No Content.
+func (t *TaskController) DeleteTask(c *gin.Context) {
+	params, ok := helpers.ValidateUUIDParams(c, "id", "task_id")
+	if !ok {
+		c.Error(problems.InvalidRequest("invalid user or task ID"))
+		return
+	}
+
+	userID := params[0]
+	taskID := params[1]
+
+	if err := t.task.DeleteTask(c, userID, taskID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// BatchTasks handles HTTP POST requests applying a batch of create, update, delete and
+// complete operations to the tasks of a user, as POST /users/:id/tasks/batch. Each
+// operation follows the rules of its own route. With "atomic" set, the operations
+// run in one transaction and none is applied when one fails; otherwise each one
+// succeeds or fails on its own. The request is rejected as a whole with a 422
+// Unprocessable Entity when the batch is invalid; otherwise it responds with HTTP
+// 200 OK and the result of every operation, with its status code and its task or
+// the problem details of its error.
+func (t *TaskController) BatchTasks(c *gin.Context) {
+	// The router matches any suffix of "tasks", of which only ":batch" is served.
+	if c.Param("batch") != ":batch" {
+		c.Error(problems.ErrRouteNotFound)
+		return
+	}
+
+	params, ok := helpers.ValidateUUIDParams(c, "id")
+	if !ok {
+		c.Error(problems.InvalidRequest("invalid user ID"))
+		return
+	}
+
+	var input requests.TaskBatchRequest
+	if err := handlers.ShouldBindJSON(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	ops := make([]domain.TaskOperation, len(input.Operations))
+	for i, in := range input.Operations {
+		ops[i] = domain.TaskOperation{Kind: domain.TaskOperationKind(in.Op)}
+		if in.TaskID != "" {
+			ops[i].TaskID = uuid.MustParse(in.TaskID)
+		}
+		if in.Task != nil {
+			ops[i].Task = &domain.Task{Title: in.Task.Title, Description: in.Task.Description, Completed: in.Task.Completed}
+		}
+	}
+
+	results, err := t.task.BatchTasks(c, params[0], ops, input.Atomic)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	problem := func(err error) problems.Problem { return problems.For(c, err) }
+	batch := responses.TaskBatch{Atomic: input.Atomic, Results: make([]responses.TaskOperationResult, len(results))}
+	for i, result := range results {
+		batch.Results[i] = responses.NewTaskOperationResult(ops[i], result, problem)
+	}
+
+	c.JSON(http.StatusOK, batch)
+}
+
+// ShareTask handles HTTP POST requests to share a task with another user.
+// It expects a JSON payload with the collaborator "user_id" and a "role" (viewer or editor).
+// Only the owner of the task can share it. On success, it responds with HTTP 201 Created
+// and the created share.
+func (t *TaskController) ShareTask(c *gin.Context) {
+	var input requests.ShareTaskRequest
+
+	params, ok := helpers.ValidateUUIDParams(c, "id", "task_id")
+	if !ok {
+		c.Error(problems.InvalidRequest("invalid user or task ID"))
+		return
+	}
+
+	if err := handlers.ShouldBindJSON(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	collaboratorID, err := helpers.ParseUUID(input.UserID)
+	if err != nil {
+		c.Error(problems.InvalidRequest(err.Error()))
+		return
+	}
+
+	share, err := t.task.ShareTask(c, params[0], params[1], collaboratorID, domain.TaskRole(input.Role))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, responses.NewTaskShare(share))
+}
+
+// FindTaskShares handles HTTP GET requests listing the collaborators of a task.
+// It is available to the owner and to the collaborators of the task.
+func (t *TaskController) FindTaskShares(c *gin.Context) {
+	params, ok := helpers.ValidateUUIDParams(c, "id", "task_id")
+	if !ok {
+		c.Error(problems.InvalidRequest("invalid user or task ID"))
+		return
+	}
+
+	shares, err := t.task.FindTaskShares(c, params[0], params[1])
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.NewTaskShares(shares))
+}
+
+// UnshareTask handles HTTP DELETE requests revoking the access of a collaborator
+// identified by the "user_id" URL parameter. On success, it responds with HTTP 204 No Content.
+func (t *TaskController) UnshareTask(c *gin.Context) {
+	params, ok := helpers.ValidateUUIDParams(c, "id", "task_id", "user_id")
+	if !ok {
+		c.Error(problems.InvalidRequest("invalid user, task or collaborator ID"))
+		return
+	}
+
+	if err := t.task.UnshareTask(c, params[0], params[1], params[2]); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/middleware"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/responses"
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/policy"
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+	"github.com/fabianoflorentino/gotostudy/core/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// memoryTaskRepository saves the tasks in memory, for the routes creating tasks.
+type memoryTaskRepository struct {
+	ports.TaskRepository
+	tasks map[uuid.UUID]*domain.Task
+}
+
+func (m *memoryTaskRepository) Save(_ context.Context, _ uuid.UUID, task *domain.Task) error {
+	m.tasks[task.ID] = task
+	return nil
+}
+
+// memoryUserRepository finds the users it holds by ID.
+type memoryUserRepository struct {
+	ports.UserRepository
+	users map[uuid.UUID]*domain.User
+}
+
+func (m *memoryUserRepository) FindByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	if user, ok := m.users[id]; ok {
+		return user, nil
+	}
+	return nil, core.ErrUserNotFound
+}
+
+// allowAll authorizes every operation.
+type allowAll struct{}
+
+func (allowAll) Authorize(context.Context, policy.Permission, uuid.UUID) error { return nil }
+
+// noopMetrics counts nothing.
+type noopMetrics struct{}
+
+func (noopMetrics) UserRegistered(context.Context, string) {}
+func (noopMetrics) TaskCreated(context.Context)            {}
+func (noopMetrics) TaskCompleted(context.Context)          {}
+
+// inlineTransactor runs the units of work without a transaction.
+type inlineTransactor struct{}
+
+func (inlineTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// discardOutbox drops the domain events.
+type discardOutbox struct {
+	ports.Outbox
+}
+
+func (discardOutbox) Append(context.Context, ...domain.DomainEvent) error { return nil }
+
+func TestTaskControllerCreateTask(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userID := uuid.New()
+	tasks := &memoryTaskRepository{tasks: map[uuid.UUID]*domain.Task{}}
+	users := &memoryUserRepository{users: map[uuid.UUID]*domain.User{userID: {ID: userID}}}
+	service := services.NewTaskService(tasks, users, allowAll{}, services.Infrastructure{
+		Metrics: noopMetrics{},
+		Events:  services.NewEventBus(1, 1),
+		Tx:      inlineTransactor{},
+		Outbox:  discardOutbox{},
+	})
+
+	r := gin.New()
+	r.Use(middleware.Problems())
+	r.POST("/users/:id/tasks", NewTaskController(service).CreateTask)
+
+	serve := func(userID uuid.UUID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/users/"+userID.String()+"/tasks", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("created", func(t *testing.T) {
+		w := serve(userID, `{"title":"Study Go","description":"Read the spec"}`)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("status = %d, body = %s, want 201", w.Code, w.Body)
+		}
+
+		var got responses.Task
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatalf("body = %s, want a task: %v", w.Body, err)
+		}
+		if got.ID == uuid.Nil || got.Title != "Study Go" || got.UserID != userID {
+			t.Errorf("task = %+v, want the created task of the user", got)
+		}
+		if _, ok := tasks.tasks[got.ID]; !ok {
+			t.Errorf("task %s was not saved", got.ID)
+		}
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		if w := serve(uuid.New(), `{"title":"Study Go"}`); w.Code != http.StatusNotFound {
+			t.Errorf("status = %d, body = %s, want 404", w.Code, w.Body)
+		}
+	})
+}
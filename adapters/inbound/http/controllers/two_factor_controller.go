@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/handlers"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/helpers"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/problems"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/requests"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/responses"
+	"github.com/fabianoflorentino/gotostudy/core/services"
+	"github.com/gin-gonic/gin"
+)
+
+// TwoFactorController handles the HTTP requests managing the TOTP second factor of a user.
+type TwoFactorController struct {
+	service *services.TwoFactorService
+}
+
+// NewTwoFactorController creates and returns a new instance of TwoFactorController.
+func NewTwoFactorController(t *services.TwoFactorService) *TwoFactorController {
+	return &TwoFactorController{service: t}
+}
+
+// EnrollTwoFactor handles the HTTP request starting the enrollment of the user
+// identified by the "id" parameter. It responds with the secret and the otpauth URI
+// to register in an authenticator app.
+func (t *TwoFactorController) EnrollTwoFactor(c *gin.Context) {
+	uid, err := helpers.ParseUUID(c.Param("id"))
+	if err != nil {
+		c.Error(problems.InvalidRequest(err.Error()))
+		return
+	}
+
+	enrollment, err := t.service.Enroll(c, uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, responses.NewTwoFactorEnrollment(enrollment))
+}
+
+// TwoFactorQRCode handles the HTTP request returning the QR code PNG image of the
+// pending enrollment of the user.
+func (t *TwoFactorController) TwoFactorQRCode(c *gin.Context) {
+	uid, err := helpers.ParseUUID(c.Param("id"))
+	if err != nil {
+		c.Error(problems.InvalidRequest(err.Error()))
+		return
+	}
+
+	image, err := t.service.QRCode(c, uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "image/png", image)
+}
+
+// ConfirmTwoFactor handles the HTTP request enabling the pending second factor with
+// a first code. The response carries the recovery codes; it is the only time they
+// are shown.
+func (t *TwoFactorController) ConfirmTwoFactor(c *gin.Context) {
+	var input requests.ConfirmTwoFactorRequest
+
+	uid, err := helpers.ParseUUID(c.Param("id"))
+	if err != nil {
+		c.Error(problems.InvalidRequest(err.Error()))
+		return
+	}
+
+	if err := handlers.ShouldBindJSON(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	codes, err := t.service.Confirm(c, uid, input.Code)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.RecoveryCodes{RecoveryCodes: codes})
+}
+
+// FindTwoFactor handles the HTTP request returning whether the user enabled the
+// second factor and how many recovery codes are left.
+func (t *TwoFactorController) FindTwoFactor(c *gin.Context) {
+	uid, err := helpers.ParseUUID(c.Param("id"))
+	if err != nil {
+		c.Error(problems.InvalidRequest(err.Error()))
+		return
+	}
+
+	twoFactor, err := t.service.Status(c, uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.NewTwoFactor(twoFactor))
+}
+
+// ResetTwoFactor handles the HTTP request removing the second factor of a user who
+// lost it. Only administrators are allowed by the default policy.
+func (t *TwoFactorController) ResetTwoFactor(c *gin.Context) {
+	uid, err := helpers.ParseUUID(c.Param("id"))
+	if err != nil {
+		c.Error(problems.InvalidRequest(err.Error()))
+		return
+	}
+
+	if err := t.service.Reset(c, uid); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
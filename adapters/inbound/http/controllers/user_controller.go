@@ -10,7 +10,9 @@ import (
 
 	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/handlers"
 	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/helpers"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/problems"
 	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/requests"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/responses"
 	"github.com/fabianoflorentino/gotostudy/core/domain"
 	"github.com/fabianoflorentino/gotostudy/core/services"
 	"github.com/gin-gonic/gin"
@@ -33,41 +35,40 @@ func NewUserController(u *services.UserService) *UserController {
 }
 
 // CreateUser handles the HTTP request for creating a new user.
-// It expects a JSON payload containing "username" and "email" fields.
-// The "username" field is required, and the "email" field must be a valid email address.
-// If the input validation fails, it responds with a 400 Bad Request status and an error message.
-// If the user creation process encounters an error, it responds with a 500 Internal Server Error status and an error message.
+// It expects a JSON payload containing "username", "email" and "password" fields.
+// The "username" field is required, the "email" field must be a valid email address and
+// the "password" must have between 8 and 72 characters.
+// Invalid payloads and the errors of the service are answered with problem details.
 // On successful user creation, it responds with a 201 Created status and the created user object in the response body.
 func (u *UserController) CreateUser(c *gin.Context) {
-	var user = &domain.User{}
+	var input requests.RegisterUserRequest
 
-	if err := handlers.ShouldBindJSON(c, &user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request, username and email are required"})
+	if err := handlers.ShouldBindJSON(c, &input); err != nil {
+		c.Error(err)
 		return
 	}
 
-	user, err := u.service.RegisterUser(c, user)
+	user, err := u.service.RegisterUser(c, &domain.User{Username: input.Username, Email: input.Email}, input.Password)
 	if err != nil {
-		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, user)
+	c.JSON(http.StatusCreated, responses.NewUser(user))
 }
 
 // GetUsers handles the HTTP GET request to retrieve all users.
 // It interacts with the service layer to fetch the list of users.
-// If an error occurs during the retrieval process, it responds with
-// an HTTP 500 status code and an error message. Otherwise, it responds
+// Errors are answered with problem details. Otherwise, it responds
 // with an HTTP 200 status code and the list of users in JSON format.
 func (u *UserController) GetAllUsers(c *gin.Context) {
 	users, err := u.service.GetAllUsers(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, users)
+	c.JSON(http.StatusOK, responses.NewUsers(users))
 }
 
 // GetUserByID handles the HTTP request to retrieve a user by their unique ID.
@@ -78,84 +79,88 @@ func (u *UserController) GetAllUsers(c *gin.Context) {
 func (u *UserController) GetUserByID(c *gin.Context) {
 	uid, err := helpers.ParseUUID(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(problems.InvalidRequest(err.Error()))
 		return
 	}
 
 	user, err := u.service.GetUserByID(c, uid)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	c.JSON(http.StatusOK, responses.NewUser(user))
 }
 
 // UpdateUser handles the HTTP request to update an existing user's information.
 // It extracts the user ID from the URL parameter, validates the input JSON payload,
 // and calls the service layer to update the user details in the system.
-// If the user ID is invalid or the input data fails validation, it responds with
-// an appropriate HTTP error status and message. On success, it returns the updated
-// user information with an HTTP 200 status.
+// Invalid IDs or payloads and the errors of the service are answered with problem
+// details. On success, it returns the updated user information with an HTTP 200 status.
 func (u *UserController) UpdateUser(c *gin.Context) {
-	var input requests.RegisterUserRequest
+	var input requests.UpdateUserRequest
 
 	uid, err := helpers.ParseUUID(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(problems.InvalidRequest(err.Error()))
 		return
 	}
 
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	if err := handlers.ShouldBindJSON(c, &input); err != nil {
+		c.Error(err)
 		return
 	}
 
-	user := u.service.UpdateUser(c, uid, &domain.User{
-		Username:  input.Username,
-		Email:     input.Email,
-		UpdatedAt: input.UpdatedAt,
-	})
+	if err := u.service.UpdateUser(c, uid, &domain.User{Username: input.Username, Email: input.Email}); err != nil {
+		c.Error(err)
+		return
+	}
+
+	user, err := u.service.GetUserByID(c, uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
 
-	c.JSON(http.StatusOK, user)
+	c.JSON(http.StatusOK, responses.NewUser(user))
 }
 
-// UpdateUserFields handles the HTTP request to update specific fields of a user.
-// It extracts the user ID from the request parameters, validates it, and parses
-// the fields to be updated from the request body. The method ensures that the
-// updates are valid before passing them to the service layer for processing.
-// If successful, it returns the updated user object in the response. In case of
-// errors, appropriate HTTP status codes and error messages are returned.
-//
-// Parameters:
-// - c: The Gin context, which provides request and response handling.
+// PatchUser handles the HTTP request to update some fields of a user. The body is
+// a JSON merge patch or a JSON patch of the username and the email address, as
+// accepted by PUT, selected by the Content-Type header. The patch applies to the
+// current user and the result is validated as a PUT body before it is saved, so
+// either the whole patch is saved or nothing.
 //
 // Possible Responses:
-//   - HTTP 400: If the user ID is invalid, the update fields are invalid, or
-//     there are validation errors.
-//   - HTTP 500: If an internal server error occurs during the update process.
-//   - HTTP 200: If the user fields are successfully updated, returning the updated user object.
-func (u *UserController) UpdateUserFields(c *gin.Context) {
-	var fields map[string]any
-
+//   - HTTP 200: The user was patched, returning the updated user.
+//   - HTTP 400: The user ID or the patch is malformed.
+//   - HTTP 404: The user does not exist.
+//   - HTTP 409: A JSON patch operation cannot be applied, or the email address is in use.
+//   - HTTP 415: The body is not a patch.
+//   - HTTP 422: The patched user is invalid.
+func (u *UserController) PatchUser(c *gin.Context) {
 	uid, err := helpers.ParseUUID(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(problems.InvalidRequest(err.Error()))
 		return
 	}
 
-	if err := c.ShouldBindJSON(&fields); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid update fields"})
-		return
-	}
+	user, err := u.service.PatchUser(c, uid, func(user *domain.User) error {
+		input := requests.UpdateUserRequest{Username: user.Username, Email: user.Email}
+		if err := handlers.ShouldBindPatch(c, &input); err != nil {
+			return err
+		}
 
-	user, err := u.service.UpdateUserFields(c, uid, fields)
+		user.Username = input.Username
+		user.Email = input.Email
+		return nil
+	})
 	if err != nil {
-		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	c.JSON(http.StatusOK, responses.NewUser(user))
 }
 
 // DeleteUser handles the HTTP DELETE request to remove a user by their unique identifier (UUID).
@@ -166,14 +171,83 @@ func (u *UserController) UpdateUserFields(c *gin.Context) {
 func (u *UserController) DeleteUser(c *gin.Context) {
 	uid, err := helpers.ParseUUID(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(problems.InvalidRequest(err.Error()))
 		return
 	}
 
 	if err := u.service.DeleteUser(c, uid); err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusNoContent, nil)
 }
+
+// VerifyEmail handles the link confirming the email address of a user, opened from
+// the email with the signed token in the "token" query parameter. It responds with
+// 400 when the token is invalid or expired.
+func (u *UserController) VerifyEmail(c *gin.Context) {
+	if err := u.service.VerifyEmail(c, c.Query("token")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email address confirmed"})
+}
+
+// ResendEmailVerification handles the HTTP request sending the link confirming the
+// email address again. It always responds with 202 for valid payloads, so it does
+// not disclose which addresses are registered.
+func (u *UserController) ResendEmailVerification(c *gin.Context) {
+	var input requests.EmailRequest
+
+	if err := handlers.ShouldBindJSON(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := u.service.ResendEmailVerification(c, input.Email); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the address is registered and not confirmed yet, a new link was sent"})
+}
+
+// RequestPasswordReset handles the HTTP request sending a password reset link. It
+// always responds with 202 for valid payloads, so it does not disclose which
+// addresses are registered.
+func (u *UserController) RequestPasswordReset(c *gin.Context) {
+	var input requests.EmailRequest
+
+	if err := handlers.ShouldBindJSON(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := u.service.RequestPasswordReset(c, input.Email); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the address is registered, a password reset link was sent"})
+}
+
+// ResetPassword handles the HTTP request setting a new password with the token of a
+// password reset link. It responds with 400 when the token is invalid, expired or
+// already used and with 422 when the password is too short or too long.
+func (u *UserController) ResetPassword(c *gin.Context) {
+	var input requests.ResetPasswordRequest
+
+	if err := handlers.ShouldBindJSON(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := u.service.ResetPassword(c, input.Token, input.Password); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password updated"})
+}
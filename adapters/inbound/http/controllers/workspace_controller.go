@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/handlers"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/helpers"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/problems"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/requests"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/responses"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/services"
+	"github.com/gin-gonic/gin"
+)
+
+// WorkspaceController handles HTTP requests related to workspaces and their members.
+type WorkspaceController struct {
+	workspace *services.WorkspaceService
+}
+
+// NewWorkspaceController creates and returns a new instance of WorkspaceController
+// with the provided WorkspaceService.
+func NewWorkspaceController(w *services.WorkspaceService) *WorkspaceController {
+	return &WorkspaceController{workspace: w}
+}
+
+// CreateWorkspace handles HTTP POST requests creating a workspace owned by the user
+// identified by the "id" URL parameter. On success, it responds with HTTP 201 Created.
+func (w *WorkspaceController) CreateWorkspace(c *gin.Context) {
+	var input requests.CreateWorkspaceRequest
+
+	params, ok := helpers.ValidateUUIDParams(c, "id")
+	if !ok {
+		c.Error(problems.InvalidRequest("invalid user ID"))
+		return
+	}
+
+	if err := handlers.ShouldBindJSON(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	workspace, err := w.workspace.CreateWorkspace(c, params[0], input.Name)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, responses.NewWorkspace(workspace))
+}
+
+// FindUserWorkspaces handles HTTP GET requests listing the workspaces of a user.
+func (w *WorkspaceController) FindUserWorkspaces(c *gin.Context) {
+	params, ok := helpers.ValidateUUIDParams(c, "id")
+	if !ok {
+		c.Error(problems.InvalidRequest("invalid user ID"))
+		return
+	}
+
+	workspaces, err := w.workspace.FindUserWorkspaces(c, params[0])
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.NewWorkspaces(workspaces))
+}
+
+// FindMembers handles HTTP GET requests listing the members of a workspace.
+// The acting user ("id") must be a member of the workspace ("workspace_id").
+func (w *WorkspaceController) FindMembers(c *gin.Context) {
+	params, ok := helpers.ValidateUUIDParams(c, "id", "workspace_id")
+	if !ok {
+		c.Error(problems.InvalidRequest("invalid user or workspace ID"))
+		return
+	}
+
+	members, err := w.workspace.FindMembers(c, params[0], params[1])
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.NewWorkspaceMembers(members))
+}
+
+// SaveMember handles HTTP PUT requests adding the user "user_id" to the workspace or
+// changing its role. The acting user ("id") must be an owner or an admin of the workspace.
+func (w *WorkspaceController) SaveMember(c *gin.Context) {
+	var input requests.SaveWorkspaceMemberRequest
+
+	params, ok := helpers.ValidateUUIDParams(c, "id", "workspace_id", "user_id")
+	if !ok {
+		c.Error(problems.InvalidRequest("invalid user, workspace or member ID"))
+		return
+	}
+
+	if err := handlers.ShouldBindJSON(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	member, err := w.workspace.SaveMember(c, params[0], params[1], params[2], domain.WorkspaceRole(input.Role))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.NewWorkspaceMember(member))
+}
+
+// RemoveMember handles HTTP DELETE requests removing the user "user_id" from the workspace.
+// On success, it responds with HTTP 204 No Content.
+func (w *WorkspaceController) RemoveMember(c *gin.Context) {
+	params, ok := helpers.ValidateUUIDParams(c, "id", "workspace_id", "user_id")
+	if !ok {
+		c.Error(problems.InvalidRequest("invalid user, workspace or member ID"))
+		return
+	}
+
+	if err := w.workspace.RemoveMember(c, params[0], params[1], params[2]); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
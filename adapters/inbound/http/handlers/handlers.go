@@ -7,19 +7,182 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/problems"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 )
 
-// ShouldBindJSON is a helper function that attempts to bind the JSON payload
-// from the HTTP request body to the provided input structure. It uses the
-// Gin framework's ShouldBindJSON method for binding. If the binding fails,
-// it responds with a 400 Bad Request status and includes the error message
-// in the response body. The function returns the error if binding fails,
-// or nil if the binding is successful.
+// useJSONNames makes the validator report the fields by their JSON names.
+var useJSONNames sync.Once
+
+// ShouldBindJSON decodes the JSON object of the request body into input, a pointer
+// to a request struct, and validates it against its binding tags. Fields of the body
+// input does not declare are rejected, so clients cannot set values such as IDs or
+// timestamps. It returns an error wrapping problems.ErrInvalidRequest when the body
+// is not a JSON object and a *problems.ValidationError listing every invalid field
+// otherwise.
 func ShouldBindJSON(c *gin.Context, input any) error {
-	if err := c.ShouldBindJSON(input); err != nil {
-		return err
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return problems.InvalidRequest("the body could not be read")
+	}
+
+	return bindJSON(body, input)
+}
+
+// bindJSON decodes and validates the JSON object body into input, as ShouldBindJSON.
+func bindJSON(body []byte, input any) error {
+	useJSONNames.Do(func() {
+		if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+			v.RegisterTagNameFunc(jsonName)
+		}
+	})
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil || fields == nil {
+		return problems.InvalidRequest("the body must be a JSON object")
+	}
+
+	var errs []problems.FieldError
+	invalid := map[string]bool{}
+	add := func(field, rule, param string) {
+		if !invalid[field] {
+			invalid[field] = true
+			errs = append(errs, problems.NewFieldError(field, rule, param))
+		}
+	}
+
+	known := knownFields(input)
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !known[name] {
+			add(name, "unknown", "")
+		}
+	}
+
+	if err := json.Unmarshal(body, input); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if !errors.As(err, &typeErr) {
+			return problems.InvalidRequest("the body must be a JSON object")
+		}
+		add(typeErr.Field, typeRule(typeErr.Type), "")
+	}
+
+	if err := binding.Validator.ValidateStruct(input); err != nil {
+		var verrs validator.ValidationErrors
+		if !errors.As(err, &verrs) {
+			return err
+		}
+		for _, fe := range verrs {
+			rule, param := validationRule(fe)
+			add(fieldPath(fe), rule, param)
+		}
+	}
+
+	if len(errs) > 0 {
+		return &problems.ValidationError{Errors: errs}
 	}
 
 	return nil
 }
+
+// knownFields returns the JSON names of the fields of the struct input points to.
+func knownFields(input any) map[string]bool {
+	t := reflect.TypeOf(input)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	known := map[string]bool{}
+	if t.Kind() != reflect.Struct {
+		return known
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		if name := jsonName(t.Field(i)); name != "" {
+			known[name] = true
+		}
+	}
+
+	return known
+}
+
+// jsonName returns the name of a struct field in JSON, or an empty string for the
+// fields left out of JSON.
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	default:
+		return name
+	}
+}
+
+// fieldPath returns the path of the field of a validation error in the JSON body,
+// without the name of the request struct.
+func fieldPath(fe validator.FieldError) string {
+	if _, path, found := strings.Cut(fe.Namespace(), "."); found {
+		return path
+	}
+
+	return fe.Field()
+}
+
+// validationRule returns the rule of the catalogs of messages a validation error
+// breaks and its parameter. The length rules of lists and maps count items rather
+// than characters, and the conditional requirements are requirements.
+func validationRule(fe validator.FieldError) (string, string) {
+	switch fe.Tag() {
+	case "required_if", "required_unless":
+		return "required", ""
+	case "min", "max":
+		if k := fe.Kind(); k == reflect.Slice || k == reflect.Map {
+			return fe.Tag() + ".items", fe.Param()
+		}
+	case "oneof":
+		return fe.Tag(), strings.ReplaceAll(fe.Param(), " ", ", ")
+	}
+
+	return fe.Tag(), fe.Param()
+}
+
+// typeRule returns the rule of the catalogs of messages for a value that does not
+// have the JSON type of the Go type t.
+func typeRule(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "type.string"
+	case reflect.Bool:
+		return "type.boolean"
+	case reflect.Slice, reflect.Array:
+		return "type.array"
+	case reflect.Map, reflect.Struct:
+		return "type.object"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "type.number"
+	default:
+		return "type"
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/problems"
+	"github.com/gin-gonic/gin"
+)
+
+type bindRequest struct {
+	Title  string   `json:"title" binding:"required,min=3"`
+	Email  string   `json:"email" binding:"required,email"`
+	Done   bool     `json:"done"`
+	Scopes []string `json:"scopes" binding:"omitempty,dive,oneof=read write"`
+}
+
+type bindItem struct {
+	Op string `json:"op" binding:"required,oneof=create delete"`
+	ID string `json:"id" binding:"required_unless=Op create,omitempty,uuid"`
+}
+
+type bindBatch struct {
+	Items []bindItem `json:"items" binding:"required,min=1,dive"`
+}
+
+func TestShouldBindJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	bind := func(body string) (bindRequest, error) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+
+		var input bindRequest
+		err := ShouldBindJSON(c, &input)
+		return input, err
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		input, err := bind(`{"title":"Study","email":"ana@example.com","done":true,"scopes":["read"]}`)
+		if err != nil {
+			t.Fatalf("ShouldBindJSON() error = %v", err)
+		}
+		if input.Title != "Study" || !input.Done {
+			t.Errorf("input = %+v", input)
+		}
+	})
+
+	t.Run("not an object", func(t *testing.T) {
+		for _, body := range []string{``, `[]`, `null`, `{"title":`} {
+			if _, err := bind(body); !errors.Is(err, problems.ErrInvalidRequest) {
+				t.Errorf("ShouldBindJSON(%q) error = %v, want ErrInvalidRequest", body, err)
+			}
+		}
+	})
+
+	t.Run("every invalid field", func(t *testing.T) {
+		_, err := bind(`{"title":"ab","done":"yes","scopes":["read","admin"],"id":"42"}`)
+
+		var validation *problems.ValidationError
+		if !errors.As(err, &validation) {
+			t.Fatalf("ShouldBindJSON() error = %v, want a *ValidationError", err)
+		}
+		if !errors.Is(err, problems.ErrValidationFailed) {
+			t.Error("the error does not wrap ErrValidationFailed")
+		}
+
+		got := map[string]string{}
+		for _, fe := range validation.Errors {
+			got[fe.Field] = fe.Code
+		}
+		want := map[string]string{
+			"id":        "unknown",
+			"done":      "type",
+			"title":     "min",
+			"email":     "required",
+			"scopes[1]": "oneof",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("field errors = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("conditional requirement", func(t *testing.T) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"items":[{"op":"create"},{"op":"delete"}]}`))
+
+		var validation *problems.ValidationError
+		if err := ShouldBindJSON(c, &bindBatch{}); !errors.As(err, &validation) {
+			t.Fatalf("ShouldBindJSON() error = %v, want a *ValidationError", err)
+		}
+		if len(validation.Errors) != 1 || validation.Errors[0].Field != "items[1].id" || validation.Errors[0].Code != "required" {
+			t.Errorf("field errors = %+v, want items[1].id required", validation.Errors)
+		}
+	})
+}
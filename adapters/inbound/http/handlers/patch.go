@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/problems"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// The media types of the bodies of PATCH requests.
+const (
+	// MergePatchMediaType is the media type of a JSON merge patch (RFC 7396).
+	MergePatchMediaType = "application/merge-patch+json"
+	// JSONPatchMediaType is the media type of a JSON patch (RFC 6902).
+	JSONPatchMediaType = "application/json-patch+json"
+)
+
+// ShouldBindPatch applies the patch in the request body to input, a pointer to the
+// request struct replacing the resource filled in with its current state, then
+// validates the result as ShouldBindJSON validates a body. The Content-Type header
+// selects the format of the patch: a JSON merge patch, also read from application/json
+// bodies, or a JSON patch. Either every operation applies or input is left as is.
+//
+// It returns an error wrapping problems.ErrUnsupportedMediaType for other content
+// types, problems.ErrInvalidRequest for malformed patches, problems.ErrPatchConflict
+// for a JSON patch that cannot be applied and a *problems.ValidationError when the
+// patched resource is invalid.
+func ShouldBindPatch(c *gin.Context, input any) error {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return problems.InvalidRequest("the body could not be read")
+	}
+
+	document, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+
+	var patched []byte
+	switch mediaType := c.ContentType(); mediaType {
+	case MergePatchMediaType, binding.MIMEJSON:
+		patched, err = mergePatch(document, body)
+	case JSONPatchMediaType:
+		patched, err = jsonPatch(document, body)
+	default:
+		return fmt.Errorf("%w: %q, use %s or %s", problems.ErrUnsupportedMediaType, mediaType, MergePatchMediaType, JSONPatchMediaType)
+	}
+	if err != nil {
+		return err
+	}
+
+	result := reflect.New(reflect.TypeOf(input).Elem())
+	if err := bindJSON(patched, result.Interface()); err != nil {
+		return err
+	}
+	reflect.ValueOf(input).Elem().Set(result.Elem())
+
+	return nil
+}
+
+// mergePatch applies the JSON merge patch body to document.
+func mergePatch(document, body []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil || fields == nil {
+		return nil, problems.InvalidRequest("the body must be a JSON object")
+	}
+
+	patched, err := jsonpatch.MergePatch(document, body)
+	if err != nil {
+		return nil, problems.InvalidRequest("the body must be a JSON merge patch")
+	}
+
+	return patched, nil
+}
+
+// jsonPatch applies the JSON patch body to document. The operations are checked
+// before any applies, so a malformed one is an invalid request rather than a
+// conflict.
+func jsonPatch(document, body []byte) ([]byte, error) {
+	patch, err := jsonpatch.DecodePatch(body)
+	if err != nil || patch == nil {
+		return nil, problems.InvalidRequest("the body must be a JSON patch, an array of operations")
+	}
+
+	for i, op := range patch {
+		if err := checkOperation(op); err != nil {
+			return nil, problems.InvalidRequest(fmt.Sprintf("operation %d %s", i, err))
+		}
+	}
+
+	patched, err := patch.Apply(document)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", problems.ErrPatchConflict, err)
+	}
+
+	return patched, nil
+}
+
+// checkOperation checks that op is a JSON patch operation with the members its
+// kind requires.
+func checkOperation(op jsonpatch.Operation) error {
+	if _, err := op.Path(); err != nil {
+		return fmt.Errorf("has no path")
+	}
+
+	switch kind := op.Kind(); kind {
+	case "remove":
+	case "add", "replace", "test":
+		if _, ok := op["value"]; !ok {
+			return fmt.Errorf("%s has no value", kind)
+		}
+	case "move", "copy":
+		if _, err := op.From(); err != nil {
+			return fmt.Errorf("%s has no from", kind)
+		}
+	default:
+		return fmt.Errorf("has an unknown op %q", kind)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/problems"
+	"github.com/gin-gonic/gin"
+)
+
+func TestShouldBindPatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	current := bindRequest{Title: "Study", Email: "ana@example.com", Scopes: []string{"read"}}
+
+	patch := func(contentType, body string) (bindRequest, error) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", contentType)
+
+		input := current
+		input.Scopes = append([]string(nil), current.Scopes...)
+		err := ShouldBindPatch(c, &input)
+		return input, err
+	}
+
+	t.Run("merge patch", func(t *testing.T) {
+		for _, contentType := range []string{MergePatchMediaType, "application/json; charset=utf-8"} {
+			input, err := patch(contentType, `{"done":true,"scopes":["read","write"]}`)
+			if err != nil {
+				t.Fatalf("ShouldBindPatch(%s) error = %v", contentType, err)
+			}
+			if input.Title != "Study" || !input.Done || len(input.Scopes) != 2 {
+				t.Errorf("ShouldBindPatch(%s) input = %+v", contentType, input)
+			}
+		}
+	})
+
+	t.Run("json patch", func(t *testing.T) {
+		input, err := patch(JSONPatchMediaType, `[
+			{"op":"test","path":"/title","value":"Study"},
+			{"op":"replace","path":"/title","value":"Study Go"},
+			{"op":"add","path":"/scopes/-","value":"write"}
+		]`)
+		if err != nil {
+			t.Fatalf("ShouldBindPatch() error = %v", err)
+		}
+		if input.Title != "Study Go" || len(input.Scopes) != 2 || input.Scopes[1] != "write" {
+			t.Errorf("input = %+v", input)
+		}
+	})
+
+	t.Run("invalid result", func(t *testing.T) {
+		input, err := patch(MergePatchMediaType, `{"title":null,"email":"ana"}`)
+
+		var validation *problems.ValidationError
+		if !errors.As(err, &validation) {
+			t.Fatalf("ShouldBindPatch() error = %v, want a *ValidationError", err)
+		}
+
+		got := map[string]string{}
+		for _, fe := range validation.Errors {
+			got[fe.Field] = fe.Code
+		}
+		if got["title"] != "required" || got["email"] != "email" {
+			t.Errorf("field errors = %v", got)
+		}
+		if input.Title != current.Title {
+			t.Errorf("input = %+v, want it left as is", input)
+		}
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := patch(JSONPatchMediaType, `[{"op":"add","path":"/id","value":"42"}]`)
+
+		var validation *problems.ValidationError
+		if !errors.As(err, &validation) || validation.Errors[0].Field != "id" || validation.Errors[0].Code != "unknown" {
+			t.Errorf("ShouldBindPatch() error = %v, want id unknown", err)
+		}
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		for _, body := range []string{
+			`[{"op":"test","path":"/title","value":"Other"},{"op":"replace","path":"/title","value":"Study Go"}]`,
+			`[{"op":"remove","path":"/missing"}]`,
+		} {
+			input, err := patch(JSONPatchMediaType, body)
+			if !errors.Is(err, problems.ErrPatchConflict) {
+				t.Errorf("ShouldBindPatch(%s) error = %v, want ErrPatchConflict", body, err)
+			}
+			if input.Title != current.Title {
+				t.Errorf("input = %+v, want it left as is", input)
+			}
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		for _, tc := range []struct{ contentType, body string }{
+			{MergePatchMediaType, `["title"]`},
+			{JSONPatchMediaType, `{"op":"remove","path":"/title"}`},
+			{JSONPatchMediaType, `[{"op":"rename","path":"/title"}]`},
+			{JSONPatchMediaType, `[{"op":"replace","path":"/title"}]`},
+		} {
+			if _, err := patch(tc.contentType, tc.body); !errors.Is(err, problems.ErrInvalidRequest) {
+				t.Errorf("ShouldBindPatch(%s) error = %v, want ErrInvalidRequest", tc.body, err)
+			}
+		}
+	})
+
+	t.Run("unsupported media type", func(t *testing.T) {
+		if _, err := patch("text/plain", `title=Study`); !errors.Is(err, problems.ErrUnsupportedMediaType) {
+			t.Errorf("ShouldBindPatch() error = %v, want ErrUnsupportedMediaType", err)
+		}
+	})
+}
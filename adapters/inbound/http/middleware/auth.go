@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/auth"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/logging"
+	"github.com/fabianoflorentino/gotostudy/core/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Authenticate requires a valid access token or API key in the "Authorization: Bearer"
+// header and stores the authenticated principal in the request context, tagging the
+// logs of the request with the user and, for API keys, the key. It must run
+// after Workspace, since tokens and keys are only valid in the workspace they were
+// issued for. Requests without valid credentials are rejected with 401.
+func Authenticate(authService *services.AuthService, apiKeys *services.APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c.GetHeader("Authorization"))
+		if !ok {
+			unauthorized(c, core.ErrUnauthenticated)
+			return
+		}
+
+		var principal *auth.Principal
+		var err error
+
+		if services.IsAPIKey(token) {
+			principal, err = apiKeys.Authenticate(c.Request.Context(), token)
+		} else {
+			principal, err = authService.Authenticate(c.Request.Context(), token)
+		}
+		if err != nil {
+			if errors.Is(err, core.ErrInvalidToken) {
+				unauthorized(c, err)
+				return
+			}
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		ctx := logging.With(c.Request.Context(), "user_id", principal.UserID)
+		if principal.IsAPIKey() {
+			ctx = logging.With(ctx, "api_key_id", principal.APIKeyID)
+		}
+		c.Request = c.Request.WithContext(auth.WithPrincipal(ctx, *principal))
+
+		c.Next()
+	}
+}
+
+// SelfOrAdmin only lets a request through when the route parameter param, when the
+// route has one, identifies the authenticated user. Administrators can act on behalf
+// of any user, unless they authenticated with an API key lacking the users:admin
+// scope. Other requests are rejected with 403.
+func SelfOrAdmin(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param(param)
+		if id == "" {
+			c.Next()
+			return
+		}
+
+		principal, err := auth.PrincipalFrom(c.Request.Context())
+		if err != nil {
+			unauthorized(c, err)
+			return
+		}
+
+		admin := principal.IsAdmin() && principal.Allows(domain.ScopeUsersAdmin)
+		if !admin && !strings.EqualFold(id, principal.UserID.String()) {
+			c.Error(core.ErrAccessDenied)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireScope restricts the routes of requests authenticated with an API key to the
+// keys granted the read scope, for GET and HEAD requests, or the write scope, for any
+// other method. Other requests are rejected with 403; interactive logins are not limited.
+func RequireScope(read domain.APIScope, write domain.APIScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := auth.PrincipalFrom(c.Request.Context())
+		if err != nil {
+			unauthorized(c, err)
+			return
+		}
+
+		scope := write
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			scope = read
+		}
+
+		if !principal.Allows(scope) {
+			c.Error(core.ErrInsufficientScope)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// bearerToken extracts the token of an "Authorization: Bearer <token>" header.
+func bearerToken(header string) (string, bool) {
+	scheme, token, found := strings.Cut(strings.TrimSpace(header), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+
+	return token, token != ""
+}
+
+// unauthorized aborts the request with err, marked as an authentication failure so
+// it is answered with 401 whatever its cause.
+func unauthorized(c *gin.Context, err error) {
+	if !errors.Is(err, core.ErrUnauthenticated) {
+		err = fmt.Errorf("%w: %w", core.ErrUnauthenticated, err)
+	}
+
+	c.Error(err)
+	c.Abort()
+}
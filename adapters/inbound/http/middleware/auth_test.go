@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fabianoflorentino/gotostudy/core/auth"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestSelfOrAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	self, other := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		path      string
+		want      int
+	}{
+		{"Self", &auth.Principal{UserID: self, Role: domain.UserRoleUser}, "/users/" + self.String(), http.StatusOK},
+		{"OtherUser", &auth.Principal{UserID: self, Role: domain.UserRoleUser}, "/users/" + other.String(), http.StatusForbidden},
+		{"Admin", &auth.Principal{UserID: self, Role: domain.UserRoleAdmin}, "/users/" + other.String(), http.StatusOK},
+		{"AdminAPIKeyWithoutUsersAdmin", &auth.Principal{UserID: self, Role: domain.UserRoleAdmin, APIKeyID: uuid.New(), Scopes: []domain.APIScope{domain.ScopeTasksRead}}, "/users/" + other.String(), http.StatusForbidden},
+		{"WithoutParam", &auth.Principal{UserID: self, Role: domain.UserRoleUser}, "/users", http.StatusOK},
+		{"Unauthenticated", nil, "/users/" + self.String(), http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(Problems(), func(c *gin.Context) {
+				if tt.principal != nil {
+					c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), *tt.principal))
+				}
+			})
+
+			ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+			group := r.Group("/", SelfOrAdmin("id"))
+			group.GET("/users", ok)
+			group.GET("/users/:id", ok)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	readOnly := auth.Principal{UserID: uuid.New(), APIKeyID: uuid.New(), Scopes: []domain.APIScope{domain.ScopeTasksRead}}
+	login := auth.Principal{UserID: uuid.New()}
+
+	tests := []struct {
+		name      string
+		principal auth.Principal
+		method    string
+		want      int
+	}{
+		{"APIKeyRead", readOnly, http.MethodGet, http.StatusOK},
+		{"APIKeyWrite", readOnly, http.MethodPost, http.StatusForbidden},
+		{"LoginWrite", login, http.MethodPost, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(Problems(), func(c *gin.Context) {
+				c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), tt.principal))
+			})
+
+			ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+			group := r.Group("/", RequireScope(domain.ScopeTasksRead, domain.ScopeTasksWrite))
+			group.GET("/tasks", ok)
+			group.POST("/tasks", ok)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, "/tasks", nil))
+
+			if w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		header string
+		token  string
+		ok     bool
+	}{
+		{"Bearer abc", "abc", true},
+		{"bearer  abc ", "abc", true},
+		{"Basic abc", "", false},
+		{"Bearer", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		token, ok := bearerToken(tt.header)
+		if token != tt.token || ok != tt.ok {
+			t.Errorf("bearerToken(%q) = %q, %v; want %q, %v", tt.header, token, ok, tt.token, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated announces that the routes it guards are deprecated since the given
+// date, with the Deprecation header of RFC 9745, and may be removed after sunset,
+// with the Sunset header of RFC 8594 when sunset is set. The Link header points to
+// the same path under the successor prefix, such as /api/v1. The query is left out,
+// as it may carry credentials, such as the access_token of the event streams.
+func Deprecated(since, sunset time.Time, successor string) gin.HandlerFunc {
+	deprecation := fmt.Sprintf("@%d", since.Unix())
+
+	return func(c *gin.Context) {
+		c.Header("Deprecation", deprecation)
+		if !sunset.IsZero() {
+			c.Header("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+
+		c.Header("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor+c.Request.URL.Path))
+
+		c.Next()
+	}
+}
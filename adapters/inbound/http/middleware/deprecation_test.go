@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDeprecated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	since := time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+
+	r := gin.New()
+	r.GET("/users/:id", Deprecated(since, sunset, "/api/v1"), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	r.GET("/tasks", Deprecated(since, time.Time{}, "/api/v1"), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	t.Run("Headers", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/42?access_token=secret", nil))
+
+		want := map[string]string{
+			"Deprecation": "@1790812800",
+			"Sunset":      "Fri, 30 Apr 2027 00:00:00 GMT",
+			"Link":        `</api/v1/users/42>; rel="successor-version"`,
+		}
+		for header, value := range want {
+			if got := w.Header().Get(header); got != value {
+				t.Errorf("%s = %q, want %q", header, got, value)
+			}
+		}
+	})
+
+	t.Run("NoSunset", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+
+		if got := w.Header().Get("Sunset"); got != "" {
+			t.Errorf("Sunset = %q, want none", got)
+		}
+		if got := w.Header().Get("Deprecation"); got == "" {
+			t.Error("Deprecation header missing")
+		}
+	})
+}
@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/problems"
+	"github.com/fabianoflorentino/gotostudy/core/auth"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/logging"
+	"github.com/fabianoflorentino/gotostudy/core/services"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header carrying the idempotency key
+	// chosen by the client for a request it may retry.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks the responses replayed to a retry.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// replayedHeaders are the response headers recorded and replayed with the body.
+var replayedHeaders = []string{"Content-Type", "Location"}
+
+// Idempotency makes the routes it guards safe to retry. The first request sent with
+// an Idempotency-Key header is processed and its response recorded; the retries
+// sent with the same key get that response again, with the Idempotent-Replayed
+// header, instead of being processed. Keys are scoped to the authenticated user,
+// so it must run after Authenticate on private routes. Anonymous requests cannot be
+// told apart from the requests of other clients: their keys are scoped to the
+// request itself, so only a retry of the same request, body included, is replayed.
+//
+// A key sent by a user with a different method, path, workspace or body is
+// rejected with 422, and a retry arriving while the first request is still
+// processed with 409.
+// Error responses are not recorded: the request can be fixed and sent again with
+// the same key. Requests without the header are processed as usual.
+func Idempotency(idempotency *services.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Request.Header[IdempotencyKeyHeader]; !ok {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.Error(problems.InvalidRequest("the body could not be read"))
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		key := c.GetHeader(IdempotencyKeyHeader)
+		fp := fingerprint(c, body)
+		userID := uuid.Nil
+		if principal, err := auth.PrincipalFrom(ctx); err == nil {
+			userID = principal.UserID
+		} else if key != "" && len(key) <= services.MaxIdempotencyKeyLength {
+			key = anonymousKey(key, fp)
+		}
+
+		replay, err := idempotency.Begin(ctx, userID, key, fp)
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		if replay != nil {
+			for name, values := range replay.Header {
+				c.Writer.Header()[name] = values
+			}
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Status(replay.Status)
+			c.Writer.Write(replay.Body)
+			c.Abort()
+			return
+		}
+
+		// The request is forgotten whenever its response is not recorded, even
+		// when the handler panics, so the client can retry it with the same key.
+		recorded := false
+		defer func() {
+			if recorded {
+				return
+			}
+			if err := idempotency.Abandon(context.WithoutCancel(ctx), userID, key); err != nil {
+				logging.FromContext(ctx).Error("failed to abandon idempotent request", "error", err)
+			}
+		}()
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		status := c.Writer.Status()
+		if len(c.Errors) > 0 || !c.Writer.Written() || status >= http.StatusBadRequest {
+			return
+		}
+
+		response := &domain.IdempotentResponse{Status: status, Header: map[string][]string{}, Body: writer.body.Bytes()}
+		for _, name := range replayedHeaders {
+			if values := c.Writer.Header().Values(name); len(values) > 0 {
+				response.Header[name] = values
+			}
+		}
+
+		if err := idempotency.Complete(context.WithoutCancel(ctx), userID, key, response); err != nil {
+			logging.FromContext(ctx).Error("failed to record idempotent response", "error", err)
+			return
+		}
+		recorded = true
+	}
+}
+
+// fingerprint identifies a request by its method, path, workspace and body, so a
+// key cannot be reused for a different request.
+func fingerprint(c *gin.Context, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+	if workspaceID, err := tenant.WorkspaceID(c.Request.Context()); err == nil {
+		hash.Write([]byte(workspaceID.String()))
+	}
+	hash.Write([]byte("\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// anonymousKey scopes the key of an anonymous request to the request, identified
+// by its fingerprint, so that different clients choosing the same key do not get
+// the responses of each other.
+func anonymousKey(key, fingerprint string) string {
+	hash := sha256.Sum256([]byte(key + "\n" + fingerprint))
+	return hex.EncodeToString(hash[:])
+}
+
+// recordingWriter copies the body of the response it writes.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/problems"
+	"github.com/fabianoflorentino/gotostudy/adapters/outbound/persistence/memory"
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/auth"
+	"github.com/fabianoflorentino/gotostudy/core/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// The requests are authenticated as the user of the X-User header.
+	authenticate := func(c *gin.Context) {
+		if id, err := uuid.Parse(c.GetHeader("X-User")); err == nil {
+			c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), auth.Principal{UserID: id}))
+		}
+	}
+
+	created := 0
+	r := gin.New()
+	r.Use(Problems())
+	r.POST("/users", authenticate, Idempotency(services.NewIdempotencyService(memory.NewIdempotencyStore(), time.Hour, time.Minute)), func(c *gin.Context) {
+		if c.Query("fail") != "" {
+			c.Error(core.ErrInvalidEmail)
+			return
+		}
+
+		created++
+		c.Header("Location", "/users/"+strconv.Itoa(created))
+		c.Header("X-Other", "not replayed")
+		c.JSON(http.StatusCreated, gin.H{"id": created})
+	})
+
+	userID := uuid.New()
+	postAs := func(user uuid.UUID, path, key, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		if user != uuid.Nil {
+			req.Header.Set("X-User", user.String())
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+	post := func(path, key, body string) *httptest.ResponseRecorder {
+		return postAs(userID, path, key, body)
+	}
+
+	t.Run("Replay", func(t *testing.T) {
+		first := post("/users", "signup", `{"name":"ana"}`)
+		retry := post("/users", "signup", `{"name":"ana"}`)
+
+		if created != 1 {
+			t.Fatalf("created %d users, want 1", created)
+		}
+		if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+			t.Errorf("retry = %d %s, want %d %s", retry.Code, retry.Body, first.Code, first.Body)
+		}
+		if retry.Header().Get("Location") != "/users/1" || retry.Header().Get(IdempotentReplayedHeader) != "true" {
+			t.Errorf("retry headers = %v", retry.Header())
+		}
+		if retry.Header().Get("X-Other") != "" || first.Header().Get(IdempotentReplayedHeader) != "" {
+			t.Errorf("headers = %v, %v", first.Header(), retry.Header())
+		}
+	})
+
+	t.Run("Reused", func(t *testing.T) {
+		w := post("/users", "signup", `{"name":"bia"}`)
+
+		if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), problems.TypeBaseURI+"idempotency-key-reused") {
+			t.Errorf("response = %d %s, want 422 idempotency-key-reused", w.Code, w.Body)
+		}
+	})
+
+	t.Run("OtherUser", func(t *testing.T) {
+		w := postAs(uuid.New(), "/users", "signup", `{"name":"ana"}`)
+
+		if created != 2 || w.Header().Get(IdempotentReplayedHeader) != "" {
+			t.Errorf("created %d users, replayed %q, want the request of another user processed", created, w.Header().Get(IdempotentReplayedHeader))
+		}
+	})
+
+	t.Run("AnonymousRetry", func(t *testing.T) {
+		first := postAs(uuid.Nil, "/users", "anonymous", `{"name":"ana"}`)
+		retry := postAs(uuid.Nil, "/users", "anonymous", `{"name":"ana"}`)
+
+		if created != 3 || retry.Header().Get(IdempotentReplayedHeader) != "true" || retry.Body.String() != first.Body.String() {
+			t.Errorf("created %d users, retry = %v %s, want the sign-up replayed", created, retry.Header(), retry.Body)
+		}
+	})
+
+	t.Run("AnonymousOtherRequest", func(t *testing.T) {
+		w := postAs(uuid.Nil, "/users", "anonymous", `{"name":"bia"}`)
+
+		if created != 4 || w.Code != http.StatusCreated || w.Header().Get(IdempotentReplayedHeader) != "" {
+			t.Errorf("created %d users, response = %d %v, want the request of another client processed", created, w.Code, w.Header())
+		}
+	})
+
+	t.Run("WithoutKey", func(t *testing.T) {
+		post("/users", "", `{"name":"ana"}`)
+		post("/users", "", `{"name":"ana"}`)
+
+		if created != 6 {
+			t.Errorf("created %d users, want 6", created)
+		}
+	})
+
+	t.Run("ErrorNotRecorded", func(t *testing.T) {
+		if w := post("/users?fail=error", "retry", `{}`); w.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("status = %d, want 422", w.Code)
+		}
+
+		if w := post("/users?fail=error", "retry", `{}`); w.Header().Get(IdempotentReplayedHeader) != "" {
+			t.Errorf("error response replayed: %v", w.Header())
+		}
+	})
+
+	t.Run("InvalidKey", func(t *testing.T) {
+		for _, user := range []uuid.UUID{userID, uuid.Nil} {
+			w := postAs(user, "/users", strings.Repeat("k", 256), `{}`)
+
+			if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), problems.TypeBaseURI+"invalid-idempotency-key") {
+				t.Errorf("response = %d %s, want 400 invalid-idempotency-key", w.Code, w.Body)
+			}
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	registry := prometheus.NewRegistry()
+	metrics, err := Metrics(registry)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	r := gin.New()
+	r.Use(metrics)
+	r.GET("/users/:id/tasks", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, path := range []string{"/users/" + uuid.NewString() + "/tasks", "/users/" + uuid.NewString() + "/tasks", "/missing"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	expected := `
+# HELP gotostudy_http_requests_total Number of HTTP requests, by method, route and status.
+# TYPE gotostudy_http_requests_total counter
+gotostudy_http_requests_total{method="GET",route="/users/:id/tasks",status="200"} 2
+gotostudy_http_requests_total{method="GET",route="unmatched",status="404"} 1
+`
+	if err := testutil.CollectAndCompare(registry, strings.NewReader(expected), "gotostudy_http_requests_total"); err != nil {
+		t.Errorf("Unexpected request counters: %v", err)
+	}
+
+	if got := testutil.CollectAndCount(registry, "gotostudy_http_request_duration_seconds"); got != 2 {
+		t.Errorf("Expected a latency series per route, got %d", got)
+	}
+}
@@ -0,0 +1,297 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/problems"
+	"github.com/fabianoflorentino/gotostudy/core/logging"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	openapi3filter.RegisterBodyDecoder("image/png", openapi3filter.FileBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("text/html", openapi3filter.FileBodyDecoder)
+}
+
+// OpenAPI validates the requests of the routes described by the OpenAPI document
+// doc before the handlers run. Invalid parameters are answered with an invalid
+// request problem and invalid bodies with a validation failure listing every
+// invalid field, as the controllers do. Credentials are left to Authenticate, and
+// the requests of routes missing from the document to the router.
+//
+// When validateResponses is set, the responses are buffered and validated too, and
+// a response that does not match the document is logged and replaced with an
+// internal error. It is meant for tests, so a drift between the controllers and the
+// document fails them. Streamed responses, server-sent events and WebSocket
+// upgrades, are not validated.
+func OpenAPI(doc *openapi3.T, validateResponses bool) (gin.HandlerFunc, error) {
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to route the OpenAPI document: %w", err)
+	}
+
+	options := &openapi3filter.Options{
+		MultiError:            true,
+		IncludeResponseStatus: true,
+		AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+	}
+
+	return func(c *gin.Context) {
+		route, params, err := router.FindRoute(c.Request)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: params,
+			Route:      route,
+			Options:    options,
+		}
+		if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+			problems.Write(c, problems.From(requestError(err)))
+			return
+		}
+
+		if !validateResponses || streamed(route.Operation) {
+			c.Next()
+			return
+		}
+
+		w := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		err = openapi3filter.ValidateResponse(c.Request.Context(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 w.status,
+			Header:                 w.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(w.body.Bytes())),
+			Options:                options,
+		})
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Error("response does not match the OpenAPI document", "error", err)
+			problems.Write(c, problems.From(err))
+			return
+		}
+
+		w.flush()
+	}, nil
+}
+
+// streamed reports whether the operation streams its response, as server-sent
+// events or over a WebSocket, so it cannot be buffered.
+func streamed(op *openapi3.Operation) bool {
+	if op.Responses.Value(strconv.Itoa(http.StatusSwitchingProtocols)) != nil {
+		return true
+	}
+
+	ok := op.Responses.Status(http.StatusOK)
+	return ok != nil && ok.Value != nil && ok.Value.Content.Get("text/event-stream") != nil
+}
+
+// requestError converts the errors of the validation of a request into the errors
+// of package problems: an unsupported media type or field errors for the body, an
+// invalid request otherwise.
+func requestError(err error) error {
+	var fields []problems.FieldError
+	var params []string
+
+	for _, err := range flatten(err) {
+		var reqErr *openapi3filter.RequestError
+		if !errors.As(err, &reqErr) {
+			return problems.InvalidRequest(err.Error())
+		}
+
+		switch {
+		case reqErr.Parameter != nil:
+			params = append(params, fmt.Sprintf("the %s parameter %q is invalid", reqErr.Parameter.In, reqErr.Parameter.Name))
+		case reqErr.RequestBody != nil && strings.HasPrefix(reqErr.Reason, "header Content-Type has unexpected value"):
+			return fmt.Errorf("%w: %q", problems.ErrUnsupportedMediaType, reqErr.Input.Request.Header.Get("Content-Type"))
+		case reqErr.RequestBody != nil:
+			schemaErrs := schemaErrors(reqErr.Err)
+			if len(schemaErrs) == 0 {
+				return problems.InvalidRequest("the body must be a JSON object")
+			}
+			for _, schemaErr := range schemaErrs {
+				fields = append(fields, fieldError(schemaErr))
+			}
+		default:
+			return problems.InvalidRequest(reqErr.Error())
+		}
+	}
+
+	if len(params) > 0 {
+		return problems.InvalidRequest(strings.Join(params, "; "))
+	}
+
+	return &problems.ValidationError{Errors: fields}
+}
+
+// schemaErrors returns the schema errors within err.
+func schemaErrors(err error) []*openapi3.SchemaError {
+	var errs []*openapi3.SchemaError
+	for _, err := range flatten(err) {
+		var schemaErr *openapi3.SchemaError
+		if errors.As(err, &schemaErr) {
+			errs = append(errs, schemaErr)
+		}
+	}
+
+	return errs
+}
+
+// flatten returns the errors of err when it is a multi error, nested ones included,
+// and err alone otherwise.
+func flatten(err error) []error {
+	multi, ok := err.(openapi3.MultiError)
+	if !ok {
+		return []error{err}
+	}
+
+	var errs []error
+	for _, err := range multi {
+		errs = append(errs, flatten(err)...)
+	}
+
+	return errs
+}
+
+// fieldError describes a schema error with the rules of the messages of package
+// problems, as the request binding does.
+func fieldError(err *openapi3.SchemaError) problems.FieldError {
+	var path strings.Builder
+	for _, key := range err.JSONPointer() {
+		if _, convErr := strconv.Atoi(key); convErr == nil {
+			path.WriteString("[" + key + "]")
+			continue
+		}
+		if path.Len() > 0 {
+			path.WriteString(".")
+		}
+		path.WriteString(key)
+	}
+
+	schema := err.Schema
+	if schema == nil {
+		schema = &openapi3.Schema{}
+	}
+
+	switch err.SchemaField {
+	case "required":
+		return problems.NewFieldError(path.String(), "required", "")
+	case "properties":
+		// kin-openapi names an unsupported property in the reason only.
+		quoted, ok := strings.CutPrefix(err.Reason, "property ")
+		quoted, ok2 := strings.CutSuffix(quoted, " is unsupported")
+		if name, unqErr := strconv.Unquote(quoted); ok && ok2 && unqErr == nil {
+			if path.Len() > 0 {
+				path.WriteString(".")
+			}
+			path.WriteString(name)
+			return problems.NewFieldError(path.String(), "unknown", "")
+		}
+	case "minLength":
+		return problems.NewFieldError(path.String(), "min", strconv.FormatUint(schema.MinLength, 10))
+	case "maxLength":
+		return problems.NewFieldError(path.String(), "max", formatLimit(schema.MaxLength))
+	case "minItems":
+		return problems.NewFieldError(path.String(), "min.items", strconv.FormatUint(schema.MinItems, 10))
+	case "maxItems":
+		return problems.NewFieldError(path.String(), "max.items", formatLimit(schema.MaxItems))
+	case "enum":
+		values := make([]string, len(schema.Enum))
+		for i, v := range schema.Enum {
+			values[i] = fmt.Sprint(v)
+		}
+		return problems.NewFieldError(path.String(), "oneof", strings.Join(values, ", "))
+	case "format":
+		if schema.Format == "email" || schema.Format == "uuid" {
+			return problems.NewFieldError(path.String(), schema.Format, "")
+		}
+	case "type":
+		switch t := schema.Type.Slice(); {
+		case len(t) == 0:
+		case t[0] == openapi3.TypeInteger:
+			return problems.NewFieldError(path.String(), "type.number", "")
+		default:
+			return problems.NewFieldError(path.String(), "type."+t[0], "")
+		}
+	}
+
+	return problems.NewFieldError(path.String(), "invalid", "")
+}
+
+// formatLimit formats an optional length limit of a schema.
+func formatLimit(limit *uint64) string {
+	if limit == nil {
+		return ""
+	}
+
+	return strconv.FormatUint(*limit, 10)
+}
+
+// bufferedWriter holds a response until it is validated.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status  int
+	body    bytes.Buffer
+	written bool
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if !w.written {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.written
+}
+
+// Flush is a no-op: the response is sent once validated.
+func (w *bufferedWriter) Flush() {}
+
+// flush sends the buffered response.
+func (w *bufferedWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.WriteHeaderNow()
+	w.ResponseWriter.Write(w.body.Bytes())
+}
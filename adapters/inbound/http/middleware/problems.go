@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/problems"
+	"github.com/fabianoflorentino/gotostudy/core/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// Problems renders the last error attached with c.Error by the following handlers as
+// a problem+json response, unless they already wrote a response. The controllers and
+// middlewares attach the errors of the core services as they are and leave choosing
+// the status code to the mapping of package problems. Internal errors are logged, as
+// their message is not disclosed to the client.
+func Problems() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		problem := problems.From(err)
+		if problem.Status >= 500 {
+			logging.FromContext(c.Request.Context()).Error("request failed", "error", err)
+		}
+
+		problems.Write(c, problem)
+	}
+}
+
+// NoRoute reports the requests for paths the API does not serve as a problem.
+func NoRoute(c *gin.Context) {
+	c.Error(problems.ErrRouteNotFound)
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/problems"
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/gin-gonic/gin"
+)
+
+func TestProblems(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(Problems())
+	r.NoRoute(NoRoute)
+	r.GET("/users/:id", func(c *gin.Context) { c.Error(core.ErrUserNotFound) })
+	r.GET("/private", func(c *gin.Context) { unauthorized(c, core.ErrInvalidToken) })
+	r.GET("/written", func(c *gin.Context) {
+		c.Error(core.ErrUserNotFound)
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	serve := func(path string) (*httptest.ResponseRecorder, problems.Problem) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		var p problems.Problem
+		json.Unmarshal(w.Body.Bytes(), &p)
+		return w, p
+	}
+
+	t.Run("core error", func(t *testing.T) {
+		w, p := serve("/users/42")
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404", w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != problems.ContentType {
+			t.Errorf("Content-Type = %q, want %q", ct, problems.ContentType)
+		}
+		if p.Type != problems.TypeBaseURI+"user-not-found" || p.Status != http.StatusNotFound || p.Instance != "/users/42" {
+			t.Errorf("problem = %+v", p)
+		}
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		w, p := serve("/private")
+
+		if w.Code != http.StatusUnauthorized || p.Type != problems.TypeBaseURI+"unauthenticated" {
+			t.Errorf("status = %d, problem = %+v, want 401 unauthenticated", w.Code, p)
+		}
+		if w.Header().Get("WWW-Authenticate") == "" {
+			t.Error("missing WWW-Authenticate challenge")
+		}
+	})
+
+	t.Run("response already written", func(t *testing.T) {
+		w, _ := serve("/written")
+
+		if w.Code != http.StatusOK {
+			t.Errorf("status = %d, want the 200 written by the handler", w.Code)
+		}
+	})
+
+	t.Run("localized", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+		req.Header.Set("Accept-Language", "pt-BR,pt;q=0.9,en;q=0.8")
+		r.ServeHTTP(w, req)
+
+		var p problems.Problem
+		json.Unmarshal(w.Body.Bytes(), &p)
+
+		if lang := w.Header().Get("Content-Language"); lang != problems.BrazilianPortuguese {
+			t.Errorf("Content-Language = %q, want %q", lang, problems.BrazilianPortuguese)
+		}
+		if p.Type != problems.TypeBaseURI+"user-not-found" || p.Title != "Usuário não encontrado" {
+			t.Errorf("problem = %+v", p)
+		}
+	})
+
+	t.Run("unknown route", func(t *testing.T) {
+		w, p := serve("/nowhere")
+
+		if w.Code != http.StatusNotFound || p.Type != problems.TypeBaseURI+"route-not-found" {
+			t.Errorf("status = %d, problem = %+v, want 404 route-not-found", w.Code, p)
+		}
+	})
+}
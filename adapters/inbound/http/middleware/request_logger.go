@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/problems"
+	"github.com/fabianoflorentino/gotostudy/core/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader is the header carrying the correlation ID of a request.
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID restricts the request IDs accepted from clients, so they can be
+// logged and echoed back safely.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestLogger assigns every request an ID, reusing the X-Request-ID header sent by
+// the client or a proxy when valid, and echoes it back in the response. It stores a
+// logger tagged with the ID in the request context and writes an access log line
+// once the request completes: at the error level for 5xx responses, warn for 4xx and
+// info otherwise.
+func RequestLogger(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		ctx := logging.WithRequestID(c.Request.Context(), requestID)
+		ctx = logging.WithLogger(ctx, logger.With("request_id", requestID))
+		c.Request = c.Request.WithContext(ctx)
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		// The logger of the request context was enriched by the later middlewares.
+		logging.FromContext(c.Request.Context()).LogAttrs(c.Request.Context(), level, "request completed",
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+		)
+	}
+}
+
+// Recovery turns a panic in a handler into a 500 problem response and logs it, with
+// the stack trace, with the logger of the request.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, recovered any) {
+		logging.FromContext(c.Request.Context()).Error("panic recovered",
+			"panic", recovered,
+			"stack", string(debug.Stack()),
+		)
+		problems.Write(c, problems.From(fmt.Errorf("panic: %v", recovered)))
+	})
+}
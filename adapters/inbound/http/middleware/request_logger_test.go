@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fabianoflorentino/gotostudy/core/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestRequestLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var out bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&out, nil))
+
+	r := gin.New()
+	r.Use(RequestLogger(logger), Recovery())
+	r.GET("/tasks/:id", func(c *gin.Context) {
+		logging.FromContext(c.Request.Context()).Info("handling")
+		c.Status(http.StatusNotFound)
+	})
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	records := func() []map[string]any {
+		var records []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			var record map[string]any
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("Invalid log line %q: %v", line, err)
+			}
+			records = append(records, record)
+		}
+		out.Reset()
+		return records
+	}
+
+	t.Run("ReusesValidRequestID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/tasks/1", nil)
+		req.Header.Set(RequestIDHeader, "abc-123")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if got := w.Header().Get(RequestIDHeader); got != "abc-123" {
+			t.Errorf("Expected request ID abc-123, got %q", got)
+		}
+
+		logs := records()
+		if len(logs) != 2 {
+			t.Fatalf("Expected 2 log lines, got %d", len(logs))
+		}
+		for _, record := range logs {
+			if record["request_id"] != "abc-123" {
+				t.Errorf("Expected request_id abc-123, got %v", record["request_id"])
+			}
+		}
+
+		access := logs[1]
+		if access["level"] != "WARN" || access["route"] != "/tasks/:id" || access["status"] != float64(http.StatusNotFound) {
+			t.Errorf("Unexpected access log %v", access)
+		}
+	})
+
+	t.Run("GeneratesRequestID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/tasks/1", nil)
+		req.Header.Set(RequestIDHeader, "not valid\n")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		records()
+
+		if _, err := uuid.Parse(w.Header().Get(RequestIDHeader)); err != nil {
+			t.Errorf("Expected a generated UUID, got %q", w.Header().Get(RequestIDHeader))
+		}
+	})
+
+	t.Run("RecoversPanic", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+
+		logs := records()
+		if len(logs) != 2 || logs[0]["msg"] != "panic recovered" || logs[1]["level"] != "ERROR" {
+			t.Errorf("Unexpected logs %v", logs)
+		}
+	})
+}
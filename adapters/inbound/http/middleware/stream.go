@@ -0,0 +1,22 @@
+package middleware
+
+import "github.com/gin-gonic/gin"
+
+// StreamCredentials lets the clients of the event streams authenticate with query
+// parameters, as browsers cannot set headers on EventSource and WebSocket requests:
+// the access_token and workspace parameters stand for the Authorization and
+// X-Workspace-ID headers the request does not send. It must run before Workspace
+// and Authenticate, and only on the stream routes, as URLs end up in the history
+// of browsers and in the logs of proxies.
+func StreamCredentials() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := c.Query("access_token"); token != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+		if workspace := c.Query("workspace"); workspace != "" && c.GetHeader(WorkspaceHeader) == "" {
+			c.Request.Header.Set(WorkspaceHeader, workspace)
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestStreamCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/events", StreamCredentials(), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetHeader("Authorization")+"|"+c.GetHeader(WorkspaceHeader))
+	})
+
+	serve := func(req *http.Request) string {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	t.Run("QueryParameters", func(t *testing.T) {
+		got := serve(httptest.NewRequest(http.MethodGet, "/events?access_token=abc&workspace=acme", nil))
+
+		if want := "Bearer abc|acme"; got != want {
+			t.Errorf("headers = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("HeadersWin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/events?access_token=abc&workspace=acme", nil)
+		req.Header.Set("Authorization", "Bearer xyz")
+		req.Header.Set(WorkspaceHeader, "default")
+
+		if got, want := serve(req), "Bearer xyz|default"; got != want {
+			t.Errorf("headers = %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	r := gin.New()
+	r.Use(Tracing(provider, propagation.TraceContext{}))
+	r.GET("/users/:id", func(c *gin.Context) {
+		if !trace.SpanContextFromContext(c.Request.Context()).IsValid() {
+			t.Error("Expected the span in the request context")
+		}
+		c.Status(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	span := spans[0]
+	t.Run("RouteTemplateName", func(t *testing.T) {
+		if span.Name != "GET /users/:id" {
+			t.Errorf("Expected span GET /users/:id, got %q", span.Name)
+		}
+	})
+
+	t.Run("ContinuesIncomingTrace", func(t *testing.T) {
+		if got := span.SpanContext.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("Expected the trace of the traceparent header, got %s", got)
+		}
+		if got := span.Parent.SpanID().String(); got != "00f067aa0ba902b7" {
+			t.Errorf("Expected the parent of the traceparent header, got %s", got)
+		}
+	})
+
+	t.Run("ServerErrorStatus", func(t *testing.T) {
+		if span.Status.Code != codes.Error {
+			t.Errorf("Expected an error status, got %v", span.Status.Code)
+		}
+	})
+}
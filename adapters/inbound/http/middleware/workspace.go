@@ -0,0 +1,73 @@
+// Package middleware contains the Gin middlewares shared by the HTTP routes.
+// They prepare the request context (workspace, authentication, logging, ...)
+// before the controllers delegate to the core services.
+package middleware
+
+import (
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/auth"
+	"github.com/fabianoflorentino/gotostudy/core/logging"
+	"github.com/fabianoflorentino/gotostudy/core/services"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
+	"github.com/gin-gonic/gin"
+)
+
+// WorkspaceHeader is the request header used to select a workspace by ID or slug.
+const WorkspaceHeader = "X-Workspace-ID"
+
+// Workspace resolves the workspace selected by the X-Workspace-ID header, falling
+// back to the default workspace, and stores it in the request context so every
+// repository query is scoped to it and every log line of the request tagged with it.
+// Unknown workspaces are rejected with 404.
+func Workspace(workspaces *services.WorkspaceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspace, err := workspaces.ResolveWorkspace(c.Request.Context(), c.GetHeader(WorkspaceHeader))
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		ctx := tenant.WithWorkspace(c.Request.Context(), workspace.ID)
+		c.Request = c.Request.WithContext(logging.With(ctx, "workspace_id", workspace.ID))
+		c.Header(WorkspaceHeader, workspace.ID.String())
+
+		c.Next()
+	}
+}
+
+// DefaultWorkspaceForAnonymous rejects with 403 the requests without a principal
+// run inside a workspace other than the default one, resolved by Workspace. Users
+// signing up join the default workspace; the owners and admins of the others add
+// their members.
+func DefaultWorkspaceForAnonymous(workspaces *services.WorkspaceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if _, err := auth.PrincipalFrom(ctx); err == nil {
+			c.Next()
+			return
+		}
+
+		workspaceID, err := tenant.WorkspaceID(ctx)
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		workspace, err := workspaces.ResolveWorkspace(ctx, "")
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		if workspace.ID != workspaceID {
+			c.Error(core.ErrWorkspaceAccessDenied)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
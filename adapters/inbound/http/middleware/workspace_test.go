@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/auth"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+	"github.com/fabianoflorentino/gotostudy/core/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// memoryWorkspaces is a WorkspaceRepository finding the workspaces it holds.
+type memoryWorkspaces struct {
+	ports.WorkspaceRepository
+	workspaces []*domain.Workspace
+}
+
+func (m *memoryWorkspaces) FindByID(_ context.Context, id uuid.UUID) (*domain.Workspace, error) {
+	for _, workspace := range m.workspaces {
+		if workspace.ID == id {
+			return workspace, nil
+		}
+	}
+	return nil, core.ErrWorkspaceNotFound
+}
+
+func (m *memoryWorkspaces) FindBySlug(_ context.Context, slug string) (*domain.Workspace, error) {
+	for _, workspace := range m.workspaces {
+		if workspace.Slug == slug {
+			return workspace, nil
+		}
+	}
+	return nil, core.ErrWorkspaceNotFound
+}
+
+func TestDefaultWorkspaceForAnonymous(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	other := &domain.Workspace{ID: uuid.New(), Slug: "other"}
+	workspaces := services.NewWorkspaceService(&memoryWorkspaces{workspaces: []*domain.Workspace{
+		{ID: uuid.New(), Slug: domain.DefaultWorkspaceSlug},
+		other,
+	}}, nil)
+
+	// The requests are authenticated when they have an X-User header.
+	authenticate := func(c *gin.Context) {
+		if id, err := uuid.Parse(c.GetHeader("X-User")); err == nil {
+			c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), auth.Principal{UserID: id}))
+		}
+	}
+
+	r := gin.New()
+	r.Use(Problems())
+	r.POST("/users", Workspace(workspaces), authenticate, DefaultWorkspaceForAnonymous(workspaces), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	tests := []struct {
+		name      string
+		workspace string
+		user      string
+		want      int
+	}{
+		{"DefaultWorkspace", "", "", http.StatusCreated},
+		{"DefaultWorkspaceBySlug", domain.DefaultWorkspaceSlug, "", http.StatusCreated},
+		{"OtherWorkspace", other.ID.String(), "", http.StatusForbidden},
+		{"OtherWorkspaceBySlug", other.Slug, "", http.StatusForbidden},
+		{"OtherWorkspaceAuthenticated", other.Slug, uuid.NewString(), http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/users", nil)
+			if tt.workspace != "" {
+				req.Header.Set(WorkspaceHeader, tt.workspace)
+			}
+			if tt.user != "" {
+				req.Header.Set("X-User", tt.user)
+			}
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
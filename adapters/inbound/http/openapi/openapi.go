@@ -0,0 +1,55 @@
+// Package openapi holds the OpenAPI 3.1 document describing the HTTP API, in
+// openapi.yaml, and the page rendering it. The document is the contract of the
+// controllers: keep it in sync with the routes of internal/server and with the
+// requests and responses packages. The tests of internal/server check that it
+// documents every route, and the OpenAPI middleware validates the requests, and in
+// tests the responses, against it.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	//go:embed openapi.yaml
+	document []byte
+
+	// DocsPage is the HTML page rendering the document served on /openapi.json.
+	//go:embed docs.html
+	DocsPage []byte
+)
+
+var (
+	loadOnce sync.Once
+	loaded   *openapi3.T
+	loadErr  error
+)
+
+// Load parses the OpenAPI document of the API. The document is parsed once and
+// shared, so callers must not modify it.
+func Load() (*openapi3.T, error) {
+	loadOnce.Do(func() {
+		loaded, loadErr = openapi3.NewLoader().LoadFromData(document)
+		if loadErr != nil {
+			loadErr = fmt.Errorf("failed to load the OpenAPI document: %w", loadErr)
+		}
+	})
+
+	return loaded, loadErr
+}
+
+// JSON returns the OpenAPI document of the API as JSON, as written in openapi.yaml.
+func JSON() ([]byte, error) {
+	var doc any
+	if err := yaml.Unmarshal(document, &doc); err != nil {
+		return nil, fmt.Errorf("failed to read the OpenAPI document: %w", err)
+	}
+
+	return json.Marshal(doc)
+}
@@ -0,0 +1,173 @@
+package problems
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Languages of the messages of the API. English is the default and the fallback of
+// the messages missing from the other catalogs.
+const (
+	English             = "en"
+	BrazilianPortuguese = "pt-BR"
+)
+
+// catalog holds the messages of a language: the titles of the problem types by
+// slug, the details of the errors by error value and the messages of the field
+// errors by validation rule. The messages of the rules are formats taking the
+// parameter of the rule.
+type catalog struct {
+	titles map[string]string
+	errors map[error]string
+	rules  map[string]string
+}
+
+// catalogs maps the supported languages to their messages.
+var catalogs = map[string]catalog{
+	English:             en,
+	BrazilianPortuguese: ptBR,
+}
+
+// Negotiate returns the supported language the client prefers the most according
+// to the value of an Accept-Language header, or English when it accepts none of
+// them. Regional variants match their language, so "pt" and "pt-PT" select
+// Brazilian Portuguese and "en-US" selects English.
+func Negotiate(acceptLanguage string) string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var ranges []weighted
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if tag != "" && q > 0 {
+			ranges = append(ranges, weighted{strings.ToLower(tag), q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	for _, r := range ranges {
+		if r.tag == "*" {
+			return English
+		}
+		base, _, _ := strings.Cut(r.tag, "-")
+		for lang := range catalogs {
+			if base == strings.ToLower(strings.SplitN(lang, "-", 2)[0]) {
+				return lang
+			}
+		}
+	}
+
+	return English
+}
+
+// Localize returns p with its title, detail and field errors in lang. Messages
+// missing from the catalog of lang are taken from the English catalog, and the
+// original messages are kept when neither has them. The context wrapped around an
+// error, such as the invalid scope of an API key, is kept after the message.
+func (p Problem) Localize(lang string) Problem {
+	if title, ok := lookup(lang, func(c catalog) (string, bool) { s, ok := c.titles[p.slug]; return s, ok }); ok {
+		p.Title = title
+	}
+
+	if p.target != nil {
+		if detail, ok := errorMessage(lang, p.target); ok {
+			if context := p.context(lang); context != "" {
+				detail += ": " + context
+			}
+			p.Detail = detail
+		}
+	}
+
+	if len(p.Errors) > 0 {
+		errs := make([]FieldError, len(p.Errors))
+		for i, fe := range p.Errors {
+			errs[i] = fe.localize(lang)
+		}
+		p.Errors = errs
+	}
+
+	return p
+}
+
+// context returns what the error of p adds to the message of the error it matched,
+// in lang when it is another error of the catalogs. The messages of the field errors
+// are the context of a validation failure.
+func (p Problem) context(lang string) string {
+	if len(p.Errors) > 0 {
+		msgs := make([]string, len(p.Errors))
+		for i, fe := range p.Errors {
+			msgs[i] = fe.Field + " " + fe.localize(lang).Message
+		}
+		return strings.Join(msgs, "; ")
+	}
+
+	if p.err == nil || p.err == p.target {
+		return ""
+	}
+
+	context, ok := strings.CutPrefix(p.err.Error(), p.target.Error()+": ")
+	if !ok {
+		return ""
+	}
+	for err := range en.errors {
+		if err.Error() == context {
+			if msg, ok := errorMessage(lang, err); ok {
+				return msg
+			}
+		}
+	}
+
+	return context
+}
+
+// localize returns fe with its message in lang. Field errors not created by
+// NewFieldError keep their message.
+func (fe FieldError) localize(lang string) FieldError {
+	if fe.rule != "" {
+		fe.Message = ruleMessage(lang, fe.rule, fe.param)
+	}
+
+	return fe
+}
+
+// errorMessage returns the message of err in lang.
+func errorMessage(lang string, err error) (string, bool) {
+	return lookup(lang, func(c catalog) (string, bool) { s, ok := c.errors[err]; return s, ok })
+}
+
+// ruleMessage returns the message of a validation rule with its parameter in lang.
+// Unknown rules are described as invalid values.
+func ruleMessage(lang, rule, param string) string {
+	format, ok := lookup(lang, func(c catalog) (string, bool) { s, ok := c.rules[rule]; return s, ok })
+	if !ok {
+		format, _ = lookup(lang, func(c catalog) (string, bool) { s, ok := c.rules["invalid"]; return s, ok })
+	}
+	if !strings.Contains(format, "%") {
+		return format
+	}
+
+	return fmt.Sprintf(format, param)
+}
+
+// lookup finds a message in the catalog of lang, then in the English one.
+func lookup(lang string, find func(catalog) (string, bool)) (string, bool) {
+	if c, ok := catalogs[lang]; ok {
+		if msg, ok := find(c); ok {
+			return msg, true
+		}
+	}
+
+	return find(en)
+}
@@ -0,0 +1,111 @@
+package problems
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", English},
+		{"pt-BR", BrazilianPortuguese},
+		{"pt-br,pt;q=0.9,en;q=0.8", BrazilianPortuguese},
+		{"pt-PT", BrazilianPortuguese},
+		{"en-US,en;q=0.9", English},
+		{"fr-FR,pt;q=0.5", BrazilianPortuguese},
+		{"en;q=0.4,pt-BR;q=0.8", BrazilianPortuguese},
+		{"pt-BR;q=0,en", English},
+		{"fr, de", English},
+		{"*", English},
+	}
+
+	for _, tt := range tests {
+		if got := Negotiate(tt.header); got != tt.want {
+			t.Errorf("Negotiate(%q) = %s, want %s", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	t.Run("portuguese", func(t *testing.T) {
+		p := From(core.ErrUserNotFound).Localize(BrazilianPortuguese)
+
+		if p.Title != "Usuário não encontrado" || p.Detail != "O usuário não foi encontrado" {
+			t.Errorf("problem = %+v", p)
+		}
+	})
+
+	t.Run("wrapped context", func(t *testing.T) {
+		p := From(fmt.Errorf("%w: tasks:write", core.ErrInsufficientScope)).Localize(BrazilianPortuguese)
+		if want := "A chave de API não concede o escopo necessário: tasks:write"; p.Detail != want {
+			t.Errorf("Detail = %q, want %q", p.Detail, want)
+		}
+
+		p = From(fmt.Errorf("%w: %w", core.ErrUnauthenticated, core.ErrInvalidToken)).Localize(BrazilianPortuguese)
+		if want := "É necessário se autenticar: O token é inválido ou expirou"; p.Detail != want {
+			t.Errorf("Detail = %q, want %q", p.Detail, want)
+		}
+	})
+
+	t.Run("field errors", func(t *testing.T) {
+		err := &ValidationError{Errors: []FieldError{
+			NewFieldError("title", "min", "3"),
+			NewFieldError("scopes", "min.items", "1"),
+		}}
+		p := From(err).Localize(BrazilianPortuguese)
+
+		if p.Errors[0].Message != "deve ter pelo menos 3 caracteres" || p.Errors[1].Code != "min" {
+			t.Errorf("Errors = %+v", p.Errors)
+		}
+		if want := "Alguns campos são inválidos: title deve ter pelo menos 3 caracteres; scopes deve ter pelo menos 1 itens"; p.Detail != want {
+			t.Errorf("Detail = %q, want %q", p.Detail, want)
+		}
+		if err.Errors[0].Message != "must have at least 3 characters" {
+			t.Errorf("the error was changed: %+v", err.Errors[0])
+		}
+	})
+
+	t.Run("internal error", func(t *testing.T) {
+		p := From(fmt.Errorf("pq: connection refused")).Localize(BrazilianPortuguese)
+
+		if p.Detail != ptBR.errors[errInternal] {
+			t.Errorf("Detail = %q", p.Detail)
+		}
+	})
+
+	t.Run("missing translation", func(t *testing.T) {
+		catalogs["xx"] = catalog{}
+		defer delete(catalogs, "xx")
+
+		p := From(core.ErrTaskNotFound).Localize("xx")
+
+		if p.Title != "Task not found" || p.Detail != en.errors[core.ErrTaskNotFound] {
+			t.Errorf("problem = %+v, want the English messages", p)
+		}
+	})
+}
+
+func TestCatalogsAreComplete(t *testing.T) {
+	for lang, c := range catalogs {
+		for _, pt := range append(types, internalError) {
+			if _, ok := c.titles[pt.slug]; !ok && lang != English {
+				t.Errorf("%s: missing the title of %s", lang, pt.slug)
+			}
+			for _, err := range pt.errs {
+				if _, ok := c.errors[err]; !ok {
+					t.Errorf("%s: missing the message of %q", lang, err)
+				}
+			}
+		}
+		for rule := range en.rules {
+			if _, ok := c.rules[rule]; !ok {
+				t.Errorf("%s: missing the message of the rule %s", lang, rule)
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package problems
+
+import "github.com/fabianoflorentino/gotostudy/core"
+
+// en is the catalog of English messages, the fallback of the other catalogs. The
+// titles of the problem types are the ones of types.
+var en = catalog{
+	titles: map[string]string{},
+	errors: map[error]string{
+		ErrInvalidRequest:       "The request is invalid",
+		ErrRouteNotFound:        "The API does not serve this path",
+		ErrValidationFailed:     "Some fields are invalid",
+		ErrUnsupportedMediaType: "The content type of the body is not supported",
+		ErrPatchConflict:        "The patch cannot be applied to the resource",
+		errInternal:             "The server could not complete the request.",
+
+		core.ErrUnauthenticated:    "Authentication is required",
+		core.ErrInvalidCredentials: "The email address or the password is wrong",
+		core.ErrTwoFactorRequired:  "The two-factor authentication code is required",
+		core.ErrSingleSignOn:       "The single sign-on failed",
+		core.ErrInvalidLoginState:  "The single sign-on is invalid or expired, start it again",
+
+		core.ErrForbidden:             "The operation is not permitted",
+		core.ErrAccessDenied:          "Access denied",
+		core.ErrInsufficientScope:     "The API key does not grant the required scope",
+		core.ErrTaskAccessDenied:      "You do not have access to this task",
+		core.ErrWorkspaceAccessDenied: "You do not have the workspace role this operation requires",
+		core.ErrEmailNotConfirmed:     "The email address is not confirmed yet",
+		core.ErrEmailNotVerified:      "The identity provider has not verified the email address",
+
+		core.ErrUserNotFound:           "The user was not found",
+		core.ErrTaskNotFound:           "The task was not found",
+		core.ErrNoTasksFound:           "The user has no tasks",
+		core.ErrTaskShareNotFound:      "The task is not shared with this user",
+		core.ErrWorkspaceNotFound:      "The workspace was not found",
+		core.ErrWorkspaceMemberMissing: "The user is not a member of the workspace",
+		core.ErrAPIKeyNotFound:         "The API key was not found",
+		core.ErrTwoFactorNotFound:      "Two-factor authentication is not set up",
+
+		core.ErrEmailAlreadyExists:       "The email address is already in use",
+		core.ErrUserAlreadyExists:        "The user already exists",
+		core.ErrWorkspaceSlugExists:      "Another workspace already uses this name",
+		core.ErrLastWorkspaceOwner:       "The workspace must keep at least one owner",
+		core.ErrTwoFactorAlreadyEnabled:  "Two-factor authentication is already enabled",
+		core.ErrIdempotencyKeyInProgress: "A request with this idempotency key is still in progress, retry it later",
+
+		core.ErrInvalidToken:          "The token is invalid or expired",
+		core.ErrInvalidTaskID:         "The task ID is invalid",
+		core.ErrWorkspaceRequired:     "A workspace is required",
+		core.ErrInvalidIdempotencyKey: "The idempotency key must have between 1 and 255 characters",
+
+		core.ErrInvalidEmail:         "The email address is invalid",
+		core.ErrInvalidPassword:      "The password must have between 8 and 72 characters",
+		core.ErrTaskTitleValid:       "The task title must have at least 3 characters",
+		core.ErrInvalidTaskRole:      "The role of a task share must be viewer or editor",
+		core.ErrShareWithOwner:       "A task cannot be shared with its owner",
+		core.ErrInvalidWorkspaceName: "The workspace name is invalid",
+		core.ErrInvalidWorkspaceRole: "The workspace role must be owner, admin or member",
+		core.ErrInvalidAPIKeyName:    "The API key name is invalid",
+		core.ErrInvalidAPIKeyScope:   "The API key scope is invalid",
+		core.ErrInvalidOTP:           "The two-factor authentication code is wrong",
+		core.ErrIdempotencyKeyReused: "The idempotency key was already used for a different request",
+		core.ErrInvalidTaskBatch:     "A task batch must have between 1 and 100 operations",
+		core.ErrInvalidTaskOperation: "The task operation is invalid",
+
+		core.ErrTaskBatchAborted: "The operation was not applied because another operation of the batch failed",
+
+		core.ErrEventBusClosed: "The server is shutting down, reconnect later",
+	},
+	rules: map[string]string{
+		"invalid":      "is invalid",
+		"unknown":      "is not an accepted field",
+		"required":     "is required",
+		"email":        "must be a valid email address",
+		"uuid":         "must be a valid UUID",
+		"min":          "must have at least %s characters",
+		"min.items":    "must have at least %s items",
+		"max":          "must have at most %s characters",
+		"max.items":    "must have at most %s items",
+		"oneof":        "must be one of %s",
+		"type":         "has the wrong type",
+		"type.string":  "must be a string",
+		"type.boolean": "must be a boolean",
+		"type.number":  "must be a number",
+		"type.array":   "must be an array",
+		"type.object":  "must be an object",
+	},
+}
@@ -0,0 +1,124 @@
+package problems
+
+import "github.com/fabianoflorentino/gotostudy/core"
+
+// ptBR is the catalog of Brazilian Portuguese messages.
+var ptBR = catalog{
+	titles: map[string]string{
+		"unauthenticated":             "Autenticação necessária",
+		"invalid-credentials":         "Credenciais inválidas",
+		"two-factor-required":         "Autenticação em dois fatores necessária",
+		"single-sign-on-failed":       "Falha no login único",
+		"forbidden":                   "Operação não permitida",
+		"insufficient-scope":          "Escopo da chave de API insuficiente",
+		"task-access-denied":          "Acesso à tarefa negado",
+		"workspace-access-denied":     "Acesso ao workspace negado",
+		"email-not-confirmed":         "Endereço de email não confirmado",
+		"email-not-verified":          "Endereço de email não verificado pelo provedor de identidade",
+		"route-not-found":             "Rota não encontrada",
+		"user-not-found":              "Usuário não encontrado",
+		"task-not-found":              "Tarefa não encontrada",
+		"task-share-not-found":        "Compartilhamento da tarefa não encontrado",
+		"workspace-not-found":         "Workspace não encontrado",
+		"workspace-member-not-found":  "Membro do workspace não encontrado",
+		"api-key-not-found":           "Chave de API não encontrada",
+		"two-factor-not-found":        "Autenticação em dois fatores não configurada",
+		"email-already-exists":        "Endereço de email já está em uso",
+		"workspace-slug-exists":       "Slug do workspace já está em uso",
+		"last-workspace-owner":        "O workspace precisa manter um proprietário",
+		"two-factor-already-enabled":  "Autenticação em dois fatores já habilitada",
+		"patch-conflict":              "Conflito no patch",
+		"idempotency-key-in-progress": "Requisição idempotente em andamento",
+		"invalid-request":             "Requisição inválida",
+		"invalid-token":               "Token inválido ou expirado",
+		"invalid-id":                  "ID inválido",
+		"workspace-required":          "Workspace obrigatório",
+		"invalid-idempotency-key":     "Chave de idempotência inválida",
+		"unsupported-media-type":      "Tipo de mídia não suportado",
+		"validation-failed":           "Falha na validação",
+		"invalid-otp":                 "Código de autenticação em dois fatores inválido",
+		"idempotency-key-reused":      "Chave de idempotência reutilizada",
+		"task-batch-aborted":          "Lote de tarefas abortado",
+		"shutting-down":               "Servidor em desligamento",
+		"internal-error":              "Erro interno do servidor",
+	},
+	errors: map[error]string{
+		ErrInvalidRequest:       "A requisição é inválida",
+		ErrRouteNotFound:        "A API não atende este caminho",
+		ErrValidationFailed:     "Alguns campos são inválidos",
+		ErrUnsupportedMediaType: "O tipo de conteúdo do corpo não é suportado",
+		ErrPatchConflict:        "O patch não pode ser aplicado ao recurso",
+		errInternal:             "O servidor não conseguiu concluir a requisição.",
+
+		core.ErrUnauthenticated:    "É necessário se autenticar",
+		core.ErrInvalidCredentials: "O endereço de email ou a senha está incorreto",
+		core.ErrTwoFactorRequired:  "O código de autenticação em dois fatores é obrigatório",
+		core.ErrSingleSignOn:       "O login único falhou",
+		core.ErrInvalidLoginState:  "O login único é inválido ou expirou, comece novamente",
+
+		core.ErrForbidden:             "A operação não é permitida",
+		core.ErrAccessDenied:          "Acesso negado",
+		core.ErrInsufficientScope:     "A chave de API não concede o escopo necessário",
+		core.ErrTaskAccessDenied:      "Você não tem acesso a esta tarefa",
+		core.ErrWorkspaceAccessDenied: "Você não tem o papel no workspace exigido por esta operação",
+		core.ErrEmailNotConfirmed:     "O endereço de email ainda não foi confirmado",
+		core.ErrEmailNotVerified:      "O provedor de identidade não verificou o endereço de email",
+
+		core.ErrUserNotFound:           "O usuário não foi encontrado",
+		core.ErrTaskNotFound:           "A tarefa não foi encontrada",
+		core.ErrNoTasksFound:           "O usuário não tem tarefas",
+		core.ErrTaskShareNotFound:      "A tarefa não está compartilhada com este usuário",
+		core.ErrWorkspaceNotFound:      "O workspace não foi encontrado",
+		core.ErrWorkspaceMemberMissing: "O usuário não é membro do workspace",
+		core.ErrAPIKeyNotFound:         "A chave de API não foi encontrada",
+		core.ErrTwoFactorNotFound:      "A autenticação em dois fatores não está configurada",
+
+		core.ErrEmailAlreadyExists:       "O endereço de email já está em uso",
+		core.ErrUserAlreadyExists:        "O usuário já existe",
+		core.ErrWorkspaceSlugExists:      "Outro workspace já usa este nome",
+		core.ErrLastWorkspaceOwner:       "O workspace precisa manter pelo menos um proprietário",
+		core.ErrTwoFactorAlreadyEnabled:  "A autenticação em dois fatores já está habilitada",
+		core.ErrIdempotencyKeyInProgress: "Uma requisição com esta chave de idempotência ainda está em andamento, tente novamente mais tarde",
+
+		core.ErrInvalidToken:          "O token é inválido ou expirou",
+		core.ErrInvalidTaskID:         "O ID da tarefa é inválido",
+		core.ErrWorkspaceRequired:     "É necessário um workspace",
+		core.ErrInvalidIdempotencyKey: "A chave de idempotência deve ter entre 1 e 255 caracteres",
+
+		core.ErrInvalidEmail:         "O endereço de email é inválido",
+		core.ErrInvalidPassword:      "A senha deve ter entre 8 e 72 caracteres",
+		core.ErrTaskTitleValid:       "O título da tarefa deve ter pelo menos 3 caracteres",
+		core.ErrInvalidTaskRole:      "O papel de um compartilhamento deve ser viewer ou editor",
+		core.ErrShareWithOwner:       "Uma tarefa não pode ser compartilhada com o seu proprietário",
+		core.ErrInvalidWorkspaceName: "O nome do workspace é inválido",
+		core.ErrInvalidWorkspaceRole: "O papel no workspace deve ser owner, admin ou member",
+		core.ErrInvalidAPIKeyName:    "O nome da chave de API é inválido",
+		core.ErrInvalidAPIKeyScope:   "O escopo da chave de API é inválido",
+		core.ErrInvalidOTP:           "O código de autenticação em dois fatores está incorreto",
+		core.ErrIdempotencyKeyReused: "A chave de idempotência já foi usada em uma requisição diferente",
+		core.ErrInvalidTaskBatch:     "Um lote de tarefas deve ter entre 1 e 100 operações",
+		core.ErrInvalidTaskOperation: "A operação de tarefa é inválida",
+
+		core.ErrTaskBatchAborted: "A operação não foi aplicada porque outra operação do lote falhou",
+
+		core.ErrEventBusClosed: "O servidor está sendo desligado, reconecte mais tarde",
+	},
+	rules: map[string]string{
+		"invalid":      "é inválido",
+		"unknown":      "não é um campo aceito",
+		"required":     "é obrigatório",
+		"email":        "deve ser um endereço de email válido",
+		"uuid":         "deve ser um UUID válido",
+		"min":          "deve ter pelo menos %s caracteres",
+		"min.items":    "deve ter pelo menos %s itens",
+		"max":          "deve ter no máximo %s caracteres",
+		"max.items":    "deve ter no máximo %s itens",
+		"oneof":        "deve ser um de %s",
+		"type":         "tem o tipo errado",
+		"type.string":  "deve ser um texto",
+		"type.boolean": "deve ser um booleano",
+		"type.number":  "deve ser um número",
+		"type.array":   "deve ser uma lista",
+		"type.object":  "deve ser um objeto",
+	},
+}
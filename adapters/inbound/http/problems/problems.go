@@ -0,0 +1,193 @@
+// Package problems renders the errors of the HTTP API as RFC 7807 problem details.
+// Every error returned by the core services is mapped to a problem type with a
+// stable URI, documented in docs/problems.md, and an HTTP status code, so clients
+// can tell failures apart without parsing the human readable messages. Those
+// messages are localized in the language negotiated with the Accept-Language
+// header of the request, from the catalogs of messages_en.go and messages_pt_br.go.
+package problems
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/fabianoflorentino/gotostudy/core/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType is the media type of the problem details of RFC 7807.
+const ContentType = "application/problem+json"
+
+// TypeBaseURI is the prefix of the URIs identifying the problem types. The slug of
+// the type is the anchor of its description in docs/problems.md.
+const TypeBaseURI = "https://github.com/fabianoflorentino/gotostudy/blob/main/docs/problems.md#"
+
+var (
+	// ErrInvalidRequest reports a request the controllers cannot decode: a body that
+	// is not a JSON object or an invalid path or query parameter.
+	ErrInvalidRequest = errors.New("invalid request")
+	// ErrRouteNotFound reports a request for a path the API does not serve.
+	ErrRouteNotFound = errors.New("route not found")
+	// ErrValidationFailed reports a request whose fields do not pass validation. The
+	// errors of the fields are carried by a *ValidationError.
+	ErrValidationFailed = errors.New("validation failed")
+	// ErrUnsupportedMediaType reports a body whose content type the route does not
+	// accept, such as a PATCH body that is neither a JSON merge patch nor a JSON patch.
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	// ErrPatchConflict reports a JSON patch that cannot be applied to the current
+	// state of the resource: a failed test operation or a missing path.
+	ErrPatchConflict = errors.New("the patch cannot be applied")
+
+	// errInternal stands for the errors without a problem type, whose message is
+	// not disclosed.
+	errInternal = errors.New("the server could not complete the request")
+)
+
+// Problem is the body of an error response. RequestID and Errors are extension
+// members: the ID of the request in the logs and, for validation failures, the
+// errors of every invalid field.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+
+	// slug identifies the problem type, err is the error the problem describes and
+	// target the error of the problem type it matched. They select the messages of
+	// the problem in the catalogs.
+	slug   string
+	err    error
+	target error
+}
+
+// FieldError describes why a field of the request is invalid. Field is the path of
+// the field in the JSON body, such as "title" or "scopes[1]", and Code a stable
+// identifier of the rule it breaks, such as "required" or "max".
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+
+	// rule and param select and fill in the message in the catalogs.
+	rule  string
+	param string
+}
+
+// NewFieldError returns the error of field for breaking rule, with the message in
+// English. The code of the error is the rule up to its first dot: the rules of the
+// catalogs refine some codes, such as "min.items" for the minimum length of a list
+// or "type.string" for a value that should be a string. param is the parameter of
+// the rule, such as the minimum length, as it should appear in the message.
+func NewFieldError(field, rule, param string) FieldError {
+	code, _, _ := strings.Cut(rule, ".")
+
+	return FieldError{
+		Field:   field,
+		Code:    code,
+		Message: ruleMessage(English, rule, param),
+		rule:    rule,
+		param:   param,
+	}
+}
+
+// ValidationError is the error of a request with invalid fields. It wraps
+// ErrValidationFailed.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+// Error lists the invalid fields and why they are invalid.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, f := range e.Errors {
+		msgs = append(msgs, f.Field+" "+f.Message)
+	}
+
+	return ErrValidationFailed.Error() + ": " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns ErrValidationFailed.
+func (e *ValidationError) Unwrap() error {
+	return ErrValidationFailed
+}
+
+// InvalidRequest returns an error wrapping ErrInvalidRequest with the given detail.
+func InvalidRequest(detail string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidRequest, detail)
+}
+
+// From returns the problem describing err. The first problem type whose errors
+// match err wins; errors without a problem type are internal errors, whose message
+// is not disclosed to the client.
+func From(err error) Problem {
+	for _, t := range types {
+		for _, target := range t.errs {
+			if errors.Is(err, target) {
+				p := Problem{
+					Type:   TypeBaseURI + t.slug,
+					Title:  t.title,
+					Status: t.status,
+					Detail: err.Error(),
+					slug:   t.slug,
+					err:    err,
+					target: target,
+				}
+
+				var validation *ValidationError
+				if errors.As(err, &validation) {
+					p.Errors = validation.Errors
+				}
+
+				return p
+			}
+		}
+	}
+
+	return Problem{
+		Type:   TypeBaseURI + internalError.slug,
+		Title:  internalError.title,
+		Status: internalError.status,
+		Detail: en.errors[errInternal],
+		slug:   internalError.slug,
+		err:    errInternal,
+		target: errInternal,
+	}
+}
+
+// Write aborts the request with the problem p, in the language negotiated with the
+// Accept-Language header of the request, filling in the path of the request as its
+// instance and the ID of the request. Unauthorized responses also carry the
+// challenge of the bearer authentication scheme.
+func Write(c *gin.Context, p Problem) {
+	lang := Negotiate(c.GetHeader("Accept-Language"))
+	p = p.Localize(lang)
+	p.Instance = c.Request.URL.Path
+	p.RequestID = logging.RequestID(c.Request.Context())
+
+	if p.Status == http.StatusUnauthorized {
+		c.Header("WWW-Authenticate", `Bearer realm="gotostudy"`)
+	}
+	c.Header("Content-Type", ContentType)
+	c.Header("Content-Language", lang)
+	c.Writer.Header().Add("Vary", "Accept-Language")
+	c.AbortWithStatusJSON(p.Status, p)
+}
+
+// For returns the problem describing err in the language negotiated with the
+// Accept-Language header of the request, to embed in a response rather than to
+// answer the request with, such as the result of an operation of a batch. Internal
+// errors are logged, as their message is not disclosed.
+func For(c *gin.Context, err error) Problem {
+	p := From(err).Localize(Negotiate(c.GetHeader("Accept-Language")))
+	p.RequestID = logging.RequestID(c.Request.Context())
+
+	if p.Status >= 500 {
+		logging.FromContext(c.Request.Context()).Error("operation failed", "error", err)
+	}
+
+	return p
+}
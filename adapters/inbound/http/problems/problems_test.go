@@ -0,0 +1,80 @@
+package problems
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+)
+
+func TestFrom(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		slug   string
+		status int
+	}{
+		{"not found", core.ErrUserNotFound, "user-not-found", http.StatusNotFound},
+		{"conflict", core.ErrEmailAlreadyExists, "email-already-exists", http.StatusConflict},
+		{"validation", core.ErrInvalidEmail, "validation-failed", http.StatusUnprocessableEntity},
+		{"wrapped", fmt.Errorf("%w: users:delete", core.ErrForbidden), "forbidden", http.StatusForbidden},
+		{"invalid request", InvalidRequest("name is required"), "invalid-request", http.StatusBadRequest},
+		{"invalid token", core.ErrInvalidToken, "invalid-token", http.StatusBadRequest},
+		{"batch aborted", core.ErrTaskBatchAborted, "task-batch-aborted", http.StatusFailedDependency},
+		{"shutting down", core.ErrEventBusClosed, "shutting-down", http.StatusServiceUnavailable},
+		{"authentication failure", fmt.Errorf("%w: %w", core.ErrUnauthenticated, core.ErrInvalidToken), "unauthenticated", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := From(tt.err)
+
+			if p.Type != TypeBaseURI+tt.slug || p.Status != tt.status {
+				t.Errorf("From(%v) = %s %d, want %s %d", tt.err, p.Type, p.Status, TypeBaseURI+tt.slug, tt.status)
+			}
+			if p.Detail != tt.err.Error() {
+				t.Errorf("Detail = %q, want %q", p.Detail, tt.err.Error())
+			}
+		})
+	}
+
+	t.Run("field errors", func(t *testing.T) {
+		fields := []FieldError{{Field: "title", Code: "required", Message: "is required"}}
+		p := From(&ValidationError{Errors: fields})
+
+		if p.Type != TypeBaseURI+"validation-failed" || p.Status != http.StatusUnprocessableEntity {
+			t.Errorf("From() = %s %d, want validation-failed 422", p.Type, p.Status)
+		}
+		if len(p.Errors) != 1 || p.Errors[0] != fields[0] {
+			t.Errorf("Errors = %v, want %v", p.Errors, fields)
+		}
+	})
+
+	t.Run("internal error", func(t *testing.T) {
+		p := From(errors.New("pq: password authentication failed for user postgres"))
+
+		if p.Type != TypeBaseURI+"internal-error" || p.Status != http.StatusInternalServerError {
+			t.Errorf("From() = %s %d, want internal-error 500", p.Type, p.Status)
+		}
+		if strings.Contains(p.Detail, "postgres") {
+			t.Errorf("Detail = %q discloses the error", p.Detail)
+		}
+	})
+}
+
+func TestTypesAreDocumented(t *testing.T) {
+	doc, err := os.ReadFile("../../../../docs/problems.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pt := range append(types, internalError) {
+		if !strings.Contains(string(doc), "## "+pt.slug+"\n") {
+			t.Errorf("problem type %s is not documented in docs/problems.md", pt.slug)
+		}
+	}
+}
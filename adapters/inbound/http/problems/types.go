@@ -0,0 +1,90 @@
+package problems
+
+import (
+	"net/http"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+)
+
+// problemType is a problem type of the API: the slug of its URI, its title, the
+// status code of its responses and the errors it describes.
+type problemType struct {
+	slug   string
+	title  string
+	status int
+	errs   []error
+}
+
+// internalError describes the errors without a problem type.
+var internalError = problemType{slug: "internal-error", title: "Internal server error", status: http.StatusInternalServerError}
+
+// types lists the problem types in matching order. Authentication failures come
+// first, so an error wrapping both core.ErrUnauthenticated and the cause of the
+// failure is reported as unauthenticated. Keep docs/problems.md in sync.
+var types = []problemType{
+	// 401 Unauthorized
+	{"unauthenticated", "Authentication required", http.StatusUnauthorized, []error{core.ErrUnauthenticated}},
+	{"invalid-credentials", "Invalid credentials", http.StatusUnauthorized, []error{core.ErrInvalidCredentials}},
+	{"two-factor-required", "Two-factor authentication required", http.StatusUnauthorized, []error{core.ErrTwoFactorRequired}},
+	{"single-sign-on-failed", "Single sign-on failed", http.StatusUnauthorized, []error{core.ErrSingleSignOn, core.ErrInvalidLoginState}},
+
+	// 403 Forbidden
+	{"forbidden", "Operation not permitted", http.StatusForbidden, []error{core.ErrForbidden, core.ErrAccessDenied}},
+	{"insufficient-scope", "Insufficient API key scope", http.StatusForbidden, []error{core.ErrInsufficientScope}},
+	{"task-access-denied", "Task access denied", http.StatusForbidden, []error{core.ErrTaskAccessDenied}},
+	{"workspace-access-denied", "Workspace access denied", http.StatusForbidden, []error{core.ErrWorkspaceAccessDenied}},
+	{"email-not-confirmed", "Email address not confirmed", http.StatusForbidden, []error{core.ErrEmailNotConfirmed}},
+	{"email-not-verified", "Email address not verified by the identity provider", http.StatusForbidden, []error{core.ErrEmailNotVerified}},
+
+	// 404 Not Found
+	{"route-not-found", "Route not found", http.StatusNotFound, []error{ErrRouteNotFound}},
+	{"user-not-found", "User not found", http.StatusNotFound, []error{core.ErrUserNotFound}},
+	{"task-not-found", "Task not found", http.StatusNotFound, []error{core.ErrTaskNotFound, core.ErrNoTasksFound}},
+	{"task-share-not-found", "Task share not found", http.StatusNotFound, []error{core.ErrTaskShareNotFound}},
+	{"workspace-not-found", "Workspace not found", http.StatusNotFound, []error{core.ErrWorkspaceNotFound}},
+	{"workspace-member-not-found", "Workspace member not found", http.StatusNotFound, []error{core.ErrWorkspaceMemberMissing}},
+	{"api-key-not-found", "API key not found", http.StatusNotFound, []error{core.ErrAPIKeyNotFound}},
+	{"two-factor-not-found", "Two-factor authentication not set up", http.StatusNotFound, []error{core.ErrTwoFactorNotFound}},
+
+	// 409 Conflict
+	{"email-already-exists", "Email address already in use", http.StatusConflict, []error{core.ErrEmailAlreadyExists, core.ErrUserAlreadyExists}},
+	{"workspace-slug-exists", "Workspace slug already in use", http.StatusConflict, []error{core.ErrWorkspaceSlugExists}},
+	{"last-workspace-owner", "Workspace must keep an owner", http.StatusConflict, []error{core.ErrLastWorkspaceOwner}},
+	{"two-factor-already-enabled", "Two-factor authentication already enabled", http.StatusConflict, []error{core.ErrTwoFactorAlreadyEnabled}},
+	{"patch-conflict", "Patch conflict", http.StatusConflict, []error{ErrPatchConflict}},
+	{"idempotency-key-in-progress", "Idempotent request in progress", http.StatusConflict, []error{core.ErrIdempotencyKeyInProgress}},
+
+	// 400 Bad Request
+	{"invalid-request", "Invalid request", http.StatusBadRequest, []error{ErrInvalidRequest}},
+	{"invalid-token", "Invalid or expired token", http.StatusBadRequest, []error{core.ErrInvalidToken}},
+	{"invalid-id", "Invalid ID", http.StatusBadRequest, []error{core.ErrInvalidTaskID}},
+	{"workspace-required", "Workspace required", http.StatusBadRequest, []error{core.ErrWorkspaceRequired}},
+	{"invalid-idempotency-key", "Invalid idempotency key", http.StatusBadRequest, []error{core.ErrInvalidIdempotencyKey}},
+
+	// 415 Unsupported Media Type
+	{"unsupported-media-type", "Unsupported media type", http.StatusUnsupportedMediaType, []error{ErrUnsupportedMediaType}},
+
+	// 422 Unprocessable Entity
+	{"validation-failed", "Validation failed", http.StatusUnprocessableEntity, []error{
+		ErrValidationFailed,
+		core.ErrInvalidEmail,
+		core.ErrInvalidPassword,
+		core.ErrTaskTitleValid,
+		core.ErrInvalidTaskRole,
+		core.ErrShareWithOwner,
+		core.ErrInvalidWorkspaceName,
+		core.ErrInvalidWorkspaceRole,
+		core.ErrInvalidAPIKeyName,
+		core.ErrInvalidAPIKeyScope,
+		core.ErrInvalidTaskBatch,
+		core.ErrInvalidTaskOperation,
+	}},
+	{"invalid-otp", "Invalid two-factor authentication code", http.StatusUnprocessableEntity, []error{core.ErrInvalidOTP}},
+	{"idempotency-key-reused", "Idempotency key reused", http.StatusUnprocessableEntity, []error{core.ErrIdempotencyKeyReused}},
+
+	// 424 Failed Dependency
+	{"task-batch-aborted", "Task batch aborted", http.StatusFailedDependency, []error{core.ErrTaskBatchAborted}},
+
+	// 503 Service Unavailable
+	{"shutting-down", "Server shutting down", http.StatusServiceUnavailable, []error{core.ErrEventBusClosed}},
+}
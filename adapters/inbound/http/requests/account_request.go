@@ -0,0 +1,14 @@
+package requests
+
+// EmailRequest represents the request payload naming the email address to send
+// a verification or password reset link to.
+type EmailRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest represents the request payload setting a new password with
+// the token of a password reset link.
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8,max=72"`
+}
@@ -0,0 +1,8 @@
+package requests
+
+// CreateAPIKeyRequest represents the request payload used to create a personal
+// API key. At least one scope is required.
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,oneof=tasks:read tasks:write users:admin"`
+}
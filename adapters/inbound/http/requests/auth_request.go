@@ -0,0 +1,22 @@
+package requests
+
+// LoginRequest represents the credentials sent to obtain a pair of tokens. OTP
+// is the TOTP or recovery code of the users with two-factor authentication.
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+	OTP      string `json:"otp"`
+}
+
+// TwoFactorChallengeRequest represents the second factor completing a single
+// sign-on answered with a two-factor challenge. OTP is a TOTP or recovery code.
+type TwoFactorChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	OTP            string `json:"otp" binding:"required"`
+}
+
+// RefreshTokenRequest represents the request payload used to exchange a
+// refresh token for a new pair of tokens.
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
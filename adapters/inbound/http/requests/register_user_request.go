@@ -1,17 +1,16 @@
 // Package requests contains the definitions of request structures used for handling
 // and validating incoming HTTP requests in the application. These structures
-// are typically used to parse and validate JSON payloads from clients.
+// are typically used to parse and validate JSON payloads from clients, and only
+// declare the fields clients are allowed to set.
 package requests
 
-import "time"
-
 // RegisterUserRequest represents the structure of a request payload
-// for registering a new user. It includes the user's username and
-// email address. Both fields are required, and the email field must
-// be a valid email format.
+// for registering a new user. It includes the user's username,
+// email address and password. All of them are required, the email
+// field must be a valid email format and the password must have between
+// 8 and 72 characters.
 type RegisterUserRequest struct {
-	Username  string    `json:"username" binding:"required"`
-	Email     string    `json:"email" binding:"required,email"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	Username string `json:"username" binding:"required,max=255"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=8,max=72"`
 }
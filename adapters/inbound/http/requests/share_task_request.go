@@ -0,0 +1,9 @@
+package requests
+
+// ShareTaskRequest represents the payload for sharing a task with another
+// user. UserID identifies the collaborator and Role must be either
+// "viewer" or "editor".
+type ShareTaskRequest struct {
+	UserID string `json:"user_id" binding:"required,uuid"`
+	Role   string `json:"role" binding:"required,oneof=viewer editor"`
+}
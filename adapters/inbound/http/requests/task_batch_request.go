@@ -0,0 +1,19 @@
+package requests
+
+// TaskBatchRequest represents the payload of a batch of task operations. The
+// operations are applied in one transaction when Atomic is set, so they all succeed
+// or none is applied; otherwise each operation succeeds or fails on its own.
+type TaskBatchRequest struct {
+	Atomic     bool                   `json:"atomic"`
+	Operations []TaskOperationRequest `json:"operations" binding:"required,min=1,max=100,dive"`
+}
+
+// TaskOperationRequest represents an operation of a task batch. Op is one of
+// "create", "update", "delete" or "complete"; TaskID identifies the task of every
+// operation but a creation, and Task holds the fields of the task created or
+// replacing the ones of the task updated.
+type TaskOperationRequest struct {
+	Op     string       `json:"op" binding:"required,oneof=create update delete complete"`
+	TaskID string       `json:"task_id" binding:"required_unless=Op create,omitempty,uuid"`
+	Task   *TaskRequest `json:"task" binding:"required_if=Op create,omitempty"`
+}
@@ -0,0 +1,10 @@
+package requests
+
+// TaskRequest represents the payload creating or replacing a task. The title must
+// have between 3 and 255 characters; the ID, the owner and the timestamps of the
+// task are set by the server. It is also the document the patches of a task apply to.
+type TaskRequest struct {
+	Title       string `json:"title" binding:"required,min=3,max=255"`
+	Description string `json:"description" binding:"max=5000"`
+	Completed   bool   `json:"completed"`
+}
@@ -0,0 +1,7 @@
+package requests
+
+// ConfirmTwoFactorRequest represents the first code of the authenticator app,
+// confirming the enrollment of a second factor.
+type ConfirmTwoFactorRequest struct {
+	Code string `json:"code" binding:"required"`
+}
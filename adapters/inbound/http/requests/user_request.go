@@ -0,0 +1,9 @@
+package requests
+
+// UpdateUserRequest represents the payload replacing the username and the email
+// address of a user. Both are required. It is also the document the patches of a
+// user apply to.
+type UpdateUserRequest struct {
+	Username string `json:"username" binding:"required,max=255"`
+	Email    string `json:"email" binding:"required,email"`
+}
@@ -0,0 +1,12 @@
+package requests
+
+// CreateWorkspaceRequest represents the payload for creating a workspace.
+type CreateWorkspaceRequest struct {
+	Name string `json:"name" binding:"required,min=3"`
+}
+
+// SaveWorkspaceMemberRequest represents the payload for adding a member to a
+// workspace or changing its role. Role must be owner, admin or member.
+type SaveWorkspaceMemberRequest struct {
+	Role string `json:"role" binding:"required,oneof=owner admin member"`
+}
@@ -0,0 +1,340 @@
+// Package responses contains the bodies of the HTTP API responses. They keep the
+// domain types out of the JSON of the API: each response declares the fields
+// clients can read, with snake_case names, and is built from a domain value by a
+// constructor of the same name.
+package responses
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/problems"
+	"github.com/fabianoflorentino/gotostudy/core/auth"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/google/uuid"
+)
+
+// User is the public representation of a user. The hash of the password is never
+// part of it.
+type User struct {
+	ID              uuid.UUID  `json:"id"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	Role            string     `json:"role"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// NewUser returns the representation of u.
+func NewUser(u *domain.User) User {
+	return User{
+		ID:              u.ID,
+		Username:        u.Username,
+		Email:           u.Email,
+		Role:            string(u.Role),
+		EmailVerifiedAt: u.EmailVerifiedAt,
+		CreatedAt:       u.CreatedAt,
+		UpdatedAt:       u.UpdatedAt,
+	}
+}
+
+// NewUsers returns the representations of users, an empty list when there are none.
+func NewUsers(users []*domain.User) []User {
+	return mapAll(users, NewUser)
+}
+
+// Task is the public representation of a task.
+type Task struct {
+	ID          uuid.UUID `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Completed   bool      `json:"completed"`
+	UserID      uuid.UUID `json:"user_id"`
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// NewTask returns the representation of t.
+func NewTask(t *domain.Task) Task {
+	return Task{
+		ID:          t.ID,
+		Title:       t.Title,
+		Description: t.Description,
+		Completed:   t.Completed,
+		UserID:      t.UserID,
+		WorkspaceID: t.WorkspaceID,
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+	}
+}
+
+// NewTasks returns the representations of tasks, an empty list when there are none.
+func NewTasks(tasks []*domain.Task) []Task {
+	return mapAll(tasks, NewTask)
+}
+
+// TaskShare is the public representation of the access of a collaborator to a task.
+type TaskShare struct {
+	TaskID    uuid.UUID `json:"task_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewTaskShare returns the representation of s.
+func NewTaskShare(s *domain.TaskShare) TaskShare {
+	return TaskShare{
+		TaskID:    s.TaskID,
+		UserID:    s.UserID,
+		Role:      string(s.Role),
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
+
+// NewTaskShares returns the representations of shares, an empty list when there
+// are none.
+func NewTaskShares(shares []*domain.TaskShare) []TaskShare {
+	return mapAll(shares, NewTaskShare)
+}
+
+// TaskBatch is the response to a batch of task operations: the results of the
+// operations, in the order of the batch.
+type TaskBatch struct {
+	Atomic  bool                  `json:"atomic"`
+	Results []TaskOperationResult `json:"results"`
+}
+
+// TaskOperationResult is the result of an operation of a task batch. Status is the
+// status code the operation would have been answered with on its own route; Task is
+// the task created, updated or completed and Error the problem of a failed operation.
+type TaskOperationResult struct {
+	Op     string            `json:"op"`
+	Status int               `json:"status"`
+	Task   *Task             `json:"task,omitempty"`
+	Error  *problems.Problem `json:"error,omitempty"`
+}
+
+// NewTaskOperationResult returns the representation of the result r of op. problem
+// describes the error of a failed operation.
+func NewTaskOperationResult(op domain.TaskOperation, r domain.TaskOperationResult, problem func(error) problems.Problem) TaskOperationResult {
+	result := TaskOperationResult{Op: string(op.Kind)}
+
+	switch {
+	case r.Err != nil:
+		p := problem(r.Err)
+		result.Status = p.Status
+		result.Error = &p
+	case op.Kind == domain.TaskOperationCreate:
+		result.Status = http.StatusCreated
+	case op.Kind == domain.TaskOperationDelete:
+		result.Status = http.StatusNoContent
+	default:
+		result.Status = http.StatusOK
+	}
+
+	if r.Err == nil && r.Task != nil {
+		task := NewTask(r.Task)
+		result.Task = &task
+	}
+
+	return result
+}
+
+// Event is the public representation of an event of the streams of a user. Task is
+// set for the task events and User for the user events.
+type Event struct {
+	ID         uint64    `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Task       *Task     `json:"task,omitempty"`
+	User       *User     `json:"user,omitempty"`
+}
+
+// NewEvent returns the representation of e.
+func NewEvent(e *domain.Event) Event {
+	event := Event{ID: e.ID, Type: string(e.Type), OccurredAt: e.OccurredAt}
+	if e.Task != nil {
+		task := NewTask(e.Task)
+		event.Task = &task
+	}
+	if e.User != nil {
+		user := NewUser(e.User)
+		event.User = &user
+	}
+
+	return event
+}
+
+// Workspace is the public representation of a workspace.
+type Workspace struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewWorkspace returns the representation of w.
+func NewWorkspace(w *domain.Workspace) Workspace {
+	return Workspace{
+		ID:        w.ID,
+		Name:      w.Name,
+		Slug:      w.Slug,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+// NewWorkspaces returns the representations of workspaces, an empty list when
+// there are none.
+func NewWorkspaces(workspaces []*domain.Workspace) []Workspace {
+	return mapAll(workspaces, NewWorkspace)
+}
+
+// WorkspaceMember is the public representation of the membership of a user in a
+// workspace.
+type WorkspaceMember struct {
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// NewWorkspaceMember returns the representation of m.
+func NewWorkspaceMember(m *domain.WorkspaceMember) WorkspaceMember {
+	return WorkspaceMember{
+		WorkspaceID: m.WorkspaceID,
+		UserID:      m.UserID,
+		Role:        string(m.Role),
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+}
+
+// NewWorkspaceMembers returns the representations of members, an empty list when
+// there are none.
+func NewWorkspaceMembers(members []*domain.WorkspaceMember) []WorkspaceMember {
+	return mapAll(members, NewWorkspaceMember)
+}
+
+// APIKey is the public representation of an API key. The key is identified by its
+// prefix; the hash of its secret is never part of it.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// NewAPIKey returns the representation of k.
+func NewAPIKey(k *domain.APIKey) APIKey {
+	scopes := make([]string, len(k.Scopes))
+	for i, scope := range k.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	return APIKey{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     scopes,
+		LastUsedAt: k.LastUsedAt,
+		CreatedAt:  k.CreatedAt,
+		UpdatedAt:  k.UpdatedAt,
+	}
+}
+
+// NewAPIKeys returns the representations of keys, an empty list when there are none.
+func NewAPIKeys(keys []*domain.APIKey) []APIKey {
+	return mapAll(keys, NewAPIKey)
+}
+
+// CreatedAPIKey is the response to the creation of an API key. Key is the full
+// secret, shown only once.
+type CreatedAPIKey struct {
+	Key    string `json:"key"`
+	APIKey APIKey `json:"api_key"`
+}
+
+// TwoFactor is the status of the two-factor authentication of a user.
+type TwoFactor struct {
+	Enabled           bool       `json:"enabled"`
+	EnabledAt         *time.Time `json:"enabled_at"`
+	RecoveryCodesLeft int        `json:"recovery_codes_left"`
+}
+
+// NewTwoFactor returns the status described by t.
+func NewTwoFactor(t *domain.TwoFactor) TwoFactor {
+	return TwoFactor{
+		Enabled:           t.Enabled(),
+		EnabledAt:         t.EnabledAt,
+		RecoveryCodesLeft: len(t.RecoveryCodes),
+	}
+}
+
+// TwoFactorEnrollment is the response to the start of a two-factor enrollment: the
+// secret to type in an authenticator app and the URI of the QR code holding it.
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// NewTwoFactorEnrollment returns the representation of e.
+func NewTwoFactorEnrollment(e *auth.TOTPEnrollment) TwoFactorEnrollment {
+	return TwoFactorEnrollment{Secret: e.Secret, URI: e.URI}
+}
+
+// TwoFactorChallenge is the response to a single sign-on of a user with two-factor
+// authentication: the token to send back with the second factor, and its lifetime
+// in seconds.
+type TwoFactorChallenge struct {
+	ChallengeToken string `json:"challenge_token"`
+	ExpiresIn      int64  `json:"expires_in"`
+}
+
+// NewTwoFactorChallenge returns the representation of c.
+func NewTwoFactorChallenge(c *auth.TwoFactorChallenge) TwoFactorChallenge {
+	return TwoFactorChallenge{ChallengeToken: c.Token, ExpiresIn: int64(time.Until(c.ExpiresAt).Seconds())}
+}
+
+// RecoveryCodes is the response to the confirmation of a two-factor enrollment.
+// The codes are shown only once.
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// Tokens is the pair of tokens answering a login or a refresh.
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// NewTokens returns the representation of p.
+func NewTokens(p *auth.TokenPair) Tokens {
+	return Tokens{
+		AccessToken:  p.AccessToken,
+		RefreshToken: p.RefreshToken,
+		TokenType:    p.TokenType,
+		ExpiresIn:    p.ExpiresIn,
+	}
+}
+
+// mapAll converts every value of values with fn.
+func mapAll[D any, R any](values []*D, fn func(*D) R) []R {
+	out := make([]R, 0, len(values))
+	for _, v := range values {
+		out = append(out, fn(v))
+	}
+
+	return out
+}
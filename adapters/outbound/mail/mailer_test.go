@@ -0,0 +1,105 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+)
+
+func TestBuildMessage(t *testing.T) {
+	date := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Format", func(t *testing.T) {
+		msg, err := buildMessage("no-reply@example.com", ports.Mail{
+			To:      "user@example.com",
+			Subject: "Confirmação",
+			Body:    "line one\nline two\n",
+		}, date)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		headers, body, found := strings.Cut(string(msg), "\r\n\r\n")
+		if !found {
+			t.Fatalf("Expected headers and body, got %q", msg)
+		}
+
+		for _, want := range []string{
+			"From: no-reply@example.com",
+			"To: user@example.com",
+			"Subject: =?utf-8?q?Confirma=C3=A7=C3=A3o?=",
+			"Date: Thu, 01 Jan 2026 12:00:00 +0000",
+			"Content-Type: text/plain; charset=UTF-8",
+		} {
+			if !strings.Contains(headers, want+"\r\n") {
+				t.Errorf("Expected header %q in %q", want, headers)
+			}
+		}
+		if !strings.Contains(headers, "@example.com>") {
+			t.Errorf("Expected a Message-ID of the sender domain in %q", headers)
+		}
+
+		if body != "line one\r\nline two\r\n" {
+			t.Errorf("Expected CRLF line endings, got %q", body)
+		}
+	})
+
+	t.Run("HeaderInjection", func(t *testing.T) {
+		tests := []ports.Mail{
+			{To: "user@example.com\r\nBcc: victim@example.com", Subject: "Hello"},
+			{To: "user@example.com", Subject: "Hello\nBcc: victim@example.com"},
+		}
+
+		for _, mail := range tests {
+			if _, err := buildMessage("no-reply@example.com", mail, date); !errors.Is(err, errHeaderInjection) {
+				t.Errorf("Expected errHeaderInjection for %+v, got: %v", mail, err)
+			}
+		}
+	})
+}
+
+func TestOutboxMailer(t *testing.T) {
+	mail := ports.Mail{To: "user@example.com", Subject: "Hello", Body: "Welcome!"}
+
+	t.Run("InMemory", func(t *testing.T) {
+		outbox := NewOutboxMailer("", "no-reply@example.com")
+
+		if err := outbox.Send(context.Background(), mail); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if mails := outbox.Mails(); len(mails) != 1 || mails[0] != mail {
+			t.Errorf("Expected the mail to be recorded, got %+v", mails)
+		}
+	})
+
+	t.Run("Directory", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "outbox")
+		outbox := NewOutboxMailer(dir, "no-reply@example.com")
+
+		for range 2 {
+			if err := outbox.Send(context.Background(), mail); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+		}
+
+		files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+		if err != nil || len(files) != 2 {
+			t.Fatalf("Expected 2 .eml files, got %v (%v)", files, err)
+		}
+
+		content, err := os.ReadFile(files[0])
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if !strings.Contains(string(content), "To: user@example.com\r\n") || !strings.HasSuffix(string(content), "Welcome!") {
+			t.Errorf("Unexpected message %q", content)
+		}
+	})
+}
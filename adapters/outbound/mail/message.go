@@ -0,0 +1,51 @@
+// Package mail implements the Mailer port: SMTPMailer delivers the emails
+// through an SMTP server and OutboxMailer keeps them in memory, and optionally
+// writes them to a directory, for development and tests.
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+)
+
+// errHeaderInjection is returned for recipients or subjects spanning several lines,
+// which would allow adding arbitrary headers to the message.
+var errHeaderInjection = errors.New("mail header contains a line break")
+
+// buildMessage formats the mail as an RFC 5322 message with a UTF-8 plain text body.
+func buildMessage(from string, mail ports.Mail, date time.Time) ([]byte, error) {
+	for _, header := range []string{from, mail.To, mail.Subject} {
+		if strings.ContainsAny(header, "\r\n") {
+			return nil, errHeaderInjection
+		}
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	domain := from[strings.LastIndex(from, "@")+1:]
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", mail.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), domain)
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(strings.ReplaceAll(mail.Body, "\r\n", "\n"), "\n", "\r\n"))
+
+	return msg.Bytes(), nil
+}
@@ -0,0 +1,71 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core/logging"
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+)
+
+// OutboxMailer implements the Mailer interface without sending anything: the
+// emails are kept in memory and, when a directory is configured, written to it
+// as .eml files that any mail client can open.
+type OutboxMailer struct {
+	dir  string
+	from string
+
+	mu    sync.Mutex
+	mails []ports.Mail
+}
+
+// NewOutboxMailer creates a new instance of OutboxMailer writing the emails from
+// from to dir. With an empty dir the emails are only kept in memory.
+func NewOutboxMailer(dir, from string) *OutboxMailer {
+	return &OutboxMailer{dir: dir, from: from}
+}
+
+// Send records the mail and writes it to the outbox directory.
+func (o *OutboxMailer) Send(ctx context.Context, mail ports.Mail) error {
+	now := time.Now()
+
+	msg, err := buildMessage(o.from, mail, now)
+	if err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.mails = append(o.mails, mail)
+
+	if o.dir == "" {
+		logging.FromContext(ctx).Info("mail kept in outbox", "to", mail.To, "subject", mail.Subject)
+		return nil
+	}
+
+	if err := os.MkdirAll(o.dir, 0o700); err != nil {
+		return err
+	}
+
+	name := filepath.Join(o.dir, fmt.Sprintf("%s-%03d.eml", now.UTC().Format("20060102T150405.000000000Z"), len(o.mails)))
+	if err := os.WriteFile(name, msg, 0o600); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Info("mail written to outbox", "to", mail.To, "subject", mail.Subject, "file", name)
+
+	return nil
+}
+
+// Mails returns the emails sent so far, oldest first.
+func (o *OutboxMailer) Mails() []ports.Mail {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return append([]ports.Mail(nil), o.mails...)
+}
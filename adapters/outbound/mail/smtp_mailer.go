@@ -0,0 +1,44 @@
+package mail
+
+import (
+	"context"
+	"net"
+	"net/smtp"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+)
+
+// SMTPMailer implements the Mailer interface by delivering the emails to an SMTP
+// server. The connection is upgraded with STARTTLS whenever the server offers it.
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer creates a new instance of SMTPMailer sending as from through the
+// server at host:port. Without username the server is used unauthenticated.
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPMailer{addr: net.JoinHostPort(host, port), from: from, auth: auth}
+}
+
+// Send delivers the mail. The context is only checked before connecting, as
+// net/smtp does not support cancellation.
+func (s *SMTPMailer) Send(ctx context.Context, mail ports.Mail) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	msg, err := buildMessage(s.from, mail, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return smtp.SendMail(s.addr, s.auth, s.from, []string{mail.To}, msg)
+}
@@ -0,0 +1,56 @@
+// Package metrics implements the business metrics port with Prometheus counters.
+package metrics
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PrometheusMetrics implements ports.BusinessMetrics with Prometheus counters.
+type PrometheusMetrics struct {
+	usersRegistered *prometheus.CounterVec
+	tasksCreated    prometheus.Counter
+	tasksCompleted  prometheus.Counter
+}
+
+// NewPrometheusMetrics creates the business counters and registers them with reg.
+func NewPrometheusMetrics(reg prometheus.Registerer) (*PrometheusMetrics, error) {
+	m := &PrometheusMetrics{
+		usersRegistered: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gotostudy_users_registered_total",
+			Help: "Number of users registered, by registration method.",
+		}, []string{"method"}),
+		tasksCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "gotostudy_tasks_created_total",
+			Help: "Number of tasks created.",
+		}),
+		tasksCompleted: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "gotostudy_tasks_completed_total",
+			Help: "Number of tasks marked as completed.",
+		}),
+	}
+
+	for _, c := range []prometheus.Collector{m.usersRegistered, m.tasksCreated, m.tasksCompleted} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// UserRegistered counts a user registered with the given method.
+func (m *PrometheusMetrics) UserRegistered(ctx context.Context, method string) {
+	m.usersRegistered.WithLabelValues(method).Inc()
+}
+
+// TaskCreated counts a created task.
+func (m *PrometheusMetrics) TaskCreated(ctx context.Context) {
+	m.tasksCreated.Inc()
+}
+
+// TaskCompleted counts a task marked as completed.
+func (m *PrometheusMetrics) TaskCompleted(ctx context.Context) {
+	m.tasksCompleted.Inc()
+}
@@ -0,0 +1,116 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/fabianoflorentino/gotostudy/internal/oidctest"
+)
+
+const redirectURL = "http://app.example.com/auth/oidc/callback"
+
+// authorize follows the authorization URL like a browser would and returns the
+// code and state of the redirect back to the client.
+func authorize(t *testing.T, authURL string) (code, state string) {
+	t.Helper()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("Expected status %d, got %d", http.StatusFound, resp.StatusCode)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("Expected a redirect, got: %v", err)
+	}
+
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestProvider(t *testing.T) {
+	idp := oidctest.NewProvider("gotostudy", "secret")
+	defer idp.Close()
+
+	ctx := context.Background()
+
+	provider, err := NewProvider(ctx, Config{
+		Issuer:       idp.Issuer(),
+		ClientID:     "gotostudy",
+		ClientSecret: "secret",
+		RedirectURL:  redirectURL,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	const verifier = "dBjftJeZ4CVP-mJ92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+	t.Run("Exchange", func(t *testing.T) {
+		idp.SetUser(oidctest.User{Subject: "42", Email: "sso@example.com", EmailVerified: true, PreferredUsername: "sso"})
+
+		code, state := authorize(t, provider.AuthCodeURL("state", "nonce", verifier))
+		if state != "state" {
+			t.Errorf("Expected state %q, got %q", "state", state)
+		}
+
+		identity, err := provider.Exchange(ctx, code, "nonce", verifier)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if identity.Subject != "42" || identity.Email != "sso@example.com" || !identity.EmailVerified || identity.Username != "sso" {
+			t.Errorf("Unexpected identity %+v", identity)
+		}
+	})
+
+	t.Run("Exchange_CodeUsedTwice", func(t *testing.T) {
+		code, _ := authorize(t, provider.AuthCodeURL("state", "nonce", verifier))
+
+		if _, err := provider.Exchange(ctx, code, "nonce", verifier); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if _, err := provider.Exchange(ctx, code, "nonce", verifier); err == nil {
+			t.Error("Expected an error redeeming the code again")
+		}
+	})
+
+	t.Run("Exchange_WrongVerifier", func(t *testing.T) {
+		code, _ := authorize(t, provider.AuthCodeURL("state", "nonce", verifier))
+
+		if _, err := provider.Exchange(ctx, code, "nonce", "wrong-verifier-wrong-verifier-wrong-verifier"); err == nil {
+			t.Error("Expected an error with the wrong code verifier")
+		}
+	})
+
+	t.Run("Exchange_NonceMismatch", func(t *testing.T) {
+		code, _ := authorize(t, provider.AuthCodeURL("state", "nonce", verifier))
+
+		if _, err := provider.Exchange(ctx, code, "other-nonce", verifier); err == nil {
+			t.Error("Expected an error with a different nonce")
+		}
+	})
+
+	t.Run("Exchange_WrongClientSecret", func(t *testing.T) {
+		other, err := NewProvider(ctx, Config{Issuer: idp.Issuer(), ClientID: "gotostudy", ClientSecret: "wrong", RedirectURL: redirectURL})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		code, _ := authorize(t, other.AuthCodeURL("state", "nonce", verifier))
+
+		if _, err := other.Exchange(ctx, code, "nonce", verifier); err == nil {
+			t.Error("Expected an error with the wrong client secret")
+		}
+	})
+}
@@ -0,0 +1,134 @@
+// Package memory provides in-memory implementations of the persistence ports, for
+// a single instance deployment or tests. Their content is lost on restart.
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+	"github.com/google/uuid"
+)
+
+// idempotencyKey identifies a request: keys are scoped to the user sending it.
+type idempotencyKey struct {
+	userID uuid.UUID
+	key    string
+}
+
+// IdempotencyStore implements the IdempotencyStore interface in memory. The
+// requests are copied in and out, so callers cannot change the stored ones.
+type IdempotencyStore struct {
+	mu       sync.Mutex
+	requests map[idempotencyKey]domain.IdempotentRequest
+}
+
+// NewIdempotencyStore creates a new, empty instance of IdempotencyStore.
+func NewIdempotencyStore() ports.IdempotencyStore {
+	return &IdempotencyStore{requests: make(map[idempotencyKey]domain.IdempotentRequest)}
+}
+
+// Create records the request, replacing an expired request with the same key.
+// It returns core.ErrIdempotencyKeyExists when the key has not expired.
+func (s *IdempotencyStore) Create(ctx context.Context, request *domain.IdempotentRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := idempotencyKey{request.UserID, request.Key}
+	if recorded, ok := s.requests[id]; ok && recorded.ExpiresAt.After(request.CreatedAt) {
+		return core.ErrIdempotencyKeyExists
+	}
+
+	recorded := *request
+	recorded.Response = copyResponse(request.Response)
+	s.requests[id] = recorded
+
+	return nil
+}
+
+// Find retrieves the request of the user sent with key.
+// It returns core.ErrIdempotencyKeyNotFound when there is none.
+func (s *IdempotencyStore) Find(ctx context.Context, userID uuid.UUID, key string) (*domain.IdempotentRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recorded, ok := s.requests[idempotencyKey{userID, key}]
+	if !ok {
+		return nil, core.ErrIdempotencyKeyNotFound
+	}
+
+	recorded.Response = copyResponse(recorded.Response)
+	return &recorded, nil
+}
+
+// Complete records the response of the request of the user sent with key, which
+// now expires at expiresAt. It returns core.ErrIdempotencyKeyNotFound when there is none.
+func (s *IdempotencyStore) Complete(ctx context.Context, userID uuid.UUID, key string, response *domain.IdempotentResponse, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := idempotencyKey{userID, key}
+	recorded, ok := s.requests[id]
+	if !ok {
+		return core.ErrIdempotencyKeyNotFound
+	}
+
+	recorded.Response = copyResponse(response)
+	recorded.ExpiresAt = expiresAt
+	s.requests[id] = recorded
+
+	return nil
+}
+
+// Delete removes the request of the user sent with key.
+// It returns core.ErrIdempotencyKeyNotFound when there is none.
+func (s *IdempotencyStore) Delete(ctx context.Context, userID uuid.UUID, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := idempotencyKey{userID, key}
+	if _, ok := s.requests[id]; !ok {
+		return core.ErrIdempotencyKeyNotFound
+	}
+
+	delete(s.requests, id)
+	return nil
+}
+
+// DeleteExpired removes the requests expired at now and returns how many.
+func (s *IdempotencyStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for id, recorded := range s.requests {
+		if !recorded.ExpiresAt.After(now) {
+			delete(s.requests, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// copyResponse returns a deep copy of response, nil for a request in progress.
+func copyResponse(response *domain.IdempotentResponse) *domain.IdempotentResponse {
+	if response == nil {
+		return nil
+	}
+
+	header := make(map[string][]string, len(response.Header))
+	for name, values := range response.Header {
+		header[name] = slices.Clone(values)
+	}
+
+	return &domain.IdempotentResponse{
+		Status: response.Status,
+		Header: header,
+		Body:   slices.Clone(response.Body),
+	}
+}
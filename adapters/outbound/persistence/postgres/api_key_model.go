@@ -0,0 +1,23 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey is the persistence model of a personal API key. Prefix is unique and
+// used to look the key up; only the hash of the secret is stored. Scopes are
+// stored space separated. Keys are removed together with their user or workspace.
+type APIKey struct {
+	ID          uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;index"`
+	WorkspaceID uuid.UUID `gorm:"type:uuid;not null;index"`
+	Name        string    `gorm:"not null"`
+	Prefix      string    `gorm:"unique;not null"`
+	SecretHash  string    `gorm:"not null"`
+	Scopes      string    `gorm:"not null"`
+	LastUsedAt  *time.Time
+	CreatedAt   time.Time `gorm:"autoCreateTime:true"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime:true"`
+}
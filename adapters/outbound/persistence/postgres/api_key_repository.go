@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PostgresAPIKeyRepository implements the APIKeyRepository interface for
+// PostgreSQL using GORM. Every query is scoped to the workspace carried by the context.
+type PostgresAPIKeyRepository struct {
+	DB *gorm.DB
+}
+
+// NewPostgresAPIKeyRepository creates a new instance of PostgresAPIKeyRepository.
+func NewPostgresAPIKeyRepository(db *gorm.DB) ports.APIKeyRepository {
+	return &PostgresAPIKeyRepository{DB: db}
+}
+
+// Save inserts the API key into the workspace carried by ctx.
+func (r *PostgresAPIKeyRepository) Save(ctx context.Context, key *domain.APIKey) error {
+	workspaceID, err := tenant.WorkspaceID(ctx)
+	if err != nil {
+		return err
+	}
+
+	key.WorkspaceID = workspaceID
+
+	scopes := make([]string, len(key.Scopes))
+	for i, scope := range key.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	return conn(ctx, r.DB).Create(&APIKey{
+		ID:          key.ID,
+		UserID:      key.UserID,
+		WorkspaceID: key.WorkspaceID,
+		Name:        key.Name,
+		Prefix:      key.Prefix,
+		SecretHash:  key.SecretHash,
+		Scopes:      strings.Join(scopes, " "),
+		CreatedAt:   key.CreatedAt,
+		UpdatedAt:   key.UpdatedAt,
+	}).Error
+}
+
+// FindByPrefix retrieves the API key identified by its public prefix.
+// It returns core.ErrAPIKeyNotFound when the workspace has no such key.
+func (r *PostgresAPIKeyRepository) FindByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	var model APIKey
+
+	db, err := r.scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Where("prefix = ?", prefix).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, core.ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+
+	return toDomainAPIKey(model), nil
+}
+
+// FindUserKeys lists the API keys of the user, newest first.
+func (r *PostgresAPIKeyRepository) FindUserKeys(ctx context.Context, userID uuid.UUID) ([]*domain.APIKey, error) {
+	var models []APIKey
+
+	db, err := r.scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Where("user_id = ?", userID).Order("created_at DESC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	keys := make([]*domain.APIKey, len(models))
+	for i, model := range models {
+		keys[i] = toDomainAPIKey(model)
+	}
+
+	return keys, nil
+}
+
+// Delete removes an API key of the user.
+// It returns core.ErrAPIKeyNotFound when the user has no such key.
+func (r *PostgresAPIKeyRepository) Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	db, err := r.scoped(ctx)
+	if err != nil {
+		return err
+	}
+
+	result := db.Where("id = ? AND user_id = ?", id, userID).Delete(&APIKey{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return core.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// TouchLastUsed records when the API key was last used.
+func (r *PostgresAPIKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	db, err := r.scoped(ctx)
+	if err != nil {
+		return err
+	}
+
+	return db.Model(&APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+}
+
+// scoped returns a query restricted to the API keys of the workspace carried by ctx.
+// It fails with core.ErrWorkspaceRequired when the context has no workspace.
+func (r *PostgresAPIKeyRepository) scoped(ctx context.Context) (*gorm.DB, error) {
+	workspaceID, err := tenant.WorkspaceID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return conn(ctx, r.DB).Where("api_keys.workspace_id = ?", workspaceID).Session(&gorm.Session{}), nil
+}
+
+// toDomainAPIKey converts the persistence model into a domain.APIKey.
+func toDomainAPIKey(model APIKey) *domain.APIKey {
+	fields := strings.Fields(model.Scopes)
+	scopes := make([]domain.APIScope, len(fields))
+	for i, scope := range fields {
+		scopes[i] = domain.APIScope(scope)
+	}
+
+	return &domain.APIKey{
+		ID:          model.ID,
+		UserID:      model.UserID,
+		WorkspaceID: model.WorkspaceID,
+		Name:        model.Name,
+		Prefix:      model.Prefix,
+		SecretHash:  model.SecretHash,
+		Scopes:      scopes,
+		LastUsedAt:  model.LastUsedAt,
+		CreatedAt:   model.CreatedAt,
+		UpdatedAt:   model.UpdatedAt,
+	}
+}
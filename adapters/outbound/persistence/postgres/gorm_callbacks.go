@@ -0,0 +1,41 @@
+package postgres
+
+import "gorm.io/gorm"
+
+// registerCallbacks registers before and after around every GORM operation: create,
+// query, update, delete, row and raw. The callbacks are named after prefix.
+func registerCallbacks(db *gorm.DB, prefix string, before func(operation string) func(*gorm.DB), after func(operation string) func(*gorm.DB)) error {
+	cb := db.Callback()
+	callbacks := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+
+	for _, c := range callbacks {
+		if err := c.before(prefix+":before_"+c.operation, before(c.operation)); err != nil {
+			return err
+		}
+		if err := c.after(prefix+":after_"+c.operation, after(c.operation)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tableOf returns the table of the statement run by tx, or "unknown" for raw queries.
+func tableOf(tx *gorm.DB) string {
+	if tx.Statement.Table == "" {
+		return "unknown"
+	}
+
+	return tx.Statement.Table
+}
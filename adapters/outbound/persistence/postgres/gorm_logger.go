@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core/logging"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// GormLogger implements the GORM logger.Interface with the logger carried by the
+// context of each query, so the queries of a request are logged with its request
+// ID. Failed queries are logged at the error level, queries slower than the slow
+// threshold at warn and every other query at debug.
+type GormLogger struct {
+	level         logger.LogLevel
+	slowThreshold time.Duration
+}
+
+// NewGormLogger creates a new instance of GormLogger reporting the queries slower
+// than slowThreshold. A zero threshold disables the slow query reports.
+func NewGormLogger(slowThreshold time.Duration) *GormLogger {
+	return &GormLogger{level: logger.Info, slowThreshold: slowThreshold}
+}
+
+// LogMode returns a copy of the logger restricted to level.
+func (g *GormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	clone := *g
+	clone.level = level
+	return &clone
+}
+
+// Info logs a message of GORM at the info level.
+func (g *GormLogger) Info(ctx context.Context, msg string, data ...any) {
+	if g.level >= logger.Info {
+		logging.FromContext(ctx).InfoContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Warn logs a message of GORM at the warn level.
+func (g *GormLogger) Warn(ctx context.Context, msg string, data ...any) {
+	if g.level >= logger.Warn {
+		logging.FromContext(ctx).WarnContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Error logs a message of GORM at the error level.
+func (g *GormLogger) Error(ctx context.Context, msg string, data ...any) {
+	if g.level >= logger.Error {
+		logging.FromContext(ctx).ErrorContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Trace logs a query once it completed. Record not found errors are expected by
+// the repositories and logged as regular queries.
+func (g *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if g.level <= logger.Silent {
+		return
+	}
+
+	log := logging.FromContext(ctx)
+	elapsed := time.Since(begin)
+
+	level, msg := slog.LevelDebug, "query"
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && g.level >= logger.Error:
+		level, msg = slog.LevelError, "query failed"
+	case g.slowThreshold > 0 && elapsed > g.slowThreshold && g.level >= logger.Warn:
+		level, msg = slog.LevelWarn, "slow query"
+	case g.level < logger.Info:
+		return
+	}
+
+	if !log.Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Duration("duration", elapsed),
+	}
+	if level == slog.LevelError {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	log.LogAttrs(ctx, level, msg, attrs...)
+}
@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+// queryStartKey is the key of the instance setting holding the start of a query.
+const queryStartKey = "metrics:query_start"
+
+// RegisterMetrics instruments db with the duration and the errors of its queries,
+// by operation and table, and registers them with reg along with the statistics
+// of the connection pool.
+func RegisterMetrics(db *gorm.DB, reg prometheus.Registerer) error {
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gotostudy_db_query_duration_seconds",
+		Help:    "Duration of the database queries, by operation and table.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+	failures := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gotostudy_db_query_errors_total",
+		Help: "Number of failed database queries, by operation and table.",
+	}, []string{"operation", "table"})
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	for _, c := range []prometheus.Collector{duration, failures, collectors.NewDBStatsCollector(sqlDB, "gotostudy")} {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+
+	before := func(string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			tx.InstanceSet(queryStartKey, time.Now())
+		}
+	}
+	after := func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			value, ok := tx.InstanceGet(queryStartKey)
+			if !ok {
+				return
+			}
+
+			table := tableOf(tx)
+			duration.WithLabelValues(operation, table).Observe(time.Since(value.(time.Time)).Seconds())
+			if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+				failures.WithLabelValues(operation, table).Inc()
+			}
+		}
+	}
+
+	return registerCallbacks(db, "metrics", before, after)
+}
@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRegisterMetrics(t *testing.T) {
+	db, mock := newMockDB(t)
+	registry := prometheus.NewRegistry()
+
+	if err := RegisterMetrics(db, registry); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	mock.ExpectQuery(`FROM "users"`).WillReturnRows(userRows())
+	mock.ExpectQuery(`FROM "tasks"`).WillReturnError(errors.New("connection reset"))
+
+	var users []User
+	db.Find(&users)
+	var tasks []Task
+	db.Find(&tasks)
+
+	t.Run("QueryDuration", func(t *testing.T) {
+		if got := testutil.CollectAndCount(registry, "gotostudy_db_query_duration_seconds"); got != 2 {
+			t.Errorf("Expected a series per table, got %d", got)
+		}
+	})
+
+	t.Run("QueryErrors", func(t *testing.T) {
+		if got := testutil.CollectAndCount(registry, "gotostudy_db_query_errors_total"); got != 1 {
+			t.Fatalf("Expected a single error series, got %d", got)
+		}
+	})
+
+	t.Run("PoolStats", func(t *testing.T) {
+		if got := testutil.CollectAndCount(registry, "go_sql_open_connections"); got != 1 {
+			t.Errorf("Expected the open connections gauge, got %d", got)
+		}
+	})
+}
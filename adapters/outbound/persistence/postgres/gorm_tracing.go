@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fabianoflorentino/gotostudy/core/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// querySpanKey is the key of the instance setting holding the span of a query.
+const querySpanKey = "tracing:query_span"
+
+// querySpan is the span of a running query and the context it replaced.
+type querySpan struct {
+	span   trace.Span
+	parent context.Context
+}
+
+// RegisterTracing instruments db with a client span per query, named after the
+// operation and the table, as a child of the span carried by the context of the
+// query. Failed queries are marked as errors; record not found errors are expected
+// by the repositories and are not.
+func RegisterTracing(db *gorm.DB, tp trace.TracerProvider) error {
+	tracer := tp.Tracer(tracing.InstrumentationName)
+
+	before := func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			table := tableOf(tx)
+			parent := tx.Statement.Context
+			ctx, span := tracer.Start(tx.Statement.Context, "gorm."+operation+" "+table,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String("db.system", "postgresql"),
+					attribute.String("db.operation.name", operation),
+					attribute.String("db.collection.name", table),
+				),
+			)
+			tx.Statement.Context = ctx
+			tx.InstanceSet(querySpanKey, querySpan{span: span, parent: parent})
+		}
+	}
+	after := func(string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			value, ok := tx.InstanceGet(querySpanKey)
+			if !ok {
+				return
+			}
+
+			query := value.(querySpan)
+			span := query.span
+			tx.Statement.Context = query.parent
+			defer span.End()
+
+			span.SetAttributes(
+				attribute.String("db.query.text", tx.Statement.SQL.String()),
+				attribute.Int64("db.response.rows", tx.Statement.RowsAffected),
+			)
+			if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+				span.RecordError(tx.Error)
+				span.SetStatus(codes.Error, tx.Error.Error())
+			}
+		}
+	}
+
+	return registerCallbacks(db, "tracing", before, after)
+}
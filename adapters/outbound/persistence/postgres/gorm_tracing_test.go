@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRegisterTracing(t *testing.T) {
+	db, mock := newMockDB(t)
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	if err := RegisterTracing(db, provider); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+
+	mock.ExpectQuery(`FROM "users"`).WillReturnRows(userRows())
+	mock.ExpectQuery(`FROM "tasks"`).WillReturnError(errors.New("connection reset"))
+
+	var users []User
+	db.WithContext(ctx).Find(&users)
+	var tasks []Task
+	db.WithContext(ctx).Find(&tasks)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %d", len(spans))
+	}
+
+	t.Run("ChildOfRequest", func(t *testing.T) {
+		for _, span := range spans[:2] {
+			if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+				t.Errorf("Expected %q to be a child of the request span", span.Name)
+			}
+		}
+	})
+
+	t.Run("Names", func(t *testing.T) {
+		if spans[0].Name != "gorm.query users" || spans[1].Name != "gorm.query tasks" {
+			t.Errorf("Unexpected span names %q and %q", spans[0].Name, spans[1].Name)
+		}
+	})
+
+	t.Run("FailedQuery", func(t *testing.T) {
+		if spans[0].Status.Code == codes.Error || spans[1].Status.Code != codes.Error {
+			t.Errorf("Expected only the failed query to be an error, got %v and %v", spans[0].Status.Code, spans[1].Status.Code)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"gorm.io/gorm"
+)
+
+// DatabaseHealthChecker checks that the database answers a ping.
+type DatabaseHealthChecker struct {
+	DB *gorm.DB
+}
+
+// NewDatabaseHealthChecker creates a new instance of DatabaseHealthChecker pinging db.
+func NewDatabaseHealthChecker(db *gorm.DB) *DatabaseHealthChecker {
+	return &DatabaseHealthChecker{DB: db}
+}
+
+// Name returns the name the database is reported under.
+func (d *DatabaseHealthChecker) Name() string {
+	return "database"
+}
+
+// Check pings the database through a connection of the pool.
+func (d *DatabaseHealthChecker) Check(ctx context.Context) error {
+	sqlDB, err := d.DB.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.PingContext(ctx)
+}
+
+// MigrationHealthChecker checks that the tables and columns of the models exist in
+// the database, which fails when a replica of a newer version runs before its
+// migrations were applied. The schema is read with a single query.
+type MigrationHealthChecker struct {
+	DB     *gorm.DB
+	models []any
+}
+
+// NewMigrationHealthChecker creates a new instance of MigrationHealthChecker
+// verifying the schema of the given models.
+func NewMigrationHealthChecker(db *gorm.DB, models ...any) *MigrationHealthChecker {
+	return &MigrationHealthChecker{DB: db, models: models}
+}
+
+// Name returns the name the migrations are reported under.
+func (m *MigrationHealthChecker) Name() string {
+	return "migrations"
+}
+
+// Check returns core.ErrMigrationsPending, listing what is missing, when a table or
+// a column of the models does not exist in the current schema.
+func (m *MigrationHealthChecker) Check(ctx context.Context) error {
+	var columns []struct {
+		TableName  string
+		ColumnName string
+	}
+
+	if err := m.DB.WithContext(ctx).Raw(
+		"SELECT table_name, column_name FROM information_schema.columns WHERE table_schema = CURRENT_SCHEMA()",
+	).Scan(&columns).Error; err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		existing[c.TableName+"."+c.ColumnName] = true
+	}
+
+	var missing []string
+	for _, model := range m.models {
+		stmt := &gorm.Statement{DB: m.DB}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			if column := stmt.Schema.Table + "." + field.DBName; !existing[column] {
+				missing = append(missing, column)
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("%w: missing %s", core.ErrMigrationsPending, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/fabianoflorentino/gotostudy/core"
+)
+
+func TestMigrationHealthChecker(t *testing.T) {
+	columns := func(extra ...string) *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"table_name", "column_name"})
+		for _, column := range append([]string{"id", "name", "slug", "created_at", "updated_at"}, extra...) {
+			rows.AddRow("workspaces", column)
+		}
+		return rows
+	}
+
+	t.Run("Current", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		mock.ExpectQuery("information_schema.columns").WillReturnRows(columns())
+
+		if err := NewMigrationHealthChecker(db, &Workspace{}).Check(context.Background()); err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Pending", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		mock.ExpectQuery("information_schema.columns").WillReturnRows(columns())
+
+		err := NewMigrationHealthChecker(db, &Workspace{}, &APIKey{}).Check(context.Background())
+		if !errors.Is(err, core.ErrMigrationsPending) {
+			t.Errorf("Expected ErrMigrationsPending, got: %v", err)
+		}
+	})
+}
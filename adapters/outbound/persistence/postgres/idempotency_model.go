@@ -0,0 +1,21 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyKey is the persistence model of a request sent with an idempotency
+// key, keyed by the user and the key. Status is zero while the request is in
+// progress; Header stores the headers of the response as a JSON object.
+type IdempotencyKey struct {
+	UserID      uuid.UUID `gorm:"primaryKey;type:uuid"`
+	Key         string    `gorm:"primaryKey;size:255"`
+	Fingerprint string    `gorm:"not null"`
+	Status      int       `gorm:"not null;default:0"`
+	Header      string    `gorm:"not null;default:''"`
+	Body        []byte
+	CreatedAt   time.Time `gorm:"not null"`
+	ExpiresAt   time.Time `gorm:"not null;index"`
+}
@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostgresIdempotencyStore implements the IdempotencyStore interface for
+// PostgreSQL using GORM. The keys are scoped to users rather than workspaces, so
+// the queries are not restricted to the workspace carried by the context.
+type PostgresIdempotencyStore struct {
+	DB *gorm.DB
+}
+
+// NewPostgresIdempotencyStore creates a new instance of PostgresIdempotencyStore.
+func NewPostgresIdempotencyStore(db *gorm.DB) ports.IdempotencyStore {
+	return &PostgresIdempotencyStore{DB: db}
+}
+
+// Create records the request, replacing an expired request with the same key. The
+// insert does nothing when a concurrent request recorded the key first, so only one
+// of them is processed.
+func (s *PostgresIdempotencyStore) Create(ctx context.Context, request *domain.IdempotentRequest) error {
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND key = ? AND expires_at <= ?", request.UserID, request.Key, request.CreatedAt).
+			Delete(&IdempotencyKey{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&IdempotencyKey{
+			UserID:      request.UserID,
+			Key:         request.Key,
+			Fingerprint: request.Fingerprint,
+			CreatedAt:   request.CreatedAt,
+			ExpiresAt:   request.ExpiresAt,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return core.ErrIdempotencyKeyExists
+		}
+
+		return nil
+	})
+}
+
+// Find retrieves the request of the user sent with key.
+// It returns core.ErrIdempotencyKeyNotFound when there is none.
+func (s *PostgresIdempotencyStore) Find(ctx context.Context, userID uuid.UUID, key string) (*domain.IdempotentRequest, error) {
+	var model IdempotencyKey
+
+	if err := s.DB.WithContext(ctx).Where("user_id = ? AND key = ?", userID, key).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, core.ErrIdempotencyKeyNotFound
+		}
+		return nil, err
+	}
+
+	request := &domain.IdempotentRequest{
+		Key:         model.Key,
+		UserID:      model.UserID,
+		Fingerprint: model.Fingerprint,
+		CreatedAt:   model.CreatedAt,
+		ExpiresAt:   model.ExpiresAt,
+	}
+
+	if model.Status != 0 {
+		response := &domain.IdempotentResponse{Status: model.Status, Body: model.Body}
+		if model.Header != "" {
+			if err := json.Unmarshal([]byte(model.Header), &response.Header); err != nil {
+				return nil, err
+			}
+		}
+		request.Response = response
+	}
+
+	return request, nil
+}
+
+// Complete records the response of the request of the user sent with key, which
+// now expires at expiresAt. It returns core.ErrIdempotencyKeyNotFound when there is none.
+func (s *PostgresIdempotencyStore) Complete(ctx context.Context, userID uuid.UUID, key string, response *domain.IdempotentResponse, expiresAt time.Time) error {
+	header, err := json.Marshal(response.Header)
+	if err != nil {
+		return err
+	}
+
+	result := s.DB.WithContext(ctx).Model(&IdempotencyKey{}).
+		Where("user_id = ? AND key = ?", userID, key).
+		Updates(map[string]any{"status": response.Status, "header": string(header), "body": response.Body, "expires_at": expiresAt})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return core.ErrIdempotencyKeyNotFound
+	}
+
+	return nil
+}
+
+// Delete removes the request of the user sent with key.
+// It returns core.ErrIdempotencyKeyNotFound when there is none.
+func (s *PostgresIdempotencyStore) Delete(ctx context.Context, userID uuid.UUID, key string) error {
+	result := s.DB.WithContext(ctx).Where("user_id = ? AND key = ?", userID, key).Delete(&IdempotencyKey{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return core.ErrIdempotencyKeyNotFound
+	}
+
+	return nil
+}
+
+// DeleteExpired removes the requests expired at now and returns how many.
+func (s *PostgresIdempotencyStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := s.DB.WithContext(ctx).Where("expires_at <= ?", now).Delete(&IdempotencyKey{})
+
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,25 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxEvent is the persistence model of a domain event in the outbox. Sequence
+// orders the events as they were recorded. The pending events are the ones without
+// DeliveredAt, retried from NextAttemptAt after Attempts failed deliveries, the
+// last one failing with LastError.
+type OutboxEvent struct {
+	Sequence      int64      `gorm:"primaryKey;autoIncrement"`
+	ID            uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex"`
+	Type          string     `gorm:"size:64;not null"`
+	AggregateID   uuid.UUID  `gorm:"type:uuid;not null"`
+	WorkspaceID   uuid.UUID  `gorm:"type:uuid;not null"`
+	Payload       string     `gorm:"type:jsonb;not null"`
+	OccurredAt    time.Time  `gorm:"not null"`
+	Attempts      int        `gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_outbox_events_pending,where:delivered_at IS NULL"`
+	LastError     string     `gorm:"not null;default:''"`
+	DeliveredAt   *time.Time `gorm:"index"`
+}
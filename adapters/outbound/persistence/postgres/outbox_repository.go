@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostgresOutbox implements the Outbox interface for PostgreSQL using GORM. It
+// takes part in the transaction carried by the context, as the repositories do.
+// The relay delivers the events of every workspace, so the queries are not
+// restricted to the workspace carried by the context.
+type PostgresOutbox struct {
+	DB *gorm.DB
+}
+
+// NewPostgresOutbox creates a new instance of PostgresOutbox.
+func NewPostgresOutbox(db *gorm.DB) ports.Outbox {
+	return &PostgresOutbox{DB: db}
+}
+
+// Append stores the events, due for delivery right away.
+func (o *PostgresOutbox) Append(ctx context.Context, events ...domain.DomainEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	models := make([]OutboxEvent, len(events))
+	for i, event := range events {
+		models[i] = OutboxEvent{
+			ID:            event.ID,
+			Type:          string(event.Type),
+			AggregateID:   event.AggregateID,
+			WorkspaceID:   event.WorkspaceID,
+			Payload:       string(event.Payload),
+			OccurredAt:    event.OccurredAt,
+			NextAttemptAt: event.OccurredAt,
+		}
+	}
+
+	return conn(ctx, o.DB).Create(&models).Error
+}
+
+// Claim returns the events due at now in the order they were recorded, locking
+// them and skipping the ones locked by another transaction, and postpones their
+// next attempt to until.
+func (o *PostgresOutbox) Claim(ctx context.Context, now time.Time, until time.Time, limit int) ([]domain.DomainEvent, error) {
+	var models []OutboxEvent
+
+	err := conn(ctx, o.DB).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("delivered_at IS NULL AND next_attempt_at <= ?", now).
+		Order("sequence").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(models) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uuid.UUID, len(models))
+	for i, model := range models {
+		ids[i] = model.ID
+	}
+
+	err = conn(ctx, o.DB).Model(&OutboxEvent{}).Where("id IN ?", ids).Update("next_attempt_at", until).Error
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]domain.DomainEvent, len(models))
+	for i, model := range models {
+		events[i] = domain.DomainEvent{
+			ID:          model.ID,
+			Type:        domain.DomainEventType(model.Type),
+			AggregateID: model.AggregateID,
+			WorkspaceID: model.WorkspaceID,
+			OccurredAt:  model.OccurredAt,
+			Payload:     []byte(model.Payload),
+			Attempts:    model.Attempts,
+		}
+	}
+
+	return events, nil
+}
+
+// MarkDelivered records the delivery of the events at the given time.
+func (o *PostgresOutbox) MarkDelivered(ctx context.Context, ids []uuid.UUID, at time.Time) error {
+	return conn(ctx, o.DB).Model(&OutboxEvent{}).Where("id IN ?", ids).Update("delivered_at", at).Error
+}
+
+// MarkFailed counts a failed delivery of the event and postpones the next one to
+// next.
+func (o *PostgresOutbox) MarkFailed(ctx context.Context, id uuid.UUID, next time.Time, cause string) error {
+	return conn(ctx, o.DB).Model(&OutboxEvent{}).Where("id = ?", id).Updates(map[string]any{
+		"attempts":        gorm.Expr("attempts + 1"),
+		"next_attempt_at": next,
+		"last_error":      cause,
+	}).Error
+}
+
+// DeleteDelivered removes the events delivered before the given time and returns
+// how many.
+func (o *PostgresOutbox) DeleteDelivered(ctx context.Context, before time.Time) (int64, error) {
+	result := conn(ctx, o.DB).Where("delivered_at < ?", before).Delete(&OutboxEvent{})
+
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
+	"github.com/google/uuid"
+)
+
+func TestPostgresOutbox(t *testing.T) {
+	ctx := tenant.WithWorkspace(context.Background(), uuid.New())
+	task := &domain.Task{ID: uuid.New(), Title: "Task"}
+
+	event, err := domain.NewDomainEvent(uuid.New(), domain.TaskCreated{TaskID: task.ID, Title: task.Title})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("AppendWithinTransaction", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		failure := errors.New("operation failed")
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "tasks"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(task.ID))
+		mock.ExpectQuery(`INSERT INTO "outbox_events"`).WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+		mock.ExpectRollback()
+
+		err := NewGormTransactor(db).WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := NewPostgresTaskRepository(db).Save(ctx, uuid.New(), task); err != nil {
+				return err
+			}
+			if err := NewPostgresOutbox(db).Append(ctx, event); err != nil {
+				return err
+			}
+			return failure
+		})
+		if !errors.Is(err, failure) {
+			t.Errorf("Expected the error of the unit of work, got: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Claim", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		now := time.Now()
+		until := now.Add(time.Minute)
+
+		rows := sqlmock.NewRows([]string{"sequence", "id", "type", "aggregate_id", "workspace_id", "payload", "occurred_at", "attempts"}).
+			AddRow(1, event.ID, string(event.Type), event.AggregateID, event.WorkspaceID, string(event.Payload), event.OccurredAt, 2)
+		mock.ExpectQuery(`FOR UPDATE SKIP LOCKED`).WithArgs(now, 10).WillReturnRows(rows)
+		mock.ExpectExec(`UPDATE "outbox_events" SET "next_attempt_at"`).WithArgs(until, event.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		events, err := NewPostgresOutbox(db).Claim(ctx, now, until, 10)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if len(events) != 1 || events[0].ID != event.ID || events[0].Type != domain.DomainEventTaskCreated || events[0].Attempts != 2 {
+			t.Errorf("Expected the pending event, got %+v", events)
+		}
+		if string(events[0].Payload) != string(event.Payload) {
+			t.Errorf("Expected the payload %s, got %s", event.Payload, events[0].Payload)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
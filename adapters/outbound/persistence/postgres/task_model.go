@@ -11,15 +11,18 @@ import (
 
 // Task represents a task entity in the system. It includes details such as
 // the task's unique identifier (ID), title, description, completion status,
-// timestamps for creation and updates, and the ID of the user who owns the task.
+// timestamps for creation and updates, the ID of the user who owns the task and
+// the ID of the workspace it belongs to. Shares lists the collaborators the task was shared with.
 // The struct is designed to work with GORM for database persistence, with
 // annotations specifying primary key, default values, and constraints.
 type Task struct {
-	ID          uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
-	Title       string    `gorm:"not null"`
-	Description string    `gorm:"not null"`
-	Completed   bool      `gorm:"default:false"`
-	CreatedAt   time.Time `gorm:"autoCreateTime:true"`
-	UpdatedAt   time.Time `gorm:"autoUpdateTime:true"`
-	UserID      uuid.UUID `gorm:"type:uuid;not null"`
+	ID          uuid.UUID   `gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	Title       string      `gorm:"not null"`
+	Description string      `gorm:"not null"`
+	Completed   bool        `gorm:"default:false"`
+	CreatedAt   time.Time   `gorm:"autoCreateTime:true"`
+	UpdatedAt   time.Time   `gorm:"autoUpdateTime:true"`
+	UserID      uuid.UUID   `gorm:"type:uuid;not null"`
+	WorkspaceID uuid.UUID   `gorm:"type:uuid;index"`
+	Shares      []TaskShare `gorm:"foreignKey:TaskID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 }
@@ -2,24 +2,26 @@ package postgres
 
 import (
 	"context"
-	"fmt"
+	"errors"
 
+	"github.com/fabianoflorentino/gotostudy/core"
 	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// insertBatchSize bounds the number of rows of a multi-row INSERT statement.
+const insertBatchSize = 100
+
 // PostgresTaskRepository is a struct that implements the TaskRepository interface
 // for PostgreSQL. It uses GORM for database operations.
+// Every query is scoped to the workspace carried by the context.
 type PostgresTaskRepository struct {
 	DB *gorm.DB
 }
 
-var (
-	// tasks []Task
-	task Task
-)
-
 // NewPostgresTaskRepository creates a new instance of PostgresTaskRepository.
 func NewPostgresTaskRepository(db *gorm.DB) *PostgresTaskRepository {
 	return &PostgresTaskRepository{DB: db}
@@ -27,8 +29,14 @@ func NewPostgresTaskRepository(db *gorm.DB) *PostgresTaskRepository {
 
 // Save persists the given Task domain entity into the PostgreSQL database.
 // It converts the domain.Task to the persistence model and inserts it using GORM.
+// The task is stored in the workspace carried by ctx.
 // Returns an error if the operation fails.
 func (t *PostgresTaskRepository) Save(ctx context.Context, userID uuid.UUID, task *domain.Task) error {
+	workspaceID, err := tenant.WorkspaceID(ctx)
+	if err != nil {
+		return err
+	}
+
 	newTask := Task{
 		ID:          task.ID,
 		Title:       task.Title,
@@ -37,9 +45,57 @@ func (t *PostgresTaskRepository) Save(ctx context.Context, userID uuid.UUID, tas
 		CreatedAt:   task.CreatedAt,
 		UpdatedAt:   task.UpdatedAt,
 		UserID:      userID,
+		WorkspaceID: workspaceID,
+	}
+
+	if err := conn(ctx, t.DB).Create(&newTask).Error; err != nil {
+		return err
 	}
 
-	return t.DB.Create(&newTask).Error
+	task.WorkspaceID = workspaceID
+
+	return nil
+}
+
+// SaveAll inserts the given tasks of the user in the workspace carried by ctx with
+// multi-row INSERT statements of at most insertBatchSize tasks, run in a single
+// transaction so either every task is stored or none is.
+func (t *PostgresTaskRepository) SaveAll(ctx context.Context, userID uuid.UUID, tasks []*domain.Task) error {
+	workspaceID, err := tenant.WorkspaceID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	models := make([]Task, len(tasks))
+	for i, task := range tasks {
+		models[i] = Task{
+			ID:          task.ID,
+			Title:       task.Title,
+			Description: task.Description,
+			Completed:   task.Completed,
+			CreatedAt:   task.CreatedAt,
+			UpdatedAt:   task.UpdatedAt,
+			UserID:      userID,
+			WorkspaceID: workspaceID,
+		}
+	}
+
+	err = conn(ctx, t.DB).Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(&models, insertBatchSize).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		task.WorkspaceID = workspaceID
+	}
+
+	return nil
 }
 
 // FindUserTasks retrieves all tasks associated with the specified user ID from the database.
@@ -55,51 +111,76 @@ func (t *PostgresTaskRepository) Save(ctx context.Context, userID uuid.UUID, tas
 func (t *PostgresTaskRepository) FindUserTasks(ctx context.Context, userID uuid.UUID) ([]*domain.Task, error) {
 	var listTasks []Task
 
-	if err := t.DB.Where("user_id = ?", userID).Find(&listTasks).Error; err != nil {
+	db, err := t.scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Where("user_id = ?", userID).Find(&listTasks).Error; err != nil {
 		return nil, err
 	}
 
 	tasks := make([]*domain.Task, len(listTasks))
 	for i, t := range listTasks {
-		tasks[i] = &domain.Task{
-			ID:          t.ID,
-			Title:       t.Title,
-			Description: t.Description,
-			Completed:   t.Completed,
-			CreatedAt:   t.CreatedAt,
-			UpdatedAt:   t.UpdatedAt,
-			UserID:      t.UserID,
-		}
+		tasks[i] = toDomainTask(t)
+	}
+
+	return tasks, nil
+}
+
+// FindSharedTasks retrieves the tasks owned by other users that were shared with
+// the specified user, regardless of the role granted.
+func (t *PostgresTaskRepository) FindSharedTasks(ctx context.Context, userID uuid.UUID) ([]*domain.Task, error) {
+	var listTasks []Task
+
+	db, err := t.scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	shared := t.DB.Model(&TaskShare{}).Select("task_id").Where("user_id = ?", userID)
+	if err := db.Where("id IN (?)", shared).Find(&listTasks).Error; err != nil {
+		return nil, err
+	}
+
+	tasks := make([]*domain.Task, len(listTasks))
+	for i, t := range listTasks {
+		tasks[i] = toDomainTask(t)
 	}
 
 	return tasks, nil
 }
 
 // FindTaskByID retrieves a task from the database by its unique identifier (taskID).
+// The task is returned when the user owns it or when it was shared with the user.
 // It returns a pointer to the domain.Task if found, or an error if the task does not exist
 // or if there is a problem accessing the database.
 //
 // Parameters:
 //   - ctx: context.Context for controlling cancellation and deadlines.
+//   - userID: uuid.UUID of the owner or of a collaborator of the task.
 //   - taskID: uuid.UUID representing the unique identifier of the task.
 //
 // Returns:
 //   - *domain.Task: pointer to the found task, or nil if not found.
 //   - error: error encountered during the operation, or nil if successful.
 func (t *PostgresTaskRepository) FindTaskByID(ctx context.Context, userID uuid.UUID, taskID uuid.UUID) (*domain.Task, error) {
-	if err := t.DB.Where("id = ? AND user_id = ?", taskID, userID).First(&task).Error; err != nil {
+	var task Task
+
+	db, err := t.scoped(ctx)
+	if err != nil {
 		return nil, err
 	}
 
-	return &domain.Task{
-		ID:          task.ID,
-		Title:       task.Title,
-		Description: task.Description,
-		Completed:   task.Completed,
-		CreatedAt:   task.CreatedAt,
-		UpdatedAt:   task.UpdatedAt,
-		UserID:      task.UserID,
-	}, nil
+	shared := t.DB.Model(&TaskShare{}).Select("task_id").Where("user_id = ?", userID)
+	if err := db.Where("id = ?", taskID).Where(t.DB.Where("user_id = ?", userID).Or("id IN (?)", shared)).First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, core.ErrTaskNotFound
+		}
+		return nil, err
+	}
+
+	return toDomainTask(task), nil
 }
 
 // Update updates the task identified by taskID in the PostgreSQL database with the values from tsk.
@@ -108,7 +189,14 @@ func (t *PostgresTaskRepository) FindTaskByID(ctx context.Context, userID uuid.U
 // taskID is the unique identifier of the task to be updated.
 // tsk is a pointer to the Task domain model containing the updated data.
 func (t *PostgresTaskRepository) Update(ctx context.Context, taskID uuid.UUID, tsk *domain.Task) error {
-	if err := t.DB.Where("id = ?", taskID).First(&task).Error; err != nil {
+	var task Task
+
+	db, err := t.scoped(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := db.Where("id = ?", taskID).First(&task).Error; err != nil {
 		return err
 	}
 
@@ -117,67 +205,165 @@ func (t *PostgresTaskRepository) Update(ctx context.Context, taskID uuid.UUID, t
 	task.Completed = tsk.Completed
 	task.UpdatedAt = tsk.UpdatedAt
 
-	return t.DB.Save(&task).Error
+	return conn(ctx, t.DB).Save(&task).Error
+}
+
+// Delete removes a task from the database identified by the given taskID.
+// It first checks if the task exists, returning an error if not found or if a database error occurs.
+// If the task exists, it deletes the task and returns any error encountered during deletion.
+func (t *PostgresTaskRepository) Delete(ctx context.Context, taskID uuid.UUID) error {
+	var task Task
+
+	db, err := t.scoped(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := db.Where("id = ?", taskID).First(&task).Error; err != nil {
+		return err
+	}
+
+	return conn(ctx, t.DB).Delete(&task).Error
 }
 
-// UpdateFields updates specific fields of a task identified by taskID in the database.
-// The fields parameter is a map where the keys are the names of the fields to update and the values are the new values for those fields.
-// Returns the updated Task domain object or an error if the update fails.
-func (t *PostgresTaskRepository) UpdateFields(ctx context.Context, taskID uuid.UUID, fields map[string]any) (*domain.Task, error) {
-	if err := t.DB.Where("id = ?", taskID).First(&task).Error; err != nil {
+// SaveTaskShare grants the collaborator in share access to the task. When the
+// collaborator already has a share on the task, its role is replaced.
+// The task must belong to the workspace carried by ctx.
+func (t *PostgresTaskRepository) SaveTaskShare(ctx context.Context, share *domain.TaskShare) error {
+	var task Task
+
+	db, err := t.scoped(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := db.Where("id = ?", share.TaskID).First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return core.ErrTaskNotFound
+		}
+		return err
+	}
+
+	model := TaskShare{
+		TaskID:    share.TaskID,
+		UserID:    share.UserID,
+		Role:      string(share.Role),
+		CreatedAt: share.CreatedAt,
+		UpdatedAt: share.UpdatedAt,
+	}
+
+	return conn(ctx, t.DB).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "task_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "updated_at"}),
+	}).Create(&model).Error
+}
+
+// FindTaskShare retrieves the share of a task for a given collaborator.
+// It returns core.ErrTaskShareNotFound when the task was not shared with the user.
+func (t *PostgresTaskRepository) FindTaskShare(ctx context.Context, taskID uuid.UUID, userID uuid.UUID) (*domain.TaskShare, error) {
+	var model TaskShare
+
+	db, err := t.scopedShares(ctx)
+	if err != nil {
 		return nil, err
 	}
 
-	if _, err := t.hasValidFields(fields); err != nil {
+	if err := db.Where("task_id = ? AND user_id = ?", taskID, userID).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, core.ErrTaskShareNotFound
+		}
 		return nil, err
 	}
 
-	task := &domain.Task{
-		ID:          task.ID,
-		Title:       task.Title,
-		Description: task.Description,
-		Completed:   task.Completed,
-		CreatedAt:   task.CreatedAt,
-		UpdatedAt:   task.UpdatedAt,
-		UserID:      task.UserID,
+	return toDomainTaskShare(model), nil
+}
+
+// FindTaskShares lists every collaborator the task was shared with.
+func (t *PostgresTaskRepository) FindTaskShares(ctx context.Context, taskID uuid.UUID) ([]*domain.TaskShare, error) {
+	var models []TaskShare
+
+	db, err := t.scopedShares(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	return task, nil
+	if err := db.Where("task_id = ?", taskID).Order("created_at").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	shares := make([]*domain.TaskShare, len(models))
+	for i, model := range models {
+		shares[i] = toDomainTaskShare(model)
+	}
+
+	return shares, nil
 }
 
-// Delete removes a task from the database identified by the given taskID.
-// It first checks if the task exists, returning an error if not found or if a database error occurs.
-// If the task exists, it deletes the task and returns any error encountered during deletion.
-func (t *PostgresTaskRepository) Delete(ctx context.Context, taskID uuid.UUID) error {
-	if err := t.DB.Where("id = ?", taskID).First(&task).Error; err != nil {
+// DeleteTaskShare revokes the access of a collaborator to the task.
+// It returns core.ErrTaskShareNotFound when there was nothing to revoke.
+func (t *PostgresTaskRepository) DeleteTaskShare(ctx context.Context, taskID uuid.UUID, userID uuid.UUID) error {
+	db, err := t.scopedShares(ctx)
+	if err != nil {
 		return err
 	}
 
-	return t.DB.Delete(&task).Error
+	result := db.Where("task_id = ? AND user_id = ?", taskID, userID).Delete(&TaskShare{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return core.ErrTaskShareNotFound
+	}
+
+	return nil
 }
 
-// hasValidFields checks if the provided fields map contains only valid task fields.
-// It returns true and nil error if at least one valid, non-empty string field is found.
-// If an invalid field is encountered, it returns false and an error indicating the invalid field.
-// If no valid fields are provided, it returns false and an error.
-// Note: This function also performs a database update for the first valid, non-empty string field found.
-func (t *PostgresTaskRepository) hasValidFields(fields map[string]any) (bool, error) {
-	validFields := map[string]bool{
-		"title":       true,
-		"description": true,
-		"completed":   true,
+// scoped returns a query on tasks restricted to the workspace carried by ctx.
+// It fails with core.ErrWorkspaceRequired when the context has no workspace.
+func (t *PostgresTaskRepository) scoped(ctx context.Context) (*gorm.DB, error) {
+	workspaceID, err := tenant.WorkspaceID(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	for key, value := range fields {
-		if !validFields[key] {
-			return false, fmt.Errorf("invalid field: %s", key)
-		}
+	return conn(ctx, t.DB).Where("tasks.workspace_id = ?", workspaceID).Session(&gorm.Session{}), nil
+}
 
-		if strValue, ok := value.(string); ok && strValue != "" {
-			t.DB.Model(&task).Update(key, strValue)
-			return true, nil
-		}
+// scopedShares returns a query on task shares restricted to the tasks of the
+// workspace carried by ctx.
+func (t *PostgresTaskRepository) scopedShares(ctx context.Context) (*gorm.DB, error) {
+	workspaceID, err := tenant.WorkspaceID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := t.DB.Model(&Task{}).Select("id").Where("workspace_id = ?", workspaceID)
+
+	return conn(ctx, t.DB).Where("task_shares.task_id IN (?)", tasks).Session(&gorm.Session{}), nil
+}
+
+// toDomainTask converts the persistence model into a domain.Task.
+func toDomainTask(task Task) *domain.Task {
+	return &domain.Task{
+		ID:          task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		Completed:   task.Completed,
+		CreatedAt:   task.CreatedAt,
+		UpdatedAt:   task.UpdatedAt,
+		UserID:      task.UserID,
+		WorkspaceID: task.WorkspaceID,
 	}
+}
 
-	return false, fmt.Errorf("no valid fields provided")
+// toDomainTaskShare converts the persistence model into a domain.TaskShare.
+func toDomainTaskShare(model TaskShare) *domain.TaskShare {
+	return &domain.TaskShare{
+		TaskID:    model.TaskID,
+		UserID:    model.UserID,
+		Role:      domain.TaskRole(model.Role),
+		CreatedAt: model.CreatedAt,
+		UpdatedAt: model.UpdatedAt,
+	}
 }
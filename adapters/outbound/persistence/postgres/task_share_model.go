@@ -0,0 +1,19 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TaskShare is the persistence model for a task shared with a collaborator.
+// The composite primary key (TaskID, UserID) guarantees that a user has at most
+// one role on a given task. Rows are removed together with the task or the user
+// through the foreign keys declared on Task.Shares and User.TaskShares.
+type TaskShare struct {
+	TaskID    uuid.UUID `gorm:"primaryKey;type:uuid"`
+	UserID    uuid.UUID `gorm:"primaryKey;type:uuid;index"`
+	Role      string    `gorm:"not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime:true"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime:true"`
+}
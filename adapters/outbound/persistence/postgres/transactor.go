@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+	"gorm.io/gorm"
+)
+
+// txKey is the context key of the transaction run by GormTransactor.
+type txKey struct{}
+
+// GormTransactor implements the Transactor interface with GORM transactions. The
+// transaction travels in the context, where the repositories of this package find
+// it with conn.
+type GormTransactor struct {
+	DB *gorm.DB
+}
+
+// NewGormTransactor creates a new instance of GormTransactor.
+func NewGormTransactor(db *gorm.DB) ports.Transactor {
+	return &GormTransactor{DB: db}
+}
+
+// WithinTransaction runs fn in a transaction, or in the transaction ctx already
+// carries, committed when fn returns nil and rolled back otherwise.
+func (t *GormTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+
+	return t.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn returns the transaction carried by ctx, or db outside of a transaction,
+// bound to ctx.
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+
+	return db.WithContext(ctx)
+}
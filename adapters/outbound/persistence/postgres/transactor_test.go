@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
+	"github.com/google/uuid"
+)
+
+func TestGormTransactor(t *testing.T) {
+	ctx := tenant.WithWorkspace(context.Background(), uuid.New())
+	task := &domain.Task{ID: uuid.New(), Title: "Task"}
+
+	t.Run("Commit", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresTaskRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "tasks"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(task.ID))
+		mock.ExpectExec(`DELETE FROM "task_shares"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err := NewGormTransactor(db).WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := repo.Save(ctx, uuid.New(), task); err != nil {
+				return err
+			}
+			// A nested unit of work joins the transaction.
+			return NewGormTransactor(db).WithinTransaction(ctx, func(ctx context.Context) error {
+				repo.DeleteTaskShare(ctx, task.ID, uuid.New())
+				return nil
+			})
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresTaskRepository(db)
+		failure := errors.New("operation failed")
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "tasks"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(task.ID))
+		mock.ExpectRollback()
+
+		err := NewGormTransactor(db).WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := repo.Save(ctx, uuid.New(), task); err != nil {
+				return err
+			}
+			return failure
+		})
+		if !errors.Is(err, failure) {
+			t.Errorf("Expected the error of the unit of work, got: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
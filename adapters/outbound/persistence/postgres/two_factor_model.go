@@ -0,0 +1,21 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TwoFactor is the persistence model of the TOTP second factor of a user, keyed
+// by the user. RecoveryCodes stores the hashes of the unused recovery codes space
+// separated. LastUsedStep is the time step of the last TOTP code accepted.
+// EnabledAt is null while the enrollment is pending confirmation.
+type TwoFactor struct {
+	UserID        uuid.UUID `gorm:"primaryKey;type:uuid"`
+	Secret        string    `gorm:"not null"`
+	RecoveryCodes string    `gorm:"not null;default:''"`
+	LastUsedStep  int64     `gorm:"not null;default:0"`
+	EnabledAt     *time.Time
+	CreatedAt     time.Time `gorm:"autoCreateTime:true"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime:true"`
+}
@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostgresTwoFactorRepository implements the TwoFactorRepository interface for
+// PostgreSQL using GORM. As for users, only the second factors of the members of
+// the workspace carried by the context are visible.
+type PostgresTwoFactorRepository struct {
+	DB *gorm.DB
+}
+
+// NewPostgresTwoFactorRepository creates a new instance of PostgresTwoFactorRepository.
+func NewPostgresTwoFactorRepository(db *gorm.DB) ports.TwoFactorRepository {
+	return &PostgresTwoFactorRepository{DB: db}
+}
+
+// FindByUserID retrieves the second factor of the user.
+// It returns core.ErrTwoFactorNotFound when the user has none.
+func (r *PostgresTwoFactorRepository) FindByUserID(ctx context.Context, userID uuid.UUID) (*domain.TwoFactor, error) {
+	var model TwoFactor
+
+	db, err := r.scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Where("user_id = ?", userID).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, core.ErrTwoFactorNotFound
+		}
+		return nil, err
+	}
+
+	return &domain.TwoFactor{
+		UserID:        model.UserID,
+		Secret:        model.Secret,
+		RecoveryCodes: strings.Fields(model.RecoveryCodes),
+		LastUsedStep:  model.LastUsedStep,
+		EnabledAt:     model.EnabledAt,
+		CreatedAt:     model.CreatedAt,
+		UpdatedAt:     model.UpdatedAt,
+	}, nil
+}
+
+// Save inserts the second factor, replacing the existing one of the user. The
+// user must be a member of the workspace carried by ctx.
+func (r *PostgresTwoFactorRepository) Save(ctx context.Context, twoFactor *domain.TwoFactor) error {
+	workspaceID, err := tenant.WorkspaceID(ctx)
+	if err != nil {
+		return err
+	}
+
+	return conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		var members int64
+		if err := tx.Model(&WorkspaceMember{}).
+			Where("workspace_id = ? AND user_id = ?", workspaceID, twoFactor.UserID).
+			Count(&members).Error; err != nil {
+			return err
+		}
+
+		if members == 0 {
+			return core.ErrUserNotFound
+		}
+
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&TwoFactor{
+			UserID:        twoFactor.UserID,
+			Secret:        twoFactor.Secret,
+			RecoveryCodes: strings.Join(twoFactor.RecoveryCodes, " "),
+			LastUsedStep:  twoFactor.LastUsedStep,
+			EnabledAt:     twoFactor.EnabledAt,
+			CreatedAt:     twoFactor.CreatedAt,
+			UpdatedAt:     twoFactor.UpdatedAt,
+		}).Error
+	})
+}
+
+// UseStep records step as the last TOTP step used by the user, provided it is
+// newer than the one recorded. The comparison is part of the update, so of two
+// logins racing with the same code only one succeeds. It returns
+// core.ErrInvalidOTP when the step was already used.
+func (r *PostgresTwoFactorRepository) UseStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	return r.updateColumns(ctx, userID, "last_used_step < ?", step, map[string]any{
+		"last_used_step": step,
+		"updated_at":     time.Now(),
+	})
+}
+
+// UseRecoveryCode removes the recovery code with the given hash from the codes of
+// the user, provided it is still among them. As for UseStep, the check is part of
+// the update. It returns core.ErrInvalidOTP when the code is unknown or used.
+func (r *PostgresTwoFactorRepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, hash string) error {
+	// The hashes are separated by spaces: padding the column with spaces matches
+	// the whole hash wherever it is in the list.
+	padded := " " + hash + " "
+
+	return r.updateColumns(ctx, userID, "position(? in ' ' || recovery_codes || ' ') > 0", padded, map[string]any{
+		"recovery_codes": gorm.Expr("btrim(replace(' ' || recovery_codes || ' ', ?, ' '))", padded),
+		"updated_at":     time.Now(),
+	})
+}
+
+// Delete removes the second factor of the user.
+// It returns core.ErrTwoFactorNotFound when the user has none.
+func (r *PostgresTwoFactorRepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	db, err := r.scoped(ctx)
+	if err != nil {
+		return err
+	}
+
+	result := db.Where("user_id = ?", userID).Delete(&TwoFactor{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return core.ErrTwoFactorNotFound
+	}
+
+	return nil
+}
+
+// updateColumns updates the second factor of the user when it matches condition.
+// It returns core.ErrInvalidOTP when no second factor was updated.
+func (r *PostgresTwoFactorRepository) updateColumns(ctx context.Context, userID uuid.UUID, condition string, arg any, columns map[string]any) error {
+	db, err := r.scoped(ctx)
+	if err != nil {
+		return err
+	}
+
+	result := db.Model(&TwoFactor{}).Where("user_id = ?", userID).Where(condition, arg).UpdateColumns(columns)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return core.ErrInvalidOTP
+	}
+
+	return nil
+}
+
+// scoped returns a query restricted to the second factors of the members of the
+// workspace carried by ctx. It fails with core.ErrWorkspaceRequired when the
+// context has no workspace.
+func (r *PostgresTwoFactorRepository) scoped(ctx context.Context) (*gorm.DB, error) {
+	workspaceID, err := tenant.WorkspaceID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	members := r.DB.Model(&WorkspaceMember{}).Select("user_id").Where("workspace_id = ?", workspaceID)
+
+	return conn(ctx, r.DB).Where("two_factors.user_id IN (?)", members).Session(&gorm.Session{}), nil
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
+	"github.com/google/uuid"
+)
+
+// The uses of the codes are conditional updates: a code used by a concurrent
+// login updates no row and is rejected.
+func TestTwoFactorRepositoryUse(t *testing.T) {
+	workspaceID, userID := uuid.New(), uuid.New()
+	ctx := tenant.WithWorkspace(context.Background(), workspaceID)
+
+	const useStep = `UPDATE "two_factors" SET "last_used_step"=$1,"updated_at"=$2 WHERE two_factors.user_id IN (SELECT "user_id" FROM "workspace_members" WHERE workspace_id = $3) AND user_id = $4 AND last_used_step < $5`
+	const useRecoveryCode = `UPDATE "two_factors" SET "recovery_codes"=btrim(replace(' ' || recovery_codes || ' ', $1, ' ')),"updated_at"=$2 WHERE two_factors.user_id IN (SELECT "user_id" FROM "workspace_members" WHERE workspace_id = $3) AND user_id = $4 AND position($5 in ' ' || recovery_codes || ' ') > 0`
+
+	tests := []struct {
+		name string
+		rows int64
+		want error
+	}{
+		{"Unused", 1, nil},
+		{"Used", 0, core.ErrInvalidOTP},
+	}
+
+	for _, tt := range tests {
+		t.Run("UseStep_"+tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+
+			mock.ExpectExec(useStep).
+				WithArgs(int64(42), anyValue{}, workspaceArg(workspaceID), userID, int64(42)).
+				WillReturnResult(sqlmock.NewResult(0, tt.rows))
+
+			if err := NewPostgresTwoFactorRepository(db).UseStep(ctx, userID, 42); !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got: %v", tt.want, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+
+		t.Run("UseRecoveryCode_"+tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+
+			mock.ExpectExec(useRecoveryCode).
+				WithArgs(" hash ", anyValue{}, workspaceArg(workspaceID), userID, " hash ").
+				WillReturnResult(sqlmock.NewResult(0, tt.rows))
+
+			if err := NewPostgresTwoFactorRepository(db).UseRecoveryCode(ctx, userID, "hash"); !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got: %v", tt.want, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	t.Run("WithoutWorkspace", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresTwoFactorRepository(db)
+
+		if err := repo.UseStep(context.Background(), userID, 42); !errors.Is(err, core.ErrWorkspaceRequired) {
+			t.Errorf("Expected ErrWorkspaceRequired, got: %v", err)
+		}
+		if err := repo.UseRecoveryCode(context.Background(), userID, "hash"); !errors.Is(err, core.ErrWorkspaceRequired) {
+			t.Errorf("Expected ErrWorkspaceRequired, got: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unexpected database access: %v", err)
+		}
+	})
+}
@@ -14,17 +14,29 @@ import (
 // It contains fields for storing user-specific information such as
 // a unique identifier (ID), username, email, and timestamps for
 // creation and last update. The ID is automatically generated as a UUID.
-// The Username and Email fields are unique and cannot be null.
+// The Username and Email fields are unique and cannot be null. PasswordHash holds
+// the hash of the user's password and Role its global role, "user" by default.
+// EmailVerifiedAt is null until the user confirmed the email address.
 // The CreatedAt and UpdatedAt fields are automatically managed by GORM
 // to track when the user was created and last updated, respectively.
 // The Tasks field establishes a one-to-many relationship with the Task entity,
 // where each user can have multiple tasks. Changes to the user will cascade
-// to associated tasks on update or delete operations.
+// to associated tasks on update or delete operations. TaskShares holds the
+// tasks of other users shared with this user, Memberships the workspaces the
+// user belongs to, APIKeys the personal API keys of the user and TwoFactor its
+// second factor; all follow the same rules.
 type User struct {
-	ID        uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
-	Username  string    `gorm:"unique;not null"`
-	Email     string    `gorm:"unique;not null"`
-	CreatedAt time.Time `gorm:"autoCreateTime:true"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime:true"`
-	Tasks     []Task    `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	ID              uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	Username        string    `gorm:"unique;not null"`
+	Email           string    `gorm:"unique;not null"`
+	PasswordHash    string    `gorm:"not null;default:''"`
+	Role            string    `gorm:"not null;default:user"`
+	EmailVerifiedAt *time.Time
+	CreatedAt       time.Time         `gorm:"autoCreateTime:true"`
+	UpdatedAt       time.Time         `gorm:"autoUpdateTime:true"`
+	Tasks           []Task            `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	TaskShares      []TaskShare       `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Memberships     []WorkspaceMember `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	APIKeys         []APIKey          `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	TwoFactor       *TwoFactor        `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 }
@@ -2,15 +2,20 @@
 // using a PostgreSQL database as the persistence layer. It leverages the GORM
 // library to interact with the database and provides methods for CRUD operations
 // on user entities, including saving, retrieving, updating, and deleting users.
+//
+// Every query is scoped to the workspace carried by the context (see package
+// tenant): a user is only visible through the workspaces it is a member of.
 package postgres
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"time"
 
 	"github.com/fabianoflorentino/gotostudy/core"
 	"github.com/fabianoflorentino/gotostudy/core/domain"
 	"github.com/fabianoflorentino/gotostudy/core/ports"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -23,13 +28,6 @@ type PostgresUserRepository struct {
 	DB *gorm.DB
 }
 
-// models is a slice of User structs, representing a collection of user data
-// that can be used for operations such as querying or processing multiple users.
-var (
-	models []User
-	model  User
-)
-
 // NewPostgresUserRepository creates a new instance of PostgresUserRepository,
 // which implements the UserRepository interface. It takes a gorm.DB instance
 // as a parameter to interact with the PostgreSQL database and returns the
@@ -41,19 +39,41 @@ func NewPostgresUserRepository(db *gorm.DB) ports.UserRepository {
 
 // Save persists a given User entity into the PostgreSQL database.
 // It converts the domain.User object into a database model (User)
-// and saves it using the GORM ORM. The method also processes the
-// associated tasks of the user, preparing them for persistence.
-// Returns an error if the operation fails.
+// and saves it using the GORM ORM together with the membership of the
+// user in the workspace of the context, in a single transaction.
+// Returns core.ErrUserAlreadyExists when another user, in any workspace, has the
+// same username or email, or an error if the operation fails.
 func (r *PostgresUserRepository) Save(ctx context.Context, user *domain.User) error {
+	workspaceID, err := tenant.WorkspaceID(ctx)
+	if err != nil {
+		return err
+	}
+
 	model := User{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:              user.ID,
+		Username:        user.Username,
+		Email:           user.Email,
+		PasswordHash:    user.PasswordHash,
+		Role:            string(user.Role),
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
 	}
 
-	return r.DB.Create(&model).Error
+	return conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&model).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return core.ErrUserAlreadyExists
+			}
+			return err
+		}
+
+		return tx.Create(&WorkspaceMember{
+			WorkspaceID: workspaceID,
+			UserID:      model.ID,
+			Role:        string(domain.WorkspaceRoleMember),
+		}).Error
+	})
 }
 
 // FindAll retrieves all user records from the database and converts them
@@ -62,19 +82,20 @@ func (r *PostgresUserRepository) Save(ctx context.Context, user *domain.User) er
 // layer. If an error occurs during the database query, it returns the error.
 // Otherwise, it returns a slice of pointers to domain.User objects.
 func (r *PostgresUserRepository) FindAll(ctx context.Context) ([]*domain.User, error) {
-	if err := r.DB.Find(&models).Error; err != nil {
+	var models []User
+
+	db, err := r.scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Find(&models).Error; err != nil {
 		return nil, err
 	}
 
 	users := make([]*domain.User, len(models))
 	for i, model := range models {
-		users[i] = &domain.User{
-			ID:        model.ID,
-			Username:  model.Username,
-			Email:     model.Email,
-			CreatedAt: model.CreatedAt,
-			UpdatedAt: model.UpdatedAt,
-		}
+		users[i] = toDomainUser(model)
 	}
 
 	return users, nil
@@ -84,53 +105,72 @@ func (r *PostgresUserRepository) FindAll(ctx context.Context) ([]*domain.User, e
 // It returns a pointer to the User domain object if found, or an error if the user does not exist
 // or if there is an issue with the database query.
 func (r *PostgresUserRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
-	tasks := make([]domain.Task, len(model.Tasks))
+	var model User
 
-	if err := r.DB.Where("id = ?", id).First(&model).Error; err != nil {
+	db, err := r.scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Where("id = ?", id).First(&model).Error; err != nil {
 		return nil, core.ErrUserNotFound
 	}
 
-	for i, task := range model.Tasks {
-		tasks[i] = domain.Task{
-			ID:          task.ID,
-			Title:       task.Title,
-			Description: task.Description,
-			Completed:   task.Completed,
-			UserID:      task.UserID,
-			CreatedAt:   task.CreatedAt,
-			UpdatedAt:   task.UpdatedAt,
+	return toDomainUser(model), nil
+}
+
+// FindByEmail retrieves a user of the current workspace by email address.
+// It returns core.ErrUserNotFound when no member of the workspace uses the email.
+func (r *PostgresUserRepository) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
+	var model User
+
+	db, err := r.scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Where("email = ?", email).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, core.ErrUserNotFound
 		}
+		return nil, err
 	}
 
-	return &domain.User{
-		ID:        model.ID,
-		Username:  model.Username,
-		Email:     model.Email,
-		CreatedAt: model.CreatedAt,
-		UpdatedAt: model.UpdatedAt,
-	}, nil
+	return toDomainUser(model), nil
 }
 
-func (r *PostgresUserRepository) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
+// FindByEmailInAnyWorkspace retrieves a user by email address whatever the workspaces
+// it belongs to, since the email of a user is unique across workspaces.
+// It returns core.ErrUserNotFound when no user uses the email.
+func (r *PostgresUserRepository) FindByEmailInAnyWorkspace(ctx context.Context, email string) (*domain.User, error) {
 	var model User
 
-	if err := r.DB.Where("email = ?", email).First(&model).Error; err != nil {
+	if err := conn(ctx, r.DB).Where("email = ?", email).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, core.ErrUserNotFound
+		}
 		return nil, err
 	}
 
-	return &domain.User{
-		ID:       model.ID,
-		Username: model.Username,
-		Email:    model.Email,
-	}, nil
+	return toDomainUser(model), nil
 }
 
 // Update updates an existing user record in the database with the provided user details.
 // It retrieves the user by the given UUID, updates the fields (Username and Email),
-// and saves the changes back to the database. If any error occurs during the process,
-// it returns the error.
+// and saves the changes back to the database. It returns core.ErrUserAlreadyExists
+// when another user has the same username or email, or any other error as is.
 func (r *PostgresUserRepository) Update(ctx context.Context, id uuid.UUID, user *domain.User) error {
-	if err := r.DB.Where("id = ?", id).First(&model).Error; err != nil {
+	var model User
+
+	db, err := r.scoped(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := db.Where("id = ?", id).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return core.ErrUserNotFound
+		}
 		return err
 	}
 
@@ -138,62 +178,121 @@ func (r *PostgresUserRepository) Update(ctx context.Context, id uuid.UUID, user
 	model.Email = user.Email
 	model.UpdatedAt = user.UpdatedAt
 
-	return r.DB.Save(&model).Error
+	if err := conn(ctx, r.DB).Save(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return core.ErrUserAlreadyExists
+		}
+		return err
+	}
+
+	return nil
 }
 
-// UpdateFields updates specific fields of a user in the database identified by the given UUID.
-// It accepts a map of field names and their new values, and applies the updates to the user record.
-// If the "username" or "email" fields are present in the map, they are updated accordingly.
-// The method retrieves the user record, updates the specified fields, and saves the changes back to the database.
-// Returns the updated user as a domain.User object or an error if the operation fails.
-func (r *PostgresUserRepository) UpdateFields(ctx context.Context, id uuid.UUID, fields map[string]any) (*domain.User, error) {
-	if err := r.DB.Where("id = ?", id).First(&model).Error; err != nil {
-		return nil, err
+// Delete removes a user from the workspace of the context based on the provided UUID.
+// The membership and the tasks of the user in the workspace are removed; the user record
+// itself is only deleted once the user no longer belongs to any workspace.
+// Returns an error if the record is not found or if any database operation fails.
+func (r *PostgresUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	var model User
+
+	workspaceID, err := tenant.WorkspaceID(ctx)
+	if err != nil {
+		return err
 	}
 
-	if _, err := r.hasValidFields(fields); err != nil {
-		return nil, err
+	db, err := r.scoped(ctx)
+	if err != nil {
+		return err
 	}
 
-	user := &domain.User{
-		ID:        model.ID,
-		Username:  model.Username,
-		Email:     model.Email,
-		CreatedAt: model.CreatedAt,
-		UpdatedAt: model.UpdatedAt,
+	if err := db.Where("id = ?", id).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return core.ErrUserNotFound
+		}
+		return err
 	}
 
-	return user, nil
+	return conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND workspace_id = ?", id, workspaceID).Delete(&Task{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ? AND workspace_id = ?", id, workspaceID).Delete(&WorkspaceMember{}).Error; err != nil {
+			return err
+		}
+
+		var remaining int64
+		if err := tx.Model(&WorkspaceMember{}).Where("user_id = ?", id).Count(&remaining).Error; err != nil {
+			return err
+		}
+
+		if remaining > 0 {
+			return nil
+		}
+
+		return tx.Delete(&model).Error
+	})
 }
 
-// Delete removes a user record from the database based on the provided UUID.
-// It first attempts to retrieve the user record with the given ID to ensure it exists.
-// If the record is found, it deletes the record from the database.
-// Returns an error if the record is not found or if any database operation fails.
-func (r *PostgresUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	if err := r.DB.Where("id = ?", id).First(&model).Error; err != nil {
+// UpdatePassword replaces the password hash of a user of the workspace of the context.
+// It returns core.ErrUserNotFound when the workspace has no such user.
+func (r *PostgresUserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	return r.updateColumns(ctx, id, map[string]any{"password_hash": passwordHash, "updated_at": time.Now()})
+}
+
+// MarkEmailVerified records when a user of the workspace of the context confirmed
+// the email address. It returns core.ErrUserNotFound when the workspace has no such user.
+func (r *PostgresUserRepository) MarkEmailVerified(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.updateColumns(ctx, id, map[string]any{"email_verified_at": at, "updated_at": time.Now()})
+}
+
+// UpdateRole replaces the role of a user of the workspace of the context. It returns
+// core.ErrUserNotFound when the workspace has no such user.
+func (r *PostgresUserRepository) UpdateRole(ctx context.Context, id uuid.UUID, role domain.UserRole) error {
+	return r.updateColumns(ctx, id, map[string]any{"role": string(role), "updated_at": time.Now()})
+}
+
+func (r *PostgresUserRepository) updateColumns(ctx context.Context, id uuid.UUID, columns map[string]any) error {
+	db, err := r.scoped(ctx)
+	if err != nil {
 		return err
 	}
 
-	return r.DB.Delete(&model).Error
-}
+	result := db.Model(&User{}).Where("id = ?", id).UpdateColumns(columns)
+	if result.Error != nil {
+		return result.Error
+	}
 
-func (r *PostgresUserRepository) hasValidFields(fields map[string]any) (bool, error) {
-	validFields := map[string]bool{
-		"username": true,
-		"email":    true,
+	if result.RowsAffected == 0 {
+		return core.ErrUserNotFound
 	}
 
-	for key, value := range fields {
-		if !validFields[key] {
-			return false, fmt.Errorf("invalid field: %s", key)
-		}
+	return nil
+}
 
-		if strValue, ok := value.(string); ok && strValue != "" {
-			r.DB.Model(&model).Update(key, strValue)
-			return true, nil
-		}
+// scoped returns a query restricted to the members of the workspace carried by ctx.
+// It fails with core.ErrWorkspaceRequired when the context has no workspace.
+func (r *PostgresUserRepository) scoped(ctx context.Context) (*gorm.DB, error) {
+	workspaceID, err := tenant.WorkspaceID(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	return false, fmt.Errorf("no valid fields provided")
+	members := r.DB.Model(&WorkspaceMember{}).Select("user_id").Where("workspace_id = ?", workspaceID)
+
+	return conn(ctx, r.DB).Where("users.id IN (?)", members).Session(&gorm.Session{}), nil
+}
+
+// toDomainUser converts the persistence model into a domain.User.
+func toDomainUser(model User) *domain.User {
+	return &domain.User{
+		ID:              model.ID,
+		Username:        model.Username,
+		Email:           model.Email,
+		Role:            domain.UserRole(model.Role),
+		PasswordHash:    model.PasswordHash,
+		EmailVerifiedAt: model.EmailVerifiedAt,
+		CreatedAt:       model.CreatedAt,
+		UpdatedAt:       model.UpdatedAt,
+	}
 }
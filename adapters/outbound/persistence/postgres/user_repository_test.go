@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
+	"github.com/google/uuid"
+)
+
+// pgError is a PostgreSQL error as translated by the dialector, by its code.
+type pgError struct {
+	Code string
+}
+
+func (e *pgError) Error() string {
+	return "ERROR: duplicate key value violates unique constraint (SQLSTATE " + e.Code + ")"
+}
+
+// The email and the username of a user are unique across workspaces: the tests
+// below check them against the users of the other workspaces too.
+func TestPostgresUserRepositoryUniqueness(t *testing.T) {
+	workspaceA, workspaceB := uuid.New(), uuid.New()
+	ctxB := tenant.WithWorkspace(context.Background(), workspaceB)
+	userOfA := uuid.New()
+
+	t.Run("FindByEmailInAnyWorkspace", func(t *testing.T) {
+		db, mock := newMockDB(t)
+
+		mock.ExpectQuery(`WHERE email = $1`).WithArgs("a@example.com", anyValue{}).
+			WillReturnRows(userRows().AddRow(userOfA, "a", "a@example.com", time.Now(), time.Now()))
+
+		user, err := NewPostgresUserRepository(db).FindByEmailInAnyWorkspace(ctxB, "a@example.com")
+		if err != nil || user.ID != userOfA {
+			t.Fatalf("Expected the user of workspace %s, got %v (%v)", workspaceA, user, err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("FindByEmailInAnyWorkspace_NotFound", func(t *testing.T) {
+		db, mock := newMockDB(t)
+
+		mock.ExpectQuery(`WHERE email = $1`).WithArgs("nobody@example.com", anyValue{}).WillReturnRows(userRows())
+
+		if _, err := NewPostgresUserRepository(db).FindByEmailInAnyWorkspace(ctxB, "nobody@example.com"); !errors.Is(err, core.ErrUserNotFound) {
+			t.Errorf("Expected ErrUserNotFound, got: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Save_Duplicate", func(t *testing.T) {
+		db, mock := newMockDB(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "users"`).WillReturnError(&pgError{Code: "23505"})
+		mock.ExpectRollback()
+
+		err := NewPostgresUserRepository(db).Save(ctxB, &domain.User{ID: uuid.New(), Username: "a", Email: "a@example.com"})
+		if !errors.Is(err, core.ErrUserAlreadyExists) {
+			t.Errorf("Expected ErrUserAlreadyExists, got: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
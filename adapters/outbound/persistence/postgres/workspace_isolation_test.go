@@ -0,0 +1,535 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The tests in this file prove that the repositories never run a query outside
+// of the workspace carried by the context: every statement touching users or
+// tasks must bind the workspace of the context, and a context without a
+// workspace must fail before reaching the database.
+
+// containsMatcher accepts a statement when it contains the expected fragment.
+var containsMatcher = sqlmock.QueryMatcherFunc(func(expected, actual string) error {
+	if !strings.Contains(actual, expected) {
+		return fmt.Errorf("query %q does not contain %q", actual, expected)
+	}
+	return nil
+})
+
+// anyValue matches any argument, for values such as timestamps or LIMIT.
+type anyValue struct{}
+
+func (anyValue) Match(driver.Value) bool { return true }
+
+// workspaceArg matches the workspace bound to a statement.
+type workspaceArg uuid.UUID
+
+func (w workspaceArg) Match(v driver.Value) bool {
+	return fmt.Sprint(v) == uuid.UUID(w).String()
+}
+
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(containsMatcher))
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{
+		Logger:                 logger.Discard,
+		SkipDefaultTransaction: true,
+		TranslateError:         true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open gorm: %v", err)
+	}
+
+	return db, mock
+}
+
+func userRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "username", "email", "created_at", "updated_at"})
+}
+
+func taskRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "title", "description", "completed", "created_at", "updated_at", "user_id", "workspace_id"})
+}
+
+func TestUserRepositoryWorkspaceIsolation(t *testing.T) {
+	workspaceA, workspaceB := uuid.New(), uuid.New()
+	ctxA := tenant.WithWorkspace(context.Background(), workspaceA)
+	ctxB := tenant.WithWorkspace(context.Background(), workspaceB)
+	userOfA := uuid.New()
+
+	const scope = `users.id IN (SELECT "user_id" FROM "workspace_members" WHERE workspace_id = $1)`
+
+	t.Run("WithoutWorkspace", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresUserRepository(db)
+		ctx := context.Background()
+
+		calls := map[string]func() error{
+			"FindAll":     func() error { _, err := repo.FindAll(ctx); return err },
+			"FindByID":    func() error { _, err := repo.FindByID(ctx, userOfA); return err },
+			"FindByEmail": func() error { _, err := repo.FindByEmail(ctx, "a@example.com"); return err },
+			"Save":        func() error { return repo.Save(ctx, &domain.User{ID: uuid.New()}) },
+			"Update":      func() error { return repo.Update(ctx, userOfA, &domain.User{}) },
+			"Delete":      func() error { return repo.Delete(ctx, userOfA) },
+		}
+
+		for name, call := range calls {
+			if err := call(); !errors.Is(err, core.ErrWorkspaceRequired) {
+				t.Errorf("%s: expected ErrWorkspaceRequired, got: %v", name, err)
+			}
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unexpected database access: %v", err)
+		}
+	})
+
+	t.Run("FindAll", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresUserRepository(db)
+
+		mock.ExpectQuery(scope).WithArgs(workspaceArg(workspaceA)).
+			WillReturnRows(userRows().AddRow(userOfA, "a", "a@example.com", time.Now(), time.Now()))
+
+		users, err := repo.FindAll(ctxA)
+		if err != nil || len(users) != 1 {
+			t.Fatalf("Expected the user of workspace A, got %v (%v)", users, err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("FindByID_OtherWorkspace", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresUserRepository(db)
+
+		mock.ExpectQuery(scope).WithArgs(workspaceArg(workspaceB), userOfA, anyValue{}).WillReturnRows(userRows())
+
+		if _, err := repo.FindByID(ctxB, userOfA); !errors.Is(err, core.ErrUserNotFound) {
+			t.Errorf("Expected ErrUserNotFound, got: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("FindByEmail_OtherWorkspace", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresUserRepository(db)
+
+		mock.ExpectQuery(scope).WithArgs(workspaceArg(workspaceB), "a@example.com", anyValue{}).WillReturnRows(userRows())
+
+		if _, err := repo.FindByEmail(ctxB, "a@example.com"); !errors.Is(err, core.ErrUserNotFound) {
+			t.Errorf("Expected ErrUserNotFound, got: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Update_OtherWorkspace", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresUserRepository(db)
+
+		mock.ExpectQuery(scope).WithArgs(workspaceArg(workspaceB), userOfA, anyValue{}).WillReturnRows(userRows())
+
+		if err := repo.Update(ctxB, userOfA, &domain.User{Username: "hijack"}); err == nil {
+			t.Error("Expected the update of a user of another workspace to fail")
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Delete_OtherWorkspace", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresUserRepository(db)
+
+		mock.ExpectQuery(scope).WithArgs(workspaceArg(workspaceB), userOfA, anyValue{}).WillReturnRows(userRows())
+
+		if err := repo.Delete(ctxB, userOfA); err == nil {
+			t.Error("Expected the deletion of a user of another workspace to fail")
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Save_JoinsWorkspace", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresUserRepository(db)
+		user := &domain.User{ID: uuid.New(), Username: "new", Email: "new@example.com", Role: domain.UserRoleUser, PasswordHash: "hash"}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "users"`).
+			WithArgs(user.Username, user.Email, user.PasswordHash, string(user.Role), anyValue{}, anyValue{}, anyValue{}, user.ID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(user.ID))
+		mock.ExpectExec(`INSERT INTO "workspace_members"`).
+			WithArgs(workspaceArg(workspaceA), user.ID, string(domain.WorkspaceRoleMember), anyValue{}, anyValue{}).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		if err := repo.Save(ctxA, user); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestTaskRepositoryWorkspaceIsolation(t *testing.T) {
+	workspaceA, workspaceB := uuid.New(), uuid.New()
+	ctxA := tenant.WithWorkspace(context.Background(), workspaceA)
+	ctxB := tenant.WithWorkspace(context.Background(), workspaceB)
+	userID, taskOfA := uuid.New(), uuid.New()
+
+	const scope = `tasks.workspace_id = $1`
+	const sharesScope = `task_shares.task_id IN (SELECT "id" FROM "tasks" WHERE workspace_id = $1)`
+
+	t.Run("WithoutWorkspace", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresTaskRepository(db)
+		ctx := context.Background()
+
+		calls := map[string]func() error{
+			"Save":            func() error { return repo.Save(ctx, userID, &domain.Task{ID: uuid.New()}) },
+			"SaveAll":         func() error { return repo.SaveAll(ctx, userID, []*domain.Task{{ID: uuid.New()}}) },
+			"FindUserTasks":   func() error { _, err := repo.FindUserTasks(ctx, userID); return err },
+			"FindSharedTasks": func() error { _, err := repo.FindSharedTasks(ctx, userID); return err },
+			"FindTaskByID":    func() error { _, err := repo.FindTaskByID(ctx, userID, taskOfA); return err },
+			"Update":          func() error { return repo.Update(ctx, taskOfA, &domain.Task{}) },
+			"Delete":          func() error { return repo.Delete(ctx, taskOfA) },
+			"SaveTaskShare":   func() error { return repo.SaveTaskShare(ctx, &domain.TaskShare{TaskID: taskOfA}) },
+			"FindTaskShare":   func() error { _, err := repo.FindTaskShare(ctx, taskOfA, userID); return err },
+			"FindTaskShares":  func() error { _, err := repo.FindTaskShares(ctx, taskOfA); return err },
+			"DeleteTaskShare": func() error { return repo.DeleteTaskShare(ctx, taskOfA, userID) },
+		}
+
+		for name, call := range calls {
+			if err := call(); !errors.Is(err, core.ErrWorkspaceRequired) {
+				t.Errorf("%s: expected ErrWorkspaceRequired, got: %v", name, err)
+			}
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unexpected database access: %v", err)
+		}
+	})
+
+	t.Run("Save_StoresWorkspace", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresTaskRepository(db)
+		task := &domain.Task{ID: uuid.New(), Title: "Task", Description: "Task of A"}
+
+		mock.ExpectQuery(`INSERT INTO "tasks"`).
+			WithArgs(task.Title, task.Description, false, anyValue{}, anyValue{}, userID, workspaceArg(workspaceA), task.ID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(task.ID))
+
+		if err := repo.Save(ctxA, userID, task); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if task.WorkspaceID != workspaceA {
+			t.Errorf("Expected task to belong to workspace A, got %s", task.WorkspaceID)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("SaveAll_MultiRowInsert", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresTaskRepository(db)
+		tasks := []*domain.Task{
+			{ID: uuid.New(), Title: "First", Description: "Task of A"},
+			{ID: uuid.New(), Title: "Second", Description: "Task of A", Completed: true},
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "tasks"`).
+			WithArgs(
+				tasks[0].Title, tasks[0].Description, false, anyValue{}, anyValue{}, userID, workspaceArg(workspaceA), tasks[0].ID,
+				tasks[1].Title, tasks[1].Description, true, anyValue{}, anyValue{}, userID, workspaceArg(workspaceA), tasks[1].ID,
+			).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(tasks[0].ID).AddRow(tasks[1].ID))
+		mock.ExpectCommit()
+
+		if err := repo.SaveAll(ctxA, userID, tasks); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		for _, task := range tasks {
+			if task.WorkspaceID != workspaceA {
+				t.Errorf("Expected task to belong to workspace A, got %s", task.WorkspaceID)
+			}
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("FindUserTasks", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresTaskRepository(db)
+
+		mock.ExpectQuery(scope).WithArgs(workspaceArg(workspaceB), userID).WillReturnRows(taskRows())
+
+		tasks, err := repo.FindUserTasks(ctxB, userID)
+		if err != nil || len(tasks) != 0 {
+			t.Fatalf("Expected no task in workspace B, got %v (%v)", tasks, err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("FindSharedTasks", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresTaskRepository(db)
+
+		mock.ExpectQuery(scope).WithArgs(workspaceArg(workspaceB), userID).WillReturnRows(taskRows())
+
+		if _, err := repo.FindSharedTasks(ctxB, userID); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("FindTaskByID_OtherWorkspace", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresTaskRepository(db)
+
+		mock.ExpectQuery(scope).WithArgs(workspaceArg(workspaceB), taskOfA, userID, userID, anyValue{}).WillReturnRows(taskRows())
+
+		if _, err := repo.FindTaskByID(ctxB, userID, taskOfA); !errors.Is(err, core.ErrTaskNotFound) {
+			t.Errorf("Expected ErrTaskNotFound, got: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Update_OtherWorkspace", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresTaskRepository(db)
+
+		mock.ExpectQuery(scope).WithArgs(workspaceArg(workspaceB), taskOfA, anyValue{}).WillReturnRows(taskRows())
+
+		if err := repo.Update(ctxB, taskOfA, &domain.Task{Title: "hijack"}); err == nil {
+			t.Error("Expected the update of a task of another workspace to fail")
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Delete_OtherWorkspace", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresTaskRepository(db)
+
+		mock.ExpectQuery(scope).WithArgs(workspaceArg(workspaceB), taskOfA, anyValue{}).WillReturnRows(taskRows())
+
+		if err := repo.Delete(ctxB, taskOfA); err == nil {
+			t.Error("Expected the deletion of a task of another workspace to fail")
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("TaskShares_OtherWorkspace", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresTaskRepository(db)
+
+		mock.ExpectQuery(scope).WithArgs(workspaceArg(workspaceB), taskOfA, anyValue{}).WillReturnRows(taskRows())
+		mock.ExpectQuery(sharesScope).WithArgs(workspaceArg(workspaceB), taskOfA, userID, anyValue{}).
+			WillReturnRows(sqlmock.NewRows([]string{"task_id", "user_id", "role"}))
+		mock.ExpectQuery(sharesScope).WithArgs(workspaceArg(workspaceB), taskOfA).
+			WillReturnRows(sqlmock.NewRows([]string{"task_id", "user_id", "role"}))
+		mock.ExpectExec(sharesScope).WithArgs(workspaceArg(workspaceB), taskOfA, userID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if err := repo.SaveTaskShare(ctxB, &domain.TaskShare{TaskID: taskOfA, UserID: userID}); !errors.Is(err, core.ErrTaskNotFound) {
+			t.Errorf("SaveTaskShare: expected ErrTaskNotFound, got: %v", err)
+		}
+		if _, err := repo.FindTaskShare(ctxB, taskOfA, userID); !errors.Is(err, core.ErrTaskShareNotFound) {
+			t.Errorf("FindTaskShare: expected ErrTaskShareNotFound, got: %v", err)
+		}
+		if shares, err := repo.FindTaskShares(ctxB, taskOfA); err != nil || len(shares) != 0 {
+			t.Errorf("FindTaskShares: expected no share, got %v (%v)", shares, err)
+		}
+		if err := repo.DeleteTaskShare(ctxB, taskOfA, userID); !errors.Is(err, core.ErrTaskShareNotFound) {
+			t.Errorf("DeleteTaskShare: expected ErrTaskShareNotFound, got: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestAPIKeyRepositoryWorkspaceIsolation(t *testing.T) {
+	workspaceB := uuid.New()
+	ctxB := tenant.WithWorkspace(context.Background(), workspaceB)
+
+	t.Run("WithoutWorkspace", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresAPIKeyRepository(db)
+
+		if _, err := repo.FindByPrefix(context.Background(), "gts_0123456789abcdef"); !errors.Is(err, core.ErrWorkspaceRequired) {
+			t.Errorf("Expected ErrWorkspaceRequired, got: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unexpected database access: %v", err)
+		}
+	})
+
+	t.Run("FindByPrefix_OtherWorkspace", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresAPIKeyRepository(db)
+
+		mock.ExpectQuery(`api_keys.workspace_id = $1`).
+			WithArgs(workspaceArg(workspaceB), "gts_0123456789abcdef", anyValue{}).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "prefix"}))
+
+		if _, err := repo.FindByPrefix(ctxB, "gts_0123456789abcdef"); !errors.Is(err, core.ErrAPIKeyNotFound) {
+			t.Errorf("Expected ErrAPIKeyNotFound, got: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestTwoFactorRepositoryWorkspaceIsolation(t *testing.T) {
+	workspaceB := uuid.New()
+	ctxB := tenant.WithWorkspace(context.Background(), workspaceB)
+	userOfA := uuid.New()
+
+	t.Run("WithoutWorkspace", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresTwoFactorRepository(db)
+
+		if _, err := repo.FindByUserID(context.Background(), userOfA); !errors.Is(err, core.ErrWorkspaceRequired) {
+			t.Errorf("Expected ErrWorkspaceRequired, got: %v", err)
+		}
+		if err := repo.Save(context.Background(), &domain.TwoFactor{UserID: userOfA}); !errors.Is(err, core.ErrWorkspaceRequired) {
+			t.Errorf("Expected ErrWorkspaceRequired, got: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unexpected database access: %v", err)
+		}
+	})
+
+	t.Run("FindByUserID_OtherWorkspace", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresTwoFactorRepository(db)
+
+		mock.ExpectQuery(`two_factors.user_id IN (SELECT "user_id" FROM "workspace_members" WHERE workspace_id = $1)`).
+			WithArgs(workspaceArg(workspaceB), userOfA, anyValue{}).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "secret"}))
+
+		if _, err := repo.FindByUserID(ctxB, userOfA); !errors.Is(err, core.ErrTwoFactorNotFound) {
+			t.Errorf("Expected ErrTwoFactorNotFound, got: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Save_OtherWorkspace", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresTwoFactorRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT count(*) FROM "workspace_members" WHERE workspace_id = $1 AND user_id = $2`).
+			WithArgs(workspaceArg(workspaceB), userOfA).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectRollback()
+
+		if err := repo.Save(ctxB, &domain.TwoFactor{UserID: userOfA, Secret: "SECRET"}); !errors.Is(err, core.ErrUserNotFound) {
+			t.Errorf("Expected ErrUserNotFound, got: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestUserRepositoryAccountUpdatesIsolation(t *testing.T) {
+	workspaceB := uuid.New()
+	ctxB := tenant.WithWorkspace(context.Background(), workspaceB)
+	userOfA := uuid.New()
+
+	t.Run("MarkEmailVerified_OtherWorkspace", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresUserRepository(db)
+
+		mock.ExpectExec(`UPDATE "users" SET`).
+			WithArgs(anyValue{}, anyValue{}, workspaceArg(workspaceB), userOfA).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if err := repo.MarkEmailVerified(ctxB, userOfA, time.Now()); !errors.Is(err, core.ErrUserNotFound) {
+			t.Errorf("Expected ErrUserNotFound, got: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("UpdatePassword_WithoutWorkspace", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		repo := NewPostgresUserRepository(db)
+
+		if err := repo.UpdatePassword(context.Background(), userOfA, "hash"); !errors.Is(err, core.ErrWorkspaceRequired) {
+			t.Errorf("Expected ErrWorkspaceRequired, got: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unexpected database access: %v", err)
+		}
+	})
+}
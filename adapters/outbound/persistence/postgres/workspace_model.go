@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Workspace is the persistence model of a workspace. The Slug is unique and
+// used to look up well-known workspaces such as the default one.
+// Members, Tasks and APIKeys are removed together with the workspace.
+type Workspace struct {
+	ID        uuid.UUID         `gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	Name      string            `gorm:"not null"`
+	Slug      string            `gorm:"unique;not null"`
+	CreatedAt time.Time         `gorm:"autoCreateTime:true"`
+	UpdatedAt time.Time         `gorm:"autoUpdateTime:true"`
+	Members   []WorkspaceMember `gorm:"foreignKey:WorkspaceID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Tasks     []Task            `gorm:"foreignKey:WorkspaceID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	APIKeys   []APIKey          `gorm:"foreignKey:WorkspaceID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// WorkspaceMember is the persistence model linking a user to a workspace.
+// The composite primary key (WorkspaceID, UserID) allows a single role per
+// user and workspace.
+type WorkspaceMember struct {
+	WorkspaceID uuid.UUID `gorm:"primaryKey;type:uuid"`
+	UserID      uuid.UUID `gorm:"primaryKey;type:uuid;index"`
+	Role        string    `gorm:"not null"`
+	CreatedAt   time.Time `gorm:"autoCreateTime:true"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime:true"`
+}
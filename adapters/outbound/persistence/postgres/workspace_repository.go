@@ -0,0 +1,176 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostgresWorkspaceRepository implements the WorkspaceRepository interface
+// for PostgreSQL using GORM.
+type PostgresWorkspaceRepository struct {
+	DB *gorm.DB
+}
+
+// NewPostgresWorkspaceRepository creates a new instance of PostgresWorkspaceRepository.
+func NewPostgresWorkspaceRepository(db *gorm.DB) *PostgresWorkspaceRepository {
+	return &PostgresWorkspaceRepository{DB: db}
+}
+
+// Save inserts the workspace into the database.
+// It returns core.ErrWorkspaceSlugExists when another workspace uses the same slug.
+func (w *PostgresWorkspaceRepository) Save(ctx context.Context, workspace *domain.Workspace) error {
+	model := Workspace{
+		ID:        workspace.ID,
+		Name:      workspace.Name,
+		Slug:      workspace.Slug,
+		CreatedAt: workspace.CreatedAt,
+		UpdatedAt: workspace.UpdatedAt,
+	}
+
+	if err := conn(ctx, w.DB).Create(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return core.ErrWorkspaceSlugExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+// FindByID retrieves a workspace by its unique identifier.
+// It returns core.ErrWorkspaceNotFound when the workspace does not exist.
+func (w *PostgresWorkspaceRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Workspace, error) {
+	return w.findOne(ctx, "id = ?", id)
+}
+
+// FindBySlug retrieves a workspace by its slug.
+// It returns core.ErrWorkspaceNotFound when the workspace does not exist.
+func (w *PostgresWorkspaceRepository) FindBySlug(ctx context.Context, slug string) (*domain.Workspace, error) {
+	return w.findOne(ctx, "slug = ?", slug)
+}
+
+// FindUserWorkspaces lists the workspaces the user is a member of.
+func (w *PostgresWorkspaceRepository) FindUserWorkspaces(ctx context.Context, userID uuid.UUID) ([]*domain.Workspace, error) {
+	var models []Workspace
+
+	memberships := w.DB.Model(&WorkspaceMember{}).Select("workspace_id").Where("user_id = ?", userID)
+	if err := conn(ctx, w.DB).Where("id IN (?)", memberships).Order("name").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	workspaces := make([]*domain.Workspace, len(models))
+	for i, model := range models {
+		workspaces[i] = toDomainWorkspace(model)
+	}
+
+	return workspaces, nil
+}
+
+// SaveMember adds the user to the workspace, replacing the role when the user
+// is already a member. It returns core.ErrUserNotFound when the user does not exist.
+func (w *PostgresWorkspaceRepository) SaveMember(ctx context.Context, member *domain.WorkspaceMember) error {
+	model := WorkspaceMember{
+		WorkspaceID: member.WorkspaceID,
+		UserID:      member.UserID,
+		Role:        string(member.Role),
+		CreatedAt:   member.CreatedAt,
+		UpdatedAt:   member.UpdatedAt,
+	}
+
+	err := conn(ctx, w.DB).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "workspace_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "updated_at"}),
+	}).Create(&model).Error
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		return core.ErrUserNotFound
+	}
+
+	return err
+}
+
+// FindMember retrieves the membership of a user in a workspace.
+// It returns core.ErrWorkspaceMemberMissing when the user is not a member.
+func (w *PostgresWorkspaceRepository) FindMember(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID) (*domain.WorkspaceMember, error) {
+	var model WorkspaceMember
+
+	if err := conn(ctx, w.DB).Where("workspace_id = ? AND user_id = ?", workspaceID, userID).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, core.ErrWorkspaceMemberMissing
+		}
+		return nil, err
+	}
+
+	return toDomainWorkspaceMember(model), nil
+}
+
+// FindMembers lists the members of a workspace.
+func (w *PostgresWorkspaceRepository) FindMembers(ctx context.Context, workspaceID uuid.UUID) ([]*domain.WorkspaceMember, error) {
+	var models []WorkspaceMember
+
+	if err := conn(ctx, w.DB).Where("workspace_id = ?", workspaceID).Order("created_at").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	members := make([]*domain.WorkspaceMember, len(models))
+	for i, model := range models {
+		members[i] = toDomainWorkspaceMember(model)
+	}
+
+	return members, nil
+}
+
+// DeleteMember removes the user from the workspace.
+// It returns core.ErrWorkspaceMemberMissing when the user was not a member.
+func (w *PostgresWorkspaceRepository) DeleteMember(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID) error {
+	result := conn(ctx, w.DB).Where("workspace_id = ? AND user_id = ?", workspaceID, userID).Delete(&WorkspaceMember{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return core.ErrWorkspaceMemberMissing
+	}
+
+	return nil
+}
+
+func (w *PostgresWorkspaceRepository) findOne(ctx context.Context, query string, args ...any) (*domain.Workspace, error) {
+	var model Workspace
+
+	if err := conn(ctx, w.DB).Where(query, args...).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, core.ErrWorkspaceNotFound
+		}
+		return nil, err
+	}
+
+	return toDomainWorkspace(model), nil
+}
+
+// toDomainWorkspace converts the persistence model into a domain.Workspace.
+func toDomainWorkspace(model Workspace) *domain.Workspace {
+	return &domain.Workspace{
+		ID:        model.ID,
+		Name:      model.Name,
+		Slug:      model.Slug,
+		CreatedAt: model.CreatedAt,
+		UpdatedAt: model.UpdatedAt,
+	}
+}
+
+// toDomainWorkspaceMember converts the persistence model into a domain.WorkspaceMember.
+func toDomainWorkspaceMember(model WorkspaceMember) *domain.WorkspaceMember {
+	return &domain.WorkspaceMember{
+		WorkspaceID: model.WorkspaceID,
+		UserID:      model.UserID,
+		Role:        domain.WorkspaceRole(model.Role),
+		CreatedAt:   model.CreatedAt,
+		UpdatedAt:   model.UpdatedAt,
+	}
+}
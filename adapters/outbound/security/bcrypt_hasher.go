@@ -0,0 +1,50 @@
+// Package security provides the implementations of the password hashing and
+// token signing ports used to authenticate users.
+package security
+
+import (
+	"errors"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BcryptHasher implements the PasswordHasher interface using bcrypt.
+type BcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher creates a new instance of BcryptHasher. A cost lower than
+// bcrypt.MinCost falls back to bcrypt.DefaultCost.
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost < bcrypt.MinCost {
+		cost = bcrypt.DefaultCost
+	}
+
+	return &BcryptHasher{cost: cost}
+}
+
+// Hash returns the bcrypt hash of the password.
+// It returns core.ErrInvalidPassword when the password exceeds the 72 bytes
+// supported by bcrypt.
+func (b *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.cost)
+	if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+		return "", core.ErrInvalidPassword
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// Compare checks the password against the bcrypt hash.
+// It returns core.ErrInvalidCredentials when they do not match.
+func (b *BcryptHasher) Compare(hash string, password string) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return core.ErrInvalidCredentials
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package security
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+)
+
+func TestBcryptHasher(t *testing.T) {
+	hasher := NewBcryptHasher(4)
+
+	hash, err := hasher.Hash("s3cret-password")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if err := hasher.Compare(hash, "s3cret-password"); err != nil {
+		t.Errorf("Expected the password to match, got: %v", err)
+	}
+
+	if err := hasher.Compare(hash, "wrong-password"); !errors.Is(err, core.ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials, got: %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package security
+
+import (
+	"errors"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/auth"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Issuer is the value of the "iss" claim of the tokens signed by JWTIssuer.
+const Issuer = "gotostudy"
+
+// claims are the JWT claims of both access and refresh tokens. The subject
+// holds the user ID.
+type claims struct {
+	WorkspaceID string         `json:"wid"`
+	Role        string         `json:"role"`
+	Kind        auth.TokenKind `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+// actionClaims are the JWT claims of the action tokens sent by email. The
+// subject holds the user ID.
+type actionClaims struct {
+	WorkspaceID string         `json:"wid"`
+	Kind        auth.TokenKind `json:"typ"`
+	Fingerprint string         `json:"fp,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// JWTIssuer implements the TokenIssuer and ActionTokenSigner interfaces with
+// HMAC-SHA256 signed JWTs.
+type JWTIssuer struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	now        func() time.Time
+}
+
+// NewJWTIssuer creates a new instance of JWTIssuer signing tokens with secret.
+// Access tokens expire after accessTTL and refresh tokens after refreshTTL.
+func NewJWTIssuer(secret []byte, accessTTL, refreshTTL time.Duration) *JWTIssuer {
+	return &JWTIssuer{
+		secret:     secret,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+		now:        time.Now,
+	}
+}
+
+// Issue signs a new pair of access and refresh tokens for the principal.
+func (j *JWTIssuer) Issue(principal auth.Principal) (*auth.TokenPair, error) {
+	now := j.now()
+
+	access, err := j.sign(principal, auth.TokenAccess, now, now.Add(j.accessTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, err := j.sign(principal, auth.TokenRefresh, now, now.Add(j.refreshTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth.TokenPair{
+		AccessToken:      access,
+		RefreshToken:     refresh,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(j.accessTTL.Seconds()),
+		AccessExpiresAt:  now.Add(j.accessTTL),
+		RefreshExpiresAt: now.Add(j.refreshTTL),
+	}, nil
+}
+
+// Verify checks the signature, the expiration and the kind of the token and
+// returns the principal it was issued for.
+// It returns core.ErrInvalidToken when any of these checks fails.
+func (j *JWTIssuer) Verify(token string, kind auth.TokenKind) (*auth.Principal, error) {
+	var c claims
+
+	_, err := jwt.ParseWithClaims(token, &c, func(*jwt.Token) (any, error) {
+		return j.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(Issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(j.now),
+	)
+	if err != nil || c.Kind != kind {
+		return nil, core.ErrInvalidToken
+	}
+
+	userID, err := uuid.Parse(c.Subject)
+	if err != nil {
+		return nil, core.ErrInvalidToken
+	}
+
+	workspaceID, err := uuid.Parse(c.WorkspaceID)
+	if err != nil {
+		return nil, core.ErrInvalidToken
+	}
+
+	return &auth.Principal{
+		UserID:      userID,
+		WorkspaceID: workspaceID,
+		Role:        domain.UserRole(c.Role),
+	}, nil
+}
+
+// SignAction signs an action token, valid until its ExpiresAt.
+func (j *JWTIssuer) SignAction(token auth.ActionToken) (string, error) {
+	if len(j.secret) == 0 {
+		return "", errors.New("jwt secret is not configured")
+	}
+
+	if !token.Kind.IsAction() {
+		return "", errors.New("not an action token kind: " + string(token.Kind))
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, actionClaims{
+		WorkspaceID: token.WorkspaceID.String(),
+		Kind:        token.Kind,
+		Fingerprint: token.Fingerprint,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    Issuer,
+			Subject:   token.UserID.String(),
+			IssuedAt:  jwt.NewNumericDate(j.now()),
+			ExpiresAt: jwt.NewNumericDate(token.ExpiresAt),
+		},
+	}).SignedString(j.secret)
+}
+
+// VerifyAction checks the signature, the expiration and the kind of an action
+// token and returns its content. It returns core.ErrInvalidToken when any of
+// these checks fails, so access and refresh tokens are rejected as well.
+func (j *JWTIssuer) VerifyAction(token string, kind auth.TokenKind) (*auth.ActionToken, error) {
+	var c actionClaims
+
+	_, err := jwt.ParseWithClaims(token, &c, func(*jwt.Token) (any, error) {
+		return j.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(Issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(j.now),
+	)
+	if err != nil || c.Kind != kind || !kind.IsAction() {
+		return nil, core.ErrInvalidToken
+	}
+
+	userID, err := uuid.Parse(c.Subject)
+	if err != nil {
+		return nil, core.ErrInvalidToken
+	}
+
+	workspaceID, err := uuid.Parse(c.WorkspaceID)
+	if err != nil {
+		return nil, core.ErrInvalidToken
+	}
+
+	return &auth.ActionToken{
+		Kind:        c.Kind,
+		UserID:      userID,
+		WorkspaceID: workspaceID,
+		Fingerprint: c.Fingerprint,
+		ExpiresAt:   c.ExpiresAt.Time,
+	}, nil
+}
+
+func (j *JWTIssuer) sign(principal auth.Principal, kind auth.TokenKind, issuedAt, expiresAt time.Time) (string, error) {
+	if len(j.secret) == 0 {
+		return "", errors.New("jwt secret is not configured")
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		WorkspaceID: principal.WorkspaceID.String(),
+		Role:        string(principal.Role),
+		Kind:        kind,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    Issuer,
+			Subject:   principal.UserID.String(),
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+
+	return token.SignedString(j.secret)
+}
@@ -0,0 +1,112 @@
+package security
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/auth"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/google/uuid"
+)
+
+func TestJWTIssuer(t *testing.T) {
+	issuer := NewJWTIssuer([]byte("test-secret"), time.Minute, time.Hour)
+	principal := auth.Principal{UserID: uuid.New(), WorkspaceID: uuid.New(), Role: domain.UserRoleAdmin}
+
+	tokens, err := issuer.Issue(principal)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	t.Run("Verify", func(t *testing.T) {
+		got, err := issuer.Verify(tokens.AccessToken, auth.TokenAccess)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if got.UserID != principal.UserID || got.WorkspaceID != principal.WorkspaceID || got.Role != principal.Role {
+			t.Errorf("Expected principal %+v, got %+v", principal, *got)
+		}
+	})
+
+	t.Run("Verify_WrongKind", func(t *testing.T) {
+		if _, err := issuer.Verify(tokens.RefreshToken, auth.TokenAccess); !errors.Is(err, core.ErrInvalidToken) {
+			t.Errorf("Expected ErrInvalidToken, got: %v", err)
+		}
+	})
+
+	t.Run("Verify_Tampered", func(t *testing.T) {
+		if _, err := issuer.Verify(tokens.AccessToken+"x", auth.TokenAccess); !errors.Is(err, core.ErrInvalidToken) {
+			t.Errorf("Expected ErrInvalidToken, got: %v", err)
+		}
+	})
+
+	t.Run("Verify_OtherSecret", func(t *testing.T) {
+		other := NewJWTIssuer([]byte("other-secret"), time.Minute, time.Hour)
+		if _, err := other.Verify(tokens.AccessToken, auth.TokenAccess); !errors.Is(err, core.ErrInvalidToken) {
+			t.Errorf("Expected ErrInvalidToken, got: %v", err)
+		}
+	})
+
+	t.Run("Verify_Expired", func(t *testing.T) {
+		expired := NewJWTIssuer([]byte("test-secret"), time.Minute, time.Hour)
+		expired.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+
+		if _, err := expired.Verify(tokens.AccessToken, auth.TokenAccess); !errors.Is(err, core.ErrInvalidToken) {
+			t.Errorf("Expected ErrInvalidToken, got: %v", err)
+		}
+	})
+}
+
+func TestJWTIssuerActionTokens(t *testing.T) {
+	issuer := NewJWTIssuer([]byte("test-secret"), time.Minute, time.Hour)
+	action := auth.ActionToken{
+		Kind:        auth.TokenPasswordReset,
+		UserID:      uuid.New(),
+		WorkspaceID: uuid.New(),
+		Fingerprint: "0123456789abcdef",
+		ExpiresAt:   time.Now().Add(time.Hour).Truncate(time.Second),
+	}
+
+	token, err := issuer.SignAction(action)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	t.Run("VerifyAction", func(t *testing.T) {
+		got, err := issuer.VerifyAction(token, auth.TokenPasswordReset)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if *got != action {
+			t.Errorf("Expected %+v, got %+v", action, *got)
+		}
+	})
+
+	t.Run("VerifyAction_WrongKind", func(t *testing.T) {
+		if _, err := issuer.VerifyAction(token, auth.TokenVerifyEmail); !errors.Is(err, core.ErrInvalidToken) {
+			t.Errorf("Expected ErrInvalidToken, got: %v", err)
+		}
+	})
+
+	t.Run("VerifyAction_Expired", func(t *testing.T) {
+		issuer.now = func() time.Time { return action.ExpiresAt.Add(time.Minute) }
+		defer func() { issuer.now = time.Now }()
+
+		if _, err := issuer.VerifyAction(token, auth.TokenPasswordReset); !errors.Is(err, core.ErrInvalidToken) {
+			t.Errorf("Expected ErrInvalidToken, got: %v", err)
+		}
+	})
+
+	t.Run("NotAnAccessToken", func(t *testing.T) {
+		if _, err := issuer.Verify(token, auth.TokenAccess); !errors.Is(err, core.ErrInvalidToken) {
+			t.Errorf("Expected ErrInvalidToken, got: %v", err)
+		}
+
+		tokens, _ := issuer.Issue(auth.Principal{UserID: action.UserID, WorkspaceID: action.WorkspaceID})
+		if _, err := issuer.VerifyAction(tokens.AccessToken, auth.TokenAccess); err == nil {
+			t.Error("Expected access tokens not to be accepted as action tokens")
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package security
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base32"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	// totpSecretSize is the size in bytes of the TOTP secrets, as recommended by RFC 4226.
+	totpSecretSize = 20
+	// qrCodeSize is the width and height in pixels of the QR code images.
+	qrCodeSize = 256
+)
+
+// totpOptions are the standard TOTP settings understood by every authenticator
+// app: 6 digits, SHA-1 and 30 second periods. One period of clock skew is accepted.
+var totpOptions = totp.ValidateOpts{
+	Period:    30,
+	Skew:      1,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+var totpSecretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TOTPAuthenticator implements the OTPAuthenticator interface with RFC 6238
+// time-based one-time passwords.
+type TOTPAuthenticator struct {
+	issuer string
+}
+
+// NewTOTPAuthenticator creates a new instance of TOTPAuthenticator. The issuer is
+// the name authenticator apps display next to the account.
+func NewTOTPAuthenticator(issuer string) *TOTPAuthenticator {
+	return &TOTPAuthenticator{issuer: issuer}
+}
+
+// GenerateSecret returns a new random secret encoded in base32.
+func (a *TOTPAuthenticator) GenerateSecret() (string, error) {
+	secret := make([]byte, totpSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return totpSecretEncoding.EncodeToString(secret), nil
+}
+
+// URI returns the otpauth:// URI registering secret for account.
+func (a *TOTPAuthenticator) URI(secret string, account string) string {
+	raw, err := totpSecretEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return ""
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      a.issuer,
+		AccountName: account,
+		Period:      uint(totpOptions.Period),
+		Digits:      totpOptions.Digits,
+		Algorithm:   totpOptions.Algorithm,
+		Secret:      raw,
+	})
+	if err != nil {
+		return ""
+	}
+
+	return key.URL()
+}
+
+// QRCode returns the URI encoded as a QR code PNG image.
+func (a *TOTPAuthenticator) QRCode(uri string) ([]byte, error) {
+	key, err := otp.NewKeyFromURL(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := key.Image(qrCodeSize, qrCodeSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Validate reports whether code is the TOTP code of secret at the given time,
+// or of the period right before or after it, and returns the time step of the
+// period it matched.
+func (a *TOTPAuthenticator) Validate(code string, secret string, at time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	period := int64(totpOptions.Period)
+
+	exact := totpOptions
+	exact.Skew = 0
+	for skew := -int64(totpOptions.Skew); skew <= int64(totpOptions.Skew); skew++ {
+		step := at.Unix()/period + skew
+		if valid, err := totp.ValidateCustom(code, secret, time.Unix(step*period, 0), exact); err == nil && valid {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,99 @@
+package security
+
+import (
+	"bytes"
+	"image/png"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+func TestTOTPAuthenticator(t *testing.T) {
+	authenticator := NewTOTPAuthenticator(Issuer)
+
+	secret, err := authenticator.GenerateSecret()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	t.Run("GenerateSecret", func(t *testing.T) {
+		if len(secret) != 32 {
+			t.Errorf("Expected a 32 character base32 secret, got %q", secret)
+		}
+
+		other, _ := authenticator.GenerateSecret()
+		if other == secret {
+			t.Error("Expected random secrets")
+		}
+	})
+
+	t.Run("URI", func(t *testing.T) {
+		uri, err := url.Parse(authenticator.URI(secret, "user@example.com"))
+		if err != nil {
+			t.Fatalf("Expected a valid URI, got: %v", err)
+		}
+
+		if uri.Scheme != "otpauth" || uri.Host != "totp" {
+			t.Errorf("Expected an otpauth://totp URI, got %s", uri)
+		}
+		if uri.Path != "/"+Issuer+":user@example.com" {
+			t.Errorf("Unexpected label %q", uri.Path)
+		}
+		if q := uri.Query(); q.Get("secret") != secret || q.Get("issuer") != Issuer || q.Get("digits") != "6" || q.Get("period") != "30" {
+			t.Errorf("Unexpected parameters %v", q)
+		}
+	})
+
+	t.Run("QRCode", func(t *testing.T) {
+		image, err := authenticator.QRCode(authenticator.URI(secret, "user@example.com"))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		decoded, err := png.Decode(bytes.NewReader(image))
+		if err != nil {
+			t.Fatalf("Expected a PNG image, got: %v", err)
+		}
+		if size := decoded.Bounds().Size(); size.X != qrCodeSize || size.Y != qrCodeSize {
+			t.Errorf("Expected a %dx%d image, got %v", qrCodeSize, qrCodeSize, size)
+		}
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+		code, err := totp.GenerateCodeCustom(secret, now, totpOptions)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		tests := []struct {
+			name string
+			at   time.Time
+			want bool
+		}{
+			{"SamePeriod", now.Add(10 * time.Second), true},
+			{"PreviousPeriodSkew", now.Add(30 * time.Second), true},
+			{"NextPeriodSkew", now.Add(-30 * time.Second), true},
+			{"Expired", now.Add(2 * time.Minute), false},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				step, got := authenticator.Validate(code, secret, tt.at)
+				if got != tt.want {
+					t.Errorf("Expected %v, got %v", tt.want, got)
+				}
+				if got && step != now.Unix()/30 {
+					t.Errorf("Expected the time step of the code %d, got %d", now.Unix()/30, step)
+				}
+			})
+		}
+
+		if _, ok := authenticator.Validate("not-a-code", secret, now); ok {
+			t.Error("Expected a malformed code to be rejected")
+		}
+	})
+}
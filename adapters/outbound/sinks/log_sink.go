@@ -0,0 +1,39 @@
+// Package sinks implements the EventSink port receiving the domain events relayed
+// from the outbox: WebhookSink posts them to the URL of an integration and LogSink
+// logs them, for development and auditing.
+package sinks
+
+import (
+	"context"
+
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/logging"
+)
+
+// LogSink implements the EventSink interface by logging the events at the debug
+// level. It never fails.
+type LogSink struct{}
+
+// NewLogSink creates a new instance of LogSink.
+func NewLogSink() *LogSink {
+	return &LogSink{}
+}
+
+// Name returns the name the sink is reported under.
+func (l *LogSink) Name() string {
+	return "log"
+}
+
+// Deliver logs the event.
+func (l *LogSink) Deliver(ctx context.Context, event domain.DomainEvent) error {
+	logging.FromContext(ctx).Debug("domain event",
+		"event_id", event.ID,
+		"type", event.Type,
+		"aggregate_id", event.AggregateID,
+		"workspace_id", event.WorkspaceID,
+		"occurred_at", event.OccurredAt,
+		"attempts", event.Attempts,
+	)
+
+	return nil
+}
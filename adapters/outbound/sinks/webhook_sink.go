@@ -0,0 +1,102 @@
+package sinks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/google/uuid"
+)
+
+// maxWebhookResponse bounds the part of a webhook response read before the
+// connection is reused.
+const maxWebhookResponse = 64 << 10
+
+// webhookEvent is the JSON body posted for a domain event. Data is the payload of
+// the event, whose fields depend on its type.
+type webhookEvent struct {
+	ID          uuid.UUID       `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID uuid.UUID       `json:"aggregate_id"`
+	WorkspaceID uuid.UUID       `json:"workspace_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// WebhookSink implements the EventSink interface by posting each event as JSON to
+// the URL of an integration. The ID of the event is sent as the Idempotency-Key
+// header, so the integration can discard the events delivered more than once, and,
+// with a secret, the body is signed in the X-Signature header as
+// "sha256=" followed by the hex HMAC-SHA256 of the body. Any response other than
+// 2xx is a failed delivery.
+type WebhookSink struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// NewWebhookSink creates a new instance of WebhookSink posting to url, signing the
+// bodies with secret unless it is empty, and giving up on a request after timeout.
+func NewWebhookSink(url string, secret string, timeout time.Duration) *WebhookSink {
+	return &WebhookSink{url: url, secret: []byte(secret), client: &http.Client{Timeout: timeout}}
+}
+
+// Name returns the name the sink is reported under.
+func (w *WebhookSink) Name() string {
+	return "webhook"
+}
+
+// Deliver posts the event to the webhook.
+func (w *WebhookSink) Deliver(ctx context.Context, event domain.DomainEvent) error {
+	body, err := json.Marshal(webhookEvent{
+		ID:          event.ID,
+		Type:        string(event.Type),
+		AggregateID: event.AggregateID,
+		WorkspaceID: event.WorkspaceID,
+		OccurredAt:  event.OccurredAt,
+		Data:        event.Payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", event.ID.String())
+	req.Header.Set("X-Event-Type", string(event.Type))
+	if len(w.secret) > 0 {
+		req.Header.Set("X-Signature", "sha256="+sign(w.secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxWebhookResponse))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+
+	return nil
+}
+
+// sign returns the hex HMAC-SHA256 of body with secret.
+func sign(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,75 @@
+package sinks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/google/uuid"
+)
+
+func TestWebhookSink(t *testing.T) {
+	event, err := domain.NewDomainEvent(uuid.New(), domain.TaskDeleted{TaskID: uuid.New(), UserID: uuid.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Deliver", func(t *testing.T) {
+		var got *http.Request
+		var body []byte
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r
+			body, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer srv.Close()
+
+		if err := NewWebhookSink(srv.URL, "secret", time.Second).Deliver(context.Background(), event); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if key := got.Header.Get("Idempotency-Key"); key != event.ID.String() {
+			t.Errorf("Expected the event ID as the idempotency key, got %q", key)
+		}
+		if signature := got.Header.Get("X-Signature"); signature != "sha256="+sign([]byte("secret"), body) {
+			t.Errorf("Expected the signature of the body, got %q", signature)
+		}
+
+		var posted webhookEvent
+		if err := json.Unmarshal(body, &posted); err != nil {
+			t.Fatalf("Expected a JSON body, got %q", body)
+		}
+		if posted.ID != event.ID || posted.Type != "TaskDeleted" || string(posted.Data) != string(event.Payload) {
+			t.Errorf("Expected the event, got %+v", posted)
+		}
+	})
+
+	t.Run("Unsigned", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Signature") != "" {
+				t.Errorf("Expected no signature without a secret, got %q", r.Header.Get("X-Signature"))
+			}
+		}))
+		defer srv.Close()
+
+		if err := NewWebhookSink(srv.URL, "", time.Second).Deliver(context.Background(), event); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer srv.Close()
+
+		if err := NewWebhookSink(srv.URL, "", time.Second).Deliver(context.Background(), event); err == nil {
+			t.Error("Expected an error for a 503 response, got nil")
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/fabianoflorentino/gotostudy/core/auth"
+	"github.com/fabianoflorentino/gotostudy/core/logging"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
+	"github.com/fabianoflorentino/gotostudy/database"
+	"github.com/fabianoflorentino/gotostudy/internal/app"
+	"github.com/fabianoflorentino/gotostudy/internal/config"
+)
+
+// cli holds the global flags shared by the commands, where they write their output
+// and how they build the application container.
+type cli struct {
+	out        io.Writer
+	configFile string
+	workspace  string
+	container  func(cfg *config.Config) (*app.AppContainer, error)
+}
+
+// parse parses args into the flags of a command. Positional arguments are rejected
+// unless the command accepts them, in which case it reads them from flags.Args.
+func parse(flags *flag.FlagSet, args []string, positional bool) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if !positional && flags.NArg() > 0 {
+		return fmt.Errorf("%s: unexpected arguments %q", flags.Name(), flags.Args())
+	}
+
+	return nil
+}
+
+// config loads the configuration from the file given by -config or CONFIG_FILE, the
+// optional .env file and the environment.
+func (c *cli) config() (*config.Config, error) {
+	return config.Load(config.Options{File: c.configFile, EnvFile: ".env"})
+}
+
+// withoutMail disables the SMTP mailer, so the commands creating users in bulk keep
+// their emails in the outbox directory instead of sending them.
+func withoutMail(cfg *config.Config) { cfg.Mail.SMTPHost = "" }
+
+// admin loads the configuration, builds the application container and runs fn with a
+// context carrying the selected workspace and the system principal, so the
+// commands go through the same services and access control policy as the HTTP API.
+// The logs are written to stderr, keeping the output of the commands on out. The
+// container is closed once fn returns. When adjust is not nil it can change the
+// configuration before the container is built.
+func (c *cli) admin(ctx context.Context, adjust func(*config.Config), fn func(ctx context.Context, container *app.AppContainer) error) (err error) {
+	cfg, err := c.config()
+	if err != nil {
+		return err
+	}
+	if adjust != nil {
+		adjust(cfg)
+	}
+
+	logger, err := logging.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+
+	container, err := c.container(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := container.Close(context.Background()); err == nil {
+			err = closeErr
+		}
+	}()
+
+	workspace, err := container.WorkspaceService.ResolveWorkspace(ctx, c.workspace)
+	if err != nil {
+		return fmt.Errorf("failed to resolve workspace %q: %w", c.workspace, err)
+	}
+
+	ctx = tenant.WithWorkspace(ctx, workspace.ID)
+	ctx = auth.WithPrincipal(ctx, auth.System(workspace.ID))
+	ctx = logging.With(ctx, "workspace_id", workspace.ID)
+
+	return fn(ctx, container)
+}
+
+// migrateCommand opens the database, which runs the migrations, and closes it.
+func migrateCommand(_ context.Context, c *cli, args []string) error {
+	if err := parse(flag.NewFlagSet("migrate", flag.ContinueOnError), args, false); err != nil {
+		return err
+	}
+
+	cfg, err := c.config()
+	if err != nil {
+		return err
+	}
+
+	db, err := database.InitDB(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	fmt.Fprintln(c.out, "database migrated")
+
+	return database.Close(db)
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/adapters/outbound/security"
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/policy"
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+	"github.com/fabianoflorentino/gotostudy/core/services"
+	"github.com/fabianoflorentino/gotostudy/internal/app"
+	"github.com/fabianoflorentino/gotostudy/internal/config"
+	"github.com/google/uuid"
+)
+
+// memoryWorkspaces holds the default workspace.
+type memoryWorkspaces struct {
+	ports.WorkspaceRepository
+	workspace *domain.Workspace
+}
+
+func (m *memoryWorkspaces) FindByID(_ context.Context, id uuid.UUID) (*domain.Workspace, error) {
+	if id != m.workspace.ID {
+		return nil, core.ErrWorkspaceNotFound
+	}
+	return m.workspace, nil
+}
+
+func (m *memoryWorkspaces) FindBySlug(_ context.Context, slug string) (*domain.Workspace, error) {
+	if slug != m.workspace.Slug {
+		return nil, core.ErrWorkspaceNotFound
+	}
+	return m.workspace, nil
+}
+
+// memoryUsers holds the users of the workspace in the order they were saved.
+type memoryUsers struct {
+	ports.UserRepository
+	users []*domain.User
+}
+
+func (m *memoryUsers) FindAll(context.Context) ([]*domain.User, error) {
+	return slices.Clone(m.users), nil
+}
+
+func (m *memoryUsers) FindByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	for _, user := range m.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return nil, core.ErrUserNotFound
+}
+
+func (m *memoryUsers) FindByEmail(_ context.Context, email string) (*domain.User, error) {
+	for _, user := range m.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, core.ErrUserNotFound
+}
+
+func (m *memoryUsers) FindByEmailInAnyWorkspace(ctx context.Context, email string) (*domain.User, error) {
+	return m.FindByEmail(ctx, email)
+}
+
+func (m *memoryUsers) Save(_ context.Context, user *domain.User) error {
+	m.users = append(m.users, user)
+	return nil
+}
+
+func (m *memoryUsers) UpdateRole(ctx context.Context, id uuid.UUID, role domain.UserRole) error {
+	user, err := m.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	user.Role = role
+	return nil
+}
+
+func (m *memoryUsers) Delete(_ context.Context, id uuid.UUID) error {
+	n := len(m.users)
+	m.users = slices.DeleteFunc(m.users, func(user *domain.User) bool { return user.ID == id })
+	if len(m.users) == n {
+		return core.ErrUserNotFound
+	}
+	return nil
+}
+
+// memoryTasks holds the tasks of the users.
+type memoryTasks struct {
+	ports.TaskRepository
+	tasks []*domain.Task
+}
+
+func (m *memoryTasks) Save(_ context.Context, _ uuid.UUID, task *domain.Task) error {
+	m.tasks = append(m.tasks, task)
+	return nil
+}
+
+func (m *memoryTasks) FindUserTasks(_ context.Context, userID uuid.UUID) ([]*domain.Task, error) {
+	var tasks []*domain.Task
+	for _, task := range m.tasks {
+		if task.UserID == userID {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, nil
+}
+
+// recordingMailer records the addresses of the sent emails.
+type recordingMailer struct {
+	to []string
+}
+
+func (m *recordingMailer) Send(_ context.Context, mail ports.Mail) error {
+	m.to = append(m.to, mail.To)
+	return nil
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) UserRegistered(context.Context, string) {}
+func (noopMetrics) TaskCreated(context.Context)            {}
+func (noopMetrics) TaskCompleted(context.Context)          {}
+
+type inlineTransactor struct{}
+
+func (inlineTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type discardOutbox struct {
+	ports.Outbox
+}
+
+func (discardOutbox) Append(context.Context, ...domain.DomainEvent) error { return nil }
+
+// testApp builds the containers of the commands from the services of the
+// application, with the default access policy, on top of repositories in memory.
+type testApp struct {
+	users   *memoryUsers
+	tasks   *memoryTasks
+	mailer  *recordingMailer
+	configs []*config.Config
+}
+
+func newTestApp() *testApp {
+	return &testApp{users: &memoryUsers{}, tasks: &memoryTasks{}, mailer: &recordingMailer{}}
+}
+
+func (a *testApp) container(cfg *config.Config) (*app.AppContainer, error) {
+	a.configs = append(a.configs, cfg)
+
+	authz, err := policy.Default()
+	if err != nil {
+		return nil, err
+	}
+
+	workspaces := &memoryWorkspaces{workspace: &domain.Workspace{ID: uuid.New(), Slug: domain.DefaultWorkspaceSlug}}
+	tokens := security.NewJWTIssuer([]byte("secret"), time.Minute, time.Hour)
+	infra := services.Infrastructure{Metrics: noopMetrics{}, Events: services.NewEventBus(1, 1), Tx: inlineTransactor{}, Outbox: discardOutbox{}}
+
+	return &app.AppContainer{
+		UserService:      services.NewUserService(a.users, security.NewBcryptHasher(4), authz, a.mailer, tokens, services.AccountLinks{}, infra),
+		TaskService:      services.NewTaskService(a.tasks, a.users, authz, infra),
+		WorkspaceService: services.NewWorkspaceService(workspaces, a.users),
+	}, nil
+}
+
+func TestCommands(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://localhost/gotostudy")
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv(passwordEnv, "")
+
+	testApp := newTestApp()
+	exec := func(t *testing.T, args ...string) string {
+		t.Helper()
+
+		var out bytes.Buffer
+		c := &cli{out: &out, container: testApp.container}
+		name := args[0]
+		if name == "user" || name == "task" {
+			name, args = name+" "+args[1], args[1:]
+		}
+		if err := commands[name](context.Background(), c, args[1:]); err != nil {
+			t.Fatalf("%s: %v", strings.Join(args, " "), err)
+		}
+		return out.String()
+	}
+
+	t.Run("user create", func(t *testing.T) {
+		out := exec(t, "user", "create", "-username", "ana", "-email", "ana@example.com", "-password", "secret123", "-admin")
+
+		if len(testApp.users.users) != 1 || strings.TrimSpace(out) != testApp.users.users[0].ID.String() {
+			t.Errorf("output = %q, want the ID of the created user", out)
+		}
+		if role := testApp.users.users[0].Role; role != domain.UserRoleAdmin {
+			t.Errorf("role = %q, want admin", role)
+		}
+	})
+
+	t.Run("seed", func(t *testing.T) {
+		exec(t, "seed", "-users", "2", "-tasks", "3")
+
+		if len(testApp.users.users) != 3 || len(testApp.tasks.tasks) != 6 {
+			t.Errorf("got %d users and %d tasks, want 3 and 6", len(testApp.users.users), len(testApp.tasks.tasks))
+		}
+		if host := testApp.configs[len(testApp.configs)-1].Mail.SMTPHost; host != "" {
+			t.Errorf("SMTP host = %q, want the mailer disabled", host)
+		}
+	})
+
+	t.Run("user list", func(t *testing.T) {
+		out := exec(t, "user", "list")
+
+		if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 4 || !strings.Contains(lines[1], "ana@example.com") {
+			t.Errorf("output = %q, want the 3 users", out)
+		}
+	})
+
+	t.Run("task list", func(t *testing.T) {
+		out := exec(t, "task", "list", "-user", testApp.users.users[1].ID.String())
+
+		if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 4 {
+			t.Errorf("output = %q, want the 3 tasks of the user", out)
+		}
+	})
+
+	file := filepath.Join(t.TempDir(), "export.json")
+	t.Run("export", func(t *testing.T) {
+		exec(t, "export", "-file", file)
+
+		export, err := readExport(file)
+		if err != nil || len(export.Users) != 3 || len(export.Users[1].Tasks) != 3 {
+			t.Errorf("export = %+v, %v, want the 3 users with their tasks", export, err)
+		}
+	})
+
+	t.Run("user delete", func(t *testing.T) {
+		ids := make([]string, 0, len(testApp.users.users))
+		for _, user := range testApp.users.users {
+			ids = append(ids, user.ID.String())
+		}
+		exec(t, append([]string{"user", "delete"}, ids...)...)
+
+		if len(testApp.users.users) != 0 {
+			t.Errorf("%d users left, want none", len(testApp.users.users))
+		}
+	})
+
+	t.Run("import", func(t *testing.T) {
+		out := exec(t, "import", "-file", file)
+
+		if !strings.Contains(out, "imported 3 users and 6 tasks, skipped 0 users") {
+			t.Errorf("output = %q, want the 3 users and 6 tasks imported", out)
+		}
+		if host := testApp.configs[len(testApp.configs)-1].Mail.SMTPHost; host != "" {
+			t.Errorf("SMTP host = %q, want the mailer disabled", host)
+		}
+	})
+
+	t.Run("import -send-emails", func(t *testing.T) {
+		out := exec(t, "import", "-file", file, "-send-emails")
+
+		if !strings.Contains(out, "skipped 3 users") {
+			t.Errorf("output = %q, want the 3 users skipped", out)
+		}
+		if host := testApp.configs[len(testApp.configs)-1].Mail.SMTPHost; host != "smtp.example.com" {
+			t.Errorf("SMTP host = %q, want smtp.example.com", host)
+		}
+	})
+}
@@ -1,29 +1,176 @@
 // File: main.go
 // Description: This is the main entry point for the GoToStudy application.
-// It initializes the application by loading environment variables, setting up the database,
-// and configuring the HTTP server with routes.
+// It loads and validates the configuration, sets up the database,
+// and either serves the HTTP API or runs one of the administration commands.
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/fabianoflorentino/gotostudy/core/logging"
 	"github.com/fabianoflorentino/gotostudy/internal/app"
+	"github.com/fabianoflorentino/gotostudy/internal/config"
 	"github.com/fabianoflorentino/gotostudy/internal/server"
-	"github.com/joho/godotenv"
+	"github.com/fabianoflorentino/gotostudy/internal/telemetry"
 )
 
-// init initializes the application by loading environment variables and initializing the database.
-// It is called before the main function.
-func init() {
-	if err := godotenv.Load(); err != nil {
-		log.Fatalf("Error loading .env file")
-	}
-}
+const usage = `Usage: gotostudy [-config file] [-workspace ref] [command]
+
+Commands:
+  serve                                  start the HTTP server (default)
+  migrate                                run the database migrations and exit
+  config                                 print the effective configuration, with secrets redacted
+  user create -username U -email E       register a user, reading the password from -password or GOTOSTUDY_PASSWORD
+  user list                              list the users of the workspace
+  user delete ID...                      delete users from the workspace
+  task list -user ID                     list the tasks of a user
+  seed [-users N] [-tasks M]             create N users with M tasks each, for development
+  export [-file path]                    write the users and tasks of the workspace as JSON
+  import [-file path] [-send-emails]     create the users and tasks of an export in the workspace
+
+The administration commands run as an administrator of the workspace selected by
+-workspace, its ID or slug, or of the default workspace. Users only become
+administrators through user create -admin.
+
+Flags:
+`
 
 // main is the entry point of the application.
-// It sets up the Gin router, configures trusted proxies, and initializes routes.
-// Finally, it starts the HTTP server.
+// It runs the command given on the command line until it finishes or, for serve,
+// until SIGINT or SIGTERM, exiting with a non-zero status when it fails.
 func main() {
-	container := app.NewAppContainer()
-	server.StartHTTPServer(container)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		logging.Fatal("gotostudy failed", "error", err)
+	}
+}
+
+// run parses the global flags and dispatches to the command named by the first
+// argument. The commands parse their own flags, so invalid arguments are reported
+// before the configuration is loaded or the database opened. The output of the
+// commands is written to out.
+func run(ctx context.Context, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("gotostudy", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	configFile := flags.String("config", "", "path of the YAML configuration file")
+	workspace := flags.String("workspace", "", "ID or slug of the workspace managed by the administration commands")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	c := &cli{out: out, configFile: *configFile, workspace: *workspace, container: app.NewAppContainer}
+
+	name, rest := flags.Arg(0), flags.Args()
+	if len(rest) > 0 {
+		rest = rest[1:]
+	}
+	if (name == "user" || name == "task") && len(rest) > 0 {
+		name, rest = name+" "+rest[0], rest[1:]
+	}
+
+	command, ok := commands[name]
+	if !ok {
+		flags.Usage()
+		return fmt.Errorf("unknown command %q", name)
+	}
+
+	return command(ctx, c, rest)
+}
+
+// commands maps the name of each command, including the subcommand of the user and
+// task commands, to the function running it.
+var commands = map[string]func(ctx context.Context, c *cli, args []string) error{
+	"":            serveCommand,
+	"serve":       serveCommand,
+	"migrate":     migrateCommand,
+	"config":      configCommand,
+	"user create": userCreateCommand,
+	"user list":   userListCommand,
+	"user delete": userDeleteCommand,
+	"task list":   taskListCommand,
+	"seed":        seedCommand,
+	"export":      exportCommand,
+	"import":      importCommand,
+}
+
+// serveCommand serves HTTP until ctx is cancelled.
+func serveCommand(ctx context.Context, c *cli, args []string) error {
+	if err := parse(flag.NewFlagSet("serve", flag.ContinueOnError), args, false); err != nil {
+		return err
+	}
+
+	cfg, err := c.config()
+	if err != nil {
+		return err
+	}
+
+	return serve(ctx, cfg)
+}
+
+// configCommand prints the effective configuration.
+func configCommand(_ context.Context, c *cli, args []string) error {
+	if err := parse(flag.NewFlagSet("config", flag.ContinueOnError), args, false); err != nil {
+		return err
+	}
+
+	cfg, err := c.config()
+	if err != nil {
+		return err
+	}
+
+	return cfg.WriteYAML(c.out)
+}
+
+// serve sets up the logger, the trace exporter, the application container and its
+// background workers, then serves HTTP until ctx is cancelled. It then drains the
+// in-flight requests, stops the background workers, closes the database connection
+// pool and flushes the pending traces.
+func serve(ctx context.Context, cfg *config.Config) (err error) {
+	logger, err := logging.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+
+	shutdownTracing, err := telemetry.SetupTracing(ctx, telemetry.TracingConfig{
+		Exporter:    cfg.Tracing.Exporter,
+		File:        cfg.Tracing.File,
+		ServiceName: cfg.Tracing.ServiceName,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, shutdownTracing(context.Background()))
+	}()
+
+	container, err := app.NewAppContainer(cfg)
+	if err != nil {
+		return err
+	}
+	container.StartWorkers()
+
+	serveErr := server.StartHTTPServer(ctx, container, cfg.Server)
+
+	closeCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	return errors.Join(serveErr, container.Close(closeCtx))
 }
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunRejectsInvalidArguments(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"unknown command", []string{"frobnicate"}, `unknown command "frobnicate"`},
+		{"unknown subcommand", []string{"user", "rename"}, `unknown command "user rename"`},
+		{"missing subcommand", []string{"task"}, `unknown command "task"`},
+		{"unexpected arguments", []string{"migrate", "now"}, `migrate: unexpected arguments ["now"]`},
+		{"user create without email", []string{"user", "create", "-username", "ana", "-password", "secret123"}, "-username, -email and -password"},
+		{"user delete without IDs", []string{"user", "delete"}, "at least one user ID is required"},
+		{"user delete with invalid ID", []string{"user", "delete", "42"}, `invalid user ID "42"`},
+		{"task list without user", []string{"task", "list"}, "-user must be a valid user ID"},
+		{"seed without users", []string{"seed", "-users", "0"}, "-users must be positive"},
+		{"seed with negative tasks", []string{"seed", "-tasks", "-1"}, "-tasks cannot be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(passwordEnv, "")
+
+			var out bytes.Buffer
+			err := run(context.Background(), tt.args, &out)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("run(%q) = %v, want an error containing %q", tt.args, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadExport(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "export.json")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	t.Run("valid export", func(t *testing.T) {
+		path := write(t, `{
+			"version": 1,
+			"exported_at": "2026-10-18T12:00:00Z",
+			"users": [{
+				"id": "0b5e2a57-8a4b-4f6e-9c36-1f0b6c7d8e9f",
+				"username": "ana",
+				"email": "ana@example.com",
+				"role": "user",
+				"password": "secret123",
+				"created_at": "2026-10-01T08:00:00Z",
+				"tasks": [{"id": "5f0c1a2b-3c4d-4e5f-8a9b-0c1d2e3f4a5b", "title": "Read", "description": "", "completed": true, "created_at": "2026-10-02T08:00:00Z"}]
+			}]
+		}`)
+
+		export, err := readExport(path)
+		if err != nil {
+			t.Fatalf("readExport() error = %v", err)
+		}
+
+		if len(export.Users) != 1 || export.Users[0].Password != "secret123" {
+			t.Fatalf("users = %+v, want ana with the password", export.Users)
+		}
+		if tasks := export.Users[0].Tasks; len(tasks) != 1 || tasks[0].Title != "Read" || !tasks[0].Completed {
+			t.Errorf("tasks = %+v, want the completed task Read", tasks)
+		}
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		_, err := readExport(write(t, `{"version": 2, "users": []}`))
+		if err == nil || !strings.Contains(err.Error(), "unsupported export version 2") {
+			t.Errorf("readExport() error = %v, want unsupported version", err)
+		}
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := readExport(write(t, `{"version": 1, "users": [], "workspaces": []}`))
+		if err == nil || !strings.Contains(err.Error(), "invalid export") {
+			t.Errorf("readExport() error = %v, want invalid export", err)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/internal/app"
+)
+
+// seedCommand fills the workspace with users and tasks for development. Every run
+// uses its own random prefix for the names and addresses, so it can be run again on
+// the same database. The seeded users get random passwords; their verification emails
+// are kept in the outbox instead of being sent.
+func seedCommand(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	users := flags.Int("users", 10, "number of users to create")
+	tasks := flags.Int("tasks", 5, "number of tasks to create for each user")
+
+	if err := parse(flags, args, false); err != nil {
+		return err
+	}
+
+	if *users < 1 || *tasks < 0 {
+		return errors.New("seed: -users must be positive and -tasks cannot be negative")
+	}
+
+	b := make([]byte, 3)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	prefix := "seed-" + hex.EncodeToString(b)
+
+	return c.admin(ctx, withoutMail, func(ctx context.Context, container *app.AppContainer) error {
+		for i := 1; i <= *users; i++ {
+			password, err := randomPassword()
+			if err != nil {
+				return err
+			}
+
+			user, err := container.UserService.RegisterUser(ctx, &domain.User{
+				Username: fmt.Sprintf("%s-%03d", prefix, i),
+				Email:    fmt.Sprintf("%s-%03d@example.com", prefix, i),
+			}, password)
+			if err != nil {
+				return fmt.Errorf("failed to create user %d: %w", i, err)
+			}
+
+			for j := 1; j <= *tasks; j++ {
+				task := &domain.Task{
+					Title:       fmt.Sprintf("Task %d of %s", j, user.Username),
+					Description: "Created by gotostudy seed",
+					Completed:   j%3 == 0,
+				}
+				if _, err := container.TaskService.CreateTask(ctx, user.ID, task); err != nil {
+					return fmt.Errorf("failed to create task %d of user %s: %w", j, user.ID, err)
+				}
+			}
+		}
+
+		fmt.Fprintf(c.out, "created %d users with %d tasks each, named %s-*\n", *users, *tasks, prefix)
+
+		return nil
+	})
+}
+
+// randomPassword returns a password for the users created without one. They choose
+// their own password with the password reset.
+func randomPassword() (string, error) {
+	b := make([]byte, 18)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
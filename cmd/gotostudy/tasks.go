@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/internal/app"
+	"github.com/google/uuid"
+)
+
+// taskListCommand prints the tasks owned by a user of the workspace as a table.
+func taskListCommand(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("task list", flag.ContinueOnError)
+	user := flags.String("user", "", "ID of the user owning the tasks")
+
+	if err := parse(flags, args, false); err != nil {
+		return err
+	}
+
+	userID, err := uuid.Parse(*user)
+	if err != nil {
+		return errors.New("task list: -user must be a valid user ID")
+	}
+
+	return c.admin(ctx, nil, func(ctx context.Context, container *app.AppContainer) error {
+		tasks, err := container.TaskService.FindUserTasks(ctx, userID)
+		if err != nil && !errors.Is(err, core.ErrNoTasksFound) {
+			return err
+		}
+
+		w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tTITLE\tCOMPLETED\tCREATED")
+		for _, task := range tasks {
+			fmt.Fprintf(w, "%s\t%s\t%t\t%s\n", task.ID, task.Title, task.Completed, task.CreatedAt.Format(time.RFC3339))
+		}
+
+		return w.Flush()
+	})
+}
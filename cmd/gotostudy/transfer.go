@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/logging"
+	"github.com/fabianoflorentino/gotostudy/internal/app"
+	"github.com/google/uuid"
+)
+
+// exportVersion is the version of the format written by export. import rejects the
+// files of other versions.
+const exportVersion = 1
+
+// exportFile is the JSON document written by export and read by import.
+type exportFile struct {
+	Version    int          `json:"version"`
+	ExportedAt time.Time    `json:"exported_at"`
+	Users      []exportUser `json:"users"`
+}
+
+// exportUser is a user of the workspace with the tasks it owns. The password hash is
+// never exported; Password can be filled in before importing, otherwise the imported
+// user gets a random password and chooses one with the password reset.
+type exportUser struct {
+	ID              uuid.UUID       `json:"id"`
+	Username        string          `json:"username"`
+	Email           string          `json:"email"`
+	Role            domain.UserRole `json:"role"`
+	Password        string          `json:"password,omitempty"`
+	EmailVerifiedAt *time.Time      `json:"email_verified_at,omitempty"`
+	CreatedAt       time.Time       `json:"created_at"`
+	Tasks           []exportTask    `json:"tasks"`
+}
+
+// exportTask is a task owned by an exported user.
+type exportTask struct {
+	ID          uuid.UUID `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Completed   bool      `json:"completed"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// exportCommand writes the users of the workspace and the tasks they own as JSON, to
+// the file given by -file or to the standard output.
+func exportCommand(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	file := flags.String("file", "", "path of the file to write, defaults to the standard output")
+
+	if err := parse(flags, args, false); err != nil {
+		return err
+	}
+
+	return c.admin(ctx, nil, func(ctx context.Context, container *app.AppContainer) error {
+		users, err := container.UserService.GetAllUsers(ctx)
+		if err != nil {
+			return err
+		}
+
+		export := exportFile{Version: exportVersion, ExportedAt: time.Now().UTC(), Users: []exportUser{}}
+		for _, user := range users {
+			tasks, err := container.TaskService.FindUserTasks(ctx, user.ID)
+			if err != nil && !errors.Is(err, core.ErrNoTasksFound) {
+				return fmt.Errorf("failed to export the tasks of user %s: %w", user.ID, err)
+			}
+
+			exported := exportUser{
+				ID:              user.ID,
+				Username:        user.Username,
+				Email:           user.Email,
+				Role:            user.Role,
+				EmailVerifiedAt: user.EmailVerifiedAt,
+				CreatedAt:       user.CreatedAt,
+				Tasks:           make([]exportTask, 0, len(tasks)),
+			}
+			for _, task := range tasks {
+				exported.Tasks = append(exported.Tasks, exportTask{
+					ID:          task.ID,
+					Title:       task.Title,
+					Description: task.Description,
+					Completed:   task.Completed,
+					CreatedAt:   task.CreatedAt,
+				})
+			}
+			export.Users = append(export.Users, exported)
+		}
+
+		out := c.out
+		if *file != "" {
+			f, err := os.Create(*file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(export); err != nil {
+			return err
+		}
+
+		if *file != "" {
+			fmt.Fprintf(c.out, "exported %d users to %s\n", len(export.Users), *file)
+		}
+
+		return nil
+	})
+}
+
+// importCommand creates the users and tasks of an export, read from the file given by
+// -file or from the standard input, in the workspace. They get new IDs. The users are
+// registered like users signing up: they get the regular user role and must confirm
+// their email address; the verification emails are only sent with -send-emails. Users
+// whose email address is already in use are skipped with their tasks.
+func importCommand(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	file := flags.String("file", "", "path of the file to read, defaults to the standard input")
+	sendEmails := flags.Bool("send-emails", false, "send the verification emails to the imported users")
+
+	if err := parse(flags, args, false); err != nil {
+		return err
+	}
+
+	export, err := readExport(*file)
+	if err != nil {
+		return err
+	}
+
+	adjust := withoutMail
+	if *sendEmails {
+		adjust = nil
+	}
+
+	return c.admin(ctx, adjust, func(ctx context.Context, container *app.AppContainer) error {
+		var users, tasks, skipped int
+		for _, exported := range export.Users {
+			password := exported.Password
+			if password == "" {
+				if password, err = randomPassword(); err != nil {
+					return err
+				}
+			}
+
+			user, err := container.UserService.RegisterUser(ctx, &domain.User{Username: exported.Username, Email: exported.Email}, password)
+			if errors.Is(err, core.ErrEmailAlreadyExists) {
+				logging.FromContext(ctx).Warn("skipping user whose email is already in use", "email", exported.Email)
+				skipped++
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to import user %s: %w", exported.Email, err)
+			}
+			users++
+
+			if exported.Role == domain.UserRoleAdmin {
+				logging.FromContext(ctx).Warn("imported administrator as regular user", "user_id", user.ID, "email", user.Email)
+			}
+
+			for _, t := range exported.Tasks {
+				task := &domain.Task{Title: t.Title, Description: t.Description, Completed: t.Completed}
+				if _, err := container.TaskService.CreateTask(ctx, user.ID, task); err != nil {
+					return fmt.Errorf("failed to import task %s of user %s: %w", t.ID, exported.Email, err)
+				}
+				tasks++
+			}
+		}
+
+		fmt.Fprintf(c.out, "imported %d users and %d tasks, skipped %d users\n", users, tasks, skipped)
+
+		return nil
+	})
+}
+
+// readExport decodes the export in the file at path, or the standard input when path
+// is empty, and checks its version.
+func readExport(path string) (*exportFile, error) {
+	var in io.Reader = os.Stdin
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var export exportFile
+	dec := json.NewDecoder(in)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&export); err != nil {
+		return nil, fmt.Errorf("import: invalid export: %w", err)
+	}
+
+	if export.Version != exportVersion {
+		return nil, fmt.Errorf("import: unsupported export version %d, expected %d", export.Version, exportVersion)
+	}
+
+	return &export, nil
+}
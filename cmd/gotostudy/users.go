@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/internal/app"
+	"github.com/google/uuid"
+)
+
+// passwordEnv is the environment variable read by user create when -password is not
+// given, keeping the password out of the process list and the shell history.
+const passwordEnv = "GOTOSTUDY_PASSWORD"
+
+// userCreateCommand registers a user in the workspace and prints its ID. As for
+// users signing up, the link confirming the email address is sent to the user. With
+// -admin the user is made an administrator, which bootstraps the first one.
+func userCreateCommand(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("user create", flag.ContinueOnError)
+	username := flags.String("username", "", "name of the user")
+	email := flags.String("email", "", "email address of the user")
+	password := flags.String("password", "", "password of the user, defaults to $"+passwordEnv)
+	admin := flags.Bool("admin", false, "grant the administrator role to the user")
+
+	if err := parse(flags, args, false); err != nil {
+		return err
+	}
+
+	if *password == "" {
+		*password = os.Getenv(passwordEnv)
+	}
+	if *username == "" || *email == "" || *password == "" {
+		return fmt.Errorf("user create: -username, -email and -password or %s are required", passwordEnv)
+	}
+
+	return c.admin(ctx, nil, func(ctx context.Context, container *app.AppContainer) error {
+		user, err := container.UserService.RegisterUser(ctx, &domain.User{Username: *username, Email: *email}, *password)
+		if err != nil {
+			return err
+		}
+
+		if *admin {
+			if err := container.UserService.PromoteToAdmin(ctx, user.ID); err != nil {
+				return err
+			}
+		}
+
+		fmt.Fprintln(c.out, user.ID)
+
+		return nil
+	})
+}
+
+// userListCommand prints the users of the workspace as a table.
+func userListCommand(ctx context.Context, c *cli, args []string) error {
+	if err := parse(flag.NewFlagSet("user list", flag.ContinueOnError), args, false); err != nil {
+		return err
+	}
+
+	return c.admin(ctx, nil, func(ctx context.Context, container *app.AppContainer) error {
+		users, err := container.UserService.GetAllUsers(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tUSERNAME\tEMAIL\tROLE\tVERIFIED\tCREATED")
+		for _, user := range users {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\t%s\n",
+				user.ID, user.Username, user.Email, user.Role, user.EmailVerified(), user.CreatedAt.Format(time.RFC3339))
+		}
+
+		return w.Flush()
+	})
+}
+
+// userDeleteCommand deletes the users given by ID from the workspace. It goes on with
+// the remaining users when one fails, and returns the errors joined.
+func userDeleteCommand(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("user delete", flag.ContinueOnError)
+	if err := parse(flags, args, true); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		return errors.New("user delete: at least one user ID is required")
+	}
+
+	ids := make([]uuid.UUID, 0, flags.NArg())
+	for _, arg := range flags.Args() {
+		id, err := uuid.Parse(arg)
+		if err != nil {
+			return fmt.Errorf("user delete: invalid user ID %q", arg)
+		}
+		ids = append(ids, id)
+	}
+
+	return c.admin(ctx, nil, func(ctx context.Context, container *app.AppContainer) error {
+		var errs []error
+		for _, id := range ids {
+			if err := container.UserService.DeleteUser(ctx, id); err != nil {
+				errs = append(errs, fmt.Errorf("user %s: %w", id, err))
+				continue
+			}
+			fmt.Fprintf(c.out, "deleted user %s\n", id)
+		}
+
+		return errors.Join(errs...)
+	})
+}
@@ -0,0 +1,128 @@
+// Package auth carries the authenticated principal of the current request
+// through a context.Context and defines the tokens handed out on login.
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/google/uuid"
+)
+
+// TokenKind distinguishes short lived access tokens from the refresh tokens
+// used to obtain new access tokens.
+type TokenKind string
+
+const (
+	TokenAccess  TokenKind = "access"
+	TokenRefresh TokenKind = "refresh"
+
+	// TokenVerifyEmail and TokenPasswordReset are the kinds of the action tokens
+	// of the links sent by email.
+	TokenVerifyEmail   TokenKind = "verify_email"
+	TokenPasswordReset TokenKind = "password_reset"
+	// TokenTwoFactor is the kind of the action token of a single sign-on waiting
+	// for the second factor of the user.
+	TokenTwoFactor TokenKind = "two_factor"
+)
+
+// Principal identifies the authenticated user of a request and the workspace
+// its credentials were issued for. Requests authenticated with an API key carry
+// the ID of the key and are limited to its Scopes.
+type Principal struct {
+	UserID      uuid.UUID
+	WorkspaceID uuid.UUID
+	Role        domain.UserRole
+	APIKeyID    uuid.UUID
+	Scopes      []domain.APIScope
+}
+
+// SystemUserID is the subject of the System principal. No user has this ID.
+var SystemUserID = uuid.Max
+
+// System returns the principal of the administrative tasks run outside of a request,
+// such as the commands of the CLI. It acts as an administrator of workspaceID.
+func System(workspaceID uuid.UUID) Principal {
+	return Principal{UserID: SystemUserID, WorkspaceID: workspaceID, Role: domain.UserRoleAdmin}
+}
+
+// IsAdmin reports whether the principal has the administrator role.
+func (p Principal) IsAdmin() bool {
+	return p.Role == domain.UserRoleAdmin
+}
+
+// IsAPIKey reports whether the principal was authenticated with an API key.
+func (p Principal) IsAPIKey() bool {
+	return p.APIKeyID != uuid.Nil
+}
+
+// Allows reports whether the principal may perform an operation requiring scope.
+// Interactive logins are not limited by scopes.
+func (p Principal) Allows(scope domain.APIScope) bool {
+	if !p.IsAPIKey() {
+		return true
+	}
+
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenPair holds the signed tokens returned by a successful login.
+type TokenPair struct {
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresIn        int64     `json:"expires_in"`
+	AccessExpiresAt  time.Time `json:"-"`
+	RefreshExpiresAt time.Time `json:"-"`
+}
+
+// IsAction reports whether the kind is one of the action tokens, sent by email or
+// handed out to complete a single sign-on.
+func (k TokenKind) IsAction() bool {
+	return k == TokenVerifyEmail || k == TokenPasswordReset || k == TokenTwoFactor
+}
+
+// ActionToken is the content of the signed token of a link sent by email or of a
+// two-factor challenge, allowing a single action on the account of a user until it
+// expires. Fingerprint binds the
+// token to the state of the account it was issued for, such as the password hash,
+// so the token stops working once the action was performed.
+type ActionToken struct {
+	Kind        TokenKind
+	UserID      uuid.UUID
+	WorkspaceID uuid.UUID
+	Fingerprint string
+	ExpiresAt   time.Time
+}
+
+// TOTPEnrollment holds what a user needs to register a new second factor in an
+// authenticator app: the base32 secret and the equivalent otpauth:// URI.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx that carries the given principal.
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the principal carried by ctx.
+// It returns core.ErrUnauthenticated when the request was not authenticated.
+func PrincipalFrom(ctx context.Context) (Principal, error) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	if !ok || principal.UserID == uuid.Nil {
+		return Principal{}, core.ErrUnauthenticated
+	}
+
+	return principal, nil
+}
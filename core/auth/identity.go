@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Identity is the identity of a user asserted by an external identity provider.
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+}
+
+// LoginFlow holds the secrets of a single sign-on login between the redirect to
+// the identity provider and its callback: the State echoed by the provider, the
+// Nonce bound to the ID token, the PKCE CodeVerifier and the workspace to sign in to.
+type LoginFlow struct {
+	URL          string    `json:"-"`
+	State        string    `json:"state"`
+	Nonce        string    `json:"nonce"`
+	CodeVerifier string    `json:"code_verifier"`
+	WorkspaceID  uuid.UUID `json:"workspace_id"`
+}
+
+// TwoFactorChallenge is handed out instead of tokens to a user with two-factor
+// authentication signing in through the identity provider. The signed Token is
+// exchanged for the tokens together with a TOTP or recovery code until ExpiresAt.
+type TwoFactorChallenge struct {
+	Token     string
+	ExpiresAt time.Time
+}
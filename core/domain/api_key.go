@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIScope restricts what a request authenticated with an API key can do.
+type APIScope string
+
+const (
+	ScopeTasksRead  APIScope = "tasks:read"
+	ScopeTasksWrite APIScope = "tasks:write"
+	ScopeUsersAdmin APIScope = "users:admin"
+)
+
+// IsValid reports whether the scope is one of the known scopes.
+func (s APIScope) IsValid() bool {
+	return s == ScopeTasksRead || s == ScopeTasksWrite || s == ScopeUsersAdmin
+}
+
+// APIKey is a long-lived credential used by scripts and CI to act on behalf of
+// a user in a workspace. Only the Prefix, shown to identify the key, and the
+// hash of its secret are stored; the secret itself is handed out once.
+type APIKey struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	WorkspaceID uuid.UUID
+	Name        string
+	Prefix      string
+	SecretHash  string `json:"-"`
+	Scopes      []APIScope
+	LastUsedAt  *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// HasScope reports whether the key was granted the scope.
+func (k *APIKey) HasScope(scope APIScope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DomainEventType names the business fact a DomainEvent records.
+type DomainEventType string
+
+// The types of the domain events, each one with the payload of the same name.
+const (
+	DomainEventUserRegistered DomainEventType = "UserRegistered"
+	DomainEventTaskCreated    DomainEventType = "TaskCreated"
+	DomainEventTaskCompleted  DomainEventType = "TaskCompleted"
+	DomainEventTaskDeleted    DomainEventType = "TaskDeleted"
+)
+
+// DomainEvent is a business fact recorded with the state change it describes and
+// relayed to the integrations. ID identifies the event across its deliveries, which
+// happen at least once, so a sink can discard the duplicates. AggregateID is the
+// user or the task the fact is about, and Payload the JSON of its DomainEventData.
+// Attempts counts the failed deliveries of the event so far.
+type DomainEvent struct {
+	ID          uuid.UUID
+	Type        DomainEventType
+	AggregateID uuid.UUID
+	WorkspaceID uuid.UUID
+	OccurredAt  time.Time
+	Payload     json.RawMessage
+	Attempts    int
+}
+
+// DomainEventData is the payload of a domain event: UserRegistered, TaskCreated,
+// TaskCompleted or TaskDeleted.
+type DomainEventData interface {
+	domainEventType() DomainEventType
+	aggregateID() uuid.UUID
+}
+
+// NewDomainEvent returns a new domain event of the workspace with the payload data,
+// occurring now.
+func NewDomainEvent(workspaceID uuid.UUID, data DomainEventData) (DomainEvent, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return DomainEvent{}, err
+	}
+
+	return DomainEvent{
+		ID:          uuid.New(),
+		Type:        data.domainEventType(),
+		AggregateID: data.aggregateID(),
+		WorkspaceID: workspaceID,
+		OccurredAt:  time.Now().UTC(),
+		Payload:     payload,
+	}, nil
+}
+
+// UserRegistered records a user signing up, with a password or through single
+// sign-on as told by Method.
+type UserRegistered struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Username string    `json:"username"`
+	Email    string    `json:"email"`
+	Method   string    `json:"method"`
+}
+
+// TaskCreated records a task created by its owner.
+type TaskCreated struct {
+	TaskID      uuid.UUID `json:"task_id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Completed   bool      `json:"completed"`
+}
+
+// TaskCompleted records a task marked as completed, by its owner or an editor as
+// told by CompletedBy. A task created completed is recorded as completed too.
+type TaskCompleted struct {
+	TaskID      uuid.UUID `json:"task_id"`
+	UserID      uuid.UUID `json:"user_id"`
+	CompletedBy uuid.UUID `json:"completed_by"`
+}
+
+// TaskDeleted records a task deleted by its owner.
+type TaskDeleted struct {
+	TaskID uuid.UUID `json:"task_id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (e UserRegistered) domainEventType() DomainEventType { return DomainEventUserRegistered }
+func (e UserRegistered) aggregateID() uuid.UUID           { return e.UserID }
+
+func (e TaskCreated) domainEventType() DomainEventType { return DomainEventTaskCreated }
+func (e TaskCreated) aggregateID() uuid.UUID           { return e.TaskID }
+
+func (e TaskCompleted) domainEventType() DomainEventType { return DomainEventTaskCompleted }
+func (e TaskCompleted) aggregateID() uuid.UUID           { return e.TaskID }
+
+func (e TaskDeleted) domainEventType() DomainEventType { return DomainEventTaskDeleted }
+func (e TaskDeleted) aggregateID() uuid.UUID           { return e.TaskID }
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventType identifies the change an Event notifies.
+type EventType string
+
+// The types of the events. The task events carry the task, the user events the
+// user. A task shared or unshared is notified to the collaborator only.
+const (
+	EventTaskCreated  EventType = "task.created"
+	EventTaskUpdated  EventType = "task.updated"
+	EventTaskDeleted  EventType = "task.deleted"
+	EventTaskShared   EventType = "task.shared"
+	EventTaskUnshared EventType = "task.unshared"
+	EventUserUpdated  EventType = "user.updated"
+	EventUserDeleted  EventType = "user.deleted"
+)
+
+// Event notifies the users in Recipients of a change of a task or a user of the
+// workspace WorkspaceID, so their clients can follow it without polling. ID is
+// assigned when the event is published and grows with every event, so a client
+// can resume after the last event it received. Task is the task after the change,
+// or as it was before its deletion; User the user after the change.
+type Event struct {
+	ID          uint64
+	Type        EventType
+	WorkspaceID uuid.UUID
+	Recipients  []uuid.UUID
+	Task        *Task
+	User        *User
+	OccurredAt  time.Time
+}
+
+// IsFor reports whether the event is sent to the user in the workspace.
+func (e *Event) IsFor(userID uuid.UUID, workspaceID uuid.UUID) bool {
+	if e.WorkspaceID != workspaceID {
+		return false
+	}
+
+	for _, recipient := range e.Recipients {
+		if recipient == userID {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// HealthStatus is the status of the application or of one of its dependencies.
+type HealthStatus string
+
+const (
+	HealthStatusUp   HealthStatus = "up"
+	HealthStatusDown HealthStatus = "down"
+)
+
+// DependencyHealth is the result of checking a single dependency, such as the
+// database, and how long the check took.
+type DependencyHealth struct {
+	Status  HealthStatus  `json:"status"`
+	Latency time.Duration `json:"-"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// HealthReport is the status of the application along with the status of each
+// of the dependencies it checked, by name. The application is up only when every
+// dependency is.
+type HealthReport struct {
+	Status       HealthStatus                `json:"status"`
+	ShuttingDown bool                        `json:"shutting_down,omitempty"`
+	Checks       map[string]DependencyHealth `json:"checks,omitempty"`
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotentRequest is a request sent with an idempotency key. It is recorded when
+// the request starts, so the retries of the request get its response instead of
+// repeating it. Keys are scoped to the user sending the request. Fingerprint
+// identifies the request, so a key cannot be reused for a different one. Response
+// is nil while the request is in progress, which lasts until ExpiresAt at most.
+type IdempotentRequest struct {
+	Key         string
+	UserID      uuid.UUID
+	Fingerprint string
+	Response    *IdempotentResponse
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// IdempotentResponse is the response replayed to the retries of an idempotent
+// request: its status code, the headers describing the body and the body.
+type IdempotentResponse struct {
+	Status int
+	Header map[string][]string
+	Body   []byte
+}
@@ -13,7 +13,8 @@ import (
 // Task represents a to-do item or activity that a user can create and manage.
 // It includes fields for a unique identifier (ID), title, description,
 // completion status (Completed), timestamps for creation and updates
-// (CreatedAt and UpdatedAt), and the ID of the user who owns the task (UserID).
+// (CreatedAt and UpdatedAt), the ID of the user who owns the task (UserID)
+// and the workspace the task belongs to (WorkspaceID).
 type Task struct {
 	ID          uuid.UUID
 	Title       string
@@ -22,4 +23,5 @@ type Task struct {
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	UserID      uuid.UUID
+	WorkspaceID uuid.UUID
 }
@@ -0,0 +1,42 @@
+package domain
+
+import "github.com/google/uuid"
+
+// TaskOperationKind is what an operation of a task batch does.
+type TaskOperationKind string
+
+// The operations of a task batch. Complete marks a task as completed without
+// changing its other fields.
+const (
+	TaskOperationCreate   TaskOperationKind = "create"
+	TaskOperationUpdate   TaskOperationKind = "update"
+	TaskOperationDelete   TaskOperationKind = "delete"
+	TaskOperationComplete TaskOperationKind = "complete"
+)
+
+// IsValid reports whether k is one of the operations of a task batch.
+func (k TaskOperationKind) IsValid() bool {
+	switch k {
+	case TaskOperationCreate, TaskOperationUpdate, TaskOperationDelete, TaskOperationComplete:
+		return true
+	default:
+		return false
+	}
+}
+
+// TaskOperation is an operation of a task batch. TaskID identifies the task
+// updated, deleted or completed; Task holds the title, the description and the
+// completion of the task created, or replacing the ones of the task updated.
+type TaskOperation struct {
+	Kind   TaskOperationKind
+	TaskID uuid.UUID
+	Task   *Task
+}
+
+// TaskOperationResult is the outcome of an operation of a task batch, at the
+// index of the operation. Task is the task created, updated or completed, nil for
+// a deletion; Err is why the operation failed.
+type TaskOperationResult struct {
+	Task *Task
+	Err  error
+}
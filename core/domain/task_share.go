@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TaskRole describes the level of access a user has on a task.
+// The owner role is implicit for the user who created the task and
+// cannot be granted through a share.
+type TaskRole string
+
+const (
+	TaskRoleOwner  TaskRole = "owner"
+	TaskRoleEditor TaskRole = "editor"
+	TaskRoleViewer TaskRole = "viewer"
+)
+
+// IsShareable reports whether the role can be granted to a collaborator.
+func (r TaskRole) IsShareable() bool {
+	return r == TaskRoleEditor || r == TaskRoleViewer
+}
+
+// CanEdit reports whether the role allows changing the task.
+func (r TaskRole) CanEdit() bool {
+	return r == TaskRoleOwner || r == TaskRoleEditor
+}
+
+// TaskShare grants a collaborator (UserID) access to a task owned by
+// another user. The Role defines whether the collaborator can only
+// read the task (viewer) or also update it (editor).
+type TaskShare struct {
+	TaskID    uuid.UUID
+	UserID    uuid.UUID
+	Role      TaskRole
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
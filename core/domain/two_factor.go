@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TwoFactor holds the TOTP second factor of a user. It is pending from the
+// enrollment until the user confirms it with a first valid code, and only then
+// required on login. RecoveryCodes holds the hashes of the unused recovery codes.
+// LastUsedStep is the time step of the last TOTP code accepted, so that neither it
+// nor an older code is accepted again.
+type TwoFactor struct {
+	UserID        uuid.UUID
+	Secret        string   `json:"-"`
+	RecoveryCodes []string `json:"-"`
+	LastUsedStep  int64    `json:"-"`
+	EnabledAt     *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Enabled reports whether the second factor was confirmed and is required on login.
+func (t *TwoFactor) Enabled() bool {
+	return t.EnabledAt != nil
+}
@@ -9,14 +9,32 @@ import (
 	"github.com/google/uuid"
 )
 
+// UserRole defines the global permissions of a user. Administrators can act on
+// behalf of any user of their workspace.
+type UserRole string
+
+const (
+	UserRoleUser  UserRole = "user"
+	UserRoleAdmin UserRole = "admin"
+)
+
 // User represents a user entity in the system.
 // It contains the user's unique identifier, username, email, and timestamps
 // for when the user was created and last updated. Additionally, it includes
-// a list of tasks associated with the user.
+// the role of the user, the hash of its password, which is never serialized,
+// and when the user confirmed owning the email address.
 type User struct {
-	ID        uuid.UUID
-	Username  string
-	Email     string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID              uuid.UUID
+	Username        string
+	Email           string
+	Role            UserRole
+	PasswordHash    string `json:"-"`
+	EmailVerifiedAt *time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// EmailVerified reports whether the user confirmed owning the email address.
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
 }
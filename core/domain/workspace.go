@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Workspace isolates a team inside a single deployment. Users join a
+// workspace through a WorkspaceMember and every user or task query is
+// restricted to the workspace of the current request.
+type Workspace struct {
+	ID        uuid.UUID
+	Name      string
+	Slug      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// DefaultWorkspaceSlug identifies the workspace used when a request does
+// not select one explicitly.
+const DefaultWorkspaceSlug = "default"
+
+// WorkspaceRole defines what a member is allowed to do inside a workspace.
+type WorkspaceRole string
+
+const (
+	WorkspaceRoleOwner  WorkspaceRole = "owner"
+	WorkspaceRoleAdmin  WorkspaceRole = "admin"
+	WorkspaceRoleMember WorkspaceRole = "member"
+)
+
+// IsValid reports whether the role is one of the known workspace roles.
+func (r WorkspaceRole) IsValid() bool {
+	switch r {
+	case WorkspaceRoleOwner, WorkspaceRoleAdmin, WorkspaceRoleMember:
+		return true
+	}
+	return false
+}
+
+// CanManageMembers reports whether the role allows adding, changing or
+// removing members of the workspace.
+func (r WorkspaceRole) CanManageMembers() bool {
+	return r == WorkspaceRoleOwner || r == WorkspaceRoleAdmin
+}
+
+// WorkspaceMember links a user to a workspace with a given role.
+type WorkspaceMember struct {
+	WorkspaceID uuid.UUID
+	UserID      uuid.UUID
+	Role        WorkspaceRole
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
@@ -6,7 +6,6 @@ var (
 	ErrUserNotFound       = errors.New("user not found")
 	ErrUserAlreadyExists  = errors.New("user already exists")
 	ErrEmailAlreadyExists = errors.New("email already exists")
-	ErrInvalidUpdateField = errors.New("invalid update fields")
 	ErrNoTasksFound       = errors.New("no tasks found for user")
 	ErrInvalidTaskID      = errors.New("invalid task ID")
 	ErrTaskNotFound       = errors.New("task not found")
@@ -25,3 +24,79 @@ var (
 	ErrDeleteTask     = errors.New("error deleting task")
 	ErrTaskTitleValid = errors.New("invalid task title")
 )
+
+var (
+	ErrTaskAccessDenied  = errors.New("task access denied")
+	ErrInvalidTaskRole   = errors.New("invalid task share role")
+	ErrTaskShareNotFound = errors.New("task share not found")
+	ErrShareWithOwner    = errors.New("task cannot be shared with its owner")
+	ErrSaveTaskShare     = errors.New("error saving task share")
+)
+
+var (
+	ErrWorkspaceRequired      = errors.New("workspace is required")
+	ErrWorkspaceNotFound      = errors.New("workspace not found")
+	ErrWorkspaceSlugExists    = errors.New("workspace slug already exists")
+	ErrInvalidWorkspaceName   = errors.New("invalid workspace name")
+	ErrInvalidWorkspaceRole   = errors.New("invalid workspace role")
+	ErrWorkspaceMemberMissing = errors.New("user is not a member of the workspace")
+	ErrWorkspaceAccessDenied  = errors.New("workspace access denied")
+	ErrLastWorkspaceOwner     = errors.New("workspace must keep at least one owner")
+	ErrSaveWorkspace          = errors.New("error saving workspace")
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrInvalidPassword    = errors.New("password must have between 8 and 72 characters")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrUnauthenticated    = errors.New("authentication required")
+	ErrAccessDenied       = errors.New("access denied")
+	ErrForbidden          = errors.New("operation not permitted")
+	ErrEmailNotConfirmed  = errors.New("email address is not confirmed")
+)
+
+var (
+	ErrAPIKeyNotFound     = errors.New("api key not found")
+	ErrInvalidAPIKeyName  = errors.New("invalid api key name")
+	ErrInvalidAPIKeyScope = errors.New("invalid api key scope")
+	ErrInsufficientScope  = errors.New("api key does not grant the required scope")
+	ErrSaveAPIKey         = errors.New("error saving api key")
+)
+
+var (
+	ErrSingleSignOn      = errors.New("single sign-on failed")
+	ErrInvalidLoginState = errors.New("invalid or expired login state")
+	ErrEmailNotVerified  = errors.New("email is not verified by the identity provider")
+)
+
+var (
+	ErrTwoFactorRequired       = errors.New("two-factor authentication code required")
+	ErrInvalidOTP              = errors.New("invalid two-factor authentication code")
+	ErrTwoFactorNotFound       = errors.New("two-factor authentication is not set up")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrSaveTwoFactor           = errors.New("error saving two-factor authentication")
+)
+
+var (
+	ErrHealthCheckTimeout = errors.New("health check timed out")
+	ErrMigrationsPending  = errors.New("database migrations are not current")
+)
+
+var (
+	ErrInvalidIdempotencyKey    = errors.New("idempotency key must have between 1 and 255 characters")
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was used for a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is in progress")
+	ErrIdempotencyKeyExists     = errors.New("idempotency key already exists")
+	ErrIdempotencyKeyNotFound   = errors.New("idempotency key not found")
+)
+
+var (
+	ErrInvalidTaskBatch     = errors.New("a task batch must have between 1 and 100 operations")
+	ErrInvalidTaskOperation = errors.New("invalid task operation")
+	ErrTaskBatchAborted     = errors.New("task operation not applied, another operation of the batch failed")
+)
+
+var (
+	ErrEventSubscriberTooSlow = errors.New("event subscriber fell too far behind")
+	ErrEventBusClosed         = errors.New("event bus closed")
+)
@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   string
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{"Defaults", "", "", `"msg":"shown"`, false},
+		{"Text", "debug", "text", "msg=shown", false},
+		{"InvalidLevel", "verbose", "json", "", true},
+		{"InvalidFormat", "info", "xml", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			logger, err := New(&out, tt.level, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			logger.Debug("hidden")
+			logger.Info("shown")
+
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("Expected %q in %q", tt.want, out.String())
+			}
+			if tt.level != "debug" && strings.Contains(out.String(), "hidden") {
+				t.Errorf("Expected debug records to be dropped, got %q", out.String())
+			}
+		})
+	}
+}
+
+func TestWith(t *testing.T) {
+	var out bytes.Buffer
+	ctx := WithLogger(context.Background(), slog.New(slog.NewJSONHandler(&out, nil)))
+	ctx = With(ctx, "workspace_id", "w1")
+
+	FromContext(ctx).Info("tagged")
+
+	if !strings.Contains(out.String(), `"workspace_id":"w1"`) {
+		t.Errorf("Expected workspace_id in %q", out.String())
+	}
+}
@@ -0,0 +1,171 @@
+// Package policy implements the role-based access control consulted by the
+// core services before each operation. Roles and the permissions they grant
+// are declared in a YAML policy file, for example:
+//
+//	roles:
+//	  anonymous:
+//	    - users:create
+//	  user:
+//	    - users:read
+//	    - tasks:*:own
+//	  admin:
+//	    - "*"
+//
+// A permission is "<resource>:<action>", where either part can be the "*"
+// wildcard. The ":own" suffix restricts the grant to resources owned by the
+// authenticated user. Requests without a principal use the anonymous role.
+package policy
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/auth"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// Permission names an operation on a resource, such as "tasks:update".
+type Permission string
+
+const (
+	UsersCreate Permission = "users:create"
+	UsersList   Permission = "users:list"
+	UsersRead   Permission = "users:read"
+	UsersUpdate Permission = "users:update"
+	UsersDelete Permission = "users:delete"
+	// UsersResetTwoFactor removes the second factor of a user who lost it.
+	UsersResetTwoFactor Permission = "users:reset-2fa"
+	// UsersPromote grants the administrator role to a user.
+	UsersPromote Permission = "users:promote"
+
+	TasksCreate Permission = "tasks:create"
+	TasksRead   Permission = "tasks:read"
+	TasksUpdate Permission = "tasks:update"
+	TasksDelete Permission = "tasks:delete"
+	TasksShare  Permission = "tasks:share"
+)
+
+// Anonymous is the role of requests without an authenticated principal.
+const Anonymous = "anonymous"
+
+// ownSuffix restricts a grant to the resources of the authenticated user.
+const ownSuffix = ":own"
+
+//go:embed default_policy.yaml
+var defaultPolicy []byte
+
+// grant is a permission pattern granted to a role.
+type grant struct {
+	resource string
+	action   string
+	own      bool
+}
+
+func (g grant) matches(permission Permission) bool {
+	resource, action, _ := strings.Cut(string(permission), ":")
+
+	return (g.resource == "*" || g.resource == resource) && (g.action == "*" || g.action == action)
+}
+
+// Engine evaluates the permissions of the principal of a request against the
+// roles of a policy file. It implements the ports.Authorizer interface.
+type Engine struct {
+	roles map[string][]grant
+}
+
+// Default returns the Engine of the policy file embedded in the binary,
+// core/policy/default_policy.yaml.
+func Default() (*Engine, error) {
+	return Parse(bytes.NewReader(defaultPolicy))
+}
+
+// LoadFile returns the Engine of the policy file at path.
+func LoadFile(path string) (*Engine, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Parse(f)
+}
+
+// Parse reads a YAML policy. It fails on empty policies and malformed permissions.
+func Parse(r io.Reader) (*Engine, error) {
+	var file struct {
+		Roles map[string][]string `yaml:"roles"`
+	}
+
+	if err := yaml.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+
+	if len(file.Roles) == 0 {
+		return nil, fmt.Errorf("invalid policy: no roles declared")
+	}
+
+	roles := make(map[string][]grant, len(file.Roles))
+	for role, permissions := range file.Roles {
+		for _, permission := range permissions {
+			g, err := parseGrant(permission)
+			if err != nil {
+				return nil, fmt.Errorf("invalid policy: role %s: %w", role, err)
+			}
+			roles[role] = append(roles[role], g)
+		}
+	}
+
+	return &Engine{roles: roles}, nil
+}
+
+func parseGrant(permission string) (grant, error) {
+	g := grant{}
+
+	if permission == "*" {
+		return grant{resource: "*", action: "*"}, nil
+	}
+
+	if strings.HasSuffix(permission, ownSuffix) {
+		g.own = true
+		permission = strings.TrimSuffix(permission, ownSuffix)
+	}
+
+	resource, action, found := strings.Cut(permission, ":")
+	if !found || resource == "" || action == "" || strings.Contains(action, ":") {
+		return grant{}, fmt.Errorf("malformed permission %q", permission)
+	}
+
+	g.resource, g.action = resource, action
+
+	return g, nil
+}
+
+// Authorize checks that the principal carried by ctx may perform the operation on a
+// resource owned by ownerID. Pass uuid.Nil for operations without an owner, which
+// grants restricted to owned resources never allow.
+// It returns an error wrapping core.ErrForbidden when the policy denies the operation.
+func (e *Engine) Authorize(ctx context.Context, permission Permission, ownerID uuid.UUID) error {
+	role, subject := Anonymous, uuid.Nil
+	if principal, err := auth.PrincipalFrom(ctx); err == nil {
+		role, subject = string(principal.Role), principal.UserID
+	}
+
+	for _, g := range e.roles[role] {
+		if !g.matches(permission) {
+			continue
+		}
+		if g.own && (ownerID == uuid.Nil || ownerID != subject) {
+			continue
+		}
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", core.ErrForbidden, permission)
+}
@@ -0,0 +1,100 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/auth"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/google/uuid"
+)
+
+func TestDefaultPolicy(t *testing.T) {
+	engine, err := Default()
+	if err != nil {
+		t.Fatalf("Expected the default policy to load, got: %v", err)
+	}
+
+	self, other := uuid.New(), uuid.New()
+	user := auth.WithPrincipal(context.Background(), auth.Principal{UserID: self, Role: domain.UserRoleUser})
+	admin := auth.WithPrincipal(context.Background(), auth.Principal{UserID: self, Role: domain.UserRoleAdmin})
+	anonymous := context.Background()
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		permission Permission
+		owner      uuid.UUID
+		allowed    bool
+	}{
+		{"AnonymousSignUp", anonymous, UsersCreate, uuid.Nil, true},
+		{"AnonymousList", anonymous, UsersList, uuid.Nil, false},
+		{"UserListsUsers", user, UsersList, uuid.Nil, true},
+		{"UserUpdatesSelf", user, UsersUpdate, self, true},
+		{"UserUpdatesOther", user, UsersUpdate, other, false},
+		{"UserDeletesOther", user, UsersDelete, other, false},
+		{"UserOwnTasks", user, TasksUpdate, self, true},
+		{"UserOtherTasks", user, TasksRead, other, false},
+		{"AdminDeletesOther", admin, UsersDelete, other, true},
+		{"AdminOtherTasks", admin, TasksShare, other, true},
+		{"UserResetsOwnTwoFactor", user, UsersResetTwoFactor, self, false},
+		{"AdminResetsTwoFactor", admin, UsersResetTwoFactor, other, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := engine.Authorize(tt.ctx, tt.permission, tt.owner)
+			if tt.allowed && err != nil {
+				t.Errorf("Expected %s to be allowed, got: %v", tt.permission, err)
+			}
+			if !tt.allowed && !errors.Is(err, core.ErrForbidden) {
+				t.Errorf("Expected %s to be forbidden, got: %v", tt.permission, err)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Run("Wildcards", func(t *testing.T) {
+		engine, err := Parse(strings.NewReader("roles:\n  user:\n    - \"*:read\"\n"))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		ctx := auth.WithPrincipal(context.Background(), auth.Principal{UserID: uuid.New(), Role: domain.UserRoleUser})
+		if err := engine.Authorize(ctx, TasksRead, uuid.New()); err != nil {
+			t.Errorf("Expected tasks:read to be allowed, got: %v", err)
+		}
+		if err := engine.Authorize(ctx, TasksUpdate, uuid.New()); !errors.Is(err, core.ErrForbidden) {
+			t.Errorf("Expected tasks:update to be forbidden, got: %v", err)
+		}
+	})
+
+	t.Run("UnknownRole", func(t *testing.T) {
+		engine, _ := Parse(strings.NewReader("roles:\n  admin:\n    - \"*\"\n"))
+
+		ctx := auth.WithPrincipal(context.Background(), auth.Principal{UserID: uuid.New(), Role: domain.UserRoleUser})
+		if err := engine.Authorize(ctx, UsersRead, uuid.Nil); !errors.Is(err, core.ErrForbidden) {
+			t.Errorf("Expected roles missing from the policy to be denied, got: %v", err)
+		}
+	})
+
+	invalid := map[string]string{
+		"Empty":            "",
+		"NoRoles":          "roles: {}\n",
+		"MalformedGrant":   "roles:\n  user:\n    - tasks\n",
+		"TooManySegments":  "roles:\n  user:\n    - tasks:read:all\n",
+		"NotAListOfGrants": "roles:\n  user: tasks:read\n",
+	}
+
+	for name, policy := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Parse(strings.NewReader(policy)); err == nil {
+				t.Error("Expected an invalid policy to be rejected")
+			}
+		})
+	}
+}
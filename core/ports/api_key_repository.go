@@ -0,0 +1,19 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/google/uuid"
+)
+
+// APIKeyRepository defines the contract for storing the API keys of the users.
+// As for users and tasks, keys are scoped to the workspace carried by the context.
+type APIKeyRepository interface {
+	Save(ctx context.Context, key *domain.APIKey) error
+	FindByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error)
+	FindUserKeys(ctx context.Context, userID uuid.UUID) ([]*domain.APIKey, error)
+	Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) error
+	TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error
+}
@@ -0,0 +1,15 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/fabianoflorentino/gotostudy/core/policy"
+	"github.com/google/uuid"
+)
+
+// Authorizer decides whether the principal of a request may perform an operation
+// on a resource owned by ownerID. It returns an error wrapping core.ErrForbidden
+// when the operation is denied.
+type Authorizer interface {
+	Authorize(ctx context.Context, permission policy.Permission, ownerID uuid.UUID) error
+}
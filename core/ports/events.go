@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+)
+
+// EventPublisher notifies the clients of the users of the changes of their tasks
+// and accounts. The services publish an event once the change is persisted, and
+// Publish never blocks on the clients receiving it.
+type EventPublisher interface {
+	Publish(ctx context.Context, event domain.Event)
+}
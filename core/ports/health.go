@@ -0,0 +1,10 @@
+package ports
+
+import "context"
+
+// HealthChecker checks that a dependency of the application is usable. Check
+// must return before ctx is done.
+type HealthChecker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
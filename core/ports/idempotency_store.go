@@ -0,0 +1,26 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/google/uuid"
+)
+
+// IdempotencyStore records the requests sent with an idempotency key and their
+// responses. Keys are scoped to the user sending the request.
+//
+// Create records a request, replacing an expired one with the same key, and
+// returns core.ErrIdempotencyKeyExists when the user already sent a request with
+// the key that has not expired. Complete records the response of a request and
+// moves its expiration to expiresAt. Find, Complete and Delete return
+// core.ErrIdempotencyKeyNotFound when the user has no request with the key.
+// DeleteExpired removes the requests expired at now and returns how many.
+type IdempotencyStore interface {
+	Create(ctx context.Context, request *domain.IdempotentRequest) error
+	Find(ctx context.Context, userID uuid.UUID, key string) (*domain.IdempotentRequest, error)
+	Complete(ctx context.Context, userID uuid.UUID, key string, response *domain.IdempotentResponse, expiresAt time.Time) error
+	Delete(ctx context.Context, userID uuid.UUID, key string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
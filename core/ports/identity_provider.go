@@ -0,0 +1,17 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/fabianoflorentino/gotostudy/core/auth"
+)
+
+// IdentityProvider signs users in through an external OpenID Connect provider
+// using the authorization code flow with PKCE.
+type IdentityProvider interface {
+	// AuthCodeURL returns the URL of the provider the user is redirected to.
+	AuthCodeURL(state string, nonce string, codeVerifier string) string
+	// Exchange redeems the authorization code and returns the identity asserted
+	// by the verified ID token.
+	Exchange(ctx context.Context, code string, nonce string, codeVerifier string) (*auth.Identity, error)
+}
@@ -0,0 +1,15 @@
+package ports
+
+import "context"
+
+// Mail is a plain text email sent to a single recipient.
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers the emails sent by the application.
+type Mailer interface {
+	Send(ctx context.Context, mail Mail) error
+}
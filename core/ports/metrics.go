@@ -0,0 +1,17 @@
+package ports
+
+import "context"
+
+// Registration methods counted by BusinessMetrics.UserRegistered.
+const (
+	RegistrationPassword = "password"
+	RegistrationOIDC     = "oidc"
+)
+
+// BusinessMetrics counts the business events shown on the dashboards of a deployment.
+// The events are recorded once persisted, so failed operations are not counted.
+type BusinessMetrics interface {
+	UserRegistered(ctx context.Context, method string)
+	TaskCreated(ctx context.Context)
+	TaskCompleted(ctx context.Context)
+}
@@ -0,0 +1,38 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/google/uuid"
+)
+
+// Outbox stores the domain events until they are relayed to the sinks. Append
+// takes part in the transaction carried by ctx, so the events are stored with the
+// state change they record, or not at all.
+//
+// Claim returns, in the order they were appended, at most limit events not
+// delivered yet whose next attempt is due at now, and postpones their next attempt
+// to until, so the other relays skip them once the transaction carried by ctx
+// commits; within it, the events are locked and skipped by the other relays.
+// Claimed events neither delivered nor failed by until are due again. MarkDelivered
+// records the delivery of the events, and MarkFailed a failed attempt, to be
+// retried at next. DeleteDelivered removes the events delivered before the given time and
+// returns how many.
+type Outbox interface {
+	Append(ctx context.Context, events ...domain.DomainEvent) error
+	Claim(ctx context.Context, now time.Time, until time.Time, limit int) ([]domain.DomainEvent, error)
+	MarkDelivered(ctx context.Context, ids []uuid.UUID, at time.Time) error
+	MarkFailed(ctx context.Context, id uuid.UUID, next time.Time, cause string) error
+	DeleteDelivered(ctx context.Context, before time.Time) (int64, error)
+}
+
+// EventSink receives the domain events relayed from the outbox, such as a webhook
+// of an integration. Delivery is at least once: an event may be delivered again,
+// with the same ID, when a delivery to any sink failed or the relay stopped before
+// recording it. A returned error makes the relay retry the event later.
+type EventSink interface {
+	Name() string
+	Deliver(ctx context.Context, event domain.DomainEvent) error
+}
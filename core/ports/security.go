@@ -0,0 +1,44 @@
+package ports
+
+import (
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core/auth"
+)
+
+// PasswordHasher hashes user passwords and checks a password against a stored hash.
+// Compare returns core.ErrInvalidCredentials when the password does not match.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Compare(hash string, password string) error
+}
+
+// TokenIssuer signs the tokens handed out to authenticated users and verifies
+// the tokens presented by clients. Verify returns core.ErrInvalidToken when the
+// token is malformed, expired, tampered with or of another kind.
+type TokenIssuer interface {
+	Issue(principal auth.Principal) (*auth.TokenPair, error)
+	Verify(token string, kind auth.TokenKind) (*auth.Principal, error)
+}
+
+// ActionTokenSigner signs the action tokens of the links sent by email and verifies
+// them. VerifyAction returns core.ErrInvalidToken when the token is malformed,
+// expired, tampered with or of another kind.
+type ActionTokenSigner interface {
+	SignAction(token auth.ActionToken) (string, error)
+	VerifyAction(token string, kind auth.TokenKind) (*auth.ActionToken, error)
+}
+
+// OTPAuthenticator generates TOTP secrets and validates the one-time codes derived
+// from them. The time is always passed in, so callers decide which clock is used.
+type OTPAuthenticator interface {
+	GenerateSecret() (string, error)
+	// URI returns the otpauth:// URI registering secret for account in an authenticator app.
+	URI(secret string, account string) string
+	// QRCode returns the URI encoded as a QR code PNG image.
+	QRCode(uri string) ([]byte, error)
+	// Validate reports whether code is a valid TOTP code of secret at the given time
+	// and returns the time step it was derived from, so callers can refuse a code
+	// that was already used.
+	Validate(code string, secret string, at time.Time) (int64, bool)
+}
@@ -11,11 +11,23 @@ import (
 )
 
 // TaskRepository defines the interface for interacting with task data storage.
-// It provides methods to find, save, update, and delete tasks.
+// It provides methods to find, save, update, and delete tasks, as well as to
+// manage the shares that give other users access to a task.
+//
+// SaveAll inserts several tasks of the user at once, all of them or none.
+// FindTaskByID returns the task when the user either owns it or is one of its
+// collaborators; callers decide what the user may do with it.
 type TaskRepository interface {
 	Save(ctx context.Context, userID uuid.UUID, task *domain.Task) error
+	SaveAll(ctx context.Context, userID uuid.UUID, tasks []*domain.Task) error
 	FindUserTasks(ctx context.Context, userID uuid.UUID) ([]*domain.Task, error)
+	FindSharedTasks(ctx context.Context, userID uuid.UUID) ([]*domain.Task, error)
 	FindTaskByID(ctx context.Context, userID uuid.UUID, taskID uuid.UUID) (*domain.Task, error)
 	Update(ctx context.Context, id uuid.UUID, task *domain.Task) error
 	Delete(ctx context.Context, taskID uuid.UUID) error
+
+	SaveTaskShare(ctx context.Context, share *domain.TaskShare) error
+	FindTaskShare(ctx context.Context, taskID uuid.UUID, userID uuid.UUID) (*domain.TaskShare, error)
+	FindTaskShares(ctx context.Context, taskID uuid.UUID) ([]*domain.TaskShare, error)
+	DeleteTaskShare(ctx context.Context, taskID uuid.UUID, userID uuid.UUID) error
 }
@@ -0,0 +1,11 @@
+package ports
+
+import "context"
+
+// Transactor runs a unit of work atomically. The repositories called with the
+// context passed to fn take part in the transaction, which is committed when fn
+// returns nil and rolled back when it returns an error, returned as is. Nested
+// calls join the transaction of the outer one.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
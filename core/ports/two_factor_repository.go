@@ -0,0 +1,22 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/google/uuid"
+)
+
+// TwoFactorRepository stores the TOTP second factor of the users. FindByUserID
+// and Delete return core.ErrTwoFactorNotFound when the user has no second factor.
+// Save inserts the second factor or replaces the existing one of the user.
+// UseStep and UseRecoveryCode record the use of a TOTP step or of a recovery code
+// atomically, returning core.ErrInvalidOTP when the step is not newer than the last
+// one used or when the recovery code is not, or no longer, among the codes.
+type TwoFactorRepository interface {
+	FindByUserID(ctx context.Context, userID uuid.UUID) (*domain.TwoFactor, error)
+	Save(ctx context.Context, twoFactor *domain.TwoFactor) error
+	UseStep(ctx context.Context, userID uuid.UUID, step int64) error
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, hash string) error
+	Delete(ctx context.Context, userID uuid.UUID) error
+}
@@ -5,6 +5,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/fabianoflorentino/gotostudy/core/domain"
 	"github.com/google/uuid"
@@ -12,14 +13,18 @@ import (
 
 // UserRepository defines the contract for a repository that manages user entities.
 // It provides methods for performing CRUD (Create, Read, Update, Delete) operations
-// on user data, as well as updating specific fields of a user. The interface abstracts
+// on user data, as well as updating the password, the role of a user and the
+// confirmation of its email address. The interface abstracts
 // the underlying data storage mechanism, allowing for flexibility and easier testing.
 type UserRepository interface {
 	FindAll(ctx context.Context) ([]*domain.User, error)
 	FindByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
 	FindByEmail(ctx context.Context, email string) (*domain.User, error)
+	FindByEmailInAnyWorkspace(ctx context.Context, email string) (*domain.User, error)
 	Save(ctx context.Context, user *domain.User) error
 	Update(ctx context.Context, id uuid.UUID, user *domain.User) error
-	UpdateFields(ctx context.Context, id uuid.UUID, fields map[string]any) (*domain.User, error)
 	Delete(ctx context.Context, id uuid.UUID) error
+	UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error
+	MarkEmailVerified(ctx context.Context, id uuid.UUID, at time.Time) error
+	UpdateRole(ctx context.Context, id uuid.UUID, role domain.UserRole) error
 }
@@ -0,0 +1,23 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/google/uuid"
+)
+
+// WorkspaceRepository defines the contract for storing workspaces and their
+// memberships. Unlike the user and task repositories it is not scoped by the
+// workspace carried in the context, since it is the one resolving it.
+type WorkspaceRepository interface {
+	Save(ctx context.Context, workspace *domain.Workspace) error
+	FindByID(ctx context.Context, id uuid.UUID) (*domain.Workspace, error)
+	FindBySlug(ctx context.Context, slug string) (*domain.Workspace, error)
+	FindUserWorkspaces(ctx context.Context, userID uuid.UUID) ([]*domain.Workspace, error)
+
+	SaveMember(ctx context.Context, member *domain.WorkspaceMember) error
+	FindMember(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID) (*domain.WorkspaceMember, error)
+	FindMembers(ctx context.Context, workspaceID uuid.UUID) ([]*domain.WorkspaceMember, error)
+	DeleteMember(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID) error
+}
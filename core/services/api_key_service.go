@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/auth"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/logging"
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
+	"github.com/google/uuid"
+)
+
+// APIKeyPrefix starts every API key, which tells them apart from access tokens
+// in the Authorization header. A key reads "gts_<prefix>_<secret>".
+const APIKeyPrefix = "gts_"
+
+// lastUsedResolution bounds how often the last use of a key is written, so a
+// busy script does not update the key on every request.
+const lastUsedResolution = time.Minute
+
+// APIKeyService manages the personal API keys used by scripts and CI. Keys are
+// bound to a user and a workspace and limited to the scopes they were created with.
+type APIKeyService struct {
+	keys ports.APIKeyRepository
+	usr  ports.UserRepository
+	now  func() time.Time
+}
+
+// NewAPIKeyService creates a new instance of APIKeyService using the provided
+// APIKeyRepository and UserRepository.
+func NewAPIKeyService(k ports.APIKeyRepository, u ports.UserRepository) *APIKeyService {
+	return &APIKeyService{keys: k, usr: u, now: time.Now}
+}
+
+// IsAPIKey reports whether the token presented by a client is an API key.
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// CreateAPIKey creates a new API key for userID in the workspace carried by ctx and
+// returns it together with the full key, which is not stored and cannot be shown again.
+// A request authenticated with an API key can only create keys within its own scopes.
+func (a *APIKeyService) CreateAPIKey(ctx context.Context, userID uuid.UUID, name string, scopes []domain.APIScope) (*domain.APIKey, string, error) {
+	workspaceID, err := tenant.WorkspaceID(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return nil, "", core.ErrInvalidAPIKeyName
+	}
+
+	scopes, err = validScopes(ctx, scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if _, err := a.usr.FindByID(ctx, userID); err != nil {
+		return nil, "", core.ErrUserNotFound
+	}
+
+	prefix, secret, err := generateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := &domain.APIKey{
+		ID:          uuid.New(),
+		UserID:      userID,
+		WorkspaceID: workspaceID,
+		Name:        name,
+		Prefix:      prefix,
+		SecretHash:  hashAPIKeySecret(secret),
+		Scopes:      scopes,
+		CreatedAt:   a.now(),
+		UpdatedAt:   a.now(),
+	}
+
+	if err := a.keys.Save(ctx, key); err != nil {
+		logging.FromContext(ctx).Error("failed to save api key", "error", err)
+		return nil, "", core.ErrSaveAPIKey
+	}
+
+	return key, prefix + "_" + secret, nil
+}
+
+// FindUserAPIKeys lists the API keys of the user. Only their prefixes are shown.
+func (a *APIKeyService) FindUserAPIKeys(ctx context.Context, userID uuid.UUID) ([]*domain.APIKey, error) {
+	return a.keys.FindUserKeys(ctx, userID)
+}
+
+// RevokeAPIKey deletes an API key of the user; requests using it are rejected from then on.
+// It returns core.ErrAPIKeyNotFound when the user has no such key.
+func (a *APIKeyService) RevokeAPIKey(ctx context.Context, userID uuid.UUID, keyID uuid.UUID) error {
+	return a.keys.Delete(ctx, userID, keyID)
+}
+
+// Authenticate returns the principal of a valid API key of the workspace carried by ctx
+// and records when the key was last used. It returns core.ErrInvalidToken otherwise.
+func (a *APIKeyService) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	prefix, secret, ok := parseAPIKey(token)
+	if !ok {
+		return nil, core.ErrInvalidToken
+	}
+
+	key, err := a.keys.FindByPrefix(ctx, prefix)
+	if errors.Is(err, core.ErrAPIKeyNotFound) {
+		return nil, core.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashAPIKeySecret(secret)), []byte(key.SecretHash)) != 1 {
+		return nil, core.ErrInvalidToken
+	}
+
+	user, err := a.usr.FindByID(ctx, key.UserID)
+	if err != nil {
+		return nil, core.ErrInvalidToken
+	}
+
+	now := a.now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := a.keys.TouchLastUsed(ctx, key.ID, now); err != nil {
+			logging.FromContext(ctx).Warn("failed to record api key usage", "api_key_id", key.ID, "error", err)
+		}
+	}
+
+	return &auth.Principal{
+		UserID:      user.ID,
+		WorkspaceID: key.WorkspaceID,
+		Role:        user.Role,
+		APIKeyID:    key.ID,
+		Scopes:      key.Scopes,
+	}, nil
+}
+
+// validScopes checks and deduplicates the requested scopes. When ctx was authenticated
+// with an API key, the scopes must be granted to that key too.
+func validScopes(ctx context.Context, scopes []domain.APIScope) ([]domain.APIScope, error) {
+	if len(scopes) == 0 {
+		return nil, core.ErrInvalidAPIKeyScope
+	}
+
+	principal, authErr := auth.PrincipalFrom(ctx)
+
+	unique := make([]domain.APIScope, 0, len(scopes))
+	seen := make(map[domain.APIScope]bool, len(scopes))
+	for _, scope := range scopes {
+		if !scope.IsValid() {
+			return nil, core.ErrInvalidAPIKeyScope
+		}
+		if authErr == nil && !principal.Allows(scope) {
+			return nil, core.ErrInsufficientScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+
+	return unique, nil
+}
+
+// generateAPIKey returns a random public prefix, used to look the key up, and a
+// random secret.
+func generateAPIKey() (string, string, error) {
+	prefix := make([]byte, 8)
+	if _, err := rand.Read(prefix); err != nil {
+		return "", "", err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	return APIKeyPrefix + hex.EncodeToString(prefix), base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// parseAPIKey splits "gts_<prefix>_<secret>" into "gts_<prefix>" and the secret.
+func parseAPIKey(token string) (string, string, bool) {
+	if !IsAPIKey(token) {
+		return "", "", false
+	}
+
+	prefix, secret, found := strings.Cut(strings.TrimPrefix(token, APIKeyPrefix), "_")
+	if !found || prefix == "" || secret == "" {
+		return "", "", false
+	}
+
+	return APIKeyPrefix + prefix, secret, true
+}
+
+// hashAPIKeySecret hashes the secret of a key. The secrets are random and long,
+// so a fast hash is enough and keeps the check cheap on every request.
+func hashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
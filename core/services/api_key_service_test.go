@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/auth"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
+	"github.com/google/uuid"
+)
+
+type mockAPIKeyRepository struct {
+	keys    map[uuid.UUID]*domain.APIKey
+	touches int
+}
+
+func newMockAPIKeyRepository() *mockAPIKeyRepository {
+	return &mockAPIKeyRepository{keys: make(map[uuid.UUID]*domain.APIKey)}
+}
+
+func (m *mockAPIKeyRepository) Save(ctx context.Context, key *domain.APIKey) error {
+	m.keys[key.ID] = key
+	return nil
+}
+
+func (m *mockAPIKeyRepository) FindByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	workspaceID, err := tenant.WorkspaceID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range m.keys {
+		if key.Prefix == prefix && key.WorkspaceID == workspaceID {
+			return key, nil
+		}
+	}
+	return nil, core.ErrAPIKeyNotFound
+}
+
+func (m *mockAPIKeyRepository) FindUserKeys(ctx context.Context, userID uuid.UUID) ([]*domain.APIKey, error) {
+	var keys []*domain.APIKey
+	for _, key := range m.keys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (m *mockAPIKeyRepository) Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	if key, exists := m.keys[id]; !exists || key.UserID != userID {
+		return core.ErrAPIKeyNotFound
+	}
+	delete(m.keys, id)
+	return nil
+}
+
+func (m *mockAPIKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	m.touches++
+	m.keys[id].LastUsedAt = &at
+	return nil
+}
+
+func TestAPIKeyService(t *testing.T) {
+	repo := newMockAPIKeyRepository()
+	userRepo := newMockUserRepository()
+	service := NewAPIKeyService(repo, userRepo)
+
+	workspaceA, workspaceB := uuid.New(), uuid.New()
+	ctxA := tenant.WithWorkspace(context.Background(), workspaceA)
+	ctxB := tenant.WithWorkspace(context.Background(), workspaceB)
+
+	user := &domain.User{ID: uuid.New(), Username: "ci", Email: "ci@example.com", Role: domain.UserRoleUser}
+	userRepo.users[user.Email] = user
+
+	key, secret, err := service.CreateAPIKey(ctxA, user.ID, "CI", []domain.APIScope{domain.ScopeTasksRead, domain.ScopeTasksRead})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	t.Run("CreateAPIKey", func(t *testing.T) {
+		if !strings.HasPrefix(secret, key.Prefix+"_") {
+			t.Errorf("Expected the key %q to start with its prefix %q", secret, key.Prefix)
+		}
+		if strings.Contains(key.SecretHash, strings.TrimPrefix(secret, key.Prefix+"_")) {
+			t.Error("Expected only the hash of the secret to be stored")
+		}
+		if len(key.Scopes) != 1 || key.WorkspaceID != workspaceA {
+			t.Errorf("Unexpected key %+v", key)
+		}
+	})
+
+	t.Run("CreateAPIKey_Invalid", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			key    string
+			scopes []domain.APIScope
+			err    error
+		}{
+			{"EmptyName", " ", []domain.APIScope{domain.ScopeTasksRead}, core.ErrInvalidAPIKeyName},
+			{"NoScope", "CI", nil, core.ErrInvalidAPIKeyScope},
+			{"UnknownScope", "CI", []domain.APIScope{"tasks:delete"}, core.ErrInvalidAPIKeyScope},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if _, _, err := service.CreateAPIKey(ctxA, user.ID, tt.key, tt.scopes); !errors.Is(err, tt.err) {
+					t.Errorf("Expected %v, got: %v", tt.err, err)
+				}
+			})
+		}
+	})
+
+	t.Run("CreateAPIKey_BeyondOwnScopes", func(t *testing.T) {
+		ctx := auth.WithPrincipal(ctxA, auth.Principal{UserID: user.ID, APIKeyID: key.ID, Scopes: key.Scopes})
+
+		_, _, err := service.CreateAPIKey(ctx, user.ID, "escalate", []domain.APIScope{domain.ScopeUsersAdmin})
+		if !errors.Is(err, core.ErrInsufficientScope) {
+			t.Errorf("Expected ErrInsufficientScope, got: %v", err)
+		}
+	})
+
+	t.Run("Authenticate", func(t *testing.T) {
+		principal, err := service.Authenticate(ctxA, secret)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if principal.UserID != user.ID || principal.APIKeyID != key.ID {
+			t.Errorf("Unexpected principal %+v", principal)
+		}
+		if !principal.Allows(domain.ScopeTasksRead) || principal.Allows(domain.ScopeTasksWrite) {
+			t.Errorf("Expected the principal to be limited to the key scopes, got %v", principal.Scopes)
+		}
+		if key.LastUsedAt == nil {
+			t.Error("Expected the last use of the key to be recorded")
+		}
+	})
+
+	t.Run("Authenticate_LastUsedResolution", func(t *testing.T) {
+		touches := repo.touches
+		if _, err := service.Authenticate(ctxA, secret); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if repo.touches != touches {
+			t.Error("Expected the last use not to be written again within a minute")
+		}
+	})
+
+	t.Run("Authenticate_Invalid", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			ctx   context.Context
+			token string
+		}{
+			{"WrongSecret", ctxA, key.Prefix + "_wrong"},
+			{"UnknownPrefix", ctxA, "gts_0000000000000000_" + strings.TrimPrefix(secret, key.Prefix+"_")},
+			{"Malformed", ctxA, "gts_"},
+			{"OtherWorkspace", ctxB, secret},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if _, err := service.Authenticate(tt.ctx, tt.token); !errors.Is(err, core.ErrInvalidToken) {
+					t.Errorf("Expected ErrInvalidToken, got: %v", err)
+				}
+			})
+		}
+	})
+
+	t.Run("RevokeAPIKey", func(t *testing.T) {
+		if err := service.RevokeAPIKey(ctxA, uuid.New(), key.ID); !errors.Is(err, core.ErrAPIKeyNotFound) {
+			t.Errorf("Expected ErrAPIKeyNotFound for another user, got: %v", err)
+		}
+
+		if err := service.RevokeAPIKey(ctxA, user.ID, key.ID); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if _, err := service.Authenticate(ctxA, secret); !errors.Is(err, core.ErrInvalidToken) {
+			t.Errorf("Expected a revoked key to be rejected, got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/auth"
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
+	"github.com/google/uuid"
+)
+
+// AuthService authenticates users with their email, password and, when enabled,
+// second factor and exchanges the signed tokens it hands out for the principal of
+// a request. Tokens are bound to the workspace they were issued in and are
+// rejected in any other.
+type AuthService struct {
+	usr       ports.UserRepository
+	hasher    ports.PasswordHasher
+	tokens    ports.TokenIssuer
+	twoFactor *TwoFactorService
+	// dummyHash is the hash of a random password, compared by Login when the user has
+	// no password, so the response time does not disclose which emails are registered.
+	dummyHash func() (string, error)
+}
+
+// NewAuthService creates a new instance of AuthService using the provided
+// UserRepository, PasswordHasher, TokenIssuer and the TwoFactorService verifying
+// the second factor of the users who enabled it.
+func NewAuthService(u ports.UserRepository, h ports.PasswordHasher, t ports.TokenIssuer, f *TwoFactorService) *AuthService {
+	dummyHash := sync.OnceValues(func() (string, error) { return h.Hash(uuid.NewString()) })
+	return &AuthService{usr: u, hasher: h, tokens: t, twoFactor: f, dummyHash: dummyHash}
+}
+
+// Login checks the credentials of a user of the workspace carried by ctx and
+// returns a new pair of access and refresh tokens. Users with two-factor
+// authentication must also send a TOTP or recovery code as otp.
+// It returns core.ErrInvalidCredentials when the email or the password is wrong,
+// core.ErrEmailNotConfirmed until the user confirmed the email address,
+// core.ErrTwoFactorRequired when otp is missing and core.ErrInvalidOTP when it is wrong.
+func (a *AuthService) Login(ctx context.Context, email string, password string, otp string) (*auth.TokenPair, error) {
+	workspaceID, err := tenant.WorkspaceID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.usr.FindByEmail(ctx, email)
+	if err != nil && !errors.Is(err, core.ErrUserNotFound) {
+		return nil, err
+	}
+
+	if user == nil || user.PasswordHash == "" {
+		if hash, err := a.dummyHash(); err == nil {
+			_ = a.hasher.Compare(hash, password)
+		}
+		return nil, core.ErrInvalidCredentials
+	}
+
+	if err := a.hasher.Compare(user.PasswordHash, password); err != nil {
+		return nil, core.ErrInvalidCredentials
+	}
+
+	if !user.EmailVerified() {
+		return nil, core.ErrEmailNotConfirmed
+	}
+
+	if err := a.twoFactor.Verify(ctx, user.ID, otp); err != nil {
+		return nil, err
+	}
+
+	return a.tokens.Issue(auth.Principal{UserID: user.ID, WorkspaceID: workspaceID, Role: user.Role})
+}
+
+// Refresh exchanges a refresh token for a new pair of tokens. The user is loaded
+// again so a deleted user can no longer refresh and a role change is picked up.
+func (a *AuthService) Refresh(ctx context.Context, refreshToken string) (*auth.TokenPair, error) {
+	principal, err := a.verify(ctx, refreshToken, auth.TokenRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.usr.FindByID(ctx, principal.UserID)
+	if err != nil {
+		return nil, core.ErrInvalidToken
+	}
+
+	return a.tokens.Issue(auth.Principal{UserID: user.ID, WorkspaceID: principal.WorkspaceID, Role: user.Role})
+}
+
+// Authenticate returns the principal of a valid access token issued for the
+// workspace carried by ctx. It returns core.ErrInvalidToken otherwise.
+func (a *AuthService) Authenticate(ctx context.Context, accessToken string) (*auth.Principal, error) {
+	return a.verify(ctx, accessToken, auth.TokenAccess)
+}
+
+func (a *AuthService) verify(ctx context.Context, token string, kind auth.TokenKind) (*auth.Principal, error) {
+	workspaceID, err := tenant.WorkspaceID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	principal, err := a.tokens.Verify(token, kind)
+	if err != nil {
+		return nil, core.ErrInvalidToken
+	}
+
+	if principal.WorkspaceID != workspaceID {
+		return nil, core.ErrInvalidToken
+	}
+
+	return principal, nil
+}
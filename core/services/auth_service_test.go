@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/auth"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
+	"github.com/google/uuid"
+)
+
+// mockTokenIssuer is a mock implementation of TokenIssuer whose tokens are the
+// readable "kind|user|workspace|role" tuple of the principal.
+type mockTokenIssuer struct{}
+
+func (m *mockTokenIssuer) Issue(principal auth.Principal) (*auth.TokenPair, error) {
+	return &auth.TokenPair{
+		AccessToken:  m.token(auth.TokenAccess, principal),
+		RefreshToken: m.token(auth.TokenRefresh, principal),
+		TokenType:    "Bearer",
+	}, nil
+}
+
+func (m *mockTokenIssuer) Verify(token string, kind auth.TokenKind) (*auth.Principal, error) {
+	parts := strings.Split(token, "|")
+	if len(parts) != 4 || auth.TokenKind(parts[0]) != kind {
+		return nil, core.ErrInvalidToken
+	}
+
+	return &auth.Principal{
+		UserID:      uuid.MustParse(parts[1]),
+		WorkspaceID: uuid.MustParse(parts[2]),
+		Role:        domain.UserRole(parts[3]),
+	}, nil
+}
+
+func (m *mockTokenIssuer) token(kind auth.TokenKind, p auth.Principal) string {
+	return strings.Join([]string{string(kind), p.UserID.String(), p.WorkspaceID.String(), string(p.Role)}, "|")
+}
+
+// countingPasswordHasher is a mockPasswordHasher counting the compared passwords.
+type countingPasswordHasher struct {
+	mockPasswordHasher
+	compared int
+}
+
+func (h *countingPasswordHasher) Compare(hash string, password string) error {
+	h.compared++
+	return h.mockPasswordHasher.Compare(hash, password)
+}
+
+func TestAuthService(t *testing.T) {
+	repo := newMockUserRepository()
+	twoFactor := NewTwoFactorService(newMockTwoFactorRepository(), repo, &mockOTPAuthenticator{}, newMockAuthorizer())
+	service := NewAuthService(repo, newMockPasswordHasher(), &mockTokenIssuer{}, twoFactor)
+
+	workspaceA, workspaceB := uuid.New(), uuid.New()
+	ctxA := tenant.WithWorkspace(context.Background(), workspaceA)
+	ctxB := tenant.WithWorkspace(context.Background(), workspaceB)
+
+	verifiedAt := time.Now()
+	user := &domain.User{ID: uuid.New(), Username: "login", Email: "login@example.com", Role: domain.UserRoleUser, PasswordHash: "hashed:" + testPassword, EmailVerifiedAt: &verifiedAt}
+	repo.users[user.Email] = user
+
+	t.Run("Login", func(t *testing.T) {
+		tokens, err := service.Login(ctxA, user.Email, testPassword, "")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		principal, err := service.Authenticate(ctxA, tokens.AccessToken)
+		if err != nil {
+			t.Fatalf("Expected the access token to authenticate, got: %v", err)
+		}
+		if principal.UserID != user.ID || principal.WorkspaceID != workspaceA {
+			t.Errorf("Unexpected principal %+v", principal)
+		}
+	})
+
+	t.Run("Login_InvalidCredentials", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			email    string
+			password string
+		}{
+			{"WrongPassword", user.Email, "wrong-password"},
+			{"UnknownEmail", "unknown@example.com", testPassword},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if _, err := service.Login(ctxA, tt.email, tt.password, ""); !errors.Is(err, core.ErrInvalidCredentials) {
+					t.Errorf("Expected ErrInvalidCredentials, got: %v", err)
+				}
+			})
+		}
+	})
+
+	t.Run("Login_ComparesUnknownEmails", func(t *testing.T) {
+		hasher := &countingPasswordHasher{}
+		service := NewAuthService(repo, hasher, &mockTokenIssuer{}, twoFactor)
+
+		for _, email := range []string{user.Email, "unknown@example.com"} {
+			if _, err := service.Login(ctxA, email, "wrong-password", ""); !errors.Is(err, core.ErrInvalidCredentials) {
+				t.Fatalf("Expected ErrInvalidCredentials, got: %v", err)
+			}
+		}
+
+		if hasher.compared != 2 {
+			t.Errorf("Expected a password comparison per login, got: %d", hasher.compared)
+		}
+	})
+
+	t.Run("Login_WithoutPassword", func(t *testing.T) {
+		legacy := &domain.User{ID: uuid.New(), Email: "legacy@example.com"}
+		repo.users[legacy.Email] = legacy
+
+		if _, err := service.Login(ctxA, legacy.Email, "", ""); !errors.Is(err, core.ErrInvalidCredentials) {
+			t.Errorf("Expected ErrInvalidCredentials, got: %v", err)
+		}
+	})
+
+	t.Run("Login_EmailNotConfirmed", func(t *testing.T) {
+		unconfirmed := &domain.User{ID: uuid.New(), Email: "unconfirmed@example.com", PasswordHash: "hashed:" + testPassword}
+		repo.users[unconfirmed.Email] = unconfirmed
+
+		if _, err := service.Login(ctxA, unconfirmed.Email, testPassword, ""); !errors.Is(err, core.ErrEmailNotConfirmed) {
+			t.Errorf("Expected ErrEmailNotConfirmed, got: %v", err)
+		}
+		if _, err := service.Login(ctxA, unconfirmed.Email, "wrong-password", ""); !errors.Is(err, core.ErrInvalidCredentials) {
+			t.Errorf("Expected the password to be checked first, got: %v", err)
+		}
+	})
+
+	t.Run("Login_WithoutWorkspace", func(t *testing.T) {
+		if _, err := service.Login(context.Background(), user.Email, testPassword, ""); !errors.Is(err, core.ErrWorkspaceRequired) {
+			t.Errorf("Expected ErrWorkspaceRequired, got: %v", err)
+		}
+	})
+
+	t.Run("Login_TwoFactor", func(t *testing.T) {
+		now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		twoFactor.now = func() time.Time { return now }
+
+		secured := &domain.User{ID: uuid.New(), Email: "2fa@example.com", Role: domain.UserRoleAdmin, PasswordHash: "hashed:" + testPassword, EmailVerifiedAt: &verifiedAt}
+		repo.users[secured.Email] = secured
+
+		if _, err := twoFactor.Enroll(ctxA, secured.ID); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		// Confirmed with the code of the previous period, as the code of the current
+		// period could not be used again to log in.
+		if _, err := twoFactor.Confirm(ctxA, secured.ID, mockOTPCode("SECRET", now.Add(-30*time.Second))); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		tests := []struct {
+			name     string
+			password string
+			otp      string
+			want     error
+		}{
+			{"MissingCode", testPassword, "", core.ErrTwoFactorRequired},
+			{"WrongCode", testPassword, "000000", core.ErrInvalidOTP},
+			{"WrongPasswordFirst", "wrong-password", mockOTPCode("SECRET", now), core.ErrInvalidCredentials},
+			{"ValidCode", testPassword, mockOTPCode("SECRET", now), nil},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if _, err := service.Login(ctxA, secured.Email, tt.password, tt.otp); !errors.Is(err, tt.want) {
+					t.Errorf("Expected %v, got: %v", tt.want, err)
+				}
+			})
+		}
+	})
+
+	t.Run("Authenticate_OtherWorkspace", func(t *testing.T) {
+		tokens, _ := service.Login(ctxA, user.Email, testPassword, "")
+
+		if _, err := service.Authenticate(ctxB, tokens.AccessToken); !errors.Is(err, core.ErrInvalidToken) {
+			t.Errorf("Expected ErrInvalidToken, got: %v", err)
+		}
+	})
+
+	t.Run("Authenticate_RefreshToken", func(t *testing.T) {
+		tokens, _ := service.Login(ctxA, user.Email, testPassword, "")
+
+		if _, err := service.Authenticate(ctxA, tokens.RefreshToken); !errors.Is(err, core.ErrInvalidToken) {
+			t.Errorf("Expected ErrInvalidToken, got: %v", err)
+		}
+	})
+
+	t.Run("Refresh", func(t *testing.T) {
+		tokens, _ := service.Login(ctxA, user.Email, testPassword, "")
+		user.Role = domain.UserRoleAdmin
+		defer func() { user.Role = domain.UserRoleUser }()
+
+		refreshed, err := service.Refresh(ctxA, tokens.RefreshToken)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		principal, err := service.Authenticate(ctxA, refreshed.AccessToken)
+		if err != nil || !principal.IsAdmin() {
+			t.Errorf("Expected the refreshed token to carry the new role, got %+v (%v)", principal, err)
+		}
+	})
+
+	t.Run("Refresh_DeletedUser", func(t *testing.T) {
+		tokens, _ := service.Login(ctxA, user.Email, testPassword, "")
+		delete(repo.users, user.Email)
+		defer func() { repo.users[user.Email] = user }()
+
+		if _, err := service.Refresh(ctxA, tokens.RefreshToken); !errors.Is(err, core.ErrInvalidToken) {
+			t.Errorf("Expected ErrInvalidToken, got: %v", err)
+		}
+	})
+}
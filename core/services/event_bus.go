@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
+	"github.com/google/uuid"
+)
+
+// EventBus is the in-process EventPublisher streaming the events of the services to
+// the clients of their recipients. It keeps the last events published in a bounded
+// replay buffer, so a client reconnecting with the ID of the last event it received
+// gets the events it missed.
+//
+// Publishing never waits for the subscribers: each one buffers a bounded number of
+// events, and a subscriber falling further behind is dropped, so the client
+// reconnects and resumes from the replay buffer rather than slowing down the
+// requests publishing events.
+type EventBus struct {
+	mu         sync.Mutex
+	lastID     uint64
+	replay     []domain.Event
+	next       int
+	bufferSize int
+	subs       map[*Subscription]struct{}
+	closed     bool
+	now        func() time.Time
+}
+
+// NewEventBus creates a new instance of EventBus keeping the last replaySize events
+// and buffering up to bufferSize events for each subscriber.
+func NewEventBus(replaySize int, bufferSize int) *EventBus {
+	return &EventBus{
+		replay:     make([]domain.Event, 0, replaySize),
+		bufferSize: bufferSize,
+		subs:       map[*Subscription]struct{}{},
+		now:        time.Now,
+	}
+}
+
+// Subscription is the stream of the events of a user in a workspace. Replay holds
+// the events missed since the event the subscription resumes after, and Events
+// delivers the following ones until it is closed: by Close, when the subscriber
+// falls too far behind or when the bus is closed, as reported by Err.
+//
+// Reset is set when the subscription cannot resume: the events following the last
+// one the client received left the replay buffer or were published before a
+// restart. The client should then reload the state it follows.
+type Subscription struct {
+	UserID      uuid.UUID
+	WorkspaceID uuid.UUID
+	Replay      []domain.Event
+	Reset       bool
+	Events      <-chan domain.Event
+
+	events chan domain.Event
+	err    error
+	bus    *EventBus
+}
+
+// Err returns why Events was closed: core.ErrEventSubscriberTooSlow when the
+// subscriber fell too far behind, core.ErrEventBusClosed when the bus was closed,
+// and nil otherwise.
+func (s *Subscription) Err() error {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	return s.err
+}
+
+// Close ends the subscription and closes Events.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	s.bus.drop(s, nil)
+}
+
+// Subscribe opens the stream of the events of userID in the workspace carried by
+// ctx. With a lastEventID other than 0, the events published after it are replayed
+// first. It returns core.ErrEventBusClosed once the bus is closed.
+func (b *EventBus) Subscribe(ctx context.Context, userID uuid.UUID, lastEventID uint64) (*Subscription, error) {
+	workspaceID, err := tenant.WorkspaceID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, core.ErrEventBusClosed
+	}
+
+	events := make(chan domain.Event, b.bufferSize)
+	sub := &Subscription{UserID: userID, WorkspaceID: workspaceID, Events: events, events: events, bus: b}
+
+	if lastEventID != 0 {
+		sub.Replay, sub.Reset = b.since(lastEventID, userID, workspaceID)
+	}
+
+	b.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// Publish assigns the next ID to the event, keeps it in the replay buffer and
+// delivers it to the subscribers it is for. Subscribers whose buffer is full are
+// dropped. Events published once the bus is closed are discarded.
+func (b *EventBus) Publish(_ context.Context, event domain.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.lastID++
+	event.ID = b.lastID
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = b.now()
+	}
+	b.keep(event)
+
+	for sub := range b.subs {
+		if !event.IsFor(sub.UserID, sub.WorkspaceID) {
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+		default:
+			b.drop(sub, core.ErrEventSubscriberTooSlow)
+		}
+	}
+}
+
+// Close closes the subscriptions, whose Err then reports core.ErrEventBusClosed,
+// and discards the events published afterwards. It lets the streams end when the
+// server shuts down.
+func (b *EventBus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subs {
+		b.drop(sub, core.ErrEventBusClosed)
+	}
+}
+
+// keep adds the event to the replay buffer, replacing the oldest one once full.
+func (b *EventBus) keep(event domain.Event) {
+	switch {
+	case cap(b.replay) == 0:
+	case len(b.replay) < cap(b.replay):
+		b.replay = append(b.replay, event)
+	default:
+		b.replay[b.next] = event
+		b.next = (b.next + 1) % len(b.replay)
+	}
+}
+
+// since returns the events of the replay buffer published after lastEventID for the
+// user in the workspace, and whether some of them are missing.
+func (b *EventBus) since(lastEventID uint64, userID uuid.UUID, workspaceID uuid.UUID) ([]domain.Event, bool) {
+	oldest := b.lastID + 1
+	if len(b.replay) > 0 {
+		oldest = b.replay[b.next].ID
+	}
+
+	if lastEventID > b.lastID || lastEventID+1 < oldest {
+		return nil, true
+	}
+
+	var events []domain.Event
+	for i := range b.replay {
+		event := b.replay[(b.next+i)%len(b.replay)]
+		if event.ID > lastEventID && event.IsFor(userID, workspaceID) {
+			events = append(events, event)
+		}
+	}
+
+	return events, false
+}
+
+// drop removes the subscription and closes its events, recording err as the reason.
+// The caller holds the lock.
+func (b *EventBus) drop(sub *Subscription, err error) {
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+
+	delete(b.subs, sub)
+	sub.err = err
+	close(sub.events)
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
+	"github.com/google/uuid"
+)
+
+// eventIDs returns the IDs of the events in order.
+func eventIDs(events []domain.Event) []uint64 {
+	ids := make([]uint64, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+	return ids
+}
+
+func TestEventBus(t *testing.T) {
+	workspaceID := uuid.New()
+	ctx := tenant.WithWorkspace(context.Background(), workspaceID)
+	userID := uuid.New()
+
+	event := func(workspaceID uuid.UUID, recipients ...uuid.UUID) domain.Event {
+		return domain.Event{Type: domain.EventTaskCreated, WorkspaceID: workspaceID, Recipients: recipients}
+	}
+
+	t.Run("Deliver", func(t *testing.T) {
+		bus := NewEventBus(10, 10)
+		sub, err := bus.Subscribe(ctx, userID, 0)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		defer sub.Close()
+
+		bus.Publish(ctx, event(workspaceID, uuid.New()))
+		bus.Publish(ctx, event(uuid.New(), userID))
+		bus.Publish(ctx, event(workspaceID, uuid.New(), userID))
+
+		got := <-sub.Events
+		if got.ID != 3 || got.OccurredAt.IsZero() {
+			t.Errorf("Expected the third event, stamped, got %+v", got)
+		}
+		if len(sub.Events) != 0 {
+			t.Errorf("Expected the events of other users and workspaces not to be delivered, got %d more", len(sub.Events))
+		}
+	})
+
+	t.Run("Replay", func(t *testing.T) {
+		bus := NewEventBus(3, 10)
+		for range 5 {
+			bus.Publish(ctx, event(workspaceID, userID))
+		}
+
+		sub, err := bus.Subscribe(ctx, userID, 3)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		defer sub.Close()
+
+		if got := eventIDs(sub.Replay); sub.Reset || len(got) != 2 || got[0] != 4 || got[1] != 5 {
+			t.Errorf("Expected events 4 and 5 to be replayed, got %v (reset: %v)", got, sub.Reset)
+		}
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		bus := NewEventBus(3, 10)
+		for range 5 {
+			bus.Publish(ctx, event(workspaceID, userID))
+		}
+
+		// Event 2 left the buffer, and event 9 was published before a restart.
+		for _, lastEventID := range []uint64{1, 9} {
+			sub, err := bus.Subscribe(ctx, userID, lastEventID)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if !sub.Reset || len(sub.Replay) != 0 {
+				t.Errorf("Expected the subscription after %d to be reset, got %v", lastEventID, eventIDs(sub.Replay))
+			}
+			sub.Close()
+		}
+	})
+
+	t.Run("SlowSubscriber", func(t *testing.T) {
+		bus := NewEventBus(10, 2)
+		slow, _ := bus.Subscribe(ctx, userID, 0)
+		other, _ := bus.Subscribe(ctx, uuid.New(), 0)
+		defer other.Close()
+
+		for range 3 {
+			bus.Publish(ctx, event(workspaceID, userID))
+		}
+
+		if len(slow.Events) != 2 {
+			t.Errorf("Expected the buffered events to be kept, got %d", len(slow.Events))
+		}
+		for range slow.Events {
+		}
+		if !errors.Is(slow.Err(), core.ErrEventSubscriberTooSlow) {
+			t.Errorf("Expected ErrEventSubscriberTooSlow, got: %v", slow.Err())
+		}
+		if other.Err() != nil {
+			t.Errorf("Expected the other subscriber to be kept, got: %v", other.Err())
+		}
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		bus := NewEventBus(10, 10)
+		sub, _ := bus.Subscribe(ctx, userID, 0)
+
+		bus.Close()
+		bus.Publish(ctx, event(workspaceID, userID))
+
+		if _, ok := <-sub.Events; ok || !errors.Is(sub.Err(), core.ErrEventBusClosed) {
+			t.Errorf("Expected the subscription to end with ErrEventBusClosed, got: %v", sub.Err())
+		}
+		if _, err := bus.Subscribe(ctx, userID, 0); !errors.Is(err, core.ErrEventBusClosed) {
+			t.Errorf("Expected ErrEventBusClosed, got: %v", err)
+		}
+		sub.Close()
+	})
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+)
+
+// HealthService reports whether the application is alive and ready to serve
+// requests. Liveness only tells the process still answers, while readiness runs
+// every HealthChecker concurrently, each bounded by the check timeout, and fails
+// as soon as the application starts shutting down so no new traffic is routed to it.
+type HealthService struct {
+	checkers     []ports.HealthChecker
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+// NewHealthService creates a new instance of HealthService running the provided
+// HealthCheckers, each given at most timeout to complete.
+func NewHealthService(timeout time.Duration, checkers ...ports.HealthChecker) *HealthService {
+	return &HealthService{checkers: checkers, timeout: timeout}
+}
+
+// Live reports the application as up. It checks no dependency, so an unavailable
+// database makes the application unready but does not get it restarted.
+func (h *HealthService) Live(ctx context.Context) *domain.HealthReport {
+	return &domain.HealthReport{Status: domain.HealthStatusUp, ShuttingDown: h.shuttingDown.Load()}
+}
+
+// Ready checks every dependency and reports the application as up only when all of
+// them are and the application is not shutting down.
+func (h *HealthService) Ready(ctx context.Context) *domain.HealthReport {
+	report := &domain.HealthReport{
+		Status:       domain.HealthStatusUp,
+		ShuttingDown: h.shuttingDown.Load(),
+		Checks:       make(map[string]domain.DependencyHealth, len(h.checkers)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	for _, checker := range h.checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result := h.check(ctx, checker)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[checker.Name()] = result
+			if result.Status != domain.HealthStatusUp {
+				report.Status = domain.HealthStatusDown
+			}
+		}()
+	}
+	wg.Wait()
+
+	if report.ShuttingDown {
+		report.Status = domain.HealthStatusDown
+	}
+
+	return report
+}
+
+// BeginShutdown makes the application unready for good. It is called when the
+// application receives a termination signal, before it stops accepting requests.
+func (h *HealthService) BeginShutdown() {
+	h.shuttingDown.Store(true)
+}
+
+// check runs a single checker, bounded by the check timeout.
+func (h *HealthService) check(ctx context.Context, checker ports.HealthChecker) domain.DependencyHealth {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := checker.Check(ctx)
+	if err == nil && ctx.Err() != nil {
+		err = core.ErrHealthCheckTimeout
+	}
+
+	result := domain.DependencyHealth{Status: domain.HealthStatusUp, Latency: time.Since(start)}
+	if err != nil {
+		result.Status = domain.HealthStatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
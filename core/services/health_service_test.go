@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+)
+
+// mockHealthChecker is a mock implementation of HealthChecker returning err, or
+// blocking until the context is done when block is set.
+type mockHealthChecker struct {
+	name  string
+	err   error
+	block bool
+}
+
+func (m *mockHealthChecker) Name() string {
+	return m.name
+}
+
+func (m *mockHealthChecker) Check(ctx context.Context) error {
+	if m.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return m.err
+}
+
+func TestHealthService(t *testing.T) {
+	t.Run("Ready", func(t *testing.T) {
+		service := NewHealthService(time.Second, &mockHealthChecker{name: "database"}, &mockHealthChecker{name: "migrations"})
+
+		report := service.Ready(context.Background())
+		if report.Status != domain.HealthStatusUp || len(report.Checks) != 2 {
+			t.Errorf("Expected the application and 2 dependencies up, got %+v", report)
+		}
+	})
+
+	t.Run("Ready_DependencyDown", func(t *testing.T) {
+		service := NewHealthService(time.Second, &mockHealthChecker{name: "database"}, &mockHealthChecker{name: "migrations", err: core.ErrMigrationsPending})
+
+		report := service.Ready(context.Background())
+		if report.Status != domain.HealthStatusDown {
+			t.Errorf("Expected the application down, got %s", report.Status)
+		}
+		if check := report.Checks["migrations"]; check.Status != domain.HealthStatusDown || check.Error != core.ErrMigrationsPending.Error() {
+			t.Errorf("Unexpected migrations check %+v", check)
+		}
+		if check := report.Checks["database"]; check.Status != domain.HealthStatusUp {
+			t.Errorf("Expected the database up, got %+v", check)
+		}
+	})
+
+	t.Run("Ready_Timeout", func(t *testing.T) {
+		service := NewHealthService(10*time.Millisecond, &mockHealthChecker{name: "database", block: true})
+
+		report := service.Ready(context.Background())
+		check := report.Checks["database"]
+		if report.Status != domain.HealthStatusDown || check.Error != context.DeadlineExceeded.Error() {
+			t.Errorf("Expected the slow dependency down, got %+v", report)
+		}
+	})
+
+	t.Run("BeginShutdown", func(t *testing.T) {
+		service := NewHealthService(time.Second, &mockHealthChecker{name: "database"})
+		service.BeginShutdown()
+
+		if report := service.Ready(context.Background()); report.Status != domain.HealthStatusDown || !report.ShuttingDown {
+			t.Errorf("Expected the application unready while shutting down, got %+v", report)
+		}
+		if report := service.Live(context.Background()); report.Status != domain.HealthStatusUp {
+			t.Errorf("Expected the application alive while shutting down, got %+v", report)
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+	"github.com/fabianoflorentino/gotostudy/core/tracing"
+	"github.com/google/uuid"
+)
+
+// MaxIdempotencyKeyLength bounds the length of an idempotency key.
+const MaxIdempotencyKeyLength = 255
+
+// IdempotencyService lets clients retry a request safely: the first request sent
+// with an idempotency key is recorded, and its response is replayed to the retries
+// sent with the same key until the key expires. Keys are scoped to the user
+// sending the request. A request in progress holds its key for a short lease, so
+// the key is released even when the request never completes, as when the instance
+// processing it crashes. Each public method is traced as a span named after the method.
+type IdempotencyService struct {
+	store ports.IdempotencyStore
+	ttl   time.Duration
+	lease time.Duration
+	now   func() time.Time
+}
+
+// NewIdempotencyService creates a new instance of IdempotencyService recording the
+// requests in store. A request in progress expires after lease, a completed one
+// after ttl.
+func NewIdempotencyService(s ports.IdempotencyStore, ttl, lease time.Duration) *IdempotencyService {
+	return &IdempotencyService{store: s, ttl: ttl, lease: lease, now: time.Now}
+}
+
+// Begin starts the request of userID sent with key, identified by fingerprint. It
+// returns nil when the request is new and must be processed, then completed with
+// Complete or abandoned with Abandon before its lease expires, and the recorded
+// response when the request is a retry of a completed one.
+//
+// It returns core.ErrInvalidIdempotencyKey for a key that is empty or too long,
+// core.ErrIdempotencyKeyReused when the key was sent with a different request and
+// core.ErrIdempotencyKeyInProgress while the first request is still processed.
+func (i *IdempotencyService) Begin(ctx context.Context, userID uuid.UUID, key, fingerprint string) (*domain.IdempotentResponse, error) {
+	ctx, span := tracing.Start(ctx, "IdempotencyService.Begin")
+	defer span.End()
+
+	if key == "" || len(key) > MaxIdempotencyKeyLength {
+		return nil, core.ErrInvalidIdempotencyKey
+	}
+
+	now := i.now()
+	request := &domain.IdempotentRequest{
+		Key:         key,
+		UserID:      userID,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(i.lease),
+	}
+
+	// The recorded request may be abandoned between Create and Find, so the
+	// request is recorded again once before giving up.
+	for range 2 {
+		err := i.store.Create(ctx, request)
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, core.ErrIdempotencyKeyExists) {
+			return nil, err
+		}
+
+		recorded, err := i.store.Find(ctx, userID, key)
+		if errors.Is(err, core.ErrIdempotencyKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case recorded.Fingerprint != fingerprint:
+			return nil, core.ErrIdempotencyKeyReused
+		case recorded.Response == nil:
+			return nil, core.ErrIdempotencyKeyInProgress
+		default:
+			return recorded.Response, nil
+		}
+	}
+
+	return nil, core.ErrIdempotencyKeyInProgress
+}
+
+// Complete records the response of the request of userID sent with key, which is
+// replayed to its retries until the key expires.
+func (i *IdempotencyService) Complete(ctx context.Context, userID uuid.UUID, key string, response *domain.IdempotentResponse) error {
+	ctx, span := tracing.Start(ctx, "IdempotencyService.Complete")
+	defer span.End()
+
+	return i.store.Complete(ctx, userID, key, response, i.now().Add(i.ttl))
+}
+
+// Abandon forgets the request of userID sent with key, which failed without a
+// response worth replaying, so it can be retried with the same key.
+func (i *IdempotencyService) Abandon(ctx context.Context, userID uuid.UUID, key string) error {
+	ctx, span := tracing.Start(ctx, "IdempotencyService.Abandon")
+	defer span.End()
+
+	if err := i.store.Delete(ctx, userID, key); err != nil && !errors.Is(err, core.ErrIdempotencyKeyNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+// PurgeExpired removes the expired requests and returns how many were removed.
+func (i *IdempotencyService) PurgeExpired(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "IdempotencyService.PurgeExpired")
+	defer span.End()
+
+	return i.store.DeleteExpired(ctx, i.now())
+}
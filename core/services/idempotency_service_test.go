@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/google/uuid"
+)
+
+type idempotencyKey struct {
+	userID uuid.UUID
+	key    string
+}
+
+// mockIdempotencyStore is a mock implementation of IdempotencyStore keeping the
+// requests in memory.
+type mockIdempotencyStore struct {
+	requests map[idempotencyKey]domain.IdempotentRequest
+}
+
+func newMockIdempotencyStore() *mockIdempotencyStore {
+	return &mockIdempotencyStore{requests: make(map[idempotencyKey]domain.IdempotentRequest)}
+}
+
+func (m *mockIdempotencyStore) Create(ctx context.Context, request *domain.IdempotentRequest) error {
+	id := idempotencyKey{request.UserID, request.Key}
+	if recorded, ok := m.requests[id]; ok && recorded.ExpiresAt.After(request.CreatedAt) {
+		return core.ErrIdempotencyKeyExists
+	}
+
+	m.requests[id] = *request
+	return nil
+}
+
+func (m *mockIdempotencyStore) Find(ctx context.Context, userID uuid.UUID, key string) (*domain.IdempotentRequest, error) {
+	recorded, ok := m.requests[idempotencyKey{userID, key}]
+	if !ok {
+		return nil, core.ErrIdempotencyKeyNotFound
+	}
+
+	return &recorded, nil
+}
+
+func (m *mockIdempotencyStore) Complete(ctx context.Context, userID uuid.UUID, key string, response *domain.IdempotentResponse, expiresAt time.Time) error {
+	id := idempotencyKey{userID, key}
+	recorded, ok := m.requests[id]
+	if !ok {
+		return core.ErrIdempotencyKeyNotFound
+	}
+
+	recorded.Response = response
+	recorded.ExpiresAt = expiresAt
+	m.requests[id] = recorded
+	return nil
+}
+
+func (m *mockIdempotencyStore) Delete(ctx context.Context, userID uuid.UUID, key string) error {
+	id := idempotencyKey{userID, key}
+	if _, ok := m.requests[id]; !ok {
+		return core.ErrIdempotencyKeyNotFound
+	}
+
+	delete(m.requests, id)
+	return nil
+}
+
+func (m *mockIdempotencyStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	var deleted int64
+	for id, recorded := range m.requests {
+		if !recorded.ExpiresAt.After(now) {
+			delete(m.requests, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+func TestIdempotencyService(t *testing.T) {
+	ctx := context.Background()
+	store := newMockIdempotencyStore()
+	service := NewIdempotencyService(store, 24*time.Hour, time.Minute)
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	userID := uuid.New()
+	response := &domain.IdempotentResponse{Status: 201, Body: []byte(`{"id":"42"}`)}
+
+	t.Run("InvalidKey", func(t *testing.T) {
+		for _, key := range []string{"", strings.Repeat("k", MaxIdempotencyKeyLength+1)} {
+			if _, err := service.Begin(ctx, userID, key, "POST /users"); !errors.Is(err, core.ErrInvalidIdempotencyKey) {
+				t.Errorf("Begin(%d characters) error = %v, want ErrInvalidIdempotencyKey", len(key), err)
+			}
+		}
+	})
+
+	t.Run("Replay", func(t *testing.T) {
+		replay, err := service.Begin(ctx, userID, "replay", "POST /users")
+		if err != nil || replay != nil {
+			t.Fatalf("Begin() = %v, %v, want a new request", replay, err)
+		}
+
+		if _, err := service.Begin(ctx, userID, "replay", "POST /users"); !errors.Is(err, core.ErrIdempotencyKeyInProgress) {
+			t.Errorf("Begin() in progress error = %v, want ErrIdempotencyKeyInProgress", err)
+		}
+
+		if err := service.Complete(ctx, userID, "replay", response); err != nil {
+			t.Fatalf("Complete() error = %v", err)
+		}
+
+		replay, err = service.Begin(ctx, userID, "replay", "POST /users")
+		if err != nil || replay == nil || replay.Status != 201 || string(replay.Body) != `{"id":"42"}` {
+			t.Errorf("Begin() = %+v, %v, want the recorded response", replay, err)
+		}
+	})
+
+	t.Run("Reused", func(t *testing.T) {
+		if _, err := service.Begin(ctx, userID, "replay", "POST /users/42/tasks"); !errors.Is(err, core.ErrIdempotencyKeyReused) {
+			t.Errorf("Begin() error = %v, want ErrIdempotencyKeyReused", err)
+		}
+	})
+
+	t.Run("ScopedToUser", func(t *testing.T) {
+		replay, err := service.Begin(ctx, uuid.New(), "replay", "POST /users")
+		if err != nil || replay != nil {
+			t.Errorf("Begin() = %v, %v, want a new request for another user", replay, err)
+		}
+	})
+
+	t.Run("Abandon", func(t *testing.T) {
+		if _, err := service.Begin(ctx, userID, "abandon", "POST /users"); err != nil {
+			t.Fatalf("Begin() error = %v", err)
+		}
+		if err := service.Abandon(ctx, userID, "abandon"); err != nil {
+			t.Fatalf("Abandon() error = %v", err)
+		}
+		if err := service.Abandon(ctx, userID, "abandon"); err != nil {
+			t.Errorf("Abandon() twice error = %v", err)
+		}
+
+		replay, err := service.Begin(ctx, userID, "abandon", "POST /users/42/tasks")
+		if err != nil || replay != nil {
+			t.Errorf("Begin() = %v, %v, want a new request", replay, err)
+		}
+	})
+
+	t.Run("LeaseExpiration", func(t *testing.T) {
+		if _, err := service.Begin(ctx, userID, "crashed", "POST /users"); err != nil {
+			t.Fatalf("Begin() error = %v", err)
+		}
+
+		now = now.Add(30 * time.Second)
+		if _, err := service.Begin(ctx, userID, "crashed", "POST /users"); !errors.Is(err, core.ErrIdempotencyKeyInProgress) {
+			t.Errorf("Begin() within the lease error = %v, want ErrIdempotencyKeyInProgress", err)
+		}
+
+		now = now.Add(30 * time.Second)
+		replay, err := service.Begin(ctx, userID, "crashed", "POST /users")
+		if err != nil || replay != nil {
+			t.Errorf("Begin() = %v, %v, want the key released once the lease expired", replay, err)
+		}
+		if err := service.Abandon(ctx, userID, "crashed"); err != nil {
+			t.Fatalf("Abandon() error = %v", err)
+		}
+	})
+
+	t.Run("Expiration", func(t *testing.T) {
+		now = now.Add(24 * time.Hour)
+
+		replay, err := service.Begin(ctx, userID, "replay", "POST /users/42/tasks")
+		if err != nil || replay != nil {
+			t.Fatalf("Begin() = %v, %v, want the expired key reusable", replay, err)
+		}
+
+		now = now.Add(24 * time.Hour)
+		deleted, err := service.PurgeExpired(ctx)
+		if err != nil || deleted != 3 {
+			t.Errorf("PurgeExpired() = %d, %v", deleted, err)
+		}
+		if len(store.requests) != 0 {
+			t.Errorf("requests left = %d, want none", len(store.requests))
+		}
+	})
+}
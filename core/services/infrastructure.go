@@ -0,0 +1,14 @@
+package services
+
+import "github.com/fabianoflorentino/gotostudy/core/ports"
+
+// Infrastructure groups the dependencies shared by the services changing users and
+// tasks: the BusinessMetrics counting the changes, the EventPublisher notifying
+// them, and the Transactor saving them with their domain events, recorded in the
+// Outbox.
+type Infrastructure struct {
+	Metrics ports.BusinessMetrics
+	Events  ports.EventPublisher
+	Tx      ports.Transactor
+	Outbox  ports.Outbox
+}
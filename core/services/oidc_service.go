@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/auth"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/logging"
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
+	"github.com/fabianoflorentino/gotostudy/internal/utils"
+	"github.com/google/uuid"
+)
+
+// twoFactorChallengeTTL is the time a user signing in through the identity provider
+// has to send the second factor.
+const twoFactorChallengeTTL = 5 * time.Minute
+
+// OIDCService signs users in through the corporate OpenID Connect provider.
+// Users are matched by their verified email and provisioned in the workspace
+// of the login on their first sign in, recorded as a domain event in the outbox
+// in the transaction saving them. As on a password login, the users who enabled
+// two-factor authentication must also send their second factor.
+type OIDCService struct {
+	idp       ports.IdentityProvider
+	usr       ports.UserRepository
+	tokens    ports.TokenIssuer
+	signer    ports.ActionTokenSigner
+	twoFactor *TwoFactorService
+	metrics   ports.BusinessMetrics
+	tx        ports.Transactor
+	outbox    ports.Outbox
+}
+
+// NewOIDCService creates a new instance of OIDCService using the provided
+// IdentityProvider, UserRepository and TokenIssuer. The two-factor challenges are
+// signed by the ActionTokenSigner and verified by the TwoFactorService.
+func NewOIDCService(i ports.IdentityProvider, u ports.UserRepository, t ports.TokenIssuer, s ports.ActionTokenSigner, f *TwoFactorService, infra Infrastructure) *OIDCService {
+	return &OIDCService{idp: i, usr: u, tokens: t, signer: s, twoFactor: f, metrics: infra.Metrics, tx: infra.Tx, outbox: infra.Outbox}
+}
+
+// StartLogin begins a login in the workspace carried by ctx. The returned flow holds
+// the URL of the identity provider and the secrets the callback must present again.
+func (o *OIDCService) StartLogin(ctx context.Context) (*auth.LoginFlow, error) {
+	workspaceID, err := tenant.WorkspaceID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	flow := &auth.LoginFlow{WorkspaceID: workspaceID}
+	for _, secret := range []*string{&flow.State, &flow.Nonce, &flow.CodeVerifier} {
+		if *secret, err = randomToken(); err != nil {
+			return nil, err
+		}
+	}
+
+	flow.URL = o.idp.AuthCodeURL(flow.State, flow.Nonce, flow.CodeVerifier)
+
+	return flow, nil
+}
+
+// CompleteLogin handles the callback of the identity provider. The state must match
+// the flow started by StartLogin. The user with the verified email of the identity is
+// signed in, or provisioned when no user uses that email yet, in any workspace, and
+// the email is confirmed as verified by the provider. Users who enabled two-factor
+// authentication get a challenge instead of the tokens, to be completed with
+// CompleteTwoFactor.
+func (o *OIDCService) CompleteLogin(ctx context.Context, flow *auth.LoginFlow, state string, code string) (*auth.TokenPair, *auth.TwoFactorChallenge, error) {
+	if flow == nil || flow.State == "" || subtle.ConstantTimeCompare([]byte(flow.State), []byte(state)) != 1 {
+		return nil, nil, core.ErrInvalidLoginState
+	}
+
+	ctx = tenant.WithWorkspace(ctx, flow.WorkspaceID)
+
+	identity, err := o.idp.Exchange(ctx, code, flow.Nonce, flow.CodeVerifier)
+	if err != nil {
+		logging.FromContext(ctx).Warn("failed to exchange authorization code", "error", err)
+		return nil, nil, core.ErrSingleSignOn
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, nil, core.ErrEmailNotVerified
+	}
+
+	user, err := o.usr.FindByEmail(ctx, identity.Email)
+	if errors.Is(err, core.ErrUserNotFound) {
+		user, err = o.provision(ctx, identity)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The identity provider verified the email, which confirms it for local logins too.
+	if !user.EmailVerified() {
+		if err := o.usr.MarkEmailVerified(ctx, user.ID, time.Now()); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := o.twoFactor.Verify(ctx, user.ID, ""); err != nil {
+		if !errors.Is(err, core.ErrTwoFactorRequired) {
+			return nil, nil, err
+		}
+
+		challenge, err := o.challenge(user.ID, flow.WorkspaceID)
+		return nil, challenge, err
+	}
+
+	tokens, err := o.tokens.Issue(auth.Principal{UserID: user.ID, WorkspaceID: flow.WorkspaceID, Role: user.Role})
+	return tokens, nil, err
+}
+
+// CompleteTwoFactor completes a single sign-on answered with a two-factor challenge,
+// with the TOTP or a recovery code of the user, and returns the tokens of the user.
+// It returns core.ErrInvalidToken when the challenge is invalid or expired,
+// core.ErrTwoFactorRequired when otp is missing and core.ErrInvalidOTP when it is wrong.
+func (o *OIDCService) CompleteTwoFactor(ctx context.Context, challenge string, otp string) (*auth.TokenPair, error) {
+	token, err := o.signer.VerifyAction(challenge, auth.TokenTwoFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = tenant.WithWorkspace(ctx, token.WorkspaceID)
+
+	user, err := o.usr.FindByID(ctx, token.UserID)
+	if err != nil {
+		return nil, core.ErrInvalidToken
+	}
+
+	if err := o.twoFactor.Verify(ctx, user.ID, otp); err != nil {
+		return nil, err
+	}
+
+	return o.tokens.Issue(auth.Principal{UserID: user.ID, WorkspaceID: token.WorkspaceID, Role: user.Role})
+}
+
+// challenge returns a new two-factor challenge of the user signing in to the workspace.
+func (o *OIDCService) challenge(userID uuid.UUID, workspaceID uuid.UUID) (*auth.TwoFactorChallenge, error) {
+	expiresAt := time.Now().Add(twoFactorChallengeTTL)
+
+	token, err := o.signer.SignAction(auth.ActionToken{
+		Kind:        auth.TokenTwoFactor,
+		UserID:      userID,
+		WorkspaceID: workspaceID,
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth.TwoFactorChallenge{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// provision creates the user of an identity signing in for the first time. Such
+// users have no password and can only sign in through the identity provider. It
+// returns core.ErrEmailAlreadyExists when a user of another workspace owns the email.
+func (o *OIDCService) provision(ctx context.Context, identity *auth.Identity) (*domain.User, error) {
+	username := strings.TrimSpace(identity.Username)
+	if username == "" {
+		username = identity.Email
+	}
+
+	// Emails are unique across workspaces: the owner of the email in another
+	// workspace cannot be provisioned again in this one.
+	if inUse, err := utils.IsEmailInUse(o.usr, ctx, identity.Email, uuid.Nil); err != nil {
+		return nil, err
+	} else if inUse {
+		return nil, core.ErrEmailAlreadyExists
+	}
+
+	now := time.Now()
+	user := &domain.User{
+		ID:              uuid.New(),
+		Username:        username,
+		Email:           identity.Email,
+		Role:            domain.UserRoleUser,
+		EmailVerifiedAt: &now,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	err := o.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := o.usr.Save(ctx, user); err != nil {
+			return err
+		}
+		return recordEvents(ctx, o.outbox, domain.UserRegistered{
+			UserID:   user.ID,
+			Username: user.Username,
+			Email:    user.Email,
+			Method:   ports.RegistrationOIDC,
+		})
+	})
+	if errors.Is(err, core.ErrUserAlreadyExists) {
+		return nil, err
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to provision user", "error", err)
+		return nil, core.ErrSaveUser
+	}
+
+	o.metrics.UserRegistered(ctx, ports.RegistrationOIDC)
+
+	return user, nil
+}
+
+// randomToken returns 32 random bytes encoded for use in URLs, suitable for the
+// state, the nonce and the PKCE code verifier.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/auth"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
+	"github.com/google/uuid"
+)
+
+// mockIdentityProvider is a mock implementation of IdentityProvider returning
+// identity for the code "valid" exchanged with the nonce and verifier it was given.
+type mockIdentityProvider struct {
+	identity     auth.Identity
+	nonce        string
+	codeVerifier string
+}
+
+func (m *mockIdentityProvider) AuthCodeURL(state string, nonce string, codeVerifier string) string {
+	m.nonce, m.codeVerifier = nonce, codeVerifier
+
+	return "https://idp.example.com/authorize?state=" + url.QueryEscape(state)
+}
+
+func (m *mockIdentityProvider) Exchange(ctx context.Context, code string, nonce string, codeVerifier string) (*auth.Identity, error) {
+	if code != "valid" || nonce != m.nonce || codeVerifier != m.codeVerifier {
+		return nil, errors.New("invalid_grant")
+	}
+
+	identity := m.identity
+	return &identity, nil
+}
+
+func TestOIDCService(t *testing.T) {
+	repo := newMockUserRepository()
+	idp := &mockIdentityProvider{}
+	issuer := &mockTokenIssuer{}
+	twoFactor := NewTwoFactorService(newMockTwoFactorRepository(), repo, &mockOTPAuthenticator{}, newMockAuthorizer())
+	service := NewOIDCService(idp, repo, issuer, &mockActionTokenSigner{}, twoFactor, newMockInfrastructure())
+
+	workspaceID := uuid.New()
+	ctx := tenant.WithWorkspace(context.Background(), workspaceID)
+
+	login := func(t *testing.T, identity auth.Identity) (*auth.TokenPair, *auth.TwoFactorChallenge, error) {
+		t.Helper()
+
+		idp.identity = identity
+
+		flow, err := service.StartLogin(ctx)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		return service.CompleteLogin(context.Background(), flow, flow.State, "valid")
+	}
+
+	t.Run("StartLogin", func(t *testing.T) {
+		flow, err := service.StartLogin(ctx)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if flow.WorkspaceID != workspaceID {
+			t.Errorf("Expected workspace %s, got %s", workspaceID, flow.WorkspaceID)
+		}
+		if flow.State == "" || flow.Nonce == "" || len(flow.CodeVerifier) < 43 {
+			t.Errorf("Expected random state, nonce and code verifier, got %+v", flow)
+		}
+		if flow.URL == "" {
+			t.Error("Expected the authorization URL")
+		}
+	})
+
+	t.Run("StartLogin_WithoutWorkspace", func(t *testing.T) {
+		if _, err := service.StartLogin(context.Background()); !errors.Is(err, core.ErrWorkspaceRequired) {
+			t.Errorf("Expected ErrWorkspaceRequired, got: %v", err)
+		}
+	})
+
+	t.Run("CompleteLogin_ExistingUser", func(t *testing.T) {
+		user := &domain.User{ID: uuid.New(), Username: "existing", Email: "existing@example.com", Role: domain.UserRoleAdmin}
+		repo.users[user.Email] = user
+
+		tokens, _, err := login(t, auth.Identity{Subject: "1", Email: user.Email, EmailVerified: true})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		principal, _ := issuer.Verify(tokens.AccessToken, auth.TokenAccess)
+		if principal.UserID != user.ID || principal.WorkspaceID != workspaceID || principal.Role != domain.UserRoleAdmin {
+			t.Errorf("Unexpected principal %+v", principal)
+		}
+		if !user.EmailVerified() {
+			t.Error("Expected the email verified by the provider to be confirmed")
+		}
+	})
+
+	t.Run("CompleteLogin_ProvisionsUser", func(t *testing.T) {
+		tokens, _, err := login(t, auth.Identity{Subject: "2", Email: "new@example.com", EmailVerified: true, Username: "newcomer"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		user, ok := repo.users["new@example.com"]
+		if !ok {
+			t.Fatal("Expected the user to be provisioned")
+		}
+		if user.Username != "newcomer" || user.Role != domain.UserRoleUser || user.PasswordHash != "" || !user.EmailVerified() {
+			t.Errorf("Unexpected provisioned user %+v", user)
+		}
+
+		principal, _ := issuer.Verify(tokens.AccessToken, auth.TokenAccess)
+		if principal.UserID != user.ID {
+			t.Errorf("Expected the token of user %s, got %s", user.ID, principal.UserID)
+		}
+	})
+
+	t.Run("CompleteLogin_ProvisionsUserWithoutUsername", func(t *testing.T) {
+		if _, _, err := login(t, auth.Identity{Subject: "3", Email: "anonymous@example.com", EmailVerified: true}); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if user := repo.users["anonymous@example.com"]; user == nil || user.Username != "anonymous@example.com" {
+			t.Errorf("Expected the email as username, got %+v", user)
+		}
+	})
+
+	t.Run("CompleteLogin_EmailInOtherWorkspace", func(t *testing.T) {
+		repo.others = map[string]*domain.User{"elsewhere@example.com": {ID: uuid.New(), Email: "elsewhere@example.com"}}
+		defer func() { repo.others = nil }()
+
+		_, _, err := login(t, auth.Identity{Subject: "5", Email: "elsewhere@example.com", EmailVerified: true})
+		if !errors.Is(err, core.ErrEmailAlreadyExists) {
+			t.Errorf("Expected ErrEmailAlreadyExists, got: %v", err)
+		}
+
+		if _, ok := repo.users["elsewhere@example.com"]; ok {
+			t.Error("Expected no user to be provisioned")
+		}
+	})
+
+	t.Run("CompleteLogin_TwoFactor", func(t *testing.T) {
+		now := time.Now()
+		twoFactor.now = func() time.Time { return now }
+
+		user := &domain.User{ID: uuid.New(), Username: "secured", Email: "secured@example.com", Role: domain.UserRoleAdmin}
+		repo.users[user.Email] = user
+
+		if _, err := twoFactor.Enroll(ctx, user.ID); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if _, err := twoFactor.Confirm(ctx, user.ID, mockOTPCode("SECRET", now.Add(-30*time.Second))); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		tokens, challenge, err := login(t, auth.Identity{Subject: "5", Email: user.Email, EmailVerified: true})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if tokens != nil || challenge == nil {
+			t.Fatalf("Expected a two-factor challenge instead of tokens, got %+v and %+v", tokens, challenge)
+		}
+
+		tests := []struct {
+			name      string
+			challenge string
+			otp       string
+			want      error
+		}{
+			{"MissingCode", challenge.Token, "", core.ErrTwoFactorRequired},
+			{"WrongCode", challenge.Token, "000000", core.ErrInvalidOTP},
+			{"ForgedChallenge", "forged", mockOTPCode("SECRET", now), core.ErrInvalidToken},
+			{"ValidCode", challenge.Token, mockOTPCode("SECRET", now), nil},
+			{"ReplayedCode", challenge.Token, mockOTPCode("SECRET", now), core.ErrInvalidOTP},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				tokens, err := service.CompleteTwoFactor(context.Background(), tt.challenge, tt.otp)
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("Expected %v, got: %v", tt.want, err)
+				}
+				if err != nil {
+					return
+				}
+
+				principal, _ := issuer.Verify(tokens.AccessToken, auth.TokenAccess)
+				if principal.UserID != user.ID || principal.WorkspaceID != workspaceID || principal.Role != domain.UserRoleAdmin {
+					t.Errorf("Unexpected principal %+v", principal)
+				}
+			})
+		}
+	})
+
+	t.Run("CompleteLogin_UnverifiedEmail", func(t *testing.T) {
+		_, _, err := login(t, auth.Identity{Subject: "4", Email: "unverified@example.com"})
+		if !errors.Is(err, core.ErrEmailNotVerified) {
+			t.Errorf("Expected ErrEmailNotVerified, got: %v", err)
+		}
+
+		if _, ok := repo.users["unverified@example.com"]; ok {
+			t.Error("Expected no user to be provisioned")
+		}
+	})
+
+	t.Run("CompleteLogin_InvalidState", func(t *testing.T) {
+		flow, _ := service.StartLogin(ctx)
+
+		tests := []struct {
+			name  string
+			flow  *auth.LoginFlow
+			state string
+		}{
+			{"WrongState", flow, "forged"},
+			{"EmptyState", &auth.LoginFlow{WorkspaceID: workspaceID}, ""},
+			{"MissingFlow", nil, flow.State},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if _, _, err := service.CompleteLogin(context.Background(), tt.flow, tt.state, "valid"); !errors.Is(err, core.ErrInvalidLoginState) {
+					t.Errorf("Expected ErrInvalidLoginState, got: %v", err)
+				}
+			})
+		}
+	})
+
+	t.Run("CompleteLogin_ExchangeFails", func(t *testing.T) {
+		flow, _ := service.StartLogin(ctx)
+
+		if _, _, err := service.CompleteLogin(context.Background(), flow, flow.State, "invalid"); !errors.Is(err, core.ErrSingleSignOn) {
+			t.Errorf("Expected ErrSingleSignOn, got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/logging"
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
+	"github.com/fabianoflorentino/gotostudy/core/tracing"
+	"github.com/google/uuid"
+)
+
+// OutboxRelay delivers the domain events recorded in the outbox to the sinks of the
+// integrations. An event is delivered at least once to every sink: it stays in the
+// outbox until all of them received it, and a failed delivery is retried after a
+// backoff doubling with each attempt, with no limit on the attempts. Only the events
+// of committed state changes reach the outbox, so the sinks never get an event
+// whose change was rolled back. The events are claimed in a short transaction and
+// delivered outside of it, so slow sinks hold no database locks.
+type OutboxRelay struct {
+	outbox     ports.Outbox
+	tx         ports.Transactor
+	sinks      []ports.EventSink
+	batchSize  int
+	lease      time.Duration
+	backoff    time.Duration
+	maxBackoff time.Duration
+	now        func() time.Time
+}
+
+// NewOutboxRelay creates a new instance of OutboxRelay delivering the events of the
+// Outbox to the sinks, batchSize events claimed per transaction of the Transactor.
+// The claimed events are left to the other relays for lease; the ones whose delivery
+// did not end by then, as when the relay stopped, are delivered again. A failed
+// delivery is retried after backoff, doubled on each attempt up to maxBackoff.
+func NewOutboxRelay(o ports.Outbox, tx ports.Transactor, sinks []ports.EventSink, batchSize int, lease time.Duration, backoff time.Duration, maxBackoff time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		outbox:     o,
+		tx:         tx,
+		sinks:      sinks,
+		batchSize:  batchSize,
+		lease:      lease,
+		backoff:    backoff,
+		maxBackoff: maxBackoff,
+		now:        time.Now,
+	}
+}
+
+// Relay delivers the pending events in the order they were recorded, batch by
+// batch until none is due, and returns how many were delivered. Each batch is
+// claimed in a transaction, so the relays of several instances deliver different
+// events. When ctx is done during a batch, its undelivered events are delivered
+// again once their claim expires.
+func (r *OutboxRelay) Relay(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "OutboxRelay.Relay")
+	defer span.End()
+
+	delivered := 0
+	for {
+		n, due, err := r.relayBatch(ctx)
+		delivered += n
+		if err != nil {
+			return delivered, err
+		}
+
+		if due < r.batchSize {
+			return delivered, nil
+		}
+	}
+}
+
+// PurgeDelivered removes the events delivered more than retention ago and returns
+// how many.
+func (r *OutboxRelay) PurgeDelivered(ctx context.Context, retention time.Duration) (int64, error) {
+	ctx, span := tracing.Start(ctx, "OutboxRelay.PurgeDelivered")
+	defer span.End()
+
+	return r.outbox.DeleteDelivered(ctx, r.now().Add(-retention))
+}
+
+// relayBatch claims a batch of pending events, delivers them and returns how many
+// were delivered and how many were due.
+func (r *OutboxRelay) relayBatch(ctx context.Context) (int, int, error) {
+	var events []domain.DomainEvent
+
+	err := r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		now := r.now()
+
+		var err error
+		events, err = r.outbox.Claim(ctx, now, now.Add(r.lease), r.batchSize)
+		return err
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var ids []uuid.UUID
+	for _, event := range events {
+		if err := r.deliver(ctx, event); err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+
+			next := r.now().Add(r.retryAfter(event.Attempts + 1))
+			logging.FromContext(ctx).Warn("failed to deliver domain event",
+				"event_id", event.ID, "type", event.Type, "attempt", event.Attempts+1, "retry_at", next, "error", err)
+
+			if err := r.outbox.MarkFailed(ctx, event.ID, next, err.Error()); err != nil {
+				return 0, 0, err
+			}
+			continue
+		}
+
+		ids = append(ids, event.ID)
+	}
+
+	// The delivered events are recorded even when ctx is done, so they are not
+	// delivered again.
+	if len(ids) > 0 {
+		if err := r.outbox.MarkDelivered(context.WithoutCancel(ctx), ids, r.now()); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return len(ids), len(events), ctx.Err()
+}
+
+// deliver delivers the event to every sink, stopping at the first failure.
+func (r *OutboxRelay) deliver(ctx context.Context, event domain.DomainEvent) error {
+	for _, sink := range r.sinks {
+		if err := sink.Deliver(ctx, event); err != nil {
+			return fmt.Errorf("%s: %w", sink.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// retryAfter returns how long to wait before the attempt following the given
+// failed attempt.
+func (r *OutboxRelay) retryAfter(attempt int) time.Duration {
+	wait := r.backoff
+	for i := 1; i < attempt && wait < r.maxBackoff; i++ {
+		wait *= 2
+	}
+
+	return min(wait, r.maxBackoff)
+}
+
+// recordEvents appends the domain events with the payloads in data to the outbox,
+// in the workspace and the transaction carried by ctx.
+func recordEvents(ctx context.Context, outbox ports.Outbox, data ...domain.DomainEventData) error {
+	workspaceID, _ := tenant.WorkspaceID(ctx)
+
+	events := make([]domain.DomainEvent, len(data))
+	for i, d := range data {
+		event, err := domain.NewDomainEvent(workspaceID, d)
+		if err != nil {
+			return err
+		}
+		events[i] = event
+	}
+
+	return outbox.Append(ctx, events...)
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+	"github.com/google/uuid"
+)
+
+// mockOutboxEntry is an event of mockOutbox with its delivery state.
+type mockOutboxEntry struct {
+	event       domain.DomainEvent
+	next        time.Time
+	cause       string
+	deliveredAt *time.Time
+}
+
+// mockOutbox is a mock implementation of Outbox keeping the events in memory.
+type mockOutbox struct {
+	events []*mockOutboxEntry
+}
+
+func newMockOutbox() *mockOutbox {
+	return &mockOutbox{}
+}
+
+func (m *mockOutbox) Append(ctx context.Context, events ...domain.DomainEvent) error {
+	for _, event := range events {
+		m.events = append(m.events, &mockOutboxEntry{event: event, next: event.OccurredAt})
+	}
+	return nil
+}
+
+func (m *mockOutbox) Claim(ctx context.Context, now time.Time, until time.Time, limit int) ([]domain.DomainEvent, error) {
+	var events []domain.DomainEvent
+	for _, entry := range m.events {
+		if entry.deliveredAt == nil && !entry.next.After(now) && len(events) < limit {
+			entry.next = until
+			events = append(events, entry.event)
+		}
+	}
+	return events, nil
+}
+
+func (m *mockOutbox) MarkDelivered(ctx context.Context, ids []uuid.UUID, at time.Time) error {
+	for _, entry := range m.events {
+		if slices.Contains(ids, entry.event.ID) {
+			entry.deliveredAt = &at
+		}
+	}
+	return nil
+}
+
+func (m *mockOutbox) MarkFailed(ctx context.Context, id uuid.UUID, next time.Time, cause string) error {
+	for _, entry := range m.events {
+		if entry.event.ID == id {
+			entry.event.Attempts++
+			entry.next, entry.cause = next, cause
+		}
+	}
+	return nil
+}
+
+func (m *mockOutbox) DeleteDelivered(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	m.events = slices.DeleteFunc(m.events, func(entry *mockOutboxEntry) bool {
+		if entry.deliveredAt != nil && entry.deliveredAt.Before(before) {
+			deleted++
+			return true
+		}
+		return false
+	})
+	return deleted, nil
+}
+
+// types returns the types of the recorded events in order.
+func (m *mockOutbox) types() []domain.DomainEventType {
+	types := make([]domain.DomainEventType, len(m.events))
+	for i, entry := range m.events {
+		types[i] = entry.event.Type
+	}
+	return types
+}
+
+// mockEventSink is a mock implementation of EventSink recording the IDs of the
+// delivered events, failing while fail is set. It calls onDeliver, when set, on
+// each delivery.
+type mockEventSink struct {
+	delivered []uuid.UUID
+	fail      bool
+	onDeliver func()
+}
+
+func (m *mockEventSink) Name() string {
+	return "mock"
+}
+
+func (m *mockEventSink) Deliver(ctx context.Context, event domain.DomainEvent) error {
+	if m.onDeliver != nil {
+		m.onDeliver()
+	}
+	if m.fail {
+		return errors.New("connection refused")
+	}
+
+	m.delivered = append(m.delivered, event.ID)
+	return nil
+}
+
+// trackingTransactor is a mockTransactor reporting whether a transaction is open.
+type trackingTransactor struct {
+	mockTransactor
+	open bool
+}
+
+func (m *trackingTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.open = true
+	defer func() { m.open = false }()
+
+	return m.mockTransactor.WithinTransaction(ctx, fn)
+}
+
+func TestOutboxRelay(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tx := &trackingTransactor{}
+	newRelay := func(t *testing.T, sinks ...*mockEventSink) (*OutboxRelay, *mockOutbox, []uuid.UUID) {
+		t.Helper()
+
+		outbox := newMockOutbox()
+		var ids []uuid.UUID
+		for range 3 {
+			event, err := domain.NewDomainEvent(uuid.New(), domain.TaskDeleted{TaskID: uuid.New(), UserID: uuid.New()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			event.OccurredAt = now.Add(-time.Minute)
+			outbox.Append(context.Background(), event)
+			ids = append(ids, event.ID)
+		}
+
+		eventSinks := make([]ports.EventSink, len(sinks))
+		for i, sink := range sinks {
+			eventSinks[i] = sink
+		}
+
+		relay := NewOutboxRelay(outbox, tx, eventSinks, 2, time.Minute, time.Second, 4*time.Second)
+		relay.now = func() time.Time { return now }
+
+		return relay, outbox, ids
+	}
+
+	t.Run("Relay", func(t *testing.T) {
+		first, second := &mockEventSink{}, &mockEventSink{}
+		relay, outbox, ids := newRelay(t, first, second)
+
+		delivered, err := relay.Relay(context.Background())
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if delivered != 3 {
+			t.Errorf("Expected 3 events delivered in two batches, got %d", delivered)
+		}
+		if !slices.Equal(first.delivered, ids) || !slices.Equal(second.delivered, ids) {
+			t.Errorf("Expected every sink to receive the events in order, got %v and %v", first.delivered, second.delivered)
+		}
+
+		if delivered, _ := relay.Relay(context.Background()); delivered != 0 {
+			t.Errorf("Expected the delivered events not to be relayed again, got %d", delivered)
+		}
+
+		now = now.Add(time.Hour)
+		if deleted, err := relay.PurgeDelivered(context.Background(), 30*time.Minute); err != nil || deleted != 3 {
+			t.Errorf("Expected the 3 delivered events to be purged, got %d, %v", deleted, err)
+		}
+		if len(outbox.events) != 0 {
+			t.Errorf("Expected an empty outbox, got %d events", len(outbox.events))
+		}
+	})
+
+	t.Run("OutsideTransaction", func(t *testing.T) {
+		sink := &mockEventSink{}
+		sink.onDeliver = func() {
+			if tx.open {
+				t.Error("Expected the events to be delivered once their claim is committed")
+			}
+		}
+		relay, _, _ := newRelay(t, sink)
+
+		if delivered, err := relay.Relay(context.Background()); err != nil || delivered != 3 {
+			t.Fatalf("Expected the 3 events to be delivered, got %d, %v", delivered, err)
+		}
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		sink := &mockEventSink{fail: true}
+		relay, outbox, ids := newRelay(t, sink)
+
+		for attempt, wait := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+			delivered, err := relay.Relay(context.Background())
+			if err != nil || delivered != 0 {
+				t.Fatalf("Expected no event delivered and no error, got %d, %v", delivered, err)
+			}
+
+			for _, entry := range outbox.events {
+				if entry.event.Attempts != attempt+1 || !entry.next.Equal(now.Add(wait)) {
+					t.Fatalf("Expected attempt %d to be retried after %s, got %d at %s", attempt+1, wait, entry.event.Attempts, entry.next)
+				}
+				if entry.cause != "mock: connection refused" {
+					t.Errorf("Expected the cause of the failure, got %q", entry.cause)
+				}
+			}
+
+			if delivered, _ := relay.Relay(context.Background()); delivered != 0 {
+				t.Fatalf("Expected the failed events not to be retried before their backoff, got %d", delivered)
+			}
+			now = now.Add(wait)
+		}
+
+		sink.fail = false
+		if delivered, err := relay.Relay(context.Background()); err != nil || delivered != 3 {
+			t.Fatalf("Expected the 3 events to be delivered once the sink recovers, got %d, %v", delivered, err)
+		}
+		if !slices.Equal(sink.delivered, ids) {
+			t.Errorf("Expected the events in order, got %v", sink.delivered)
+		}
+	})
+
+	t.Run("FailingSink", func(t *testing.T) {
+		working, failing := &mockEventSink{}, &mockEventSink{fail: true}
+		relay, outbox, _ := newRelay(t, working, failing)
+
+		if _, err := relay.Relay(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		for _, entry := range outbox.events {
+			if entry.deliveredAt != nil {
+				t.Errorf("Expected the events to stay pending while a sink fails, got %s delivered", entry.event.ID)
+			}
+		}
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		relay, outbox, _ := newRelay(t, &mockEventSink{fail: true})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if _, err := relay.Relay(ctx); !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected context.Canceled, got: %v", err)
+		}
+		for _, entry := range outbox.events[:2] {
+			if entry.event.Attempts != 0 || !entry.next.Equal(now.Add(time.Minute)) {
+				t.Errorf("Expected a cancelled delivery to be retried once its claim expires, got attempt %d at %s", entry.event.Attempts, entry.next)
+			}
+		}
+	})
+}
@@ -7,32 +7,53 @@
 // application's core logic and the underlying data repositories, enforcing rules such as user existence
 // and task validation.
 //
+// Tasks can be shared with other users as viewers or editors. Collaborators can read shared tasks,
+// editors can also update them, and only the owner can delete a task or manage its shares.
+// Before these checks, every operation is authorized against the access control policy for the
+// user acting on the tasks. The changes of a task are published as events to its owner and its
+// collaborators once they are saved. The creation, the completion and the deletion of a task are
+// also recorded as domain events in the outbox, in the transaction saving the change.
+//
 // This package depends on the core, domain, and ports packages for error definitions, domain models,
 // and repository interfaces, respectively.
 package services
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/fabianoflorentino/gotostudy/core"
 	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/policy"
 	"github.com/fabianoflorentino/gotostudy/core/ports"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
+	"github.com/fabianoflorentino/gotostudy/core/tracing"
 	"github.com/fabianoflorentino/gotostudy/internal/utils"
 	"github.com/google/uuid"
 )
 
+// MaxTaskBatchSize bounds the number of operations of a task batch.
+const MaxTaskBatchSize = 100
+
 // TaskService provides methods to manage tasks by interacting with the TaskRepository.
 // It acts as a service layer between the application logic and the data access layer.
+// Each public method is traced as a span named after the method.
 type TaskService struct {
-	tsk ports.TaskRepository
-	usr ports.UserRepository
+	tsk     ports.TaskRepository
+	usr     ports.UserRepository
+	authz   ports.Authorizer
+	metrics ports.BusinessMetrics
+	tx      ports.Transactor
+	events  ports.EventPublisher
+	outbox  ports.Outbox
 }
 
-// NewTaskService creates a new instance of TaskService using the provided TaskRepository.
+// NewTaskService creates a new instance of TaskService using the provided TaskRepository,
+// UserRepository and Authorizer.
 // It returns a pointer to the initialized TaskService.
-func NewTaskService(t ports.TaskRepository, u ports.UserRepository) *TaskService {
-	return &TaskService{tsk: t, usr: u}
+func NewTaskService(t ports.TaskRepository, u ports.UserRepository, a ports.Authorizer, i Infrastructure) *TaskService {
+	return &TaskService{tsk: t, usr: u, authz: a, metrics: i.Metrics, tx: i.Tx, events: i.Events, outbox: i.Outbox}
 }
 
 // CreateTask creates a new task for the specified user.
@@ -40,6 +61,13 @@ func NewTaskService(t ports.TaskRepository, u ports.UserRepository) *TaskService
 // If the user exists, it attempts to save the task using the underlying task repository.
 // Returns an error if saving fails, or nil on success.
 func (t *TaskService) CreateTask(ctx context.Context, userID uuid.UUID, task *domain.Task) (uuid.UUID, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.CreateTask")
+	defer span.End()
+
+	if err := t.authz.Authorize(ctx, policy.TasksCreate, userID); err != nil {
+		return uuid.Nil, err
+	}
+
 	// Check if the user exists before creating a task.
 	if !t.userExists(ctx, userID) {
 		return uuid.Nil, core.ErrUserNotFound
@@ -55,17 +83,36 @@ func (t *TaskService) CreateTask(ctx context.Context, userID uuid.UUID, task *do
 	task.CreatedAt = time.Now()
 	task.UpdatedAt = time.Now()
 
-	if err := t.tsk.Save(ctx, userID, task); err != nil {
+	err := t.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := t.tsk.Save(ctx, userID, task); err != nil {
+			return err
+		}
+		return recordEvents(ctx, t.outbox, createdEvents(task)...)
+	})
+	if err != nil {
 		return uuid.Nil, core.ErrCreateTask
 	}
 
-	return task.ID, core.ErrCreateTask
+	t.metrics.TaskCreated(ctx)
+	if task.Completed {
+		t.metrics.TaskCompleted(ctx)
+	}
+	t.publish(ctx, domain.EventTaskCreated, task, task.UserID)
+
+	return task.ID, nil
 }
 
 // FindUserTasks retrieves all tasks associated with the specified user ID.
 // It accepts a context for request-scoped values and cancellation, and a userID of type uuid.UUID.
 // Returns a slice of pointers to domain.Task and an error if the operation fails.
 func (t *TaskService) FindUserTasks(ctx context.Context, userID uuid.UUID) ([]*domain.Task, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.FindUserTasks")
+	defer span.End()
+
+	if err := t.authz.Authorize(ctx, policy.TasksRead, userID); err != nil {
+		return nil, err
+	}
+
 	if !t.userExists(ctx, userID) {
 		return nil, core.ErrUserNotFound
 	}
@@ -82,7 +129,35 @@ func (t *TaskService) FindUserTasks(ctx context.Context, userID uuid.UUID) ([]*d
 	return tasks, nil
 }
 
+// FindSharedTasks retrieves the tasks other users shared with the specified user.
+// It returns core.ErrUserNotFound when the user does not exist and core.ErrNoTasksFound
+// when nothing was shared with the user.
+func (t *TaskService) FindSharedTasks(ctx context.Context, userID uuid.UUID) ([]*domain.Task, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.FindSharedTasks")
+	defer span.End()
+
+	if err := t.authz.Authorize(ctx, policy.TasksRead, userID); err != nil {
+		return nil, err
+	}
+
+	if !t.userExists(ctx, userID) {
+		return nil, core.ErrUserNotFound
+	}
+
+	tasks, err := t.tsk.FindSharedTasks(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tasks) == 0 {
+		return nil, core.ErrNoTasksFound
+	}
+
+	return tasks, nil
+}
+
 // GetTaskByID retrieves a task by its unique identifier.
+// The task is visible to its owner and to the collaborators it was shared with.
 // It returns the corresponding Task if found, or an error if the task does not exist,
 // the provided taskID is invalid, or another error occurs during retrieval.
 //
@@ -94,62 +169,448 @@ func (t *TaskService) FindUserTasks(ctx context.Context, userID uuid.UUID) ([]*d
 //   - *domain.Task: pointer to the retrieved Task, or nil if not found or on error.
 //   - error: error encountered during retrieval, or nil if successful.
 func (t *TaskService) FindTaskByID(ctx context.Context, userID uuid.UUID, taskID uuid.UUID) (*domain.Task, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.FindTaskByID")
+	defer span.End()
+
+	if err := t.authz.Authorize(ctx, policy.TasksRead, userID); err != nil {
+		return nil, err
+	}
+
 	task, err := t.taskExists(ctx, userID, taskID)
 	if err != nil {
 		return nil, err
 	}
 
+	if _, err := t.taskRole(ctx, userID, task); err != nil {
+		return nil, err
+	}
+
 	return task, nil
 }
 
 // UpdateTask updates an existing task identified by taskID with the provided task details.
-// It returns an error if the taskID is invalid, the user does not exist, or if there is a failure
-// during the update process.
+// The task can be updated by its owner or by a collaborator with the editor role.
+// It returns an error if the taskID is invalid, the user does not exist, the user is not allowed
+// to edit the task, or if there is a failure during the update process.
 func (t *TaskService) UpdateTask(ctx context.Context, userID uuid.UUID, taskID uuid.UUID, task *domain.Task) error {
+	ctx, span := tracing.Start(ctx, "TaskService.UpdateTask")
+	defer span.End()
+
+	_, err := t.update(ctx, userID, taskID, func(existingTask *domain.Task) error {
+		existingTask.Title = task.Title
+		existingTask.Description = task.Description
+		existingTask.Completed = task.Completed
+		return nil
+	})
+
+	return err
+}
+
+// PatchTask applies patch to the task identified by taskID and saves the result,
+// under the rules of UpdateTask. patch changes the title, the description and the
+// completion of the task it receives; its error is returned as is and leaves the
+// task unchanged. It returns the patched task.
+func (t *TaskService) PatchTask(ctx context.Context, userID uuid.UUID, taskID uuid.UUID, patch func(*domain.Task) error) (*domain.Task, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.PatchTask")
+	defer span.End()
+
+	return t.update(ctx, userID, taskID, patch)
+}
+
+// update applies change to the task identified by taskID once the user is allowed
+// to edit it, and saves the result in a single update.
+func (t *TaskService) update(ctx context.Context, userID uuid.UUID, taskID uuid.UUID, change func(*domain.Task) error) (*domain.Task, error) {
+	if err := t.authz.Authorize(ctx, policy.TasksUpdate, userID); err != nil {
+		return nil, err
+	}
+
 	if taskID == uuid.Nil {
-		return core.ErrInvalidTaskID
+		return nil, core.ErrInvalidTaskID
 	}
 
 	// Check if the user exists before proceeding with the task update.
 	if !t.userExists(ctx, userID) {
-		return core.ErrUserNotFound
+		return nil, core.ErrUserNotFound
 	}
 
 	// Check if the task exists before updating it.
 	existingTask, err := t.taskExists(ctx, userID, taskID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	role, err := t.taskRole(ctx, userID, existingTask)
+	if err != nil {
+		return nil, err
 	}
 
-	existingTask.Title = task.Title
-	existingTask.Description = task.Description
-	existingTask.Completed = task.Completed
+	if !role.CanEdit() {
+		return nil, core.ErrTaskAccessDenied
+	}
 
-	if err := t.tsk.Update(ctx, taskID, existingTask); err != nil {
-		return err
+	wasCompleted := existingTask.Completed
+
+	if err := change(existingTask); err != nil {
+		return nil, err
 	}
+	existingTask.UpdatedAt = time.Now()
 
-	return nil
+	completed := !wasCompleted && existingTask.Completed
+
+	err = t.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := t.tsk.Update(ctx, taskID, existingTask); err != nil {
+			return err
+		}
+		if !completed {
+			return nil
+		}
+		return recordEvents(ctx, t.outbox, domain.TaskCompleted{TaskID: taskID, UserID: existingTask.UserID, CompletedBy: userID})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if completed {
+		t.metrics.TaskCompleted(ctx)
+	}
+	t.publish(ctx, domain.EventTaskUpdated, existingTask, t.audience(ctx, existingTask)...)
+
+	return existingTask, nil
 }
 
 // DeleteTask deletes a task identified by the given taskID.
+// Only the owner of the task can delete it; collaborators get core.ErrTaskAccessDenied.
 // It returns an error if the taskID is invalid, if the task does not exist,
 // or if there is a failure during the deletion process.
 func (t *TaskService) DeleteTask(ctx context.Context, userID uuid.UUID, taskID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "TaskService.DeleteTask")
+	defer span.End()
+
+	return t.delete(ctx, userID, taskID)
+}
+
+// delete removes the task identified by taskID once the user is allowed to.
+func (t *TaskService) delete(ctx context.Context, userID uuid.UUID, taskID uuid.UUID) error {
+	if err := t.authz.Authorize(ctx, policy.TasksDelete, userID); err != nil {
+		return err
+	}
+
 	// Validate the taskID to ensure it is not a nil UUID.
 	if taskID == uuid.Nil {
 		return core.ErrInvalidTaskID
 	}
 
 	// Check if the task exists before attempting to delete it.'
-	if _, err := t.taskExists(ctx, userID, taskID); err != nil {
+	task, err := t.taskExists(ctx, userID, taskID)
+	if err != nil {
 		return err
 	}
 
-	if err := t.tsk.Delete(ctx, taskID); err != nil {
+	if task.UserID != userID {
+		return core.ErrTaskAccessDenied
+	}
+
+	// The collaborators lose their shares with the task.
+	audience := t.audience(ctx, task)
+
+	err = t.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := t.tsk.Delete(ctx, taskID); err != nil {
+			return err
+		}
+		return recordEvents(ctx, t.outbox, domain.TaskDeleted{TaskID: taskID, UserID: task.UserID})
+	})
+	if err != nil {
 		return err
 	}
 
+	t.publish(ctx, domain.EventTaskDeleted, task, audience...)
+
+	return nil
+}
+
+// BatchTasks applies the operations of a batch to the tasks of userID and returns
+// their results in the order of the operations. Each operation follows the rules
+// of CreateTask, UpdateTask or DeleteTask; completing a task is an update of its
+// completion alone. The tasks created are inserted together, then the other
+// operations are applied in order.
+//
+// An atomic batch runs in a single transaction: when an operation fails, none is
+// applied and the results of the other operations report core.ErrTaskBatchAborted.
+// Otherwise every operation is applied on its own, whether the others fail or not.
+// It returns core.ErrInvalidTaskBatch for an empty batch or one with more than
+// MaxTaskBatchSize operations, and core.ErrUserNotFound when the user does not exist.
+func (t *TaskService) BatchTasks(ctx context.Context, userID uuid.UUID, ops []domain.TaskOperation, atomic bool) ([]domain.TaskOperationResult, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.BatchTasks")
+	defer span.End()
+
+	if len(ops) == 0 || len(ops) > MaxTaskBatchSize {
+		return nil, core.ErrInvalidTaskBatch
+	}
+
+	if !t.userExists(ctx, userID) {
+		return nil, core.ErrUserNotFound
+	}
+
+	if !atomic {
+		return t.applyBatch(ctx, userID, ops, false), nil
+	}
+
+	// The business events and the events of the batch are counted and published
+	// once its transaction committed.
+	effects := &batchEffects{}
+	batch := *t
+	batch.metrics = effects
+	batch.events = effects
+
+	var results []domain.TaskOperationResult
+	err := t.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		results = batch.applyBatch(ctx, userID, ops, true)
+		for _, result := range results {
+			if result.Err != nil {
+				return core.ErrTaskBatchAborted
+			}
+		}
+		return nil
+	})
+
+	if errors.Is(err, core.ErrTaskBatchAborted) {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i] = domain.TaskOperationResult{Err: core.ErrTaskBatchAborted}
+			}
+		}
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	effects.replay(t.metrics, t.events)
+	return results, nil
+}
+
+// applyBatch applies the operations of a batch and returns their results. In an
+// atomic batch the operations following a failed one are not attempted.
+func (t *TaskService) applyBatch(ctx context.Context, userID uuid.UUID, ops []domain.TaskOperation, atomic bool) []domain.TaskOperationResult {
+	results := make([]domain.TaskOperationResult, len(ops))
+	failed := t.createAll(ctx, userID, ops, results, atomic)
+
+	for i, op := range ops {
+		switch {
+		case op.Kind == domain.TaskOperationCreate:
+			continue
+		case failed && atomic:
+			results[i].Err = core.ErrTaskBatchAborted
+		default:
+			results[i] = t.applyOperation(ctx, userID, op)
+		}
+
+		failed = failed || results[i].Err != nil
+	}
+
+	return results
+}
+
+// applyOperation applies an operation of a batch other than a creation.
+func (t *TaskService) applyOperation(ctx context.Context, userID uuid.UUID, op domain.TaskOperation) domain.TaskOperationResult {
+	var result domain.TaskOperationResult
+
+	switch op.Kind {
+	case domain.TaskOperationUpdate:
+		if op.Task == nil {
+			result.Err = core.ErrInvalidTaskOperation
+			break
+		}
+		result.Task, result.Err = t.update(ctx, userID, op.TaskID, func(task *domain.Task) error {
+			task.Title = op.Task.Title
+			task.Description = op.Task.Description
+			task.Completed = op.Task.Completed
+			return nil
+		})
+	case domain.TaskOperationComplete:
+		result.Task, result.Err = t.update(ctx, userID, op.TaskID, func(task *domain.Task) error {
+			task.Completed = true
+			return nil
+		})
+	case domain.TaskOperationDelete:
+		result.Err = t.delete(ctx, userID, op.TaskID)
+	default:
+		result.Err = core.ErrInvalidTaskOperation
+	}
+
+	return result
+}
+
+// createAll creates the tasks of the create operations of a batch with a single
+// insert, records their results and reports whether one of them failed. An
+// operation with an invalid title fails on its own, while the others fail together
+// when the insert does. In an atomic batch nothing is inserted once one failed.
+func (t *TaskService) createAll(ctx context.Context, userID uuid.UUID, ops []domain.TaskOperation, results []domain.TaskOperationResult, atomic bool) bool {
+	var (
+		tasks   []*domain.Task
+		indexes []int
+		failed  bool
+	)
+
+	authzErr := t.authz.Authorize(ctx, policy.TasksCreate, userID)
+	now := time.Now()
+
+	for i, op := range ops {
+		switch {
+		case op.Kind != domain.TaskOperationCreate:
+			continue
+		case authzErr != nil:
+			results[i].Err = authzErr
+		case op.Task == nil || !utils.IsTaskTitleValid(op.Task.Title):
+			results[i].Err = core.ErrTaskTitleValid
+		default:
+			tasks = append(tasks, &domain.Task{
+				ID:          uuid.New(),
+				Title:       op.Task.Title,
+				Description: op.Task.Description,
+				Completed:   op.Task.Completed,
+				CreatedAt:   now,
+				UpdatedAt:   now,
+				UserID:      userID,
+			})
+			indexes = append(indexes, i)
+			continue
+		}
+
+		failed = true
+	}
+
+	if len(tasks) == 0 || (failed && atomic) {
+		return failed
+	}
+
+	err := t.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := t.tsk.SaveAll(ctx, userID, tasks); err != nil {
+			return err
+		}
+
+		var data []domain.DomainEventData
+		for _, task := range tasks {
+			data = append(data, createdEvents(task)...)
+		}
+		return recordEvents(ctx, t.outbox, data...)
+	})
+	for j, i := range indexes {
+		if err != nil {
+			results[i].Err = core.ErrCreateTask
+			failed = true
+			continue
+		}
+
+		results[i].Task = tasks[j]
+		t.metrics.TaskCreated(ctx)
+		if tasks[j].Completed {
+			t.metrics.TaskCompleted(ctx)
+		}
+		t.publish(ctx, domain.EventTaskCreated, tasks[j], userID)
+	}
+
+	return failed
+}
+
+// ShareTask grants collaboratorID the given role on a task owned by ownerID.
+// Sharing again with the same collaborator replaces the previous role.
+// It returns core.ErrInvalidTaskRole for roles other than viewer and editor,
+// core.ErrTaskAccessDenied when ownerID does not own the task, and
+// core.ErrShareWithOwner when the owner tries to share the task with themselves.
+func (t *TaskService) ShareTask(ctx context.Context, ownerID uuid.UUID, taskID uuid.UUID, collaboratorID uuid.UUID, role domain.TaskRole) (*domain.TaskShare, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.ShareTask")
+	defer span.End()
+
+	if err := t.authz.Authorize(ctx, policy.TasksShare, ownerID); err != nil {
+		return nil, err
+	}
+
+	if !role.IsShareable() {
+		return nil, core.ErrInvalidTaskRole
+	}
+
+	task, err := t.taskExists(ctx, ownerID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.UserID != ownerID {
+		return nil, core.ErrTaskAccessDenied
+	}
+
+	if collaboratorID == ownerID {
+		return nil, core.ErrShareWithOwner
+	}
+
+	if !t.userExists(ctx, collaboratorID) {
+		return nil, core.ErrUserNotFound
+	}
+
+	share := &domain.TaskShare{
+		TaskID:    taskID,
+		UserID:    collaboratorID,
+		Role:      role,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if err := t.tsk.SaveTaskShare(ctx, share); err != nil {
+		return nil, core.ErrSaveTaskShare
+	}
+
+	t.publish(ctx, domain.EventTaskShared, task, collaboratorID)
+
+	return share, nil
+}
+
+// FindTaskShares lists the collaborators of a task. Any user with access to the
+// task, owner or collaborator, can see who else it was shared with.
+func (t *TaskService) FindTaskShares(ctx context.Context, userID uuid.UUID, taskID uuid.UUID) ([]*domain.TaskShare, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.FindTaskShares")
+	defer span.End()
+
+	if err := t.authz.Authorize(ctx, policy.TasksRead, userID); err != nil {
+		return nil, err
+	}
+
+	task, err := t.taskExists(ctx, userID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := t.taskRole(ctx, userID, task); err != nil {
+		return nil, err
+	}
+
+	return t.tsk.FindTaskShares(ctx, taskID)
+}
+
+// UnshareTask revokes the access of collaboratorID to the task. The owner can
+// revoke any collaborator, while a collaborator can only remove themselves.
+func (t *TaskService) UnshareTask(ctx context.Context, userID uuid.UUID, taskID uuid.UUID, collaboratorID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "TaskService.UnshareTask")
+	defer span.End()
+
+	if err := t.authz.Authorize(ctx, policy.TasksShare, userID); err != nil {
+		return err
+	}
+
+	task, err := t.taskExists(ctx, userID, taskID)
+	if err != nil {
+		return err
+	}
+
+	if task.UserID != userID && collaboratorID != userID {
+		return core.ErrTaskAccessDenied
+	}
+
+	if err := t.tsk.DeleteTaskShare(ctx, taskID, collaboratorID); err != nil {
+		return err
+	}
+
+	t.publish(ctx, domain.EventTaskUnshared, task, collaboratorID)
+
 	return nil
 }
 
@@ -182,3 +643,100 @@ func (t *TaskService) taskExists(ctx context.Context, userID uuid.UUID, taskID u
 
 	return task, nil
 }
+
+// taskRole resolves the role userID has on the task: owner when the user created it,
+// otherwise the role of the share. It returns core.ErrTaskAccessDenied when the task
+// was not shared with the user.
+func (t *TaskService) taskRole(ctx context.Context, userID uuid.UUID, task *domain.Task) (domain.TaskRole, error) {
+	if task.UserID == userID {
+		return domain.TaskRoleOwner, nil
+	}
+
+	share, err := t.tsk.FindTaskShare(ctx, task.ID, userID)
+	if err != nil {
+		if errors.Is(err, core.ErrTaskShareNotFound) {
+			return "", core.ErrTaskAccessDenied
+		}
+		return "", err
+	}
+
+	return share.Role, nil
+}
+
+// createdEvents returns the domain events of the creation of task: TaskCreated, and
+// TaskCompleted when it is created completed.
+func createdEvents(task *domain.Task) []domain.DomainEventData {
+	data := []domain.DomainEventData{domain.TaskCreated{
+		TaskID:      task.ID,
+		UserID:      task.UserID,
+		Title:       task.Title,
+		Description: task.Description,
+		Completed:   task.Completed,
+	}}
+	if task.Completed {
+		data = append(data, domain.TaskCompleted{TaskID: task.ID, UserID: task.UserID, CompletedBy: task.UserID})
+	}
+
+	return data
+}
+
+// audience returns the users notified of the changes of the task: its owner and
+// its collaborators. The owner alone is notified when the shares cannot be read.
+func (t *TaskService) audience(ctx context.Context, task *domain.Task) []uuid.UUID {
+	audience := []uuid.UUID{task.UserID}
+
+	shares, err := t.tsk.FindTaskShares(ctx, task.ID)
+	if err != nil {
+		return audience
+	}
+
+	for _, share := range shares {
+		audience = append(audience, share.UserID)
+	}
+
+	return audience
+}
+
+// publish notifies the recipients of a change of the task in the workspace carried
+// by ctx. The event holds a copy of the task, as it is at the time of the change.
+func (t *TaskService) publish(ctx context.Context, eventType domain.EventType, task *domain.Task, recipients ...uuid.UUID) {
+	workspaceID, _ := tenant.WorkspaceID(ctx)
+	snapshot := *task
+
+	t.events.Publish(ctx, domain.Event{
+		Type:        eventType,
+		WorkspaceID: workspaceID,
+		Recipients:  recipients,
+		Task:        &snapshot,
+	})
+}
+
+// batchEffects records the business events and the events of an atomic task batch,
+// to count and publish them only once the batch committed.
+type batchEffects struct {
+	effects []func(ports.BusinessMetrics, ports.EventPublisher)
+}
+
+func (b *batchEffects) UserRegistered(ctx context.Context, method string) {
+	b.effects = append(b.effects, func(m ports.BusinessMetrics, _ ports.EventPublisher) { m.UserRegistered(ctx, method) })
+}
+
+func (b *batchEffects) TaskCreated(ctx context.Context) {
+	b.effects = append(b.effects, func(m ports.BusinessMetrics, _ ports.EventPublisher) { m.TaskCreated(ctx) })
+}
+
+func (b *batchEffects) TaskCompleted(ctx context.Context) {
+	b.effects = append(b.effects, func(m ports.BusinessMetrics, _ ports.EventPublisher) { m.TaskCompleted(ctx) })
+}
+
+func (b *batchEffects) Publish(ctx context.Context, event domain.Event) {
+	b.effects = append(b.effects, func(_ ports.BusinessMetrics, p ports.EventPublisher) { p.Publish(ctx, event) })
+}
+
+// replay counts the recorded business events in m and publishes the recorded events
+// with p, in the order they were recorded.
+func (b *batchEffects) replay(m ports.BusinessMetrics, p ports.EventPublisher) {
+	for _, effect := range b.effects {
+		effect(m, p)
+	}
+}
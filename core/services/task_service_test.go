@@ -2,20 +2,32 @@ package services
 
 import (
 	"context"
+	"errors"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/fabianoflorentino/gotostudy/core"
 	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/policy"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
 	"github.com/google/uuid"
 )
 
 type mockTaskRepository struct {
-	tasks map[string]*domain.Task
+	tasks  map[string]*domain.Task
+	shares map[string]*domain.TaskShare
 }
 
 func newMockTaskRepository() *mockTaskRepository {
-	return &mockTaskRepository{tasks: make(map[string]*domain.Task)}
+	return &mockTaskRepository{
+		tasks:  make(map[string]*domain.Task),
+		shares: make(map[string]*domain.TaskShare),
+	}
+}
+
+func shareKey(taskID, userID uuid.UUID) string {
+	return taskID.String() + ":" + userID.String()
 }
 
 func (m *mockTaskRepository) Save(ctx context.Context, userID uuid.UUID, task *domain.Task) error {
@@ -23,6 +35,13 @@ func (m *mockTaskRepository) Save(ctx context.Context, userID uuid.UUID, task *d
 	return nil
 }
 
+func (m *mockTaskRepository) SaveAll(ctx context.Context, userID uuid.UUID, tasks []*domain.Task) error {
+	for _, task := range tasks {
+		m.tasks[task.ID.String()] = task
+	}
+	return nil
+}
+
 func (m *mockTaskRepository) FindUserTasks(ctx context.Context, userID uuid.UUID) ([]*domain.Task, error) {
 	var userTasks []*domain.Task
 	for _, task := range m.tasks {
@@ -72,12 +91,61 @@ func (m *mockTaskRepository) Delete(ctx context.Context, taskID uuid.UUID) error
 	return nil
 }
 
+func (m *mockTaskRepository) FindSharedTasks(ctx context.Context, userID uuid.UUID) ([]*domain.Task, error) {
+	var sharedTasks []*domain.Task
+	for _, share := range m.shares {
+		if share.UserID == userID {
+			sharedTasks = append(sharedTasks, m.tasks[share.TaskID.String()])
+		}
+	}
+
+	return sharedTasks, nil
+}
+
+func (m *mockTaskRepository) SaveTaskShare(ctx context.Context, share *domain.TaskShare) error {
+	m.shares[shareKey(share.TaskID, share.UserID)] = share
+	return nil
+}
+
+func (m *mockTaskRepository) FindTaskShare(ctx context.Context, taskID, userID uuid.UUID) (*domain.TaskShare, error) {
+	share, exists := m.shares[shareKey(taskID, userID)]
+	if !exists {
+		return nil, core.ErrTaskShareNotFound
+	}
+
+	return share, nil
+}
+
+func (m *mockTaskRepository) FindTaskShares(ctx context.Context, taskID uuid.UUID) ([]*domain.TaskShare, error) {
+	var shares []*domain.TaskShare
+	for _, share := range m.shares {
+		if share.TaskID == taskID {
+			shares = append(shares, share)
+		}
+	}
+
+	return shares, nil
+}
+
+func (m *mockTaskRepository) DeleteTaskShare(ctx context.Context, taskID, userID uuid.UUID) error {
+	if _, exists := m.shares[shareKey(taskID, userID)]; !exists {
+		return core.ErrTaskShareNotFound
+	}
+
+	delete(m.shares, shareKey(taskID, userID))
+	return nil
+}
+
 type mockTaskRepositoryWithError struct{}
 
 func (m *mockTaskRepositoryWithError) Save(ctx context.Context, userID uuid.UUID, task *domain.Task) error {
 	return core.ErrCreateTask
 }
 
+func (m *mockTaskRepositoryWithError) SaveAll(ctx context.Context, userID uuid.UUID, tasks []*domain.Task) error {
+	return core.ErrCreateTask
+}
+
 func (m *mockTaskRepositoryWithError) FindUserTasks(ctx context.Context, userID uuid.UUID) ([]*domain.Task, error) {
 	return nil, core.ErrFindUserTasks
 }
@@ -94,10 +162,66 @@ func (m *mockTaskRepositoryWithError) Delete(ctx context.Context, taskID uuid.UU
 	return core.ErrDeleteTask
 }
 
+func (m *mockTaskRepositoryWithError) FindSharedTasks(ctx context.Context, userID uuid.UUID) ([]*domain.Task, error) {
+	return nil, core.ErrFindUserTasks
+}
+
+func (m *mockTaskRepositoryWithError) SaveTaskShare(ctx context.Context, share *domain.TaskShare) error {
+	return core.ErrSaveTaskShare
+}
+
+func (m *mockTaskRepositoryWithError) FindTaskShare(ctx context.Context, taskID, userID uuid.UUID) (*domain.TaskShare, error) {
+	return nil, core.ErrTaskShareNotFound
+}
+
+func (m *mockTaskRepositoryWithError) FindTaskShares(ctx context.Context, taskID uuid.UUID) ([]*domain.TaskShare, error) {
+	return nil, core.ErrTaskShareNotFound
+}
+
+func (m *mockTaskRepositoryWithError) DeleteTaskShare(ctx context.Context, taskID, userID uuid.UUID) error {
+	return core.ErrTaskShareNotFound
+}
+
+// mockTransactor is a mock implementation of Transactor restoring the tasks of
+// repo and the events of outbox when the transaction is rolled back.
+type mockTransactor struct {
+	repo   *mockTaskRepository
+	outbox *mockOutbox
+}
+
+func (m *mockTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	var snapshot map[string]domain.Task
+	if m.repo != nil {
+		snapshot = make(map[string]domain.Task, len(m.repo.tasks))
+		for id, task := range m.repo.tasks {
+			snapshot[id] = *task
+		}
+	}
+	var appended int
+	if m.outbox != nil {
+		appended = len(m.outbox.events)
+	}
+
+	err := fn(ctx)
+	if err != nil {
+		if m.repo != nil {
+			m.repo.tasks = make(map[string]*domain.Task, len(snapshot))
+			for id, task := range snapshot {
+				m.repo.tasks[id] = &task
+			}
+		}
+		if m.outbox != nil {
+			m.outbox.events = m.outbox.events[:appended]
+		}
+	}
+
+	return err
+}
+
 func TestCreateTask(t *testing.T) {
 	mockTaskRepo := newMockTaskRepository()
 	mockUserRepo := newMockUserRepository()
-	taskService := NewTaskService(mockTaskRepo, mockUserRepo)
+	taskService := NewTaskService(mockTaskRepo, mockUserRepo, newMockAuthorizer(), newMockInfrastructure())
 	userID := uuid.New()
 
 	testNewTask := []struct {
@@ -162,14 +286,18 @@ func TestCreateTask(t *testing.T) {
 			Completed:   false,
 		}
 
-		_, err := taskService.CreateTask(context.Background(), userID, &task)
-		if err != core.ErrCreateTask {
-			t.Fatalf("Expected ErrCreateTask for duplicate ID, got: %v", err)
+		first, err := taskService.CreateTask(context.Background(), userID, &task)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
 		}
 
-		_, err = taskService.CreateTask(context.Background(), userID, &task)
-		if err != core.ErrCreateTask {
-			t.Errorf("Expected ErrCreateTask for duplicate ID, got: %v", err)
+		second, err := taskService.CreateTask(context.Background(), userID, &task)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if first == second {
+			t.Errorf("Expected a new ID for each created task, got %v twice", first)
 		}
 	})
 
@@ -226,7 +354,7 @@ func TestCreateTask(t *testing.T) {
 
 	t.Run("CreateTaskWithError", func(t *testing.T) {
 		mockTaskRepoWithError := &mockTaskRepositoryWithError{}
-		taskServiceWithError := NewTaskService(mockTaskRepoWithError, mockUserRepo)
+		taskServiceWithError := NewTaskService(mockTaskRepoWithError, mockUserRepo, newMockAuthorizer(), newMockInfrastructure())
 		task := domain.Task{
 			ID:          uuid.New(),
 			UserID:      userID,
@@ -252,7 +380,7 @@ func TestCreateTask(t *testing.T) {
 func TestFindUserTask(t *testing.T) {
 	mockTaskRepo := newMockTaskRepository()
 	mockUserRepo := newMockUserRepository()
-	taskService := NewTaskService(mockTaskRepo, mockUserRepo)
+	taskService := NewTaskService(mockTaskRepo, mockUserRepo, newMockAuthorizer(), newMockInfrastructure())
 	userID := uuid.New()
 
 	mockUserRepo.users[userID.String()] = &domain.User{
@@ -305,3 +433,429 @@ func TestFindUserTask(t *testing.T) {
 	}
 	t.Run("FindUserTasks_NonExistentUser", findUserTasksNonExistentUser)
 }
+
+func TestShareTask(t *testing.T) {
+	mockTaskRepo := newMockTaskRepository()
+	mockUserRepo := newMockUserRepository()
+	taskService := NewTaskService(mockTaskRepo, mockUserRepo, newMockAuthorizer(), newMockInfrastructure())
+
+	ownerID := uuid.New()
+	editorID := uuid.New()
+	viewerID := uuid.New()
+	strangerID := uuid.New()
+
+	for _, id := range []uuid.UUID{ownerID, editorID, viewerID, strangerID} {
+		mockUserRepo.users[id.String()] = &domain.User{ID: id, Username: id.String(), Email: id.String() + "@example.com"}
+	}
+
+	task := &domain.Task{ID: uuid.New(), UserID: ownerID, Title: "Shared Task", Description: "This task is shared"}
+	mockTaskRepo.Save(context.Background(), ownerID, task)
+
+	t.Run("ShareTask_Editor", func(t *testing.T) {
+		share, err := taskService.ShareTask(context.Background(), ownerID, task.ID, editorID, domain.TaskRoleEditor)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if share.Role != domain.TaskRoleEditor || share.UserID != editorID {
+			t.Errorf("Unexpected share: %+v", share)
+		}
+	})
+
+	t.Run("ShareTask_Viewer", func(t *testing.T) {
+		if _, err := taskService.ShareTask(context.Background(), ownerID, task.ID, viewerID, domain.TaskRoleViewer); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("ShareTask_InvalidRole", func(t *testing.T) {
+		_, err := taskService.ShareTask(context.Background(), ownerID, task.ID, viewerID, domain.TaskRoleOwner)
+		if !errors.Is(err, core.ErrInvalidTaskRole) {
+			t.Errorf("Expected ErrInvalidTaskRole, got: %v", err)
+		}
+	})
+
+	t.Run("ShareTask_WithOwner", func(t *testing.T) {
+		_, err := taskService.ShareTask(context.Background(), ownerID, task.ID, ownerID, domain.TaskRoleViewer)
+		if !errors.Is(err, core.ErrShareWithOwner) {
+			t.Errorf("Expected ErrShareWithOwner, got: %v", err)
+		}
+	})
+
+	t.Run("ShareTask_NotOwner", func(t *testing.T) {
+		_, err := taskService.ShareTask(context.Background(), editorID, task.ID, strangerID, domain.TaskRoleViewer)
+		if !errors.Is(err, core.ErrTaskAccessDenied) {
+			t.Errorf("Expected ErrTaskAccessDenied, got: %v", err)
+		}
+	})
+
+	t.Run("ShareTask_UnknownCollaborator", func(t *testing.T) {
+		_, err := taskService.ShareTask(context.Background(), ownerID, task.ID, uuid.New(), domain.TaskRoleViewer)
+		if !errors.Is(err, core.ErrUserNotFound) {
+			t.Errorf("Expected ErrUserNotFound, got: %v", err)
+		}
+	})
+
+	t.Run("FindSharedTasks", func(t *testing.T) {
+		tasks, err := taskService.FindSharedTasks(context.Background(), viewerID)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(tasks) != 1 || tasks[0].ID != task.ID {
+			t.Errorf("Expected the shared task, got: %+v", tasks)
+		}
+
+		if _, err := taskService.FindSharedTasks(context.Background(), strangerID); !errors.Is(err, core.ErrNoTasksFound) {
+			t.Errorf("Expected ErrNoTasksFound, got: %v", err)
+		}
+	})
+
+	t.Run("FindTaskByID_Collaborators", func(t *testing.T) {
+		if _, err := taskService.FindTaskByID(context.Background(), viewerID, task.ID); err != nil {
+			t.Errorf("Expected viewer to read the task, got: %v", err)
+		}
+		if _, err := taskService.FindTaskByID(context.Background(), strangerID, task.ID); !errors.Is(err, core.ErrTaskAccessDenied) {
+			t.Errorf("Expected ErrTaskAccessDenied, got: %v", err)
+		}
+	})
+
+	t.Run("UpdateTask_Roles", func(t *testing.T) {
+		update := &domain.Task{Title: "Edited by collaborator", Description: "Edited"}
+		if err := taskService.UpdateTask(context.Background(), editorID, task.ID, update); err != nil {
+			t.Errorf("Expected editor to update the task, got: %v", err)
+		}
+		if err := taskService.UpdateTask(context.Background(), viewerID, task.ID, update); !errors.Is(err, core.ErrTaskAccessDenied) {
+			t.Errorf("Expected ErrTaskAccessDenied for viewer, got: %v", err)
+		}
+		if err := taskService.UpdateTask(context.Background(), strangerID, task.ID, update); !errors.Is(err, core.ErrTaskAccessDenied) {
+			t.Errorf("Expected ErrTaskAccessDenied for stranger, got: %v", err)
+		}
+	})
+
+	t.Run("PatchTask_Roles", func(t *testing.T) {
+		complete := func(task *domain.Task) error {
+			task.Completed = true
+			return nil
+		}
+
+		patched, err := taskService.PatchTask(context.Background(), editorID, task.ID, complete)
+		if err != nil {
+			t.Fatalf("Expected editor to patch the task, got: %v", err)
+		}
+		if !patched.Completed || patched.Title != "Edited by collaborator" {
+			t.Errorf("Expected only the completion to change, got %+v", patched)
+		}
+		if _, err := taskService.PatchTask(context.Background(), viewerID, task.ID, complete); !errors.Is(err, core.ErrTaskAccessDenied) {
+			t.Errorf("Expected ErrTaskAccessDenied for viewer, got: %v", err)
+		}
+	})
+
+	t.Run("PatchTask_PatchError", func(t *testing.T) {
+		errPatch := errors.New("invalid patch")
+
+		_, err := taskService.PatchTask(context.Background(), ownerID, task.ID, func(*domain.Task) error { return errPatch })
+		if !errors.Is(err, errPatch) {
+			t.Errorf("Expected the error of the patch, got: %v", err)
+		}
+	})
+
+	t.Run("FindTaskShares", func(t *testing.T) {
+		shares, err := taskService.FindTaskShares(context.Background(), editorID, task.ID)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(shares) != 2 {
+			t.Errorf("Expected 2 shares, got: %d", len(shares))
+		}
+	})
+
+	t.Run("UnshareTask", func(t *testing.T) {
+		if err := taskService.UnshareTask(context.Background(), editorID, task.ID, viewerID); !errors.Is(err, core.ErrTaskAccessDenied) {
+			t.Errorf("Expected ErrTaskAccessDenied, got: %v", err)
+		}
+		if err := taskService.UnshareTask(context.Background(), viewerID, task.ID, viewerID); err != nil {
+			t.Errorf("Expected collaborator to leave the task, got: %v", err)
+		}
+		if _, err := taskService.FindTaskByID(context.Background(), viewerID, task.ID); !errors.Is(err, core.ErrTaskAccessDenied) {
+			t.Errorf("Expected ErrTaskAccessDenied after unshare, got: %v", err)
+		}
+	})
+
+	t.Run("DeleteTask_OnlyOwner", func(t *testing.T) {
+		if err := taskService.DeleteTask(context.Background(), editorID, task.ID); !errors.Is(err, core.ErrTaskAccessDenied) {
+			t.Errorf("Expected ErrTaskAccessDenied for editor, got: %v", err)
+		}
+		if err := taskService.DeleteTask(context.Background(), ownerID, task.ID); err != nil {
+			t.Errorf("Expected owner to delete the task, got: %v", err)
+		}
+	})
+}
+
+func TestTaskServicePolicy(t *testing.T) {
+	mockTaskRepo := newMockTaskRepository()
+	mockUserRepo := newMockUserRepository()
+	taskService := NewTaskService(mockTaskRepo, mockUserRepo, newMockAuthorizer(policy.TasksCreate, policy.TasksRead, policy.TasksUpdate, policy.TasksDelete, policy.TasksShare), newMockInfrastructure())
+
+	ownerID, collaboratorID := uuid.New(), uuid.New()
+	mockUserRepo.users[ownerID.String()] = &domain.User{ID: ownerID, Email: "owner@example.com"}
+
+	task := &domain.Task{ID: uuid.New(), UserID: ownerID, Title: "Protected Task", Description: "Denied by policy"}
+	mockTaskRepo.Save(context.Background(), ownerID, task)
+
+	calls := map[string]func() error{
+		"CreateTask": func() error {
+			_, err := taskService.CreateTask(context.Background(), ownerID, &domain.Task{Title: "New Task"})
+			return err
+		},
+		"FindUserTasks":   func() error { _, err := taskService.FindUserTasks(context.Background(), ownerID); return err },
+		"FindSharedTasks": func() error { _, err := taskService.FindSharedTasks(context.Background(), ownerID); return err },
+		"FindTaskByID":    func() error { _, err := taskService.FindTaskByID(context.Background(), ownerID, task.ID); return err },
+		"UpdateTask":      func() error { return taskService.UpdateTask(context.Background(), ownerID, task.ID, task) },
+		"PatchTask": func() error {
+			_, err := taskService.PatchTask(context.Background(), ownerID, task.ID, func(*domain.Task) error { return nil })
+			return err
+		},
+		"DeleteTask": func() error { return taskService.DeleteTask(context.Background(), ownerID, task.ID) },
+		"ShareTask": func() error {
+			_, err := taskService.ShareTask(context.Background(), ownerID, task.ID, collaboratorID, domain.TaskRoleViewer)
+			return err
+		},
+		"FindTaskShares": func() error { _, err := taskService.FindTaskShares(context.Background(), ownerID, task.ID); return err },
+		"UnshareTask":    func() error { return taskService.UnshareTask(context.Background(), ownerID, task.ID, collaboratorID) },
+		"BatchTasks": func() error {
+			results, err := taskService.BatchTasks(context.Background(), ownerID, []domain.TaskOperation{
+				{Kind: domain.TaskOperationCreate, Task: &domain.Task{Title: "New Task"}},
+			}, false)
+			if err != nil {
+				return err
+			}
+			return results[0].Err
+		},
+	}
+
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			if err := call(); !errors.Is(err, core.ErrForbidden) {
+				t.Errorf("Expected ErrForbidden, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestTaskServiceMetrics(t *testing.T) {
+	mockTaskRepo := newMockTaskRepository()
+	mockUserRepo := newMockUserRepository()
+	metrics := newMockBusinessMetrics()
+	taskService := NewTaskService(mockTaskRepo, mockUserRepo, newMockAuthorizer(), Infrastructure{Metrics: metrics, Events: newMockEventPublisher(), Tx: &mockTransactor{}, Outbox: newMockOutbox()})
+
+	userID := uuid.New()
+	mockUserRepo.users[userID.String()] = &domain.User{ID: userID, Username: "testuser", Email: "testuser@example.com"}
+
+	task := &domain.Task{Title: "Measured Task", Description: "This task is counted"}
+	taskService.CreateTask(context.Background(), userID, task)
+
+	t.Run("TaskCreated", func(t *testing.T) {
+		if metrics.created != 1 || metrics.completed != 0 {
+			t.Errorf("Expected 1 created and 0 completed tasks, got %d and %d", metrics.created, metrics.completed)
+		}
+	})
+
+	t.Run("TaskCompleted", func(t *testing.T) {
+		done := &domain.Task{Title: task.Title, Description: task.Description, Completed: true}
+		for range 2 {
+			if err := taskService.UpdateTask(context.Background(), userID, task.ID, done); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+		}
+
+		if metrics.completed != 1 {
+			t.Errorf("Expected the task to be counted once as completed, got %d", metrics.completed)
+		}
+	})
+}
+
+func TestTaskServiceEvents(t *testing.T) {
+	mockTaskRepo := newMockTaskRepository()
+	mockUserRepo := newMockUserRepository()
+	events := newMockEventPublisher()
+	outbox := newMockOutbox()
+	taskService := NewTaskService(mockTaskRepo, mockUserRepo, newMockAuthorizer(), Infrastructure{Metrics: newMockBusinessMetrics(), Events: events, Tx: &mockTransactor{repo: mockTaskRepo, outbox: outbox}, Outbox: outbox})
+
+	workspaceID := uuid.New()
+	ctx := tenant.WithWorkspace(context.Background(), workspaceID)
+	ownerID := uuid.New()
+	collaboratorID := uuid.New()
+	for _, id := range []uuid.UUID{ownerID, collaboratorID} {
+		mockUserRepo.users[id.String()] = &domain.User{ID: id, Username: id.String(), Email: id.String() + "@example.com"}
+	}
+
+	task := &domain.Task{Title: "Followed Task", Description: "This task is followed"}
+	if _, err := taskService.CreateTask(ctx, ownerID, task); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, err := taskService.ShareTask(ctx, ownerID, task.ID, collaboratorID, domain.TaskRoleViewer); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := taskService.UpdateTask(ctx, ownerID, task.ID, &domain.Task{Title: "Renamed Task", Completed: true}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := taskService.DeleteTask(ctx, ownerID, task.ID); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	want := []domain.EventType{domain.EventTaskCreated, domain.EventTaskShared, domain.EventTaskUpdated, domain.EventTaskDeleted}
+	if got := events.types(); !slices.Equal(got, want) {
+		t.Fatalf("Expected events %v, got %v", want, got)
+	}
+
+	for _, event := range events.events {
+		if event.WorkspaceID != workspaceID || event.Task == nil || event.Task.ID != task.ID {
+			t.Errorf("Expected a %s event for the task in the workspace, got %+v", event.Type, event)
+		}
+	}
+	if recipients := events.events[2].Recipients; !slices.Equal(recipients, []uuid.UUID{ownerID, collaboratorID}) {
+		t.Errorf("Expected the update to be published to the owner and the collaborator, got %v", recipients)
+	}
+	if recipients := events.events[3].Recipients; !slices.Equal(recipients, []uuid.UUID{ownerID, collaboratorID}) {
+		t.Errorf("Expected the deletion to be published to the owner and the collaborator, got %v", recipients)
+	}
+	if title := events.events[2].Task.Title; title != "Renamed Task" {
+		t.Errorf("Expected the update to carry the updated task, got %q", title)
+	}
+
+	recorded := []domain.DomainEventType{domain.DomainEventTaskCreated, domain.DomainEventTaskCompleted, domain.DomainEventTaskDeleted}
+	if got := outbox.types(); !slices.Equal(got, recorded) {
+		t.Fatalf("Expected domain events %v in the outbox, got %v", recorded, got)
+	}
+	for _, entry := range outbox.events {
+		if entry.event.WorkspaceID != workspaceID || entry.event.AggregateID != task.ID {
+			t.Errorf("Expected a %s event for the task in the workspace, got %+v", entry.event.Type, entry.event)
+		}
+	}
+
+	t.Run("AtomicRollback", func(t *testing.T) {
+		published, appended := len(events.events), len(outbox.events)
+
+		_, err := taskService.BatchTasks(ctx, ownerID, []domain.TaskOperation{
+			{Kind: domain.TaskOperationCreate, Task: &domain.Task{Title: "Rolled Back Task"}},
+			{Kind: domain.TaskOperationDelete, TaskID: uuid.New()},
+		}, true)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if len(events.events) != published {
+			t.Errorf("Expected the rolled back batch not to publish events, got %v", events.types()[published:])
+		}
+		if len(outbox.events) != appended {
+			t.Errorf("Expected the rolled back batch not to record domain events, got %v", outbox.types()[appended:])
+		}
+	})
+}
+
+func TestBatchTasks(t *testing.T) {
+	mockTaskRepo := newMockTaskRepository()
+	mockUserRepo := newMockUserRepository()
+	metrics := newMockBusinessMetrics()
+	taskService := NewTaskService(mockTaskRepo, mockUserRepo, newMockAuthorizer(), Infrastructure{Metrics: metrics, Events: newMockEventPublisher(), Tx: &mockTransactor{repo: mockTaskRepo}, Outbox: newMockOutbox()})
+
+	ctx := context.Background()
+	userID := uuid.New()
+	mockUserRepo.users[userID.String()] = &domain.User{ID: userID, Username: "testuser", Email: "testuser@example.com"}
+
+	newTask := func(title string) *domain.Task {
+		task := &domain.Task{ID: uuid.New(), UserID: userID, Title: title}
+		mockTaskRepo.Save(ctx, userID, task)
+		return task
+	}
+
+	t.Run("InvalidBatch", func(t *testing.T) {
+		for _, ops := range [][]domain.TaskOperation{nil, make([]domain.TaskOperation, MaxTaskBatchSize+1)} {
+			if _, err := taskService.BatchTasks(ctx, userID, ops, false); !errors.Is(err, core.ErrInvalidTaskBatch) {
+				t.Errorf("Expected ErrInvalidTaskBatch for %d operations, got: %v", len(ops), err)
+			}
+		}
+
+		ops := []domain.TaskOperation{{Kind: domain.TaskOperationDelete, TaskID: uuid.New()}}
+		if _, err := taskService.BatchTasks(ctx, uuid.New(), ops, false); !errors.Is(err, core.ErrUserNotFound) {
+			t.Errorf("Expected ErrUserNotFound, got: %v", err)
+		}
+	})
+
+	t.Run("NonAtomic", func(t *testing.T) {
+		pending := newTask("Pending Task")
+
+		results, err := taskService.BatchTasks(ctx, userID, []domain.TaskOperation{
+			{Kind: domain.TaskOperationCreate, Task: &domain.Task{Title: "Created Task"}},
+			{Kind: domain.TaskOperationCreate, Task: &domain.Task{Title: "No"}},
+			{Kind: domain.TaskOperationComplete, TaskID: pending.ID},
+			{Kind: domain.TaskOperationDelete, TaskID: uuid.New()},
+			{Kind: "archive", TaskID: pending.ID},
+		}, false)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if results[0].Err != nil || results[0].Task == nil || mockTaskRepo.tasks[results[0].Task.ID.String()] == nil {
+			t.Errorf("Expected the task to be created, got %+v", results[0])
+		}
+		if !results[2].Task.Completed || !mockTaskRepo.tasks[pending.ID.String()].Completed {
+			t.Errorf("Expected the task to be completed, got %+v", results[2])
+		}
+		for i, want := range map[int]error{1: core.ErrTaskTitleValid, 3: core.ErrTaskNotFound, 4: core.ErrInvalidTaskOperation} {
+			if !errors.Is(results[i].Err, want) {
+				t.Errorf("Expected operation %d to fail with %v, got: %v", i, want, results[i].Err)
+			}
+		}
+		if metrics.created != 1 || metrics.completed != 1 {
+			t.Errorf("Expected 1 created and 1 completed tasks, got %d and %d", metrics.created, metrics.completed)
+		}
+	})
+
+	t.Run("AtomicRollback", func(t *testing.T) {
+		task := newTask("Original Title")
+		tasks := len(mockTaskRepo.tasks)
+		created := metrics.created
+
+		results, err := taskService.BatchTasks(ctx, userID, []domain.TaskOperation{
+			{Kind: domain.TaskOperationCreate, Task: &domain.Task{Title: "Rolled Back Task"}},
+			{Kind: domain.TaskOperationUpdate, TaskID: task.ID, Task: &domain.Task{Title: "Changed Title"}},
+			{Kind: domain.TaskOperationDelete, TaskID: uuid.New()},
+			{Kind: domain.TaskOperationComplete, TaskID: task.ID},
+		}, true)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		for i, want := range []error{core.ErrTaskBatchAborted, core.ErrTaskBatchAborted, core.ErrTaskNotFound, core.ErrTaskBatchAborted} {
+			if !errors.Is(results[i].Err, want) || results[i].Task != nil {
+				t.Errorf("Expected operation %d to fail with %v, got: %+v", i, want, results[i])
+			}
+		}
+		if len(mockTaskRepo.tasks) != tasks || mockTaskRepo.tasks[task.ID.String()].Title != "Original Title" {
+			t.Errorf("Expected the batch to be rolled back, got %d tasks and %+v", len(mockTaskRepo.tasks), mockTaskRepo.tasks[task.ID.String()])
+		}
+		if metrics.created != created {
+			t.Errorf("Expected the rolled back task not to be counted, got %d", metrics.created)
+		}
+	})
+
+	t.Run("AtomicCommit", func(t *testing.T) {
+		task := newTask("Deleted Task")
+		created := metrics.created
+
+		results, err := taskService.BatchTasks(ctx, userID, []domain.TaskOperation{
+			{Kind: domain.TaskOperationDelete, TaskID: task.ID},
+			{Kind: domain.TaskOperationCreate, Task: &domain.Task{Title: "Committed Task"}},
+		}, true)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if results[0].Err != nil || results[1].Err != nil || mockTaskRepo.tasks[task.ID.String()] != nil {
+			t.Errorf("Expected both operations to be applied, got %+v", results)
+		}
+		if metrics.created != created+1 {
+			t.Errorf("Expected the committed task to be counted, got %d", metrics.created-created)
+		}
+	})
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/auth"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/logging"
+	"github.com/fabianoflorentino/gotostudy/core/policy"
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+	"github.com/google/uuid"
+)
+
+// recoveryCodeCount is the number of recovery codes handed out when the second
+// factor is enabled. Each code can be used once instead of a TOTP code.
+const recoveryCodeCount = 10
+
+// recoveryCodeEncoding encodes the random bytes of the recovery codes.
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TwoFactorService manages the TOTP second factor of the users: the enrollment
+// in an authenticator app, its confirmation, the recovery codes and the reset by
+// an administrator. It also verifies the second factor during login.
+type TwoFactorService struct {
+	tfa   ports.TwoFactorRepository
+	usr   ports.UserRepository
+	otp   ports.OTPAuthenticator
+	authz ports.Authorizer
+	now   func() time.Time
+}
+
+// NewTwoFactorService creates a new instance of TwoFactorService using the provided
+// TwoFactorRepository, UserRepository, OTPAuthenticator and Authorizer.
+func NewTwoFactorService(f ports.TwoFactorRepository, u ports.UserRepository, o ports.OTPAuthenticator, a ports.Authorizer) *TwoFactorService {
+	return &TwoFactorService{tfa: f, usr: u, otp: o, authz: a, now: time.Now}
+}
+
+// Enroll generates a new TOTP secret for the user. The second factor stays pending,
+// and is not required on login, until it is confirmed with ConfirmTwoFactor.
+// Enrolling again replaces a pending secret; an enabled one must be reset first.
+func (s *TwoFactorService) Enroll(ctx context.Context, userID uuid.UUID) (*auth.TOTPEnrollment, error) {
+	if err := s.authz.Authorize(ctx, policy.UsersUpdate, userID); err != nil {
+		return nil, err
+	}
+
+	user, err := s.usr.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.pending(ctx, userID); err != nil && !errors.Is(err, core.ErrTwoFactorNotFound) {
+		return nil, err
+	}
+
+	secret, err := s.otp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	if err := s.tfa.Save(ctx, &domain.TwoFactor{UserID: userID, Secret: secret, CreatedAt: now, UpdatedAt: now}); err != nil {
+		logging.FromContext(ctx).Error("failed to save two-factor enrollment", "user_id", userID, "error", err)
+		return nil, core.ErrSaveTwoFactor
+	}
+
+	return &auth.TOTPEnrollment{Secret: secret, URI: s.otp.URI(secret, user.Email)}, nil
+}
+
+// QRCode returns the QR code PNG image of the pending enrollment of the user, to be
+// scanned with an authenticator app. The secret is no longer shown once enabled.
+func (s *TwoFactorService) QRCode(ctx context.Context, userID uuid.UUID) ([]byte, error) {
+	if err := s.authz.Authorize(ctx, policy.UsersUpdate, userID); err != nil {
+		return nil, err
+	}
+
+	user, err := s.usr.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	twoFactor, err := s.pending(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.otp.QRCode(s.otp.URI(twoFactor.Secret, user.Email))
+}
+
+// Confirm enables the pending second factor of the user with a first valid code
+// from the authenticator app. It returns the recovery codes, which are only stored
+// hashed and therefore shown this single time.
+func (s *TwoFactorService) Confirm(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	if err := s.authz.Authorize(ctx, policy.UsersUpdate, userID); err != nil {
+		return nil, err
+	}
+
+	twoFactor, err := s.pending(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	step, ok := s.otp.Validate(code, twoFactor.Secret, now)
+	if !ok {
+		return nil, core.ErrInvalidOTP
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	twoFactor.EnabledAt = &now
+	twoFactor.RecoveryCodes = hashes
+	twoFactor.LastUsedStep = step
+	twoFactor.UpdatedAt = now
+
+	if err := s.tfa.Save(ctx, twoFactor); err != nil {
+		logging.FromContext(ctx).Error("failed to enable two-factor authentication", "user_id", userID, "error", err)
+		return nil, core.ErrSaveTwoFactor
+	}
+
+	return codes, nil
+}
+
+// Status returns the second factor of the user.
+// It returns core.ErrTwoFactorNotFound when the user never enrolled.
+func (s *TwoFactorService) Status(ctx context.Context, userID uuid.UUID) (*domain.TwoFactor, error) {
+	if err := s.authz.Authorize(ctx, policy.UsersRead, userID); err != nil {
+		return nil, err
+	}
+
+	return s.tfa.FindByUserID(ctx, userID)
+}
+
+// Reset removes the second factor of a user who lost access to it, so the user can
+// log in with the password alone and enroll again. Only administrators may reset it.
+func (s *TwoFactorService) Reset(ctx context.Context, userID uuid.UUID) error {
+	if err := s.authz.Authorize(ctx, policy.UsersResetTwoFactor, userID); err != nil {
+		return err
+	}
+
+	return s.tfa.Delete(ctx, userID)
+}
+
+// Verify checks the second factor of a user logging in. Users without an enabled
+// second factor pass. Otherwise code must be the current TOTP code or an unused
+// recovery code, which is consumed. A TOTP code is accepted once: a code replayed,
+// or older than the last one accepted, is wrong. The repository records the use
+// atomically, so concurrent logins cannot both accept the same code. It returns
+// core.ErrTwoFactorRequired when code is empty and core.ErrInvalidOTP when it is wrong.
+func (s *TwoFactorService) Verify(ctx context.Context, userID uuid.UUID, code string) error {
+	twoFactor, err := s.tfa.FindByUserID(ctx, userID)
+	if errors.Is(err, core.ErrTwoFactorNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !twoFactor.Enabled() {
+		return nil
+	}
+
+	if strings.TrimSpace(code) == "" {
+		return core.ErrTwoFactorRequired
+	}
+
+	if step, ok := s.otp.Validate(code, twoFactor.Secret, s.now()); ok {
+		return s.use(ctx, userID, "TOTP code", s.tfa.UseStep(ctx, userID, step))
+	}
+
+	return s.use(ctx, userID, "recovery code", s.tfa.UseRecoveryCode(ctx, userID, hashRecoveryCode(code)))
+}
+
+// use maps the error of recording the use of a second factor code, logging the
+// failures other than core.ErrInvalidOTP.
+func (s *TwoFactorService) use(ctx context.Context, userID uuid.UUID, kind string, err error) error {
+	if err == nil || errors.Is(err, core.ErrInvalidOTP) {
+		return err
+	}
+
+	logging.FromContext(ctx).Error("failed to record used "+kind, "user_id", userID, "error", err)
+	return core.ErrSaveTwoFactor
+}
+
+// pending returns the second factor of the user, failing with
+// core.ErrTwoFactorAlreadyEnabled when it was already confirmed.
+func (s *TwoFactorService) pending(ctx context.Context, userID uuid.UUID) (*domain.TwoFactor, error) {
+	twoFactor, err := s.tfa.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if twoFactor.Enabled() {
+		return nil, core.ErrTwoFactorAlreadyEnabled
+	}
+
+	return twoFactor, nil
+}
+
+// newRecoveryCodes returns new recovery codes formatted as "xxxxx-xxxxx" together
+// with their hashes.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+
+	for i := range codes {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))[:10]
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode returns the SHA-256 hex digest of a recovery code, ignoring
+// case, spaces and dashes. Recovery codes are random, so a fast hash is enough.
+func hashRecoveryCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(normalized))
+
+	return hex.EncodeToString(sum[:])
+}
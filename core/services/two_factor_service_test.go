@@ -0,0 +1,333 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/policy"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
+	"github.com/google/uuid"
+)
+
+// mockTwoFactorRepository is a mock implementation of TwoFactorRepository keeping
+// the second factors in memory.
+type mockTwoFactorRepository struct {
+	factors map[uuid.UUID]domain.TwoFactor
+}
+
+func newMockTwoFactorRepository() *mockTwoFactorRepository {
+	return &mockTwoFactorRepository{factors: make(map[uuid.UUID]domain.TwoFactor)}
+}
+
+func (m *mockTwoFactorRepository) FindByUserID(ctx context.Context, userID uuid.UUID) (*domain.TwoFactor, error) {
+	twoFactor, ok := m.factors[userID]
+	if !ok {
+		return nil, core.ErrTwoFactorNotFound
+	}
+
+	twoFactor.RecoveryCodes = append([]string(nil), twoFactor.RecoveryCodes...)
+	return &twoFactor, nil
+}
+
+func (m *mockTwoFactorRepository) Save(ctx context.Context, twoFactor *domain.TwoFactor) error {
+	m.factors[twoFactor.UserID] = *twoFactor
+	return nil
+}
+
+func (m *mockTwoFactorRepository) UseStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	twoFactor, ok := m.factors[userID]
+	if !ok || step <= twoFactor.LastUsedStep {
+		return core.ErrInvalidOTP
+	}
+
+	twoFactor.LastUsedStep = step
+	m.factors[userID] = twoFactor
+	return nil
+}
+
+func (m *mockTwoFactorRepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, hash string) error {
+	twoFactor, ok := m.factors[userID]
+	if !ok || !slices.Contains(twoFactor.RecoveryCodes, hash) {
+		return core.ErrInvalidOTP
+	}
+
+	twoFactor.RecoveryCodes = slices.DeleteFunc(slices.Clone(twoFactor.RecoveryCodes), func(code string) bool { return code == hash })
+	m.factors[userID] = twoFactor
+	return nil
+}
+
+func (m *mockTwoFactorRepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	if _, ok := m.factors[userID]; !ok {
+		return core.ErrTwoFactorNotFound
+	}
+
+	delete(m.factors, userID)
+	return nil
+}
+
+// staleTwoFactorRepository returns the second factors as they were when it was
+// created, like a login that read them before a concurrent one recorded its code.
+type staleTwoFactorRepository struct {
+	*mockTwoFactorRepository
+	snapshot map[uuid.UUID]domain.TwoFactor
+}
+
+func newStaleTwoFactorRepository(m *mockTwoFactorRepository) *staleTwoFactorRepository {
+	return &staleTwoFactorRepository{mockTwoFactorRepository: m, snapshot: maps.Clone(m.factors)}
+}
+
+func (m *staleTwoFactorRepository) FindByUserID(ctx context.Context, userID uuid.UUID) (*domain.TwoFactor, error) {
+	twoFactor, ok := m.snapshot[userID]
+	if !ok {
+		return nil, core.ErrTwoFactorNotFound
+	}
+
+	return &twoFactor, nil
+}
+
+// mockOTPAuthenticator is a mock implementation of OTPAuthenticator whose valid
+// code is the secret followed by the number of the 30 second period, accepting
+// the periods right before and after it.
+type mockOTPAuthenticator struct{}
+
+func (m *mockOTPAuthenticator) GenerateSecret() (string, error) {
+	return "SECRET", nil
+}
+
+func (m *mockOTPAuthenticator) URI(secret string, account string) string {
+	return "otpauth://totp/" + account + "?secret=" + secret
+}
+
+func (m *mockOTPAuthenticator) QRCode(uri string) ([]byte, error) {
+	return []byte("png:" + uri), nil
+}
+
+func (m *mockOTPAuthenticator) Validate(code string, secret string, at time.Time) (int64, bool) {
+	for _, skew := range []time.Duration{0, -30 * time.Second, 30 * time.Second} {
+		if code == mockOTPCode(secret, at.Add(skew)) {
+			return at.Add(skew).Unix() / 30, true
+		}
+	}
+	return 0, false
+}
+
+func mockOTPCode(secret string, at time.Time) string {
+	return fmt.Sprintf("%s-%d", secret, at.Unix()/30)
+}
+
+func TestTwoFactorService(t *testing.T) {
+	users := newMockUserRepository()
+	repo := newMockTwoFactorRepository()
+	service := NewTwoFactorService(repo, users, &mockOTPAuthenticator{}, newMockAuthorizer())
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	ctx := tenant.WithWorkspace(context.Background(), uuid.New())
+
+	newUser := func(email string) *domain.User {
+		user := &domain.User{ID: uuid.New(), Username: email, Email: email, Role: domain.UserRoleUser}
+		users.users[email] = user
+		return user
+	}
+
+	// enable enrolls and confirms the second factor of user, returning its recovery codes.
+	enable := func(t *testing.T, user *domain.User) []string {
+		t.Helper()
+
+		if _, err := service.Enroll(ctx, user.ID); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		codes, err := service.Confirm(ctx, user.ID, mockOTPCode("SECRET", now))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		return codes
+	}
+
+	t.Run("Enroll", func(t *testing.T) {
+		user := newUser("enroll@example.com")
+
+		enrollment, err := service.Enroll(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if enrollment.Secret != "SECRET" || enrollment.URI != "otpauth://totp/enroll@example.com?secret=SECRET" {
+			t.Errorf("Unexpected enrollment %+v", enrollment)
+		}
+
+		twoFactor, err := service.Status(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if twoFactor.Enabled() {
+			t.Error("Expected the second factor to be pending")
+		}
+
+		if err := service.Verify(ctx, user.ID, ""); err != nil {
+			t.Errorf("Expected a pending second factor not to be required, got: %v", err)
+		}
+	})
+
+	t.Run("QRCode", func(t *testing.T) {
+		user := newUser("qr@example.com")
+		if _, err := service.Enroll(ctx, user.ID); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		image, err := service.QRCode(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if string(image) != "png:otpauth://totp/qr@example.com?secret=SECRET" {
+			t.Errorf("Unexpected QR code %q", image)
+		}
+	})
+
+	t.Run("Confirm", func(t *testing.T) {
+		user := newUser("confirm@example.com")
+		if _, err := service.Enroll(ctx, user.ID); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if _, err := service.Confirm(ctx, user.ID, mockOTPCode("SECRET", now.Add(-time.Hour))); !errors.Is(err, core.ErrInvalidOTP) {
+			t.Errorf("Expected ErrInvalidOTP for an expired code, got: %v", err)
+		}
+
+		codes, err := service.Confirm(ctx, user.ID, mockOTPCode("SECRET", now))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if len(codes) != recoveryCodeCount {
+			t.Errorf("Expected %d recovery codes, got %d", recoveryCodeCount, len(codes))
+		}
+
+		stored := repo.factors[user.ID]
+		if !stored.Enabled() || !stored.EnabledAt.Equal(now) {
+			t.Errorf("Expected the second factor to be enabled at %s, got %+v", now, stored)
+		}
+		for i, code := range codes {
+			if stored.RecoveryCodes[i] == code || stored.RecoveryCodes[i] != hashRecoveryCode(code) {
+				t.Errorf("Expected recovery code %q to be stored hashed", code)
+			}
+		}
+	})
+
+	t.Run("Enabled_CannotEnrollOrShowSecret", func(t *testing.T) {
+		user := newUser("enabled@example.com")
+		enable(t, user)
+
+		if _, err := service.Enroll(ctx, user.ID); !errors.Is(err, core.ErrTwoFactorAlreadyEnabled) {
+			t.Errorf("Expected ErrTwoFactorAlreadyEnabled on enroll, got: %v", err)
+		}
+		if _, err := service.QRCode(ctx, user.ID); !errors.Is(err, core.ErrTwoFactorAlreadyEnabled) {
+			t.Errorf("Expected ErrTwoFactorAlreadyEnabled on QR code, got: %v", err)
+		}
+		if _, err := service.Confirm(ctx, user.ID, mockOTPCode("SECRET", now)); !errors.Is(err, core.ErrTwoFactorAlreadyEnabled) {
+			t.Errorf("Expected ErrTwoFactorAlreadyEnabled on confirm, got: %v", err)
+		}
+	})
+
+	t.Run("Verify", func(t *testing.T) {
+		user := newUser("verify@example.com")
+		codes := enable(t, user)
+		confirmedAt := now
+
+		// The next period, in which the code of the confirmation is still valid.
+		now = now.Add(30 * time.Second)
+
+		tests := []struct {
+			name string
+			code string
+			want error
+		}{
+			{"Missing", "", core.ErrTwoFactorRequired},
+			{"Wrong", "000000", core.ErrInvalidOTP},
+			{"Expired", mockOTPCode("SECRET", now.Add(-time.Hour)), core.ErrInvalidOTP},
+			{"ConfirmationCodeReplayed", mockOTPCode("SECRET", confirmedAt), core.ErrInvalidOTP},
+			{"Current", mockOTPCode("SECRET", now), nil},
+			{"Replayed", mockOTPCode("SECRET", now), core.ErrInvalidOTP},
+			{"RecoveryCode", codes[0], nil},
+			{"RecoveryCodeUsedTwice", codes[0], core.ErrInvalidOTP},
+			{"RecoveryCodeFormatting", " " + codes[1][:5] + codes[1][6:] + " ", nil},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if err := service.Verify(ctx, user.ID, tt.code); !errors.Is(err, tt.want) {
+					t.Errorf("Expected %v, got: %v", tt.want, err)
+				}
+			})
+		}
+
+		if left := len(repo.factors[user.ID].RecoveryCodes); left != recoveryCodeCount-2 {
+			t.Errorf("Expected %d recovery codes left, got %d", recoveryCodeCount-2, left)
+		}
+	})
+
+	t.Run("Verify_ConcurrentLogins", func(t *testing.T) {
+		user := newUser("concurrent@example.com")
+		codes := enable(t, user)
+		now = now.Add(30 * time.Second)
+
+		// The second login read the second factor before the first one used its codes.
+		stale := NewTwoFactorService(newStaleTwoFactorRepository(repo), users, &mockOTPAuthenticator{}, newMockAuthorizer())
+		stale.now = service.now
+
+		for _, code := range []string{mockOTPCode("SECRET", now), codes[0]} {
+			if err := service.Verify(ctx, user.ID, code); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if err := stale.Verify(ctx, user.ID, code); !errors.Is(err, core.ErrInvalidOTP) {
+				t.Errorf("Expected ErrInvalidOTP for the code used concurrently, got: %v", err)
+			}
+		}
+	})
+
+	t.Run("Verify_WithoutTwoFactor", func(t *testing.T) {
+		if err := service.Verify(ctx, uuid.New(), ""); err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		user := newUser("reset@example.com")
+		enable(t, user)
+
+		if err := service.Reset(ctx, user.ID); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if err := service.Verify(ctx, user.ID, ""); err != nil {
+			t.Errorf("Expected the second factor to be removed, got: %v", err)
+		}
+		if err := service.Reset(ctx, user.ID); !errors.Is(err, core.ErrTwoFactorNotFound) {
+			t.Errorf("Expected ErrTwoFactorNotFound, got: %v", err)
+		}
+	})
+
+	t.Run("Reset_Forbidden", func(t *testing.T) {
+		user := newUser("reset-forbidden@example.com")
+		enable(t, user)
+
+		denied := NewTwoFactorService(repo, users, &mockOTPAuthenticator{}, newMockAuthorizer(policy.UsersResetTwoFactor))
+
+		if err := denied.Reset(ctx, user.ID); !errors.Is(err, core.ErrForbidden) {
+			t.Errorf("Expected ErrForbidden, got: %v", err)
+		}
+		if _, ok := repo.factors[user.ID]; !ok {
+			t.Error("Expected the second factor to be kept")
+		}
+	})
+}
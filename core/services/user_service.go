@@ -13,39 +13,90 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
-	"log"
+	"fmt"
+	"net/url"
 	"time"
 
 	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/auth"
 	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/logging"
+	"github.com/fabianoflorentino/gotostudy/core/policy"
 	"github.com/fabianoflorentino/gotostudy/core/ports"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
+	"github.com/fabianoflorentino/gotostudy/core/tracing"
 	"github.com/fabianoflorentino/gotostudy/internal/utils"
 	"github.com/google/uuid"
 )
 
+const (
+	// emailVerificationTTL is how long the link confirming an email address is valid.
+	emailVerificationTTL = 24 * time.Hour
+	// passwordResetTTL is how long the link resetting a password is valid.
+	passwordResetTTL = time.Hour
+)
+
+// AccountLinks holds the URLs of the links sent by email. The signed token is
+// added to them as the "token" query parameter.
+type AccountLinks struct {
+	VerifyEmailURL   string
+	PasswordResetURL string
+}
+
 // UserService is a service layer struct that provides methods to manage user-related operations.
 // It depends on a UserRepository interface (defined in the ports package) to interact with the underlying data storage.
+// Every operation is first authorized by the Authorizer against the access control policy.
+// It also sends the emails confirming the address of new users and resetting forgotten passwords.
+// The changes of a user are published as events to the user once they are saved, and the
+// registration of a user is recorded as a domain event in the outbox, in the transaction saving it.
+// Each public method runs in its own span, child of the span of the request.
 type UserService struct {
-	usr ports.UserRepository
+	usr     ports.UserRepository
+	hasher  ports.PasswordHasher
+	authz   ports.Authorizer
+	mailer  ports.Mailer
+	signer  ports.ActionTokenSigner
+	links   AccountLinks
+	metrics ports.BusinessMetrics
+	events  ports.EventPublisher
+	tx      ports.Transactor
+	outbox  ports.Outbox
 }
 
 // NewUserService creates and returns a new instance of UserService.
 // It takes a UserRepository as a parameter, which is used to interact
-// with the underlying data storage for user-related operations.
-func NewUserService(u ports.UserRepository) *UserService {
-	return &UserService{usr: u}
+// with the underlying data storage for user-related operations. The Mailer
+// sends the links built from AccountLinks, signed by the ActionTokenSigner.
+func NewUserService(u ports.UserRepository, h ports.PasswordHasher, a ports.Authorizer, m ports.Mailer, s ports.ActionTokenSigner, l AccountLinks, i Infrastructure) *UserService {
+	return &UserService{usr: u, hasher: h, authz: a, mailer: m, signer: s, links: l, metrics: i.Metrics, events: i.Events, tx: i.Tx, outbox: i.Outbox}
 }
 
-// RegisterUser creates a new user with the provided name and email, assigns a unique ID,
-// and initializes an empty list of tasks for the user. It then saves the user to the repository.
-// If the save operation fails, it logs the error and returns it. On success, it returns the created user.
-func (u *UserService) RegisterUser(ctx context.Context, user *domain.User) (*domain.User, error) {
+// RegisterUser creates a new user with the provided name, email and password, assigns a unique ID,
+// and stores the hash of the password. New users always get the regular user role. It then saves
+// the user to the repository. If the save operation fails, it logs the error and returns it.
+// On success, it sends the link confirming the email address and returns the created user. The
+// user cannot log in before confirming; a failure to send the link is only logged, as it can be
+// sent again with ResendEmailVerification.
+func (u *UserService) RegisterUser(ctx context.Context, user *domain.User, password string) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.RegisterUser")
+	defer span.End()
+
+	if err := u.authz.Authorize(ctx, policy.UsersCreate, uuid.Nil); err != nil {
+		return nil, err
+	}
+
 	// Validate email format
 	if err := utils.IsEmailValid(user.Email); err != nil {
 		return nil, err
 	}
 
+	if len(password) < 8 || len(password) > 72 {
+		return nil, core.ErrInvalidPassword
+	}
+
 	emailInUse, err := utils.IsEmailInUse(u.usr, ctx, user.Email, uuid.Nil)
 	if err != nil {
 		return nil, err
@@ -55,15 +106,43 @@ func (u *UserService) RegisterUser(ctx context.Context, user *domain.User) (*dom
 		return nil, core.ErrEmailAlreadyExists
 	}
 
+	hash, err := u.hasher.Hash(password)
+	if err != nil {
+		return nil, err
+	}
+
 	user.ID = uuid.New()
+	user.Role = domain.UserRoleUser
+	user.PasswordHash = hash
+	user.EmailVerifiedAt = nil
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 
-	if err := u.usr.Save(ctx, user); err != nil {
-		log.Printf("Error saving user: %v", err)
+	err = u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.usr.Save(ctx, user); err != nil {
+			return err
+		}
+		return recordEvents(ctx, u.outbox, domain.UserRegistered{
+			UserID:   user.ID,
+			Username: user.Username,
+			Email:    user.Email,
+			Method:   ports.RegistrationPassword,
+		})
+	})
+	if errors.Is(err, core.ErrUserAlreadyExists) {
+		return nil, err
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to save user", "error", err)
 		return nil, core.ErrSaveUser
 	}
 
+	u.metrics.UserRegistered(ctx, ports.RegistrationPassword)
+
+	if err := u.sendEmailVerification(ctx, user); err != nil {
+		logging.FromContext(ctx).Warn("failed to send email verification", "user_id", user.ID, "error", err)
+	}
+
 	return user, nil
 }
 
@@ -71,6 +150,13 @@ func (u *UserService) RegisterUser(ctx context.Context, user *domain.User) (*dom
 // It returns a slice of User objects and an error if any occurs during the retrieval process.
 // If an error is encountered, it logs the error and returns nil along with the error.
 func (u *UserService) GetAllUsers(ctx context.Context) ([]*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetAllUsers")
+	defer span.End()
+
+	if err := u.authz.Authorize(ctx, policy.UsersList, uuid.Nil); err != nil {
+		return nil, err
+	}
+
 	users, err := u.usr.FindAll(ctx)
 	if err != nil {
 		return nil, core.ErrFindAllUsers
@@ -90,8 +176,15 @@ func (u *UserService) GetAllUsers(ctx context.Context) ([]*domain.User, error) {
 //   - *domain.User: A pointer to the User object if found.
 //   - error: An error object if there is an issue during retrieval.
 func (u *UserService) GetUserByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByID")
+	defer span.End()
+
+	if err := u.authz.Authorize(ctx, policy.UsersRead, id); err != nil {
+		return nil, err
+	}
+
 	user, err := u.usr.FindByID(ctx, id)
-	if errors.Is(err, core.ErrUserNotFound) {
+	if err != nil {
 		return nil, err
 	}
 
@@ -103,6 +196,46 @@ func (u *UserService) GetUserByID(ctx context.Context, id uuid.UUID) (*domain.Us
 // the updated user information. If the update operation fails, it logs the error and returns it.
 // Otherwise, it returns nil to indicate success.
 func (u *UserService) UpdateUser(ctx context.Context, id uuid.UUID, user *domain.User) error {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUser")
+	defer span.End()
+
+	if err := u.authz.Authorize(ctx, policy.UsersUpdate, id); err != nil {
+		return err
+	}
+
+	return u.update(ctx, id, user)
+}
+
+// PatchUser applies patch to the user identified by id and saves the result. patch
+// changes the username and the email address of the user it receives; its error is
+// returned as is and leaves the user unchanged. The patched user is validated as
+// UpdateUser validates a replacement, and saved in a single update.
+func (u *UserService) PatchUser(ctx context.Context, id uuid.UUID, patch func(*domain.User) error) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.PatchUser")
+	defer span.End()
+
+	if err := u.authz.Authorize(ctx, policy.UsersUpdate, id); err != nil {
+		return nil, err
+	}
+
+	user, err := u.usr.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := patch(user); err != nil {
+		return nil, err
+	}
+
+	if err := u.update(ctx, id, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// update validates the username and the email address of user and saves them.
+func (u *UserService) update(ctx context.Context, id uuid.UUID, user *domain.User) error {
 	// Validate email format
 	if emailValid := utils.IsEmailValid(user.Email); emailValid != nil {
 		return core.ErrInvalidEmail
@@ -120,53 +253,257 @@ func (u *UserService) UpdateUser(ctx context.Context, id uuid.UUID, user *domain
 	user.UpdatedAt = time.Now()
 
 	if err := u.usr.Update(ctx, id, user); err != nil {
-		log.Printf("Error updating user: %v", err)
+		if errors.Is(err, core.ErrUserNotFound) || errors.Is(err, core.ErrUserAlreadyExists) {
+			return err
+		}
+		logging.FromContext(ctx).Error("failed to update user", "user_id", id, "error", err)
 		return core.ErrUpdateUser
 	}
 
+	// The update only carries the changed fields, the event the whole user.
+	if saved, err := u.usr.FindByID(ctx, id); err == nil {
+		u.publish(ctx, domain.EventUserUpdated, saved)
+	}
+
 	return nil
 }
 
-// UpdateUserFields updates specific fields of a user identified by the given UUID.
-// It takes a map of field names and their corresponding values to be updated.
-// The method interacts with the repository layer to perform the update operation.
-// If the update is successful, it returns the updated user object.
-// In case of an error during the update, it logs the error and returns it.
-func (u *UserService) UpdateUserFields(ctx context.Context, id uuid.UUID, fields map[string]any) (*domain.User, error) {
-	// Validate email format
-	if emailValid := utils.IsEmailValid(fields["email"].(string)); emailValid != nil {
-		return nil, core.ErrInvalidEmail
+// DeleteUser removes a user from the repository based on the provided UUID.
+// It returns an error if the deletion process fails, logging the error for debugging purposes.
+func (u *UserService) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUser")
+	defer span.End()
+
+	if err := u.authz.Authorize(ctx, policy.UsersDelete, id); err != nil {
+		return err
 	}
 
-	// Check if the email is already in use by another user
-	if email, ok := fields["email"].(string); ok {
-		if emailInUse, err := utils.IsEmailInUse(u.usr, ctx, email, id); err != nil {
-			return nil, core.ErrEmailAlreadyExists
-		} else if emailInUse {
-			return nil, core.ErrEmailAlreadyExists
+	if err := u.usr.Delete(ctx, id); err != nil {
+		if errors.Is(err, core.ErrUserNotFound) {
+			return err
 		}
+		logging.FromContext(ctx).Error("failed to delete user", "user_id", id, "error", err)
+		return core.ErrDeleteUser
+	}
+
+	u.publish(ctx, domain.EventUserDeleted, &domain.User{ID: id})
+
+	return nil
+}
+
+// PromoteToAdmin grants the administrator role to the user identified by id. Users
+// can only become administrators this way, through an administrator or the system
+// principal of the command line, which bootstraps the first one.
+func (u *UserService) PromoteToAdmin(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "UserService.PromoteToAdmin")
+	defer span.End()
+
+	if err := u.authz.Authorize(ctx, policy.UsersPromote, id); err != nil {
+		return err
+	}
+
+	if err := u.usr.UpdateRole(ctx, id, domain.UserRoleAdmin); err != nil {
+		if errors.Is(err, core.ErrUserNotFound) {
+			return err
+		}
+		logging.FromContext(ctx).Error("failed to promote user", "user_id", id, "error", err)
+		return core.ErrUpdateUser
 	}
 
-	// Update the updated_at field to the current time
-	fields["updated_at"] = time.Now()
+	if user, err := u.usr.FindByID(ctx, id); err == nil {
+		u.publish(ctx, domain.EventUserUpdated, user)
+	}
+
+	return nil
+}
+
+// ResendEmailVerification sends the link confirming the email address again to
+// the user of the workspace with that email. Unknown and already confirmed
+// addresses are silently ignored, so the response does not disclose them.
+func (u *UserService) ResendEmailVerification(ctx context.Context, email string) error {
+	ctx, span := tracing.Start(ctx, "UserService.ResendEmailVerification")
+	defer span.End()
 
-	// Call the repository to update the user fields
-	updatedUser, err := u.usr.UpdateFields(ctx, id, fields)
+	user, err := u.usr.FindByEmail(ctx, email)
+	if errors.Is(err, core.ErrUserNotFound) {
+		return nil
+	}
 	if err != nil {
-		log.Printf("Error updating user fields: %v", fields)
-		return nil, core.ErrUpdateUser
+		return err
+	}
+
+	if user.EmailVerified() {
+		return nil
 	}
 
-	return updatedUser, nil
+	return u.sendEmailVerification(ctx, user)
 }
 
-// DeleteUser removes a user from the repository based on the provided UUID.
-// It returns an error if the deletion process fails, logging the error for debugging purposes.
-func (u *UserService) DeleteUser(ctx context.Context, id uuid.UUID) error {
-	if err := u.usr.Delete(ctx, id); err != nil {
-		log.Printf("Error deleting user: %v", err)
-		return core.ErrDeleteUser
+// VerifyEmail confirms the email address of the user the token was sent to. The
+// token carries the workspace of the user, so ctx does not need to.
+// It returns core.ErrInvalidToken when the token is invalid, expired or was sent
+// to a previous email address of the user.
+func (u *UserService) VerifyEmail(ctx context.Context, token string) error {
+	ctx, span := tracing.Start(ctx, "UserService.VerifyEmail")
+	defer span.End()
+
+	claims, err := u.signer.VerifyAction(token, auth.TokenVerifyEmail)
+	if err != nil {
+		return core.ErrInvalidToken
+	}
+
+	ctx = tenant.WithWorkspace(ctx, claims.WorkspaceID)
+
+	user, err := u.usr.FindByID(ctx, claims.UserID)
+	if err != nil || claims.Fingerprint != fingerprint(user.Email) {
+		return core.ErrInvalidToken
+	}
+
+	if user.EmailVerified() {
+		return nil
+	}
+
+	return u.usr.MarkEmailVerified(ctx, user.ID, time.Now())
+}
+
+// RequestPasswordReset sends a link resetting the password to the user of the
+// workspace with that email. Unknown addresses are silently ignored, so the
+// response does not disclose them.
+func (u *UserService) RequestPasswordReset(ctx context.Context, email string) error {
+	ctx, span := tracing.Start(ctx, "UserService.RequestPasswordReset")
+	defer span.End()
+
+	user, err := u.usr.FindByEmail(ctx, email)
+	if errors.Is(err, core.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	link, err := u.link(ctx, u.links.PasswordResetURL, auth.TokenPasswordReset, user, fingerprint(user.PasswordHash), passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	return u.mailer.Send(ctx, ports.Mail{
+		To:      user.Email,
+		Subject: "Reset your GoToStudy password",
+		Body: fmt.Sprintf("Hello %s,\n\n"+
+			"Someone asked to reset the password of your GoToStudy account. Follow the link below "+
+			"within %s to choose a new password:\n\n%s\n\n"+
+			"If it was not you, ignore this email: your password stays unchanged.\n",
+			user.Username, passwordResetTTL, link),
+	})
+}
+
+// ResetPassword sets a new password for the user the token was sent to. The token
+// is bound to the previous password, so it can only be used once. As the link was
+// received by email, the email address of the user is confirmed as well.
+// It returns core.ErrInvalidToken when the token is invalid, expired or already used.
+func (u *UserService) ResetPassword(ctx context.Context, token string, password string) error {
+	ctx, span := tracing.Start(ctx, "UserService.ResetPassword")
+	defer span.End()
+
+	claims, err := u.signer.VerifyAction(token, auth.TokenPasswordReset)
+	if err != nil {
+		return core.ErrInvalidToken
+	}
+
+	ctx = tenant.WithWorkspace(ctx, claims.WorkspaceID)
+
+	user, err := u.usr.FindByID(ctx, claims.UserID)
+	if err != nil || claims.Fingerprint != fingerprint(user.PasswordHash) {
+		return core.ErrInvalidToken
+	}
+
+	if len(password) < 8 || len(password) > 72 {
+		return core.ErrInvalidPassword
+	}
+
+	hash, err := u.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	if err := u.usr.UpdatePassword(ctx, user.ID, hash); err != nil {
+		logging.FromContext(ctx).Error("failed to update password", "user_id", user.ID, "error", err)
+		return core.ErrUpdateUser
+	}
+
+	if !user.EmailVerified() {
+		return u.usr.MarkEmailVerified(ctx, user.ID, time.Now())
 	}
 
 	return nil
 }
+
+// sendEmailVerification sends the link confirming the email address of the user.
+func (u *UserService) sendEmailVerification(ctx context.Context, user *domain.User) error {
+	link, err := u.link(ctx, u.links.VerifyEmailURL, auth.TokenVerifyEmail, user, fingerprint(user.Email), emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return u.mailer.Send(ctx, ports.Mail{
+		To:      user.Email,
+		Subject: "Confirm your GoToStudy email address",
+		Body: fmt.Sprintf("Hello %s,\n\n"+
+			"Welcome to GoToStudy! Follow the link below within %s to confirm your email address:\n\n%s\n\n"+
+			"If you did not sign up, ignore this email.\n",
+			user.Username, emailVerificationTTL, link),
+	})
+}
+
+// link returns base with a signed action token of the user in the workspace of ctx.
+func (u *UserService) link(ctx context.Context, base string, kind auth.TokenKind, user *domain.User, fp string, ttl time.Duration) (string, error) {
+	workspaceID, err := tenant.WorkspaceID(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := u.signer.SignAction(auth.ActionToken{
+		Kind:        kind,
+		UserID:      user.ID,
+		WorkspaceID: workspaceID,
+		Fingerprint: fp,
+		ExpiresAt:   time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	link, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	return link.String(), nil
+}
+
+// fingerprint returns a short digest of value, binding an action token to the
+// state of the account without disclosing it.
+func fingerprint(value string) string {
+	sum := sha256.Sum256([]byte(value))
+
+	return hex.EncodeToString(sum[:8])
+}
+
+// publish notifies the user of a change of their account in the workspace carried
+// by ctx. The event holds a copy of the user without the hash of the password.
+func (u *UserService) publish(ctx context.Context, eventType domain.EventType, user *domain.User) {
+	workspaceID, _ := tenant.WorkspaceID(ctx)
+	snapshot := *user
+	snapshot.PasswordHash = ""
+
+	u.events.Publish(ctx, domain.Event{
+		Type:        eventType,
+		WorkspaceID: workspaceID,
+		Recipients:  []uuid.UUID{user.ID},
+		User:        &snapshot,
+	})
+}
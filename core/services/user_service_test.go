@@ -2,21 +2,33 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/auth"
 	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/policy"
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+	"github.com/fabianoflorentino/gotostudy/core/tenant"
 	"github.com/fabianoflorentino/gotostudy/internal/utils"
 	"github.com/google/uuid"
 )
 
-// mockUserRepository is a mock implementation of UserRepository for testing
+// mockUserRepository is a mock implementation of UserRepository for testing.
+// others holds the users of other workspaces, only found by FindByEmailInAnyWorkspace.
 type mockUserRepository struct {
-	users map[string]*domain.User
+	users  map[string]*domain.User
+	others map[string]*domain.User
 }
 
 func newMockUserRepository() *mockUserRepository {
@@ -54,6 +66,14 @@ func (m *mockUserRepository) FindByEmail(ctx context.Context, email string) (*do
 	return nil, core.ErrUserNotFound
 }
 
+func (m *mockUserRepository) FindByEmailInAnyWorkspace(ctx context.Context, email string) (*domain.User, error) {
+	if user, exists := m.others[email]; exists {
+		return user, nil
+	}
+
+	return m.FindByEmail(ctx, email)
+}
+
 func (m *mockUserRepository) Save(ctx context.Context, user *domain.User) error {
 	if user.Email == "save_error@example.com" {
 		return fmt.Errorf("simulated save error")
@@ -75,22 +95,6 @@ func (m *mockUserRepository) Update(ctx context.Context, id uuid.UUID, user *dom
 	return core.ErrUserNotFound
 }
 
-func (m *mockUserRepository) UpdateFields(ctx context.Context, id uuid.UUID, fields map[string]any) (*domain.User, error) {
-	for _, user := range m.users {
-		if user.ID == id {
-			// Simple field update - in a real implementation this would be more robust
-			if email, ok := fields["email"].(string); ok {
-				user.Email = email
-			}
-			if username, ok := fields["username"].(string); ok {
-				user.Username = username
-			}
-			return user, nil
-		}
-	}
-	return nil, core.ErrUserNotFound
-}
-
 func (m *mockUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	for email, user := range m.users {
 		if user.ID == id {
@@ -101,8 +105,211 @@ func (m *mockUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+func (m *mockUserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	user, err := m.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	user.PasswordHash = passwordHash
+	return nil
+}
+
+func (m *mockUserRepository) MarkEmailVerified(ctx context.Context, id uuid.UUID, at time.Time) error {
+	user, err := m.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	user.EmailVerifiedAt = &at
+	return nil
+}
+
+func (m *mockUserRepository) UpdateRole(ctx context.Context, id uuid.UUID, role domain.UserRole) error {
+	user, err := m.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	user.Role = role
+	return nil
+}
+
+const testPassword = "s3cret-password"
+
+// mockPasswordHasher is a mock implementation of PasswordHasher that stores the
+// password with a prefix instead of hashing it.
+type mockPasswordHasher struct{}
+
+func newMockPasswordHasher() *mockPasswordHasher {
+	return &mockPasswordHasher{}
+}
+
+func (m *mockPasswordHasher) Hash(password string) (string, error) {
+	return "hashed:" + password, nil
+}
+
+func (m *mockPasswordHasher) Compare(hash string, password string) error {
+	if hash != "hashed:"+password {
+		return core.ErrInvalidCredentials
+	}
+	return nil
+}
+
+// testAccountLinks are the links sent by the UserService in tests.
+var testAccountLinks = AccountLinks{
+	VerifyEmailURL:   "https://app.example.com/verify",
+	PasswordResetURL: "https://app.example.com/reset?lang=en",
+}
+
+// mockMailer is a mock implementation of Mailer recording the sent emails.
+type mockMailer struct {
+	mails []ports.Mail
+}
+
+func newMockMailer() *mockMailer {
+	return &mockMailer{}
+}
+
+func (m *mockMailer) Send(ctx context.Context, mail ports.Mail) error {
+	m.mails = append(m.mails, mail)
+	return nil
+}
+
+// lastToken returns the token of the link in the last email sent to address.
+func (m *mockMailer) lastToken(t *testing.T, address string) string {
+	t.Helper()
+
+	for i := len(m.mails) - 1; i >= 0; i-- {
+		if m.mails[i].To != address {
+			continue
+		}
+		for _, field := range strings.Fields(m.mails[i].Body) {
+			if link, err := url.Parse(field); err == nil && link.Query().Has("token") {
+				return link.Query().Get("token")
+			}
+		}
+	}
+
+	t.Fatalf("no link sent to %s", address)
+	return ""
+}
+
+// mockActionTokenSigner is a mock implementation of ActionTokenSigner whose tokens
+// are the readable "kind|user|workspace|fingerprint|expiration" tuple.
+type mockActionTokenSigner struct{}
+
+func (m *mockActionTokenSigner) SignAction(token auth.ActionToken) (string, error) {
+	return strings.Join([]string{
+		string(token.Kind), token.UserID.String(), token.WorkspaceID.String(), token.Fingerprint,
+		strconv.FormatInt(token.ExpiresAt.Unix(), 10),
+	}, "|"), nil
+}
+
+func (m *mockActionTokenSigner) VerifyAction(token string, kind auth.TokenKind) (*auth.ActionToken, error) {
+	parts := strings.Split(token, "|")
+	if len(parts) != 5 || auth.TokenKind(parts[0]) != kind {
+		return nil, core.ErrInvalidToken
+	}
+
+	expiresAt, err := strconv.ParseInt(parts[4], 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return nil, core.ErrInvalidToken
+	}
+
+	return &auth.ActionToken{
+		Kind:        kind,
+		UserID:      uuid.MustParse(parts[1]),
+		WorkspaceID: uuid.MustParse(parts[2]),
+		Fingerprint: parts[3],
+		ExpiresAt:   time.Unix(expiresAt, 0),
+	}, nil
+}
+
+// mockAuthorizer is a mock implementation of Authorizer allowing every operation
+// except the permissions listed in denied.
+type mockAuthorizer struct {
+	denied map[policy.Permission]bool
+}
+
+func newMockAuthorizer(denied ...policy.Permission) *mockAuthorizer {
+	m := &mockAuthorizer{denied: make(map[policy.Permission]bool)}
+	for _, permission := range denied {
+		m.denied[permission] = true
+	}
+	return m
+}
+
+func (m *mockAuthorizer) Authorize(ctx context.Context, permission policy.Permission, ownerID uuid.UUID) error {
+	if m.denied[permission] {
+		return core.ErrForbidden
+	}
+	return nil
+}
+
+// mockBusinessMetrics is a mock implementation of BusinessMetrics counting the events.
+type mockBusinessMetrics struct {
+	registered map[string]int
+	created    int
+	completed  int
+}
+
+func newMockBusinessMetrics() *mockBusinessMetrics {
+	return &mockBusinessMetrics{registered: make(map[string]int)}
+}
+
+func (m *mockBusinessMetrics) UserRegistered(ctx context.Context, method string) {
+	m.registered[method]++
+}
+
+func (m *mockBusinessMetrics) TaskCreated(ctx context.Context) {
+	m.created++
+}
+
+func (m *mockBusinessMetrics) TaskCompleted(ctx context.Context) {
+	m.completed++
+}
+
+// mockEventPublisher is a mock implementation of EventPublisher recording the events.
+type mockEventPublisher struct {
+	events []domain.Event
+}
+
+func newMockEventPublisher() *mockEventPublisher {
+	return &mockEventPublisher{}
+}
+
+func (m *mockEventPublisher) Publish(ctx context.Context, event domain.Event) {
+	m.events = append(m.events, event)
+}
+
+// types returns the types of the recorded events in order.
+func (m *mockEventPublisher) types() []domain.EventType {
+	types := make([]domain.EventType, len(m.events))
+	for i, event := range m.events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+// newMockInfrastructure returns the Infrastructure of the services under test, made
+// of new mocks.
+func newMockInfrastructure() Infrastructure {
+	return Infrastructure{Metrics: newMockBusinessMetrics(), Events: newMockEventPublisher(), Tx: &mockTransactor{}, Outbox: newMockOutbox()}
+}
+
 type mockUserRepositoryWithError struct{}
 
+// mockUserRepositoryFailingUpdates is a mockUserRepository whose updates fail as
+// when the database is unreachable.
+type mockUserRepositoryFailingUpdates struct {
+	*mockUserRepository
+}
+
+func (m *mockUserRepositoryFailingUpdates) Update(ctx context.Context, id uuid.UUID, user *domain.User) error {
+	return errors.New("connection reset by peer")
+}
+
 func (m *mockUserRepositoryWithError) FindAll(ctx context.Context) ([]*domain.User, error) {
 	return nil, core.ErrFindAllUsers
 }
@@ -115,6 +322,10 @@ func (m *mockUserRepositoryWithError) FindByEmail(ctx context.Context, email str
 	return nil, core.ErrFindByEmail
 }
 
+func (m *mockUserRepositoryWithError) FindByEmailInAnyWorkspace(ctx context.Context, email string) (*domain.User, error) {
+	return nil, core.ErrFindByEmail
+}
+
 func (m *mockUserRepositoryWithError) Save(ctx context.Context, user *domain.User) error {
 	return core.ErrSaveUser
 }
@@ -123,17 +334,26 @@ func (m *mockUserRepositoryWithError) Update(ctx context.Context, id uuid.UUID,
 	return core.ErrUserNotFound
 }
 
-func (m *mockUserRepositoryWithError) UpdateFields(ctx context.Context, id uuid.UUID, fields map[string]any) (*domain.User, error) {
-	return nil, core.ErrUserNotFound
-}
-
 func (m *mockUserRepositoryWithError) Delete(ctx context.Context, id uuid.UUID) error {
 	return core.ErrDeleteUser
 }
 
+func (m *mockUserRepositoryWithError) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	return core.ErrUserNotFound
+}
+
+func (m *mockUserRepositoryWithError) MarkEmailVerified(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return core.ErrUserNotFound
+}
+
+func (m *mockUserRepositoryWithError) UpdateRole(ctx context.Context, id uuid.UUID, role domain.UserRole) error {
+	return core.ErrUserNotFound
+}
+
 func TestRegisterUser(t *testing.T) {
 	repo := newMockUserRepository()
-	service := NewUserService(repo)
+	metrics := newMockBusinessMetrics()
+	service := NewUserService(repo, newMockPasswordHasher(), newMockAuthorizer(), newMockMailer(), &mockActionTokenSigner{}, testAccountLinks, Infrastructure{Metrics: metrics, Events: newMockEventPublisher(), Tx: &mockTransactor{}, Outbox: newMockOutbox()})
 
 	testNewUsers := []struct {
 		Context context.Context
@@ -146,7 +366,7 @@ func TestRegisterUser(t *testing.T) {
 	t.Run("RegisterUser", func(t *testing.T) {
 		for _, user := range testNewUsers {
 			t.Run(user.User.Username, func(t *testing.T) {
-				createdUser, err := service.RegisterUser(user.Context, &user.User)
+				createdUser, err := service.RegisterUser(user.Context, &user.User, testPassword)
 				if err != nil {
 					t.Fatalf("Failed to create user: %v", err)
 				}
@@ -158,15 +378,21 @@ func TestRegisterUser(t *testing.T) {
 		}
 	})
 
+	t.Run("RegisterUser_Metrics", func(t *testing.T) {
+		if got := metrics.registered[ports.RegistrationPassword]; got != len(testNewUsers) {
+			t.Errorf("Expected %d registered users, got %d", len(testNewUsers), got)
+		}
+	})
+
 	t.Run("RegisterUser_DuplicateEmail", func(t *testing.T) {
 		user := domain.User{Username: "duplicateuser", Email: "duplicate@example.com"}
-		_, err := service.RegisterUser(context.Background(), &user)
+		_, err := service.RegisterUser(context.Background(), &user, testPassword)
 		if err != nil {
 			t.Fatalf("Failed to create user: %v", err)
 		}
 
 		// Try to create the user again
-		_, err = service.RegisterUser(context.Background(), &user)
+		_, err = service.RegisterUser(context.Background(), &user, testPassword)
 		if err == nil {
 			t.Errorf("Expected error when creating duplicate user, got nil")
 		}
@@ -174,7 +400,7 @@ func TestRegisterUser(t *testing.T) {
 
 	t.Run("RegisterUser_InvalidEmail", func(t *testing.T) {
 		user := domain.User{Username: "invalidUser", Email: "invalidemail"}
-		_, err := service.RegisterUser(context.Background(), &user)
+		_, err := service.RegisterUser(context.Background(), &user, testPassword)
 		if err == nil {
 			t.Errorf("Expected error when creating user with invalid email, got nil")
 		}
@@ -182,12 +408,25 @@ func TestRegisterUser(t *testing.T) {
 
 	t.Run("RegisterUser_EmailInUse", func(t *testing.T) {
 		user := domain.User{Username: "emailInUseUser", Email: "error@example.com"}
-		_, err := service.RegisterUser(context.Background(), &user)
+		_, err := service.RegisterUser(context.Background(), &user, testPassword)
 		if err == nil {
 			t.Errorf("Expected error when creating user with email in use, got nil")
 		}
 	})
 
+	t.Run("RegisterUser_EmailInOtherWorkspace", func(t *testing.T) {
+		repo.others = map[string]*domain.User{"elsewhere@example.com": {ID: uuid.New(), Email: "elsewhere@example.com"}}
+		defer func() { repo.others = nil }()
+
+		user := domain.User{Username: "elsewhere", Email: "elsewhere@example.com"}
+		if _, err := service.RegisterUser(context.Background(), &user, testPassword); !errors.Is(err, core.ErrEmailAlreadyExists) {
+			t.Errorf("Expected ErrEmailAlreadyExists, got: %v", err)
+		}
+		if _, ok := repo.users["elsewhere@example.com"]; ok {
+			t.Error("Expected no user to be saved")
+		}
+	})
+
 	t.Run("RegisterUser_EmailInUseError", func(t *testing.T) {
 		user := domain.User{Username: "errorUser", Email: "error@example.com"}
 		_, emailCheckError := utils.IsEmailInUse(repo, context.Background(), user.Email, uuid.Nil)
@@ -195,17 +434,39 @@ func TestRegisterUser(t *testing.T) {
 			t.Fatalf("Expected error from email checker, got nil")
 		}
 
-		_, err := service.RegisterUser(context.Background(), &user)
+		_, err := service.RegisterUser(context.Background(), &user, testPassword)
 		if err == nil {
 			t.Errorf("Expected error when email check fails, got nil")
 		}
 	})
 
+	t.Run("RegisterUser_Credentials", func(t *testing.T) {
+		user := domain.User{Username: "credentials", Email: "credentials@example.com", Role: domain.UserRoleAdmin}
+		createdUser, err := service.RegisterUser(context.Background(), &user, testPassword)
+		if err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		if createdUser.PasswordHash != "hashed:"+testPassword {
+			t.Errorf("Expected the password to be hashed, got %q", createdUser.PasswordHash)
+		}
+		if createdUser.Role != domain.UserRoleUser {
+			t.Errorf("Expected role %q, got %q", domain.UserRoleUser, createdUser.Role)
+		}
+	})
+
+	t.Run("RegisterUser_InvalidPassword", func(t *testing.T) {
+		user := domain.User{Username: "shortPassword", Email: "short@example.com"}
+		_, err := service.RegisterUser(context.Background(), &user, "short")
+		if !errors.Is(err, core.ErrInvalidPassword) {
+			t.Errorf("Expected ErrInvalidPassword, got: %v", err)
+		}
+	})
+
 	t.Run("RegisterUser_SaveError", func(t *testing.T) {
 		log.SetOutput(io.Discard)
 
 		user := domain.User{Username: "errorUser", Email: "save_error@example.com"}
-		_, err := service.RegisterUser(context.Background(), &user)
+		_, err := service.RegisterUser(context.Background(), &user, testPassword)
 		if err == nil {
 			t.Errorf("Expected error when saving user fails, got nil")
 		}
@@ -214,7 +475,7 @@ func TestRegisterUser(t *testing.T) {
 
 func TestGetAllUsers(t *testing.T) {
 	repo := newMockUserRepository()
-	service := NewUserService(repo)
+	service := NewUserService(repo, newMockPasswordHasher(), newMockAuthorizer(), newMockMailer(), &mockActionTokenSigner{}, testAccountLinks, newMockInfrastructure())
 
 	// Create some test users
 	testUsers := []domain.User{
@@ -224,7 +485,7 @@ func TestGetAllUsers(t *testing.T) {
 	}
 
 	for _, user := range testUsers {
-		_, err := service.RegisterUser(context.Background(), &user)
+		_, err := service.RegisterUser(context.Background(), &user, testPassword)
 		if err != nil {
 			t.Fatalf("Failed to create user: %v", err)
 		}
@@ -242,7 +503,7 @@ func TestGetAllUsers(t *testing.T) {
 
 	t.Run("GetAllUsers_Empty", func(t *testing.T) {
 		emptyRepo := newMockUserRepository()
-		emptyService := NewUserService(emptyRepo)
+		emptyService := NewUserService(emptyRepo, newMockPasswordHasher(), newMockAuthorizer(), newMockMailer(), &mockActionTokenSigner{}, testAccountLinks, newMockInfrastructure())
 
 		users, err := emptyService.GetAllUsers(context.Background())
 		if err != nil {
@@ -255,7 +516,7 @@ func TestGetAllUsers(t *testing.T) {
 
 	t.Run("GetAllUsers_Error", func(t *testing.T) {
 		errorRepo := &mockUserRepositoryWithError{}
-		errorService := NewUserService(errorRepo)
+		errorService := NewUserService(errorRepo, newMockPasswordHasher(), newMockAuthorizer(), newMockMailer(), &mockActionTokenSigner{}, testAccountLinks, newMockInfrastructure())
 
 		_, err := errorService.GetAllUsers(context.Background())
 		if err == nil {
@@ -266,11 +527,11 @@ func TestGetAllUsers(t *testing.T) {
 
 func TestGetUserByID(t *testing.T) {
 	repo := newMockUserRepository()
-	service := NewUserService(repo)
+	service := NewUserService(repo, newMockPasswordHasher(), newMockAuthorizer(), newMockMailer(), &mockActionTokenSigner{}, testAccountLinks, newMockInfrastructure())
 
 	// Create a test user
 	user := domain.User{Username: "testuser", Email: "testuser@example.com"}
-	_, err := service.RegisterUser(context.Background(), &user)
+	_, err := service.RegisterUser(context.Background(), &user, testPassword)
 	if err != nil {
 		t.Fatalf("Failed to create user: %v", err)
 	}
@@ -298,7 +559,7 @@ func TestGetUserByID(t *testing.T) {
 
 	t.Run("GetUserByID_Error", func(t *testing.T) {
 		errorRepo := &mockUserRepositoryWithError{}
-		errorService := NewUserService(errorRepo)
+		errorService := NewUserService(errorRepo, newMockPasswordHasher(), newMockAuthorizer(), newMockMailer(), &mockActionTokenSigner{}, testAccountLinks, newMockInfrastructure())
 
 		_, err := errorService.GetUserByID(context.Background(), user.ID)
 		if err == nil {
@@ -317,11 +578,11 @@ func TestGetUserByID(t *testing.T) {
 
 func TestUpdateUser(t *testing.T) {
 	repo := newMockUserRepository()
-	service := NewUserService(repo)
+	service := NewUserService(repo, newMockPasswordHasher(), newMockAuthorizer(), newMockMailer(), &mockActionTokenSigner{}, testAccountLinks, newMockInfrastructure())
 
 	// Create a test user
 	user := domain.User{ID: uuid.New(), Username: "testuser", Email: "testuser@example.com"}
-	_, err := service.RegisterUser(context.Background(), &user)
+	_, err := service.RegisterUser(context.Background(), &user, testPassword)
 	if err != nil {
 		t.Fatalf("Failed to create user: %v", err)
 	}
@@ -340,14 +601,14 @@ func TestUpdateUser(t *testing.T) {
 		nonExistentID := uuid.New()
 		updatedUser := domain.User{ID: nonExistentID, Username: "updateduser", Email: "updateduser_notfound@example.com"}
 		err := service.UpdateUser(context.Background(), updatedUser.ID, &updatedUser)
-		if !errors.Is(err, core.ErrUpdateUser) {
-			t.Fatalf("Expected ErrUpdateUser, got: %v", err)
+		if !errors.Is(err, core.ErrUserNotFound) {
+			t.Fatalf("Expected ErrUserNotFound, got: %v", err)
 		}
 	})
 
 	t.Run("UpdateUser_Error", func(t *testing.T) {
 		errorRepo := &mockUserRepositoryWithError{}
-		errorService := NewUserService(errorRepo)
+		errorService := NewUserService(errorRepo, newMockPasswordHasher(), newMockAuthorizer(), newMockMailer(), &mockActionTokenSigner{}, testAccountLinks, newMockInfrastructure())
 
 		err := errorService.UpdateUser(context.Background(), user.ID, &user)
 		if err == nil {
@@ -366,7 +627,7 @@ func TestUpdateUser(t *testing.T) {
 	t.Run("UpdateUser_AlreadyExists", func(t *testing.T) {
 		// Create another user to cause email conflict
 		anotherUser := domain.User{Username: "anotheruser", Email: "anotheruser@example.com"}
-		_, err := service.RegisterUser(context.Background(), &anotherUser)
+		_, err := service.RegisterUser(context.Background(), &anotherUser, testPassword)
 		if err != nil {
 			t.Fatalf("Failed to create another user: %v", err)
 		}
@@ -380,91 +641,83 @@ func TestUpdateUser(t *testing.T) {
 	})
 }
 
-func TestUpdateUserFields(t *testing.T) {
+func TestPatchUser(t *testing.T) {
 	repo := newMockUserRepository()
-	service := NewUserService(repo)
+	service := NewUserService(repo, newMockPasswordHasher(), newMockAuthorizer(), newMockMailer(), &mockActionTokenSigner{}, testAccountLinks, newMockInfrastructure())
 
 	// Create a test user
 	user := domain.User{ID: uuid.New(), Username: "testuser", Email: "testuser@example.com"}
-	_, err := service.RegisterUser(context.Background(), &user)
+	_, err := service.RegisterUser(context.Background(), &user, testPassword)
 	if err != nil {
 		t.Fatalf("Failed to create user: %v", err)
 	}
 
-	t.Run("UpdateUserFields", func(t *testing.T) {
-		updatedFields := map[string]interface{}{
-			"username": "updateduser",
-			"email":    "updateduser@example.com",
-		}
-		_, err := service.UpdateUserFields(context.Background(), user.ID, updatedFields)
+	t.Run("PatchUser", func(t *testing.T) {
+		patched, err := service.PatchUser(context.Background(), user.ID, func(u *domain.User) error {
+			u.Username = "patcheduser"
+			return nil
+		})
 		if err != nil {
-			t.Fatalf("Failed to update user fields: %v", err)
+			t.Fatalf("Failed to patch user: %v", err)
 		}
-	})
-
-	t.Run("UpdateUserFields_NotFound", func(t *testing.T) {
-		log.SetOutput(io.Discard)
-
-		nonExistentID := uuid.New()
-		updatedFields := map[string]interface{}{
-			"username": "updateduser",
-			"email":    "updateduser@example.com",
+		if patched.Username != "patcheduser" || patched.Email != user.Email {
+			t.Errorf("Expected only the username to change, got %+v", patched)
 		}
-		_, err := service.UpdateUserFields(context.Background(), nonExistentID, updatedFields)
-		if !errors.Is(err, core.ErrUpdateUser) {
-			t.Fatalf("Expected ErrUpdateUser, got: %v", err)
+		if stored, _ := repo.FindByID(context.Background(), user.ID); stored.Username != "patcheduser" {
+			t.Errorf("Expected the patched user to be saved, got %+v", stored)
 		}
 	})
 
-	t.Run("UpdateUserFields_Error", func(t *testing.T) {
-		nonExistentID := uuid.New()
-		updatedFields := map[string]any{
-			"username": "updateduser",
-			"email":    "updateduser@example.com",
-		}
-		_, err := service.UpdateUserFields(context.Background(), nonExistentID, updatedFields)
-		if !errors.Is(err, core.ErrUpdateUser) {
-			t.Fatalf("Expected ErrUpdateUser, got: %v", err)
+	t.Run("PatchUser_NotFound", func(t *testing.T) {
+		_, err := service.PatchUser(context.Background(), uuid.New(), func(u *domain.User) error { return nil })
+		if !errors.Is(err, core.ErrUserNotFound) {
+			t.Fatalf("Expected ErrUserNotFound, got: %v", err)
 		}
 	})
 
-	t.Run("UpdateUserFields_Error", func(t *testing.T) {
-		errorRepo := &mockUserRepositoryWithError{}
-		errorService := NewUserService(errorRepo)
+	t.Run("PatchUser_PatchError", func(t *testing.T) {
+		errPatch := errors.New("invalid patch")
 
-		updatedFields := map[string]interface{}{
-			"username": "updateduser",
-			"email":    "updateduser@example.com",
-		}
-		_, err := errorService.UpdateUserFields(context.Background(), user.ID, updatedFields)
-		if err == nil {
-			t.Errorf("Expected error when repository fails, got nil")
+		_, err := service.PatchUser(context.Background(), user.ID, func(u *domain.User) error { return errPatch })
+		if !errors.Is(err, errPatch) {
+			t.Fatalf("Expected the error of the patch, got: %v", err)
 		}
 	})
 
-	t.Run("UpdateUserFields_InvalidEmail", func(t *testing.T) {
-		updatedFields := map[string]any{
-			"email": "invalidemail",
+	t.Run("PatchUser_Error", func(t *testing.T) {
+		log.SetOutput(io.Discard)
+
+		failing := &mockUserRepositoryFailingUpdates{repo}
+		failingService := NewUserService(failing, newMockPasswordHasher(), newMockAuthorizer(), newMockMailer(), &mockActionTokenSigner{}, testAccountLinks, newMockInfrastructure())
+
+		_, err := failingService.PatchUser(context.Background(), user.ID, func(u *domain.User) error { return nil })
+		if !errors.Is(err, core.ErrUpdateUser) {
+			t.Fatalf("Expected ErrUpdateUser, got: %v", err)
 		}
-		_, err := service.UpdateUserFields(context.Background(), user.ID, updatedFields)
-		if err == nil {
-			t.Errorf("Expected error when updating user with invalid email, got nil")
+	})
+
+	t.Run("PatchUser_InvalidEmail", func(t *testing.T) {
+		_, err := service.PatchUser(context.Background(), user.ID, func(u *domain.User) error {
+			u.Email = "invalidemail"
+			return nil
+		})
+		if !errors.Is(err, core.ErrInvalidEmail) {
+			t.Errorf("Expected ErrInvalidEmail, got: %v", err)
 		}
 	})
 
-	t.Run("UpdateUserFields_AlreadyExists", func(t *testing.T) {
+	t.Run("PatchUser_AlreadyExists", func(t *testing.T) {
 		// Create another user to cause email conflict
 		anotherUser := domain.User{Username: "anotheruser", Email: "anotheruser@example.com"}
-		_, err := service.RegisterUser(context.Background(), &anotherUser)
+		_, err := service.RegisterUser(context.Background(), &anotherUser, testPassword)
 		if err != nil {
 			t.Fatalf("Failed to create another user: %v", err)
 		}
 
-		// Try to update the original user to have the same email as the new user
-		updatedFields := map[string]interface{}{
-			"email": anotherUser.Email,
-		}
-		_, err = service.UpdateUserFields(context.Background(), user.ID, updatedFields)
+		_, err = service.PatchUser(context.Background(), user.ID, func(u *domain.User) error {
+			u.Email = anotherUser.Email
+			return nil
+		})
 		if !errors.Is(err, core.ErrEmailAlreadyExists) {
 			t.Fatalf("Expected ErrEmailAlreadyExists, got: %v", err)
 		}
@@ -473,11 +726,11 @@ func TestUpdateUserFields(t *testing.T) {
 
 func TestDeleteUser(t *testing.T) {
 	repo := newMockUserRepository()
-	service := NewUserService(repo)
+	service := NewUserService(repo, newMockPasswordHasher(), newMockAuthorizer(), newMockMailer(), &mockActionTokenSigner{}, testAccountLinks, newMockInfrastructure())
 
 	// Create a test user
 	user := domain.User{ID: uuid.New(), Username: "testuser", Email: "testuser@example.com"}
-	_, err := service.RegisterUser(context.Background(), &user)
+	_, err := service.RegisterUser(context.Background(), &user, testPassword)
 	if err != nil {
 		t.Fatalf("Failed to create user: %v", err)
 	}
@@ -501,7 +754,7 @@ func TestDeleteUser(t *testing.T) {
 
 	t.Run("DeleteUser_Error", func(t *testing.T) {
 		errorRepo := &mockUserRepositoryWithError{}
-		errorService := NewUserService(errorRepo)
+		errorService := NewUserService(errorRepo, newMockPasswordHasher(), newMockAuthorizer(), newMockMailer(), &mockActionTokenSigner{}, testAccountLinks, newMockInfrastructure())
 
 		err := errorService.DeleteUser(context.Background(), user.ID)
 		if err == nil {
@@ -509,3 +762,255 @@ func TestDeleteUser(t *testing.T) {
 		}
 	})
 }
+
+func TestPromoteToAdmin(t *testing.T) {
+	repo := newMockUserRepository()
+	service := NewUserService(repo, newMockPasswordHasher(), newMockAuthorizer(), newMockMailer(), &mockActionTokenSigner{}, testAccountLinks, newMockInfrastructure())
+
+	user := domain.User{Username: "promoted", Email: "promoted@example.com"}
+	if _, err := service.RegisterUser(context.Background(), &user, testPassword); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	t.Run("PromoteToAdmin", func(t *testing.T) {
+		if err := service.PromoteToAdmin(context.Background(), user.ID); err != nil {
+			t.Fatalf("Failed to promote user: %v", err)
+		}
+
+		if saved, _ := repo.FindByID(context.Background(), user.ID); saved.Role != domain.UserRoleAdmin {
+			t.Errorf("Expected role admin, got: %v", saved.Role)
+		}
+	})
+
+	t.Run("PromoteToAdmin_Denied", func(t *testing.T) {
+		denied := NewUserService(repo, newMockPasswordHasher(), newMockAuthorizer(policy.UsersPromote), newMockMailer(), &mockActionTokenSigner{}, testAccountLinks, newMockInfrastructure())
+
+		if err := denied.PromoteToAdmin(context.Background(), user.ID); !errors.Is(err, core.ErrForbidden) {
+			t.Errorf("Expected ErrForbidden, got: %v", err)
+		}
+	})
+
+	t.Run("PromoteToAdmin_NotFound", func(t *testing.T) {
+		if err := service.PromoteToAdmin(context.Background(), uuid.New()); !errors.Is(err, core.ErrUserNotFound) {
+			t.Errorf("Expected ErrUserNotFound, got: %v", err)
+		}
+	})
+}
+
+func TestUserServiceEvents(t *testing.T) {
+	repo := newMockUserRepository()
+	events := newMockEventPublisher()
+	outbox := newMockOutbox()
+	service := NewUserService(repo, newMockPasswordHasher(), newMockAuthorizer(), newMockMailer(), &mockActionTokenSigner{}, testAccountLinks, Infrastructure{Metrics: newMockBusinessMetrics(), Events: events, Tx: &mockTransactor{outbox: outbox}, Outbox: outbox})
+
+	user := domain.User{ID: uuid.New(), Username: "followed", Email: "followed@example.com"}
+	if _, err := service.RegisterUser(context.Background(), &user, testPassword); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	if err := service.UpdateUser(context.Background(), user.ID, &domain.User{Username: "renamed", Email: "followed@example.com"}); err != nil {
+		t.Fatalf("Failed to update user: %v", err)
+	}
+	if err := service.DeleteUser(context.Background(), user.ID); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+
+	want := []domain.EventType{domain.EventUserUpdated, domain.EventUserDeleted}
+	if got := events.types(); !slices.Equal(got, want) {
+		t.Fatalf("Expected events %v, got %v", want, got)
+	}
+
+	updated := events.events[0]
+	if updated.User.Username != "renamed" || updated.User.PasswordHash != "" {
+		t.Errorf("Expected the updated user without its password hash, got %+v", updated.User)
+	}
+	for _, event := range events.events {
+		if !slices.Equal(event.Recipients, []uuid.UUID{user.ID}) {
+			t.Errorf("Expected the %s event to be published to the user, got %v", event.Type, event.Recipients)
+		}
+	}
+
+	if got := outbox.types(); !slices.Equal(got, []domain.DomainEventType{domain.DomainEventUserRegistered}) {
+		t.Fatalf("Expected the registration in the outbox, got %v", got)
+	}
+	var registered domain.UserRegistered
+	if err := json.Unmarshal(outbox.events[0].event.Payload, &registered); err != nil {
+		t.Fatalf("Expected a JSON payload, got: %v", err)
+	}
+	if registered.UserID != user.ID || registered.Email != user.Email || registered.Method != string(ports.RegistrationPassword) {
+		t.Errorf("Expected the registered user, got %+v", registered)
+	}
+}
+
+func TestUserServicePolicy(t *testing.T) {
+	repo := newMockUserRepository()
+	service := NewUserService(repo, newMockPasswordHasher(), newMockAuthorizer(policy.UsersCreate, policy.UsersList, policy.UsersRead, policy.UsersUpdate, policy.UsersDelete), newMockMailer(), &mockActionTokenSigner{}, testAccountLinks, newMockInfrastructure())
+
+	user := &domain.User{ID: uuid.New(), Username: "protected", Email: "protected@example.com"}
+	repo.users[user.Email] = user
+
+	calls := map[string]func() error{
+		"RegisterUser": func() error {
+			_, err := service.RegisterUser(context.Background(), &domain.User{Username: "new", Email: "new@example.com"}, testPassword)
+			return err
+		},
+		"GetAllUsers": func() error { _, err := service.GetAllUsers(context.Background()); return err },
+		"GetUserByID": func() error { _, err := service.GetUserByID(context.Background(), user.ID); return err },
+		"UpdateUser": func() error {
+			return service.UpdateUser(context.Background(), user.ID, &domain.User{Email: "x@example.com"})
+		},
+		"PatchUser": func() error {
+			_, err := service.PatchUser(context.Background(), user.ID, func(*domain.User) error { return nil })
+			return err
+		},
+		"DeleteUser": func() error { return service.DeleteUser(context.Background(), user.ID) },
+	}
+
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			if err := call(); !errors.Is(err, core.ErrForbidden) {
+				t.Errorf("Expected ErrForbidden, got: %v", err)
+			}
+		})
+	}
+
+	if _, exists := repo.users[user.Email]; !exists || len(repo.users) != 1 {
+		t.Error("Expected denied operations not to reach the repository")
+	}
+}
+
+func TestUserServiceAccountLinks(t *testing.T) {
+	repo := newMockUserRepository()
+	mailer := newMockMailer()
+	service := NewUserService(repo, newMockPasswordHasher(), newMockAuthorizer(), mailer, &mockActionTokenSigner{}, testAccountLinks, newMockInfrastructure())
+
+	workspaceID := uuid.New()
+	ctx := tenant.WithWorkspace(context.Background(), workspaceID)
+
+	register := func(t *testing.T, email string) *domain.User {
+		t.Helper()
+
+		user, err := service.RegisterUser(ctx, &domain.User{Username: email, Email: email}, testPassword)
+		if err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		return user
+	}
+
+	t.Run("RegisterUser_SendsVerification", func(t *testing.T) {
+		user := register(t, "verify@example.com")
+
+		if user.EmailVerified() {
+			t.Error("Expected a new user not to be verified")
+		}
+
+		mail := mailer.mails[len(mailer.mails)-1]
+		if mail.To != user.Email || !strings.Contains(mail.Body, testAccountLinks.VerifyEmailURL+"?token=") {
+			t.Errorf("Expected a verification link sent to %s, got %+v", user.Email, mail)
+		}
+	})
+
+	t.Run("VerifyEmail", func(t *testing.T) {
+		user := register(t, "confirm@example.com")
+		token := mailer.lastToken(t, user.Email)
+
+		// The link is opened without workspace, which the token carries.
+		if err := service.VerifyEmail(context.Background(), token); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if !user.EmailVerified() {
+			t.Error("Expected the email to be verified")
+		}
+	})
+
+	t.Run("VerifyEmail_InvalidToken", func(t *testing.T) {
+		user := register(t, "invalid@example.com")
+		token := mailer.lastToken(t, user.Email)
+
+		changed := register(t, "changed@example.com")
+		changedToken := mailer.lastToken(t, changed.Email)
+		changed.Email = "other@example.com"
+
+		expired, _ := (&mockActionTokenSigner{}).SignAction(auth.ActionToken{
+			Kind: auth.TokenVerifyEmail, UserID: user.ID, WorkspaceID: workspaceID,
+			Fingerprint: fingerprint(user.Email), ExpiresAt: time.Now().Add(-time.Minute),
+		})
+
+		tests := []struct {
+			name  string
+			token string
+		}{
+			{"Malformed", "not-a-token"},
+			{"Expired", expired},
+			{"OtherKind", strings.Replace(token, string(auth.TokenVerifyEmail), string(auth.TokenPasswordReset), 1)},
+			{"EmailChanged", changedToken},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if err := service.VerifyEmail(context.Background(), tt.token); !errors.Is(err, core.ErrInvalidToken) {
+					t.Errorf("Expected ErrInvalidToken, got: %v", err)
+				}
+			})
+		}
+	})
+
+	t.Run("ResendEmailVerification", func(t *testing.T) {
+		user := register(t, "resend@example.com")
+		sent := len(mailer.mails)
+
+		if err := service.ResendEmailVerification(ctx, user.Email); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if err := service.ResendEmailVerification(ctx, "unknown@example.com"); err != nil {
+			t.Fatalf("Expected unknown addresses to be ignored, got: %v", err)
+		}
+
+		if len(mailer.mails) != sent+1 {
+			t.Errorf("Expected a single email, got %d", len(mailer.mails)-sent)
+		}
+	})
+
+	t.Run("ResetPassword", func(t *testing.T) {
+		user := register(t, "reset@example.com")
+
+		if err := service.RequestPasswordReset(ctx, user.Email); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		mail := mailer.mails[len(mailer.mails)-1]
+		if !strings.Contains(mail.Body, "https://app.example.com/reset?lang=en&token=") {
+			t.Errorf("Expected a password reset link, got %q", mail.Body)
+		}
+		token := mailer.lastToken(t, user.Email)
+
+		if err := service.ResetPassword(context.Background(), token, "short"); !errors.Is(err, core.ErrInvalidPassword) {
+			t.Errorf("Expected ErrInvalidPassword, got: %v", err)
+		}
+
+		if err := service.ResetPassword(context.Background(), token, "n3w-password"); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if user.PasswordHash != "hashed:n3w-password" {
+			t.Errorf("Expected the new password to be stored, got %q", user.PasswordHash)
+		}
+		if !user.EmailVerified() {
+			t.Error("Expected the reset link to verify the email")
+		}
+
+		if err := service.ResetPassword(context.Background(), token, "an0ther-password"); !errors.Is(err, core.ErrInvalidToken) {
+			t.Errorf("Expected the token to be single use, got: %v", err)
+		}
+	})
+
+	t.Run("RequestPasswordReset_UnknownEmail", func(t *testing.T) {
+		sent := len(mailer.mails)
+
+		if err := service.RequestPasswordReset(ctx, "nobody@example.com"); err != nil {
+			t.Fatalf("Expected unknown addresses to be ignored, got: %v", err)
+		}
+		if len(mailer.mails) != sent {
+			t.Error("Expected no email")
+		}
+	})
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+	"github.com/google/uuid"
+)
+
+// WorkspaceService manages workspaces and their members. Workspaces isolate
+// teams sharing one deployment: users and tasks are only visible inside the
+// workspace selected for the request.
+type WorkspaceService struct {
+	wks ports.WorkspaceRepository
+	usr ports.UserRepository
+}
+
+// NewWorkspaceService creates a new instance of WorkspaceService using the provided
+// WorkspaceRepository and UserRepository.
+func NewWorkspaceService(w ports.WorkspaceRepository, u ports.UserRepository) *WorkspaceService {
+	return &WorkspaceService{wks: w, usr: u}
+}
+
+// ResolveWorkspace finds the workspace selected by a request. The reference can be
+// either the workspace ID or its slug; an empty reference selects the default workspace.
+// It returns core.ErrWorkspaceNotFound when no workspace matches the reference.
+func (w *WorkspaceService) ResolveWorkspace(ctx context.Context, reference string) (*domain.Workspace, error) {
+	reference = strings.TrimSpace(reference)
+	if reference == "" {
+		return w.wks.FindBySlug(ctx, domain.DefaultWorkspaceSlug)
+	}
+
+	if id, err := uuid.Parse(reference); err == nil {
+		return w.wks.FindByID(ctx, id)
+	}
+
+	return w.wks.FindBySlug(ctx, reference)
+}
+
+// CreateWorkspace creates a new workspace owned by ownerID. The owner must be a user
+// of the workspace carried by ctx; it becomes the first member of the new workspace
+// with the owner role.
+func (w *WorkspaceService) CreateWorkspace(ctx context.Context, ownerID uuid.UUID, name string) (*domain.Workspace, error) {
+	name = strings.TrimSpace(name)
+	slug := slugify(name)
+	if len(name) < 3 || slug == "" {
+		return nil, core.ErrInvalidWorkspaceName
+	}
+
+	if _, err := w.usr.FindByID(ctx, ownerID); err != nil {
+		return nil, core.ErrUserNotFound
+	}
+
+	workspace := &domain.Workspace{
+		ID:        uuid.New(),
+		Name:      name,
+		Slug:      slug,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if err := w.wks.Save(ctx, workspace); err != nil {
+		if errors.Is(err, core.ErrWorkspaceSlugExists) {
+			return nil, err
+		}
+		return nil, core.ErrSaveWorkspace
+	}
+
+	owner := &domain.WorkspaceMember{
+		WorkspaceID: workspace.ID,
+		UserID:      ownerID,
+		Role:        domain.WorkspaceRoleOwner,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	if err := w.wks.SaveMember(ctx, owner); err != nil {
+		return nil, core.ErrSaveWorkspace
+	}
+
+	return workspace, nil
+}
+
+// FindUserWorkspaces lists the workspaces the user belongs to.
+func (w *WorkspaceService) FindUserWorkspaces(ctx context.Context, userID uuid.UUID) ([]*domain.Workspace, error) {
+	return w.wks.FindUserWorkspaces(ctx, userID)
+}
+
+// FindMembers lists the members of a workspace. Only members of the workspace can
+// see who else belongs to it.
+func (w *WorkspaceService) FindMembers(ctx context.Context, userID uuid.UUID, workspaceID uuid.UUID) ([]*domain.WorkspaceMember, error) {
+	if _, err := w.member(ctx, workspaceID, userID); err != nil {
+		return nil, err
+	}
+
+	return w.wks.FindMembers(ctx, workspaceID)
+}
+
+// SaveMember adds memberID to the workspace or changes its role. The acting user must
+// be an owner or an admin of the workspace, and only owners can grant or revoke the
+// owner role. It returns core.ErrLastWorkspaceOwner when the change would leave the
+// workspace without owners.
+func (w *WorkspaceService) SaveMember(ctx context.Context, userID uuid.UUID, workspaceID uuid.UUID, memberID uuid.UUID, role domain.WorkspaceRole) (*domain.WorkspaceMember, error) {
+	if !role.IsValid() {
+		return nil, core.ErrInvalidWorkspaceRole
+	}
+
+	actor, err := w.manager(ctx, workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := w.wks.FindMember(ctx, workspaceID, memberID)
+	if err != nil && !errors.Is(err, core.ErrWorkspaceMemberMissing) {
+		return nil, err
+	}
+
+	if (role == domain.WorkspaceRoleOwner || (current != nil && current.Role == domain.WorkspaceRoleOwner)) &&
+		actor.Role != domain.WorkspaceRoleOwner {
+		return nil, core.ErrWorkspaceAccessDenied
+	}
+
+	if current != nil && current.Role == domain.WorkspaceRoleOwner && role != domain.WorkspaceRoleOwner {
+		if err := w.keepAnOwner(ctx, workspaceID); err != nil {
+			return nil, err
+		}
+	}
+
+	member := &domain.WorkspaceMember{
+		WorkspaceID: workspaceID,
+		UserID:      memberID,
+		Role:        role,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	if current != nil {
+		member.CreatedAt = current.CreatedAt
+	}
+
+	if err := w.wks.SaveMember(ctx, member); err != nil {
+		if errors.Is(err, core.ErrUserNotFound) {
+			return nil, err
+		}
+		return nil, core.ErrSaveWorkspace
+	}
+
+	return member, nil
+}
+
+// RemoveMember removes memberID from the workspace. Owners and admins can remove
+// other members, only owners can remove an owner, and any member can leave the
+// workspace by removing themselves, as long as an owner remains.
+func (w *WorkspaceService) RemoveMember(ctx context.Context, userID uuid.UUID, workspaceID uuid.UUID, memberID uuid.UUID) error {
+	actor, err := w.member(ctx, workspaceID, userID)
+	if err != nil {
+		return err
+	}
+
+	target, err := w.wks.FindMember(ctx, workspaceID, memberID)
+	if err != nil {
+		return err
+	}
+
+	if userID != memberID {
+		if !actor.Role.CanManageMembers() {
+			return core.ErrWorkspaceAccessDenied
+		}
+		if target.Role == domain.WorkspaceRoleOwner && actor.Role != domain.WorkspaceRoleOwner {
+			return core.ErrWorkspaceAccessDenied
+		}
+	}
+
+	if target.Role == domain.WorkspaceRoleOwner {
+		if err := w.keepAnOwner(ctx, workspaceID); err != nil {
+			return err
+		}
+	}
+
+	return w.wks.DeleteMember(ctx, workspaceID, memberID)
+}
+
+// member returns the membership of userID in the workspace, translating a missing
+// membership into core.ErrWorkspaceAccessDenied.
+func (w *WorkspaceService) member(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID) (*domain.WorkspaceMember, error) {
+	if _, err := w.wks.FindByID(ctx, workspaceID); err != nil {
+		return nil, err
+	}
+
+	member, err := w.wks.FindMember(ctx, workspaceID, userID)
+	if errors.Is(err, core.ErrWorkspaceMemberMissing) {
+		return nil, core.ErrWorkspaceAccessDenied
+	}
+
+	return member, err
+}
+
+// manager returns the membership of userID when it is allowed to manage members.
+func (w *WorkspaceService) manager(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID) (*domain.WorkspaceMember, error) {
+	member, err := w.member(ctx, workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !member.Role.CanManageMembers() {
+		return nil, core.ErrWorkspaceAccessDenied
+	}
+
+	return member, nil
+}
+
+// keepAnOwner fails with core.ErrLastWorkspaceOwner when the workspace has a single owner.
+func (w *WorkspaceService) keepAnOwner(ctx context.Context, workspaceID uuid.UUID) error {
+	members, err := w.wks.FindMembers(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+
+	owners := 0
+	for _, m := range members {
+		if m.Role == domain.WorkspaceRoleOwner {
+			owners++
+		}
+	}
+
+	if owners <= 1 {
+		return core.ErrLastWorkspaceOwner
+	}
+
+	return nil
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slugify derives a URL friendly identifier from a workspace name.
+func slugify(name string) string {
+	return strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
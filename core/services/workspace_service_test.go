@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/google/uuid"
+)
+
+type mockWorkspaceRepository struct {
+	workspaces map[uuid.UUID]*domain.Workspace
+	members    map[string]*domain.WorkspaceMember
+}
+
+func newMockWorkspaceRepository() *mockWorkspaceRepository {
+	return &mockWorkspaceRepository{
+		workspaces: make(map[uuid.UUID]*domain.Workspace),
+		members:    make(map[string]*domain.WorkspaceMember),
+	}
+}
+
+func memberKey(workspaceID, userID uuid.UUID) string {
+	return workspaceID.String() + ":" + userID.String()
+}
+
+func (m *mockWorkspaceRepository) Save(ctx context.Context, workspace *domain.Workspace) error {
+	for _, w := range m.workspaces {
+		if w.Slug == workspace.Slug {
+			return core.ErrWorkspaceSlugExists
+		}
+	}
+	m.workspaces[workspace.ID] = workspace
+	return nil
+}
+
+func (m *mockWorkspaceRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Workspace, error) {
+	if workspace, exists := m.workspaces[id]; exists {
+		return workspace, nil
+	}
+	return nil, core.ErrWorkspaceNotFound
+}
+
+func (m *mockWorkspaceRepository) FindBySlug(ctx context.Context, slug string) (*domain.Workspace, error) {
+	for _, workspace := range m.workspaces {
+		if workspace.Slug == slug {
+			return workspace, nil
+		}
+	}
+	return nil, core.ErrWorkspaceNotFound
+}
+
+func (m *mockWorkspaceRepository) FindUserWorkspaces(ctx context.Context, userID uuid.UUID) ([]*domain.Workspace, error) {
+	var workspaces []*domain.Workspace
+	for _, member := range m.members {
+		if member.UserID == userID {
+			workspaces = append(workspaces, m.workspaces[member.WorkspaceID])
+		}
+	}
+	return workspaces, nil
+}
+
+func (m *mockWorkspaceRepository) SaveMember(ctx context.Context, member *domain.WorkspaceMember) error {
+	m.members[memberKey(member.WorkspaceID, member.UserID)] = member
+	return nil
+}
+
+func (m *mockWorkspaceRepository) FindMember(ctx context.Context, workspaceID, userID uuid.UUID) (*domain.WorkspaceMember, error) {
+	if member, exists := m.members[memberKey(workspaceID, userID)]; exists {
+		return member, nil
+	}
+	return nil, core.ErrWorkspaceMemberMissing
+}
+
+func (m *mockWorkspaceRepository) FindMembers(ctx context.Context, workspaceID uuid.UUID) ([]*domain.WorkspaceMember, error) {
+	var members []*domain.WorkspaceMember
+	for _, member := range m.members {
+		if member.WorkspaceID == workspaceID {
+			members = append(members, member)
+		}
+	}
+	return members, nil
+}
+
+func (m *mockWorkspaceRepository) DeleteMember(ctx context.Context, workspaceID, userID uuid.UUID) error {
+	if _, exists := m.members[memberKey(workspaceID, userID)]; !exists {
+		return core.ErrWorkspaceMemberMissing
+	}
+	delete(m.members, memberKey(workspaceID, userID))
+	return nil
+}
+
+func TestResolveWorkspace(t *testing.T) {
+	repo := newMockWorkspaceRepository()
+	service := NewWorkspaceService(repo, newMockUserRepository())
+
+	defaultWorkspace := &domain.Workspace{ID: uuid.New(), Name: "Default", Slug: domain.DefaultWorkspaceSlug}
+	teamWorkspace := &domain.Workspace{ID: uuid.New(), Name: "Team", Slug: "team"}
+	repo.workspaces[defaultWorkspace.ID] = defaultWorkspace
+	repo.workspaces[teamWorkspace.ID] = teamWorkspace
+
+	tests := []struct {
+		name      string
+		reference string
+		want      *domain.Workspace
+		err       error
+	}{
+		{"Default", "", defaultWorkspace, nil},
+		{"ByID", teamWorkspace.ID.String(), teamWorkspace, nil},
+		{"BySlug", "team", teamWorkspace, nil},
+		{"Unknown", "unknown", nil, core.ErrWorkspaceNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := service.ResolveWorkspace(context.Background(), tt.reference)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected %v, got: %v", tt.err, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected workspace %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCreateWorkspace(t *testing.T) {
+	repo := newMockWorkspaceRepository()
+	userRepo := newMockUserRepository()
+	service := NewWorkspaceService(repo, userRepo)
+
+	owner := &domain.User{ID: uuid.New(), Username: "owner", Email: "owner@example.com"}
+	userRepo.users[owner.Email] = owner
+
+	t.Run("CreateWorkspace", func(t *testing.T) {
+		workspace, err := service.CreateWorkspace(context.Background(), owner.ID, "Platform Team")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if workspace.Slug != "platform-team" {
+			t.Errorf("Expected slug platform-team, got %s", workspace.Slug)
+		}
+
+		member, err := repo.FindMember(context.Background(), workspace.ID, owner.ID)
+		if err != nil || member.Role != domain.WorkspaceRoleOwner {
+			t.Errorf("Expected owner membership, got %+v (%v)", member, err)
+		}
+	})
+
+	t.Run("CreateWorkspace_DuplicateSlug", func(t *testing.T) {
+		_, err := service.CreateWorkspace(context.Background(), owner.ID, "platform team")
+		if !errors.Is(err, core.ErrWorkspaceSlugExists) {
+			t.Errorf("Expected ErrWorkspaceSlugExists, got: %v", err)
+		}
+	})
+
+	t.Run("CreateWorkspace_InvalidName", func(t *testing.T) {
+		_, err := service.CreateWorkspace(context.Background(), owner.ID, " -- ")
+		if !errors.Is(err, core.ErrInvalidWorkspaceName) {
+			t.Errorf("Expected ErrInvalidWorkspaceName, got: %v", err)
+		}
+	})
+
+	t.Run("CreateWorkspace_UnknownOwner", func(t *testing.T) {
+		_, err := service.CreateWorkspace(context.Background(), uuid.New(), "Another Team")
+		if !errors.Is(err, core.ErrUserNotFound) {
+			t.Errorf("Expected ErrUserNotFound, got: %v", err)
+		}
+	})
+}
+
+func TestWorkspaceMembers(t *testing.T) {
+	repo := newMockWorkspaceRepository()
+	service := NewWorkspaceService(repo, newMockUserRepository())
+
+	workspace := &domain.Workspace{ID: uuid.New(), Name: "Team", Slug: "team"}
+	repo.workspaces[workspace.ID] = workspace
+
+	ownerID, adminID, memberID, outsiderID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	repo.SaveMember(context.Background(), &domain.WorkspaceMember{WorkspaceID: workspace.ID, UserID: ownerID, Role: domain.WorkspaceRoleOwner})
+	repo.SaveMember(context.Background(), &domain.WorkspaceMember{WorkspaceID: workspace.ID, UserID: adminID, Role: domain.WorkspaceRoleAdmin})
+
+	t.Run("SaveMember_ByAdmin", func(t *testing.T) {
+		if _, err := service.SaveMember(context.Background(), adminID, workspace.ID, memberID, domain.WorkspaceRoleMember); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("SaveMember_AdminCannotGrantOwner", func(t *testing.T) {
+		_, err := service.SaveMember(context.Background(), adminID, workspace.ID, memberID, domain.WorkspaceRoleOwner)
+		if !errors.Is(err, core.ErrWorkspaceAccessDenied) {
+			t.Errorf("Expected ErrWorkspaceAccessDenied, got: %v", err)
+		}
+	})
+
+	t.Run("SaveMember_ByMember", func(t *testing.T) {
+		_, err := service.SaveMember(context.Background(), memberID, workspace.ID, outsiderID, domain.WorkspaceRoleMember)
+		if !errors.Is(err, core.ErrWorkspaceAccessDenied) {
+			t.Errorf("Expected ErrWorkspaceAccessDenied, got: %v", err)
+		}
+	})
+
+	t.Run("SaveMember_InvalidRole", func(t *testing.T) {
+		_, err := service.SaveMember(context.Background(), ownerID, workspace.ID, memberID, domain.WorkspaceRole("guest"))
+		if !errors.Is(err, core.ErrInvalidWorkspaceRole) {
+			t.Errorf("Expected ErrInvalidWorkspaceRole, got: %v", err)
+		}
+	})
+
+	t.Run("FindMembers_Outsider", func(t *testing.T) {
+		_, err := service.FindMembers(context.Background(), outsiderID, workspace.ID)
+		if !errors.Is(err, core.ErrWorkspaceAccessDenied) {
+			t.Errorf("Expected ErrWorkspaceAccessDenied, got: %v", err)
+		}
+	})
+
+	t.Run("FindMembers", func(t *testing.T) {
+		members, err := service.FindMembers(context.Background(), memberID, workspace.ID)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(members) != 3 {
+			t.Errorf("Expected 3 members, got %d", len(members))
+		}
+	})
+
+	t.Run("RemoveMember_LastOwner", func(t *testing.T) {
+		err := service.RemoveMember(context.Background(), ownerID, workspace.ID, ownerID)
+		if !errors.Is(err, core.ErrLastWorkspaceOwner) {
+			t.Errorf("Expected ErrLastWorkspaceOwner, got: %v", err)
+		}
+	})
+
+	t.Run("RemoveMember_AdminCannotRemoveOwner", func(t *testing.T) {
+		err := service.RemoveMember(context.Background(), adminID, workspace.ID, ownerID)
+		if !errors.Is(err, core.ErrWorkspaceAccessDenied) {
+			t.Errorf("Expected ErrWorkspaceAccessDenied, got: %v", err)
+		}
+	})
+
+	t.Run("RemoveMember_Leave", func(t *testing.T) {
+		if err := service.RemoveMember(context.Background(), memberID, workspace.ID, memberID); err != nil {
+			t.Errorf("Expected member to leave the workspace, got: %v", err)
+		}
+	})
+}
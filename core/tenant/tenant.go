@@ -0,0 +1,30 @@
+// Package tenant carries the workspace of the current request through a
+// context.Context. Repositories read the workspace from the context to scope
+// every query, so a request can only ever see the data of its own workspace.
+package tenant
+
+import (
+	"context"
+
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/google/uuid"
+)
+
+type workspaceKey struct{}
+
+// WithWorkspace returns a copy of ctx that carries the given workspace ID.
+func WithWorkspace(ctx context.Context, workspaceID uuid.UUID) context.Context {
+	return context.WithValue(ctx, workspaceKey{}, workspaceID)
+}
+
+// WorkspaceID returns the workspace carried by ctx.
+// It returns core.ErrWorkspaceRequired when ctx has no workspace, which makes
+// unscoped queries fail instead of silently reading every workspace.
+func WorkspaceID(ctx context.Context) (uuid.UUID, error) {
+	workspaceID, ok := ctx.Value(workspaceKey{}).(uuid.UUID)
+	if !ok || workspaceID == uuid.Nil {
+		return uuid.Nil, core.ErrWorkspaceRequired
+	}
+
+	return workspaceID, nil
+}
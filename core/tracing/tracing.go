@@ -0,0 +1,21 @@
+// Package tracing starts the spans of the services with the global OpenTelemetry
+// tracer provider. Until the application configures an exporter the provider is a
+// no-op, so the services can be traced without depending on how traces are exported.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName identifies the spans created by the application.
+const InstrumentationName = "github.com/fabianoflorentino/gotostudy"
+
+// Start starts a span named name as a child of the span carried by ctx, and returns
+// a copy of ctx carrying the new span. The caller must end the span.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(InstrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
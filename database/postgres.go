@@ -1,49 +1,84 @@
 // Package database provides functionality to interact with a PostgreSQL database
 // using the GORM library. It includes methods to establish a connection, close
-// the connection, and construct the connection string dynamically based on
-// environment variables. This package is designed to simplify database operations
+// the connection, and run the migrations, with the connection settings of the
+// application configuration. This package is designed to simplify database operations
 // and ensure proper resource management.
 package database
 
 import (
 	"errors"
 	"fmt"
-	"log"
-	"os"
 
+	persistence "github.com/fabianoflorentino/gotostudy/adapters/outbound/persistence/postgres"
 	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/internal/config"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 // InitDB initializes the database connection using GORM and PostgreSQL.
-// It reads the connection parameters from environment variables and sets up
-// the database connection. It also enables the pgcrypto extension if it is not
-// already enabled. The function logs fatal errors if the connection fails or
-// if the extension cannot be enabled.
-func InitDB() (*gorm.DB, error) {
-
-	dsn := setPostgresConnectionString()
-	db, err := gorm.Open(postgres.New(postgres.Config{DSN: dsn, PreferSimpleProtocol: true}), &gorm.Config{})
+// It connects with the connection string of cfg, logging the queries slower than
+// its slow query threshold. It also enables the pgcrypto extension if it is not
+// already enabled, runs the migrations and creates the default workspace. When
+// one of these steps fails, the connection is closed and the error returned.
+func InitDB(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: cfg.DSN(), PreferSimpleProtocol: true}), &gorm.Config{
+		TranslateError: true,
+		Logger:         persistence.NewGormLogger(cfg.SlowQueryThreshold),
+	})
 	if err != nil {
-		log.Fatalf("failed to connect to database: %v", err)
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	if err := prepare(db); err != nil {
+		Close(db)
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Close closes the connection pool of db, waiting for the queries in progress.
+func Close(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.Close()
+}
+
+// prepare enables the extensions, runs the migrations and creates the default
+// workspace of a new connection.
+func prepare(db *gorm.DB) error {
 	// Enable the pgcrypto extension
 	if err := enablePgcryptoExtension(db); err != nil {
-		log.Fatalf("failed to enable pgcrypto extension: %v", err)
+		return fmt.Errorf("failed to enable pgcrypto extension: %w", err)
 	}
 
-	models, err := getAllModels()
-	if err != nil {
-		log.Fatalf("failed to get models: %v", err)
+	// Users created before email verification existed must not be locked out.
+	unverifiedColumn := db.Migrator().HasTable(&persistence.User{}) &&
+		!db.Migrator().HasColumn(&persistence.User{}, "EmailVerifiedAt")
+
+	if err := runMigrations(db, Models()...); err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	if err := runMigrations(db, models...); err != nil {
-		log.Fatalf("failed to run migrations: %v", err)
+	if unverifiedColumn {
+		if err := verifyExistingUsers(db); err != nil {
+			return fmt.Errorf("failed to verify existing users: %w", err)
+		}
 	}
 
-	return db, nil
+	if err := createRelationConstraints(db); err != nil {
+		return fmt.Errorf("failed to create constraints: %w", err)
+	}
+
+	if err := ensureDefaultWorkspace(db); err != nil {
+		return fmt.Errorf("failed to create default workspace: %w", err)
+	}
+
+	return nil
 }
 
 // enablePgcryptoExtension checks if the pgcrypto extension exists and creates it if not.
@@ -69,25 +104,6 @@ func enablePgcryptoExtension(db *gorm.DB) error {
 	return nil
 }
 
-// setPostgresConnectionString constructs the connection string for PostgreSQL
-// using the environment variables defined above.
-// It returns a string that can be used to connect to the PostgreSQL database.
-func setPostgresConnectionString() string {
-	var (
-		username = os.Getenv("POSTGRES_USER")
-		host     = os.Getenv("POSTGRES_HOST")
-		password = os.Getenv("POSTGRES_PASSWORD")
-		database = os.Getenv("POSTGRES_DB")
-		port     = os.Getenv("POSTGRES_PORT")
-		sslmode  = os.Getenv("POSTGRES_SSLMODE")
-		timezone = os.Getenv("POSTGRES_TIMEZONE")
-	)
-
-	return "user=" + username + " password=" + password + " host=" + host +
-		" port=" + port + " dbname=" + database +
-		" sslmode=" + sslmode + " TimeZone=" + timezone
-}
-
 // runMigrations applies database migrations for the provided models using GORM's AutoMigrate method.
 // It iterates over the given models and attempts to migrate each one. If any migration fails,
 // it returns an error indicating the model that failed and the reason.
@@ -108,10 +124,79 @@ func runMigrations(db *gorm.DB, models ...any) error {
 	return nil
 }
 
-// getAllModels returns a slice of all models to be migrated.
-func getAllModels() ([]any, error) {
+// Models returns a slice of all models to be migrated.
+// The models are the persistence models of the postgres adapter, ordered so
+// that referenced tables are created before the tables referencing them.
+func Models() []any {
 	return []any{
-		domain.User{},
-		domain.Task{},
-	}, nil
+		&persistence.Workspace{},
+		&persistence.User{},
+		&persistence.WorkspaceMember{},
+		&persistence.Task{},
+		&persistence.TaskShare{},
+		&persistence.APIKey{},
+		&persistence.TwoFactor{},
+		&persistence.IdempotencyKey{},
+		&persistence.OutboxEvent{},
+	}
+}
+
+// createRelationConstraints creates the foreign keys declared by one-to-many
+// relationships. GORM only creates a constraint while migrating the table that
+// holds it, so the constraints declared on the parent side are created here.
+func createRelationConstraints(db *gorm.DB) error {
+	constraints := []struct {
+		model any
+		name  string
+	}{
+		{&persistence.User{}, "Tasks"},
+		{&persistence.User{}, "TaskShares"},
+		{&persistence.User{}, "Memberships"},
+		{&persistence.User{}, "APIKeys"},
+		{&persistence.User{}, "TwoFactor"},
+		{&persistence.Workspace{}, "Members"},
+		{&persistence.Workspace{}, "Tasks"},
+		{&persistence.Workspace{}, "APIKeys"},
+		{&persistence.Task{}, "Shares"},
+	}
+
+	for _, c := range constraints {
+		if db.Migrator().HasConstraint(c.model, c.name) {
+			continue
+		}
+		if err := db.Migrator().CreateConstraint(c.model, c.name); err != nil {
+			return fmt.Errorf("failed to create constraint %s on %T: %v", c.name, c.model, err)
+		}
+	}
+
+	return nil
+}
+
+// verifyExistingUsers confirms the email address of the users created before
+// email verification was introduced, as they were never sent a link.
+func verifyExistingUsers(db *gorm.DB) error {
+	return db.Exec("UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL").Error
+}
+
+// ensureDefaultWorkspace creates the default workspace when it does not exist yet
+// and moves the users and tasks created before workspaces existed into it.
+func ensureDefaultWorkspace(db *gorm.DB) error {
+	workspace := persistence.Workspace{Name: "Default", Slug: domain.DefaultWorkspaceSlug}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(persistence.Workspace{Slug: domain.DefaultWorkspaceSlug}).FirstOrCreate(&workspace).Error; err != nil {
+			return err
+		}
+
+		orphans := tx.Model(&persistence.WorkspaceMember{}).Select("user_id")
+		if err := tx.Exec(
+			"INSERT INTO workspace_members (workspace_id, user_id, role, created_at, updated_at) "+
+				"SELECT ?, id, ?, NOW(), NOW() FROM users WHERE id NOT IN (?)",
+			workspace.ID, string(domain.WorkspaceRoleMember), orphans,
+		).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&persistence.Task{}).Where("workspace_id IS NULL").Update("workspace_id", workspace.ID).Error
+	})
 }
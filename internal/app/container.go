@@ -5,66 +5,343 @@
 package app
 
 import (
-	"log"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/fabianoflorentino/gotostudy/adapters/outbound/mail"
+	"github.com/fabianoflorentino/gotostudy/adapters/outbound/metrics"
+	"github.com/fabianoflorentino/gotostudy/adapters/outbound/oidc"
+	"github.com/fabianoflorentino/gotostudy/adapters/outbound/persistence/memory"
 	"github.com/fabianoflorentino/gotostudy/adapters/outbound/persistence/postgres"
-	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/adapters/outbound/security"
+	eventsinks "github.com/fabianoflorentino/gotostudy/adapters/outbound/sinks"
+	"github.com/fabianoflorentino/gotostudy/core/logging"
+	"github.com/fabianoflorentino/gotostudy/core/policy"
+	"github.com/fabianoflorentino/gotostudy/core/ports"
 	"github.com/fabianoflorentino/gotostudy/core/services"
 	"github.com/fabianoflorentino/gotostudy/database"
+	"github.com/fabianoflorentino/gotostudy/internal/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"go.opentelemetry.io/otel"
 	"gorm.io/gorm"
 )
 
 // AppContainer is a struct that serves as a dependency injection container
 // for the application. It holds references to shared resources and services
 // that are used throughout the application, such as the database connection
-// (DB) and the UserService for managing user-related operations.
+// (DB) and the UserService for managing user-related operations. Metrics is the
+// Prometheus registry holding the metrics of the application, and EventBus streams
+// the events of the services to the clients, with a heartbeat every EventHeartbeat.
 type AppContainer struct {
-	DB          *gorm.DB
-	UserService *services.UserService
-	TaskService *services.TaskService
+	DB                 *gorm.DB
+	Metrics            *prometheus.Registry
+	UserService        *services.UserService
+	TaskService        *services.TaskService
+	WorkspaceService   *services.WorkspaceService
+	AuthService        *services.AuthService
+	APIKeyService      *services.APIKeyService
+	OIDCService        *services.OIDCService
+	TwoFactorService   *services.TwoFactorService
+	HealthService      *services.HealthService
+	IdempotencyService *services.IdempotencyService
+	EventBus           *services.EventBus
+	EventHeartbeat     time.Duration
+
+	// relay and outbox are used by the background workers relaying and purging the
+	// outbox events, started by StartWorkers.
+	relay  *services.OutboxRelay
+	outbox config.OutboxConfig
+
+	// closers release the resources of the application, such as the background
+	// workers, in reverse order of registration.
+	closers []func(context.Context) error
 }
 
 // NewAppContainer initializes and returns a new instance of AppContainer.
-// It sets up the database connection, initializes the user repository and service,
-// and performs database migrations for the User domain model. If any errors occur
-// during database initialization or migration, they are logged. The returned
-// AppContainer includes the database connection and the user service.
-func NewAppContainer() *AppContainer {
-	db, err := database.InitDB()
+// It sets up the database connection, which also runs the database migrations,
+// and initializes the repositories and services. If any step fails, the database
+// connection is closed and the error returned. The returned AppContainer includes
+// the database connection and the application services, and must be closed. The
+// background workers are only started by StartWorkers.
+func NewAppContainer(cfg *config.Config) (*AppContainer, error) {
+	db, err := database.InitDB(cfg.Database)
 	if err != nil {
-		log.Printf("failed to initialize database: %v", err)
-		return nil
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	container, err := newAppContainer(db, cfg)
+	if err != nil {
+		database.Close(db)
+		return nil, err
 	}
 
-	usrService := usrService(db)
-	tskService := tskService(db)
+	return container, nil
+}
+
+// newAppContainer initializes the services of the application on top of db.
+func newAppContainer(db *gorm.DB, cfg *config.Config) (*AppContainer, error) {
+	authz, err := accessPolicy(cfg.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load access control policy: %w", err)
+	}
 
-	return &AppContainer{
-		DB:          db,
-		UserService: usrService,
-		TaskService: tskService,
+	if err := postgres.RegisterTracing(db, otel.GetTracerProvider()); err != nil {
+		return nil, fmt.Errorf("failed to register database tracing: %w", err)
 	}
+
+	registry, business, err := metricsRegistry(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register metrics: %w", err)
+	}
+
+	hasher := security.NewBcryptHasher(0)
+	tokens := tokenIssuer(cfg.Auth)
+
+	events := services.NewEventBus(cfg.Events.ReplaySize, cfg.Events.BufferSize)
+
+	infra := services.Infrastructure{
+		Metrics: business,
+		Events:  events,
+		Tx:      postgres.NewGormTransactor(db),
+		Outbox:  postgres.NewPostgresOutbox(db),
+	}
+
+	usrService := usrService(db, cfg, hasher, authz, tokens, infra)
+	tskService := tskService(db, authz, infra)
+	wksService := wksService(db)
+	tfaService := tfaService(db, authz)
+	athService := athService(db, hasher, tokens, tfaService)
+	keyService := keyService(db)
+	sooService := oidcService(db, cfg.OIDC, tokens, tfaService, infra)
+	hltService := hltService(db, cfg.Health)
+	idmService := idmService(db, cfg.Idempotency)
+	relay := outboxRelay(db, cfg.Outbox)
+
+	container := &AppContainer{
+		DB:                 db,
+		Metrics:            registry,
+		UserService:        usrService,
+		TaskService:        tskService,
+		WorkspaceService:   wksService,
+		AuthService:        athService,
+		APIKeyService:      keyService,
+		OIDCService:        sooService,
+		TwoFactorService:   tfaService,
+		HealthService:      hltService,
+		IdempotencyService: idmService,
+		EventBus:           events,
+		EventHeartbeat:     cfg.Events.Heartbeat,
+		relay:              relay,
+		outbox:             cfg.Outbox,
+	}
+	container.onClose(func(context.Context) error { return database.Close(db) })
+
+	return container, nil
 }
 
-func usrService(db *gorm.DB) *services.UserService {
-	usr := postgres.NewPostgresUserRepository(db)
-	srv := services.NewUserService(usr)
+// StartWorkers starts the background workers purging the expired idempotency keys
+// and relaying the outbox events to the sinks and purging them. Only the server runs
+// them, not the commands of the CLI; Close stops them before closing the database.
+func (a *AppContainer) StartWorkers() {
+	a.onClose(runEvery("purge idempotency keys", idempotencyPurgeInterval, purgeIdempotencyKeys(a.IdempotencyService)))
+	a.onClose(runEvery("relay outbox events", a.outbox.PollInterval, relayOutboxEvents(a.relay)))
+	a.onClose(runEvery("purge outbox events", outboxPurgeInterval, purgeOutboxEvents(a.relay, a.outbox.Retention)))
+}
 
-	if err := db.AutoMigrate(&domain.User{}); err != nil {
-		log.Printf("failed to migrate user repository: %v", err)
+// Close stops the background workers and closes the database connection pool. It
+// runs every closer even when one fails, and returns the errors joined. ctx bounds
+// how long the workers may take to finish their current job.
+func (a *AppContainer) Close(ctx context.Context) error {
+	var errs []error
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		if err := a.closers[i](ctx); err != nil {
+			errs = append(errs, err)
+		}
 	}
 
+	return errors.Join(errs...)
+}
+
+// onClose registers fn to be run by Close. The closers run in reverse order of
+// registration, so the database is closed after the workers using it stopped.
+func (a *AppContainer) onClose(fn func(context.Context) error) {
+	a.closers = append(a.closers, fn)
+}
+
+func usrService(db *gorm.DB, cfg *config.Config, hasher ports.PasswordHasher, authz ports.Authorizer, signer ports.ActionTokenSigner, infra services.Infrastructure) *services.UserService {
+	usr := postgres.NewPostgresUserRepository(db)
+	srv := services.NewUserService(usr, hasher, authz, mailer(cfg.Mail), signer, accountLinks(cfg.Links), infra)
+
 	return srv
 }
 
-func tskService(db *gorm.DB) *services.TaskService {
+func tskService(db *gorm.DB, authz ports.Authorizer, infra services.Infrastructure) *services.TaskService {
 	tsk := postgres.NewPostgresTaskRepository(db)
 	usr := postgres.NewPostgresUserRepository(db)
-	tskService := services.NewTaskService(tsk, usr)
+	tskService := services.NewTaskService(tsk, usr, authz, infra)
+
+	return tskService
+}
+
+func wksService(db *gorm.DB) *services.WorkspaceService {
+	wks := postgres.NewPostgresWorkspaceRepository(db)
+	usr := postgres.NewPostgresUserRepository(db)
+
+	return services.NewWorkspaceService(wks, usr)
+}
+
+func athService(db *gorm.DB, hasher ports.PasswordHasher, tokens ports.TokenIssuer, tfa *services.TwoFactorService) *services.AuthService {
+	usr := postgres.NewPostgresUserRepository(db)
+
+	return services.NewAuthService(usr, hasher, tokens, tfa)
+}
+
+func tfaService(db *gorm.DB, authz ports.Authorizer) *services.TwoFactorService {
+	tfa := postgres.NewPostgresTwoFactorRepository(db)
+	usr := postgres.NewPostgresUserRepository(db)
+
+	return services.NewTwoFactorService(tfa, usr, security.NewTOTPAuthenticator(security.Issuer), authz)
+}
+
+func keyService(db *gorm.DB) *services.APIKeyService {
+	key := postgres.NewPostgresAPIKeyRepository(db)
+	usr := postgres.NewPostgresUserRepository(db)
+
+	return services.NewAPIKeyService(key, usr)
+}
 
-	if err := db.AutoMigrate(&domain.Task{}); err != nil {
-		log.Printf("failed to migrate task repository: %v", err)
+// idmService builds the idempotency service keeping the requests in the configured
+// store: in PostgreSQL, shared by every instance, or in the memory of this one.
+func idmService(db *gorm.DB, cfg config.IdempotencyConfig) *services.IdempotencyService {
+	var store ports.IdempotencyStore
+	if strings.EqualFold(cfg.Store, "memory") {
+		store = memory.NewIdempotencyStore()
+	} else {
+		store = postgres.NewPostgresIdempotencyStore(db)
 	}
 
-	return tskService
+	return services.NewIdempotencyService(store, cfg.TTL, cfg.Lease)
+}
+
+// hltService builds the health service checking the connection to the database and
+// that its migrations are current, each within the configured check timeout.
+func hltService(db *gorm.DB, cfg config.HealthConfig) *services.HealthService {
+	return services.NewHealthService(
+		cfg.CheckTimeout,
+		postgres.NewDatabaseHealthChecker(db),
+		postgres.NewMigrationHealthChecker(db, database.Models()...),
+	)
+}
+
+// oidcService builds the single sign-on service from the OIDC configuration. Single
+// sign-on is disabled, and nil returned, when no issuer is configured or the provider
+// is unreachable.
+func oidcService(db *gorm.DB, cfg config.OIDCConfig, tokens *security.JWTIssuer, tfa *services.TwoFactorService, infra services.Infrastructure) *services.OIDCService {
+	if !cfg.Enabled() {
+		return nil
+	}
+
+	idp, err := oidc.NewProvider(context.Background(), oidc.Config{
+		Issuer:       cfg.Issuer,
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+	})
+	if err != nil {
+		slog.Error("failed to initialize single sign-on", "issuer", cfg.Issuer, "error", err)
+		return nil
+	}
+
+	usr := postgres.NewPostgresUserRepository(db)
+
+	return services.NewOIDCService(idp, usr, tokens, tokens, tfa, infra)
+}
+
+// outboxRelay creates the relay delivering the domain events of the outbox to the
+// log and, when configured, to the webhook of cfg.
+func outboxRelay(db *gorm.DB, cfg config.OutboxConfig) *services.OutboxRelay {
+	sinks := []ports.EventSink{eventsinks.NewLogSink()}
+	if cfg.WebhookURL != "" {
+		sinks = append(sinks, eventsinks.NewWebhookSink(cfg.WebhookURL, cfg.WebhookSecret, cfg.WebhookTimeout))
+	}
+
+	return services.NewOutboxRelay(postgres.NewPostgresOutbox(db), postgres.NewGormTransactor(db), sinks, cfg.BatchSize, cfg.Lease, cfg.RetryBackoff, cfg.MaxRetryBackoff)
+}
+
+// metricsRegistry creates the Prometheus registry exposed on /metrics, with the Go
+// runtime and process metrics, the duration and errors of the queries run on db, the
+// statistics of its connection pool and the business counters.
+func metricsRegistry(db *gorm.DB) (*prometheus.Registry, *metrics.PrometheusMetrics, error) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	if err := postgres.RegisterMetrics(db, registry); err != nil {
+		return nil, nil, err
+	}
+
+	business, err := metrics.NewPrometheusMetrics(registry)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return registry, business, nil
+}
+
+// mailer builds the SMTP mailer from the mail configuration. Without an SMTP host
+// the emails are written to the outbox directory, or only logged when it is unset,
+// for development.
+func mailer(cfg config.MailConfig) ports.Mailer {
+	if cfg.SMTPHost == "" {
+		slog.Warn("SMTP_HOST is not set, emails are kept in the outbox")
+		return mail.NewOutboxMailer(cfg.OutboxDir, cfg.From)
+	}
+
+	return mail.NewSMTPMailer(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort), cfg.SMTPUsername, cfg.SMTPPassword, cfg.From)
+}
+
+// accountLinks builds the links sent by email from the public URL of the API. The
+// password reset link can point to the form of a frontend, which then posts the
+// token to /api/v1/auth/password-reset/confirm.
+func accountLinks(cfg config.LinksConfig) services.AccountLinks {
+	return services.AccountLinks{
+		VerifyEmailURL:   cfg.BaseURL + "/api/v1/auth/verify-email",
+		PasswordResetURL: cfg.PasswordResetURL,
+	}
+}
+
+// accessPolicy loads the access control policy from the configured file, or the
+// default policy embedded in the binary when none is configured.
+func accessPolicy(cfg config.AuthConfig) (*policy.Engine, error) {
+	if cfg.PolicyFile != "" {
+		return policy.LoadFile(cfg.PolicyFile)
+	}
+
+	return policy.Default()
+}
+
+// tokenIssuer builds the JWT issuer from the auth configuration. Without a JWT
+// secret a random secret is generated, so tokens do not survive a restart and are
+// not shared between replicas.
+func tokenIssuer(cfg config.AuthConfig) *security.JWTIssuer {
+	secret := []byte(cfg.JWTSecret)
+	if len(secret) == 0 {
+		slog.Warn("JWT_SECRET is not set, using a random secret")
+
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			logging.Fatal("failed to generate jwt secret", "error", err)
+		}
+	}
+
+	return security.NewJWTIssuer(secret, cfg.AccessTTL, cfg.RefreshTTL)
 }
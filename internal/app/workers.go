@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core/services"
+)
+
+const (
+	// idempotencyPurgeInterval is how often the expired idempotency keys are removed.
+	idempotencyPurgeInterval = time.Hour
+	// outboxPurgeInterval is how often the delivered outbox events are removed.
+	outboxPurgeInterval = time.Hour
+)
+
+// runEvery runs job in the background every interval until the returned function
+// is called. That function cancels the running job and waits for it to return, at
+// most until ctx is done. A failed job is logged and run again on the next tick.
+func runEvery(name string, interval time.Duration, job func(context.Context) error) func(context.Context) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := job(ctx); err != nil && ctx.Err() == nil {
+					slog.Error("background job failed", "job", name, "error", err)
+				}
+			}
+		}
+	}()
+
+	return func(stopCtx context.Context) error {
+		cancel()
+
+		select {
+		case <-done:
+			return nil
+		case <-stopCtx.Done():
+			return stopCtx.Err()
+		}
+	}
+}
+
+// purgeIdempotencyKeys returns the job removing the expired idempotency keys.
+func purgeIdempotencyKeys(idm *services.IdempotencyService) func(context.Context) error {
+	return func(ctx context.Context) error {
+		deleted, err := idm.PurgeExpired(ctx)
+		if err != nil {
+			return err
+		}
+
+		slog.Debug("purged expired idempotency keys", "count", deleted)
+		return nil
+	}
+}
+
+// relayOutboxEvents delivers the pending domain events of the outbox to the sinks.
+func relayOutboxEvents(relay *services.OutboxRelay) func(context.Context) error {
+	return func(ctx context.Context) error {
+		delivered, err := relay.Relay(ctx)
+		if delivered > 0 {
+			slog.Debug("relayed outbox events", "count", delivered)
+		}
+
+		return err
+	}
+}
+
+// purgeOutboxEvents removes the outbox events delivered more than retention ago.
+func purgeOutboxEvents(relay *services.OutboxRelay, retention time.Duration) func(context.Context) error {
+	return func(ctx context.Context) error {
+		deleted, err := relay.PurgeDelivered(ctx, retention)
+		if err != nil {
+			return err
+		}
+
+		slog.Debug("purged delivered outbox events", "count", deleted)
+		return nil
+	}
+}
@@ -0,0 +1,269 @@
+// Package config loads the configuration of the application. Settings come from
+// the defaults, then an optional YAML file, then the environment, which includes
+// the variables of an optional .env file; each source overrides the previous one.
+// The configuration is validated as a whole before the application starts, so
+// every invalid setting is reported at once.
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config is the configuration of the application. Each setting is read from the
+// YAML key in its yaml tag and from the environment variable in its env tag;
+// settings tagged secret are redacted when the configuration is printed.
+type Config struct {
+	Server      ServerConfig      `yaml:"server"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Log         LogConfig         `yaml:"log"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	Auth        AuthConfig        `yaml:"auth"`
+	OIDC        OIDCConfig        `yaml:"oidc"`
+	Mail        MailConfig        `yaml:"mail"`
+	Links       LinksConfig       `yaml:"links"`
+	Health      HealthConfig      `yaml:"health"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	Events      EventsConfig      `yaml:"events"`
+	Outbox      OutboxConfig      `yaml:"outbox"`
+}
+
+// ServerConfig holds the port, the timeouts and the request validation of the HTTP server.
+type ServerConfig struct {
+	Port              int           `yaml:"port" env:"PORT"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT"`
+	// ShutdownDelay is how long the readiness probe fails before the server stops
+	// accepting connections, so load balancers stop routing requests to it.
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" env:"SHUTDOWN_DELAY"`
+	// ShutdownTimeout bounds how long the in-flight requests may take to complete.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	// ValidateRequests validates the requests against the OpenAPI document of the
+	// API before the handlers run.
+	ValidateRequests bool `yaml:"validate_requests" env:"HTTP_VALIDATE_REQUESTS"`
+	// LegacySunset is the date announced in the Sunset header of the deprecated
+	// routes outside /api/v1, after which they may be removed.
+	LegacySunset time.Time `yaml:"legacy_sunset" env:"HTTP_LEGACY_SUNSET"`
+}
+
+// Addr returns the TCP address the server listens on.
+func (s ServerConfig) Addr() string {
+	return ":" + strconv.Itoa(s.Port)
+}
+
+// DatabaseConfig holds the connection settings of PostgreSQL. URL, when set, takes
+// precedence over the individual settings.
+type DatabaseConfig struct {
+	URL                string        `yaml:"url" env:"DATABASE_URL" secret:"url"`
+	Host               string        `yaml:"host" env:"POSTGRES_HOST"`
+	Port               int           `yaml:"port" env:"POSTGRES_PORT"`
+	User               string        `yaml:"user" env:"POSTGRES_USER"`
+	Password           string        `yaml:"password" env:"POSTGRES_PASSWORD" secret:"true"`
+	Name               string        `yaml:"name" env:"POSTGRES_DB"`
+	SSLMode            string        `yaml:"sslmode" env:"POSTGRES_SSLMODE"`
+	TimeZone           string        `yaml:"timezone" env:"POSTGRES_TIMEZONE"`
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold" env:"DB_SLOW_QUERY_THRESHOLD"`
+}
+
+// DSN returns the connection string of the database: URL when set, or a keyword
+// and value string built from the individual settings.
+func (d DatabaseConfig) DSN() string {
+	if d.URL != "" {
+		return d.URL
+	}
+
+	params := []string{
+		"host=" + quoteDSN(d.Host),
+		"port=" + strconv.Itoa(d.Port),
+		"user=" + quoteDSN(d.User),
+		"password=" + quoteDSN(d.Password),
+		"dbname=" + quoteDSN(d.Name),
+		"sslmode=" + quoteDSN(d.SSLMode),
+	}
+	if d.TimeZone != "" {
+		params = append(params, "TimeZone="+quoteDSN(d.TimeZone))
+	}
+
+	return strings.Join(params, " ")
+}
+
+// quoteDSN quotes a value of a keyword and value connection string when needed.
+func quoteDSN(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// LogConfig holds the level and the format of the logs.
+type LogConfig struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL"`
+	Format string `yaml:"format" env:"LOG_FORMAT"`
+}
+
+// TracingConfig selects the exporter of the traces. The OTLP exporter reads the
+// address of the collector from the standard OTEL_EXPORTER_OTLP_* variables.
+type TracingConfig struct {
+	Exporter    string `yaml:"exporter" env:"OTEL_TRACES_EXPORTER"`
+	File        string `yaml:"file" env:"OTEL_TRACES_FILE"`
+	ServiceName string `yaml:"service_name" env:"OTEL_SERVICE_NAME"`
+}
+
+// AuthConfig holds the settings of the tokens and of the access control policy.
+// Without JWTSecret a random secret is generated at startup, so tokens do not
+// survive a restart and are not shared between replicas.
+type AuthConfig struct {
+	JWTSecret  string        `yaml:"jwt_secret" env:"JWT_SECRET" secret:"true"`
+	AccessTTL  time.Duration `yaml:"access_ttl" env:"JWT_ACCESS_TTL"`
+	RefreshTTL time.Duration `yaml:"refresh_ttl" env:"JWT_REFRESH_TTL"`
+	PolicyFile string        `yaml:"policy_file" env:"POLICY_FILE"`
+}
+
+// OIDCConfig holds the settings of the OpenID Connect provider. Single sign-on is
+// disabled when Issuer is empty.
+type OIDCConfig struct {
+	Issuer       string `yaml:"issuer" env:"OIDC_ISSUER"`
+	ClientID     string `yaml:"client_id" env:"OIDC_CLIENT_ID"`
+	ClientSecret string `yaml:"client_secret" env:"OIDC_CLIENT_SECRET" secret:"true"`
+	RedirectURL  string `yaml:"redirect_url" env:"OIDC_REDIRECT_URL"`
+}
+
+// Enabled reports whether single sign-on is configured.
+func (o OIDCConfig) Enabled() bool {
+	return o.Issuer != ""
+}
+
+// MailConfig holds the sender of the emails and the SMTP server delivering them.
+// Without SMTPHost the emails are written to OutboxDir, or only logged when it is
+// empty, for development.
+type MailConfig struct {
+	From         string `yaml:"from" env:"MAIL_FROM"`
+	OutboxDir    string `yaml:"outbox_dir" env:"MAIL_OUTBOX_DIR"`
+	SMTPHost     string `yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     int    `yaml:"smtp_port" env:"SMTP_PORT"`
+	SMTPUsername string `yaml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+}
+
+// LinksConfig holds the public URLs the links sent by email point to. BaseURL
+// defaults to the local address of the server; PasswordResetURL can point to the
+// form of a frontend and defaults to the endpoint of the API.
+type LinksConfig struct {
+	BaseURL          string `yaml:"base_url" env:"APP_BASE_URL"`
+	PasswordResetURL string `yaml:"password_reset_url" env:"PASSWORD_RESET_URL"`
+}
+
+// HealthConfig holds the settings of the readiness probe.
+type HealthConfig struct {
+	CheckTimeout time.Duration `yaml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT"`
+}
+
+// IdempotencyConfig holds the settings of the Idempotency-Key support. Store is
+// where the requests and their responses are kept, postgres to share them between
+// instances or memory for a single instance; they are replayed for TTL. A request
+// still in progress after Lease, such as one whose instance crashed, no longer
+// holds its key.
+type IdempotencyConfig struct {
+	Store string        `yaml:"store" env:"IDEMPOTENCY_STORE"`
+	TTL   time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL"`
+	Lease time.Duration `yaml:"lease" env:"IDEMPOTENCY_LEASE"`
+}
+
+// EventsConfig holds the settings of the streams of task and user events. The last
+// ReplaySize events are kept to resume the streams of reconnecting clients; a client
+// falling BufferSize events behind is disconnected. The streams send a heartbeat
+// every Heartbeat to keep idle connections open.
+type EventsConfig struct {
+	ReplaySize int           `yaml:"replay_size" env:"EVENTS_REPLAY_SIZE"`
+	BufferSize int           `yaml:"buffer_size" env:"EVENTS_BUFFER_SIZE"`
+	Heartbeat  time.Duration `yaml:"heartbeat" env:"EVENTS_HEARTBEAT"`
+}
+
+// OutboxConfig holds the settings of the relay delivering the domain events of the
+// outbox. It looks for pending events every PollInterval and claims up to
+// BatchSize of them at a time, for Lease: the claimed events whose delivery did
+// not end by then are delivered again. A failed delivery is retried after
+// RetryBackoff, doubled on each attempt up to MaxRetryBackoff, and the delivered
+// events are purged after Retention. The events are logged, and posted to
+// WebhookURL when set, signed with WebhookSecret unless it is empty.
+type OutboxConfig struct {
+	PollInterval    time.Duration `yaml:"poll_interval" env:"OUTBOX_POLL_INTERVAL"`
+	BatchSize       int           `yaml:"batch_size" env:"OUTBOX_BATCH_SIZE"`
+	Lease           time.Duration `yaml:"lease" env:"OUTBOX_LEASE"`
+	RetryBackoff    time.Duration `yaml:"retry_backoff" env:"OUTBOX_RETRY_BACKOFF"`
+	MaxRetryBackoff time.Duration `yaml:"max_retry_backoff" env:"OUTBOX_MAX_RETRY_BACKOFF"`
+	Retention       time.Duration `yaml:"retention" env:"OUTBOX_RETENTION"`
+	WebhookURL      string        `yaml:"webhook_url" env:"OUTBOX_WEBHOOK_URL"`
+	WebhookSecret   string        `yaml:"webhook_secret" env:"OUTBOX_WEBHOOK_SECRET" secret:"true"`
+	WebhookTimeout  time.Duration `yaml:"webhook_timeout" env:"OUTBOX_WEBHOOK_TIMEOUT"`
+}
+
+// Default returns the configuration used for the settings no source sets.
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:              8080,
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownDelay:     5 * time.Second,
+			ShutdownTimeout:   30 * time.Second,
+			LegacySunset:      time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC),
+		},
+		Database: DatabaseConfig{
+			Host:               "localhost",
+			Port:               5432,
+			SSLMode:            "disable",
+			TimeZone:           "UTC",
+			SlowQueryThreshold: 200 * time.Millisecond,
+		},
+		Log:     LogConfig{Level: "info", Format: "json"},
+		Tracing: TracingConfig{Exporter: "none", ServiceName: "gotostudy"},
+		Auth: AuthConfig{
+			AccessTTL:  15 * time.Minute,
+			RefreshTTL: 7 * 24 * time.Hour,
+		},
+		Mail:        MailConfig{From: "GoToStudy <no-reply@localhost>", SMTPPort: 587},
+		Health:      HealthConfig{CheckTimeout: 2 * time.Second},
+		Idempotency: IdempotencyConfig{Store: "postgres", TTL: 24 * time.Hour, Lease: time.Minute},
+		Events:      EventsConfig{ReplaySize: 1000, BufferSize: 64, Heartbeat: 15 * time.Second},
+		Outbox: OutboxConfig{
+			PollInterval:    time.Second,
+			BatchSize:       100,
+			Lease:           5 * time.Minute,
+			RetryBackoff:    time.Second,
+			MaxRetryBackoff: 5 * time.Minute,
+			Retention:       7 * 24 * time.Hour,
+			WebhookTimeout:  10 * time.Second,
+		},
+	}
+}
+
+// resolve fills the settings whose default depends on other settings.
+func (c *Config) resolve() {
+	if c.Links.BaseURL == "" {
+		c.Links.BaseURL = fmt.Sprintf("http://localhost:%d", c.Server.Port)
+	}
+	c.Links.BaseURL = strings.TrimRight(c.Links.BaseURL, "/")
+
+	if c.Links.PasswordResetURL == "" {
+		c.Links.PasswordResetURL = c.Links.BaseURL + "/api/v1/auth/password-reset"
+	}
+}
+
+// redactURL hides the password of a URL.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redacted
+	}
+
+	return u.Redacted()
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setRequiredEnv sets the settings without a default.
+func setRequiredEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("POSTGRES_USER", "gts")
+	t.Setenv("POSTGRES_DB", "gts")
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		setRequiredEnv(t)
+
+		cfg, err := Load(Options{})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.Server.Addr() != ":8080" || cfg.Links.PasswordResetURL != "http://localhost:8080/api/v1/auth/password-reset" {
+			t.Errorf("Unexpected defaults %+v", cfg)
+		}
+	})
+
+	t.Run("Precedence", func(t *testing.T) {
+		setRequiredEnv(t)
+		file := writeFile(t, "config.yaml", "server:\n  port: 9000\n  write_timeout: 1m\nlog:\n  level: debug\n")
+		t.Setenv("LOG_LEVEL", "warn")
+		t.Setenv("HTTP_VALIDATE_REQUESTS", "true")
+		t.Setenv("HTTP_LEGACY_SUNSET", "2027-01-31")
+
+		cfg, err := Load(Options{File: file})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.Server.Port != 9000 || cfg.Server.WriteTimeout != time.Minute {
+			t.Errorf("Expected the settings of the file, got %+v", cfg.Server)
+		}
+		if !cfg.Server.ValidateRequests {
+			t.Error("Expected the boolean setting of the environment")
+		}
+		if want := time.Date(2027, time.January, 31, 0, 0, 0, 0, time.UTC); !cfg.Server.LegacySunset.Equal(want) {
+			t.Errorf("Expected the date setting of the environment, got %v", cfg.Server.LegacySunset)
+		}
+		if cfg.Log.Level != "warn" {
+			t.Errorf("Expected the environment to override the file, got %q", cfg.Log.Level)
+		}
+		if cfg.Links.BaseURL != "http://localhost:9000" {
+			t.Errorf("Expected the base URL on the configured port, got %q", cfg.Links.BaseURL)
+		}
+	})
+
+	t.Run("EnvFile", func(t *testing.T) {
+		setRequiredEnv(t)
+		// Setenv restores SMTP_HOST once the test completed.
+		t.Setenv("SMTP_HOST", "")
+		os.Unsetenv("SMTP_HOST")
+		envFile := writeFile(t, ".env", "SMTP_HOST=smtp.example.com\n")
+
+		cfg, err := Load(Options{EnvFile: envFile})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.Mail.SMTPHost != "smtp.example.com" {
+			t.Errorf("Expected the setting of the .env file, got %q", cfg.Mail.SMTPHost)
+		}
+
+		if _, err := Load(Options{EnvFile: filepath.Join(t.TempDir(), "missing.env")}); err != nil {
+			t.Errorf("Expected a missing .env file to be ignored, got: %v", err)
+		}
+	})
+
+	t.Run("DatabaseURL", func(t *testing.T) {
+		t.Setenv("DATABASE_URL", "postgres://gts:secret@db:5432/gts")
+
+		cfg, err := Load(Options{})
+		if err != nil {
+			t.Fatalf("Expected the URL to replace the individual settings, got: %v", err)
+		}
+		if cfg.Database.DSN() != "postgres://gts:secret@db:5432/gts" {
+			t.Errorf("Expected the URL as DSN, got %q", cfg.Database.DSN())
+		}
+	})
+
+	t.Run("UnknownFileKey", func(t *testing.T) {
+		setRequiredEnv(t)
+		file := writeFile(t, "config.yaml", "server:\n  prot: 9000\n")
+
+		if _, err := Load(Options{File: file}); err == nil || !strings.Contains(err.Error(), "prot") {
+			t.Errorf("Expected the unknown key to be reported, got: %v", err)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Setenv("PORT", "http")
+		t.Setenv("LOG_FORMAT", "xml")
+		t.Setenv("DATABASE_URL", "mysql://gts:secret@db/gts")
+		t.Setenv("OIDC_ISSUER", "https://login.example.com")
+		t.Setenv("IDEMPOTENCY_STORE", "redis")
+		t.Setenv("EVENTS_BUFFER_SIZE", "0")
+		t.Setenv("OUTBOX_WEBHOOK_URL", "hooks.example.com")
+
+		_, err := Load(Options{})
+		if err == nil {
+			t.Fatal("Expected an error")
+		}
+
+		for _, want := range []string{
+			`server.port (PORT): must be an integer, got "http"`,
+			`log.format (LOG_FORMAT): must be one of json, text`,
+			`database.url (DATABASE_URL)`,
+			`oidc.client_id (OIDC_CLIENT_ID): is required`,
+			`idempotency.store (IDEMPOTENCY_STORE): must be one of postgres, memory`,
+			`events.buffer_size (EVENTS_BUFFER_SIZE): must be at least 1, got 0`,
+			`outbox.webhook_url (OUTBOX_WEBHOOK_URL): must be an absolute https or http URL`,
+		} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("Expected %q in the error, got:\n%v", want, err)
+			}
+		}
+		if strings.Contains(err.Error(), "secret") {
+			t.Errorf("Expected the password of the URL to be redacted, got:\n%v", err)
+		}
+	})
+}
+
+func TestDatabaseConfigDSN(t *testing.T) {
+	cfg := DatabaseConfig{Host: "db", Port: 5432, User: "gts", Password: "it's secret", Name: "gts", SSLMode: "disable"}
+
+	want := `host=db port=5432 user=gts password='it\'s secret' dbname=gts sslmode=disable`
+	if got := cfg.DSN(); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestWriteYAML(t *testing.T) {
+	cfg := Default()
+	cfg.Database.URL = "postgres://gts:hunter2@db:5432/gts"
+	cfg.Auth.JWTSecret = "hunter2"
+	cfg.resolve()
+
+	var out bytes.Buffer
+	if err := cfg.WriteYAML(&out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if strings.Contains(out.String(), "hunter2") {
+		t.Errorf("Expected the secrets to be redacted, got:\n%s", out.String())
+	}
+	for _, want := range []string{"jwt_secret: '[REDACTED]' # JWT_SECRET", "url: postgres://gts:xxxxx@db:5432/gts # DATABASE_URL", "write_timeout: 30s"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in:\n%s", want, out.String())
+		}
+	}
+}
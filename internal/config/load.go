@@ -0,0 +1,228 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces the secrets in the printed configuration.
+const redacted = "[REDACTED]"
+
+// Options selects the optional sources of the configuration.
+type Options struct {
+	// File is the path of the YAML configuration file. When empty, the CONFIG_FILE
+	// environment variable is used; without either no file is read.
+	File string
+	// EnvFile is the path of the .env file whose variables are added to the
+	// environment, without overriding the variables already set. A missing
+	// file is ignored.
+	EnvFile string
+}
+
+// setting is a single setting of the configuration, along with its YAML path, its
+// environment variable and how it is redacted.
+type setting struct {
+	path   string
+	key    string
+	env    string
+	secret string
+	value  reflect.Value
+}
+
+// Load builds the configuration from the defaults, the YAML file, and the
+// environment, and validates it. The returned error lists every invalid setting.
+func Load(opts Options) (*Config, error) {
+	if opts.EnvFile != "" {
+		if err := godotenv.Load(opts.EnvFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read %s: %w", opts.EnvFile, err)
+		}
+	}
+
+	cfg := Default()
+
+	file := opts.File
+	if file == "" {
+		file = os.Getenv("CONFIG_FILE")
+	}
+	if file != "" {
+		if err := cfg.readFile(file); err != nil {
+			return nil, err
+		}
+	}
+
+	errs := cfg.readEnv()
+	cfg.resolve()
+	errs = append(errs, cfg.validate()...)
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+
+	return &cfg, nil
+}
+
+// readFile overrides the configuration with the settings of the YAML file at path.
+// Unknown keys are rejected, as they are most likely typos.
+func (c *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read configuration file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// readEnv overrides the configuration with the environment variables that are set
+// and not empty, returning an error for each value that cannot be parsed.
+func (c *Config) readEnv() []error {
+	var errs []error
+
+	for _, s := range c.settings() {
+		value, ok := os.LookupEnv(s.env)
+		if !ok || value == "" {
+			continue
+		}
+
+		if err := parseInto(s.value, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s (%s): %w", s.path, s.env, err))
+		}
+	}
+
+	return errs
+}
+
+// parseInto parses value into the setting v according to its type.
+func parseInto(v reflect.Value, value string) error {
+	switch v.Interface().(type) {
+	case string:
+		v.SetString(value)
+	case int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("must be an integer, got %q", value)
+		}
+		v.SetInt(int64(n))
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("must be true or false, got %q", value)
+		}
+		v.SetBool(b)
+	case time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("must be a duration such as 30s or 5m, got %q", value)
+		}
+		v.SetInt(int64(d))
+	case time.Time:
+		t, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return fmt.Errorf("must be a date such as 2006-01-02, got %q", value)
+		}
+		v.Set(reflect.ValueOf(t))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+
+	return nil
+}
+
+// settings lists every setting of the configuration, in declaration order.
+func (c *Config) settings() []setting {
+	var settings []setting
+
+	root := reflect.ValueOf(c).Elem()
+	for i := range root.NumField() {
+		section, sectionKey := root.Field(i), root.Type().Field(i).Tag.Get("yaml")
+
+		for j := range section.NumField() {
+			field := section.Type().Field(j)
+			key := field.Tag.Get("yaml")
+			settings = append(settings, setting{
+				path:   sectionKey + "." + key,
+				key:    key,
+				env:    field.Tag.Get("env"),
+				secret: field.Tag.Get("secret"),
+				value:  section.Field(j),
+			})
+		}
+	}
+
+	return settings
+}
+
+// envOf returns the environment variable of the setting at path.
+func (c *Config) envOf(path string) string {
+	for _, s := range c.settings() {
+		if s.path == path {
+			return s.env
+		}
+	}
+
+	return ""
+}
+
+// WriteYAML writes the effective configuration to w as YAML, each setting commented
+// with its environment variable. Secrets are redacted, and the password of the
+// database URL hidden.
+func (c *Config) WriteYAML(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	var section *yaml.Node
+
+	for _, s := range c.settings() {
+		sectionKey, _, _ := strings.Cut(s.path, ".")
+		if section == nil || root.Content[len(root.Content)-2].Value != sectionKey {
+			section = &yaml.Node{Kind: yaml.MappingNode}
+			root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: sectionKey}, section)
+		}
+
+		var value any = s.value.Interface()
+		switch v := value.(type) {
+		case time.Duration:
+			value = v.String()
+		case time.Time:
+			value = v.Format(time.DateOnly)
+		case string:
+			switch {
+			case v == "":
+			case s.secret == "url":
+				value = redactURL(v)
+			case s.secret != "":
+				value = redacted
+			}
+		}
+
+		node := &yaml.Node{}
+		if err := node.Encode(value); err != nil {
+			return err
+		}
+		node.LineComment = s.env
+
+		section.Content = append(section.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: s.key}, node)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
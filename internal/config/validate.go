@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	logLevels         = []string{"debug", "info", "warn", "error"}
+	logFormats        = []string{"json", "text"}
+	traceExporters    = []string{"none", "stdout", "otlp"}
+	sslModes          = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	idempotencyStores = []string{"postgres", "memory"}
+)
+
+// validator collects the invalid settings of a configuration.
+type validator struct {
+	cfg  *Config
+	errs []error
+}
+
+// fail records that the setting at path is invalid.
+func (v *validator) fail(path string, format string, args ...any) {
+	v.errs = append(v.errs, fmt.Errorf("%s (%s): %s", path, v.cfg.envOf(path), fmt.Sprintf(format, args...)))
+}
+
+func (v *validator) port(path string, port int) {
+	if port < 1 || port > 65535 {
+		v.fail(path, "must be a port between 1 and 65535, got %d", port)
+	}
+}
+
+func (v *validator) nonNegative(path string, d time.Duration) {
+	if d < 0 {
+		v.fail(path, "must not be negative, got %s", d)
+	}
+}
+
+func (v *validator) positive(path string, d time.Duration) {
+	if d <= 0 {
+		v.fail(path, "must be positive, got %s", d)
+	}
+}
+
+func (v *validator) atLeast(path string, n int, min int) {
+	if n < min {
+		v.fail(path, "must be at least %d, got %d", min, n)
+	}
+}
+
+func (v *validator) oneOf(path string, value string, allowed []string) {
+	if !slices.Contains(allowed, strings.ToLower(value)) {
+		v.fail(path, "must be one of %s, got %q", strings.Join(allowed, ", "), value)
+	}
+}
+
+func (v *validator) required(path string, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.fail(path, "is required")
+	}
+}
+
+func (v *validator) absoluteURL(path string, value string, schemes ...string) {
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" || !slices.Contains(schemes, u.Scheme) {
+		v.fail(path, "must be an absolute %s URL, got %q", strings.Join(schemes, " or "), value)
+	}
+}
+
+// validate returns an error for each invalid setting of the configuration.
+func (c *Config) validate() []error {
+	v := &validator{cfg: c}
+
+	v.port("server.port", c.Server.Port)
+	v.nonNegative("server.read_timeout", c.Server.ReadTimeout)
+	v.nonNegative("server.read_header_timeout", c.Server.ReadHeaderTimeout)
+	v.nonNegative("server.write_timeout", c.Server.WriteTimeout)
+	v.nonNegative("server.idle_timeout", c.Server.IdleTimeout)
+	v.nonNegative("server.shutdown_delay", c.Server.ShutdownDelay)
+	v.positive("server.shutdown_timeout", c.Server.ShutdownTimeout)
+
+	if c.Database.URL != "" {
+		if u, err := url.Parse(c.Database.URL); err != nil || u.Host == "" || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+			v.fail("database.url", "must be an absolute postgres or postgresql URL, got %q", redactURL(c.Database.URL))
+		}
+	} else {
+		v.required("database.host", c.Database.Host)
+		v.port("database.port", c.Database.Port)
+		v.required("database.user", c.Database.User)
+		v.required("database.name", c.Database.Name)
+		v.oneOf("database.sslmode", c.Database.SSLMode, sslModes)
+	}
+	v.nonNegative("database.slow_query_threshold", c.Database.SlowQueryThreshold)
+
+	v.oneOf("log.level", c.Log.Level, logLevels)
+	v.oneOf("log.format", c.Log.Format, logFormats)
+	v.oneOf("tracing.exporter", c.Tracing.Exporter, traceExporters)
+
+	v.positive("auth.access_ttl", c.Auth.AccessTTL)
+	v.positive("auth.refresh_ttl", c.Auth.RefreshTTL)
+	if c.Auth.RefreshTTL > 0 && c.Auth.RefreshTTL <= c.Auth.AccessTTL {
+		v.fail("auth.refresh_ttl", "must be longer than the access token TTL %s, got %s", c.Auth.AccessTTL, c.Auth.RefreshTTL)
+	}
+
+	if c.OIDC.Enabled() {
+		v.absoluteURL("oidc.issuer", c.OIDC.Issuer, "https", "http")
+		v.required("oidc.client_id", c.OIDC.ClientID)
+		v.absoluteURL("oidc.redirect_url", c.OIDC.RedirectURL, "https", "http")
+	}
+
+	if _, err := mail.ParseAddress(c.Mail.From); err != nil {
+		v.fail("mail.from", "must be an email address such as \"GoToStudy <no-reply@example.com>\", got %q", c.Mail.From)
+	}
+	if c.Mail.SMTPHost != "" {
+		v.port("mail.smtp_port", c.Mail.SMTPPort)
+	}
+
+	v.absoluteURL("links.base_url", c.Links.BaseURL, "https", "http")
+	v.absoluteURL("links.password_reset_url", c.Links.PasswordResetURL, "https", "http")
+
+	v.positive("health.check_timeout", c.Health.CheckTimeout)
+
+	v.oneOf("idempotency.store", c.Idempotency.Store, idempotencyStores)
+	v.positive("idempotency.ttl", c.Idempotency.TTL)
+	v.positive("idempotency.lease", c.Idempotency.Lease)
+
+	v.atLeast("events.replay_size", c.Events.ReplaySize, 0)
+	v.atLeast("events.buffer_size", c.Events.BufferSize, 1)
+	v.positive("events.heartbeat", c.Events.Heartbeat)
+
+	v.positive("outbox.poll_interval", c.Outbox.PollInterval)
+	v.atLeast("outbox.batch_size", c.Outbox.BatchSize, 1)
+	v.positive("outbox.lease", c.Outbox.Lease)
+	v.positive("outbox.retry_backoff", c.Outbox.RetryBackoff)
+	if c.Outbox.MaxRetryBackoff < c.Outbox.RetryBackoff {
+		v.fail("outbox.max_retry_backoff", "must not be shorter than the retry backoff %s, got %s", c.Outbox.RetryBackoff, c.Outbox.MaxRetryBackoff)
+	}
+	v.positive("outbox.retention", c.Outbox.Retention)
+	if c.Outbox.WebhookURL != "" {
+		v.absoluteURL("outbox.webhook_url", c.Outbox.WebhookURL, "https", "http")
+		v.positive("outbox.webhook_timeout", c.Outbox.WebhookTimeout)
+	}
+
+	return v.errs
+}
@@ -1,64 +1,326 @@
 package server
 
 import (
-	"log"
-	"os"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
 
 	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/controllers"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/middleware"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/openapi"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
 	"github.com/fabianoflorentino/gotostudy/internal/app"
+	"github.com/fabianoflorentino/gotostudy/internal/config"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
 )
 
-// StartHTTPServer initializes a new Gin HTTP server with the specified configuration.
-// It sets the server to run in release mode, configures trusted proxies,
-// and sets up the router with the provided controller.
-func StartHTTPServer(container *app.AppContainer) {
-	r := gin.Default()
+// v1Prefix is the path prefix of version 1 of the API.
+const v1Prefix = "/api/v1"
+
+// legacyDeprecation is the date the routes of the API moved under /api/v1, since
+// which their aliases at the root are deprecated.
+var legacyDeprecation = time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+
+// StartHTTPServer initializes a new Gin HTTP server with the specified configuration
+// and serves the routes of newRouter until ctx is done, then shuts it down with
+// serve. The event streams end when the server shuts down. It returns an error when
+// the server cannot start or does not stop within the shutdown timeout.
+func StartHTTPServer(ctx context.Context, container *app.AppContainer, cfg config.ServerConfig) error {
+	r, err := newRouter(container, cfg)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              cfg.Addr(),
+		Handler:           r,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+	srv.RegisterOnShutdown(container.EventBus.Close)
+
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to start HTTP server: %w", err)
+	}
+
+	return serve(ctx, srv, ln, container, cfg)
+}
+
+// newRouter sets up the middlewares and the routes of the API. Every request is
+// logged, traced and measured, and its errors are answered as problem+json. When the
+// server validates the requests against the OpenAPI document, the responses are
+// validated too in the test mode of Gin.
+func newRouter(container *app.AppContainer, cfg config.ServerConfig) (*gin.Engine, error) {
+	r := gin.New()
+	r.ContextWithFallback = true
+	r.Use(
+		middleware.RequestLogger(slog.Default()),
+		middleware.Tracing(otel.GetTracerProvider(), otel.GetTextMapPropagator()),
+		middleware.Recovery(),
+	)
+
+	metrics, err := middleware.Metrics(container.Metrics)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register HTTP metrics: %w", err)
+	}
+	r.Use(metrics)
+
+	if cfg.ValidateRequests {
+		doc, err := openapi.Load()
+		if err != nil {
+			return nil, err
+		}
+		validate, err := middleware.OpenAPI(doc, gin.Mode() == gin.TestMode)
+		if err != nil {
+			return nil, err
+		}
+		r.Use(validate)
+	}
+
+	r.Use(middleware.Problems())
+	r.NoRoute(middleware.NoRoute)
 
 	setTrustedProxies(r)
 
-	registerUserRoutes(r, container)
-	registerTaskRoutes(r, container)
-	registerHealthRoutes(r)
+	registerHealthRoutes(r, container)
+	registerMetricsRoutes(r, container)
+	if err := registerOpenAPIRoutes(r); err != nil {
+		return nil, err
+	}
+
+	registerV1Routes(r.Group(v1Prefix), container)
+	registerV1Routes(r.Group("/", middleware.Deprecated(legacyDeprecation, cfg.LegacySunset, v1Prefix)), container)
 
-	if err := r.Run(":" + os.Getenv("PORT")); err != nil {
-		log.Printf("Failed to start HTTP server: %v", err)
+	return r, nil
+}
+
+// registerV1Routes sets up the routes of version 1 of the API on r. They are served
+// under /api/v1 and, deprecated, at the root where they were first published.
+// They run inside the workspace of the X-Workspace-ID header. Apart from login,
+// single sign-on, token refresh and sign up, they require an access token or an API
+// key; API keys need the tasks:read or tasks:write scope for the task routes and
+// users:admin for the others.
+//
+// Each version registers its own controllers, requests and responses on a group of
+// its own, on the same services of container: a version 2 with different DTOs is a
+// registerV2Routes of its controllers on /api/v2, next to this one.
+func registerV1Routes(r *gin.RouterGroup, container *app.AppContainer) {
+	registerOIDCRoutes(r, container)
+	registerAccountLinkRoutes(r, container)
+
+	api := r.Group("/", middleware.Workspace(container.WorkspaceService))
+	registerAuthRoutes(api, container)
+
+	private := api.Group("/", middleware.Authenticate(container.AuthService, container.APIKeyService), middleware.SelfOrAdmin("id"))
+	users := private.Group("/", middleware.RequireScope(domain.ScopeUsersAdmin, domain.ScopeUsersAdmin))
+	tasks := private.Group("/", middleware.RequireScope(domain.ScopeTasksRead, domain.ScopeTasksWrite))
+
+	registerUserRoutes(api, users, container)
+	registerTaskRoutes(tasks, container)
+	registerEventRoutes(r, container)
+	registerWorkspaceRoutes(users, container)
+	registerAPIKeyRoutes(users, container)
+	registerTwoFactorRoutes(users, container)
+}
+
+// serve runs srv on ln until ctx is done. The readiness probe then starts failing and,
+// after the shutdown delay letting load balancers stop routing new requests, the
+// server stops accepting connections and waits for the in-flight requests, at most
+// for the shutdown timeout.
+func serve(ctx context.Context, srv *http.Server, ln net.Listener, container *app.AppContainer, cfg config.ServerConfig) error {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Serve(ln)
+	}()
+
+	slog.Info("HTTP server started", "addr", ln.Addr().String())
+
+	select {
+	case err := <-errs:
+		return fmt.Errorf("HTTP server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	container.HealthService.BeginShutdown()
+	slog.Info("shutting down HTTP server", "delay", cfg.ShutdownDelay, "timeout", cfg.ShutdownTimeout)
+	time.Sleep(cfg.ShutdownDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to drain HTTP requests: %w", err)
 	}
+
+	return nil
 }
 
 // RegisterUserRoutes sets up the user-related routes for the Gin HTTP server.
 // It registers the routes for creating a user, getting all users, and getting a user by ID.
-func registerUserRoutes(r *gin.Engine, container *app.AppContainer) {
+// Creating a user and asking for the links sent by email are public, every other
+// route requires authentication. Users signing up can only join the default workspace,
+// and can retry with an Idempotency-Key.
+func registerUserRoutes(public *gin.RouterGroup, private *gin.RouterGroup, container *app.AppContainer) {
 	userController := controllers.NewUserController(container.UserService)
+	idempotent := middleware.Idempotency(container.IdempotencyService)
 
-	r.POST("/users", userController.CreateUser)
-	r.GET("/users", userController.GetAllUsers)
-	r.GET("/users/:id", userController.GetUserByID)
-	r.PUT("/users/:id", userController.UpdateUser)
-	r.PATCH("/users/:id", userController.UpdateUserFields)
-	r.DELETE("/users/:id", userController.DeleteUser)
+	public.POST("/users", middleware.DefaultWorkspaceForAnonymous(container.WorkspaceService), idempotent, userController.CreateUser)
+	public.POST("/auth/verify-email/resend", userController.ResendEmailVerification)
+	public.POST("/auth/password-reset", userController.RequestPasswordReset)
+	private.GET("/users", userController.GetAllUsers)
+	private.GET("/users/:id", userController.GetUserByID)
+	private.PUT("/users/:id", userController.UpdateUser)
+	private.PATCH("/users/:id", userController.PatchUser)
+	private.DELETE("/users/:id", userController.DeleteUser)
+}
+
+// RegisterAuthRoutes sets up the public routes used to log in and to refresh tokens.
+func registerAuthRoutes(r *gin.RouterGroup, container *app.AppContainer) {
+	authController := controllers.NewAuthController(container.AuthService)
+
+	r.POST("/auth/login", authController.Login)
+	r.POST("/auth/refresh", authController.Refresh)
+}
+
+// RegisterAccountLinkRoutes sets up the routes of the links sent by email. Their
+// signed token carries the workspace, as links opened from an email cannot send the
+// X-Workspace-ID header.
+func registerAccountLinkRoutes(r *gin.RouterGroup, container *app.AppContainer) {
+	userController := controllers.NewUserController(container.UserService)
+
+	r.GET("/auth/verify-email", userController.VerifyEmail)
+	r.POST("/auth/password-reset/confirm", userController.ResetPassword)
+}
+
+// RegisterOIDCRoutes sets up the single sign-on routes when an OpenID Connect provider
+// is configured. The login runs inside the workspace of the request, while the
+// callback restores it from the login flow, as the provider redirects the browser
+// back without the X-Workspace-ID header, and the second factor from the challenge.
+func registerOIDCRoutes(r *gin.RouterGroup, container *app.AppContainer) {
+	if container.OIDCService == nil {
+		return
+	}
+
+	oidcController := controllers.NewOIDCController(container.OIDCService)
+
+	r.GET("/auth/oidc/login", middleware.Workspace(container.WorkspaceService), oidcController.Login)
+	r.GET("/auth/oidc/callback", oidcController.Callback)
+	r.POST("/auth/oidc/two-factor", oidcController.CompleteTwoFactor)
 }
 
 // RegisterTaskRoutes sets up the task-related routes for the Gin HTTP server.
-func registerTaskRoutes(r *gin.Engine, container *app.AppContainer) {
+// It also registers the routes used to share a task with other users and to apply
+// a batch of operations to the tasks of a user. Creating tasks, alone or in a batch,
+// and sharing a task can be retried with an Idempotency-Key.
+func registerTaskRoutes(r *gin.RouterGroup, container *app.AppContainer) {
 	taskController := controllers.NewTaskController(container.TaskService)
+	idempotent := middleware.Idempotency(container.IdempotencyService)
 
-	r.POST("/users/:id/tasks", taskController.CreateTask)
+	r.POST("/users/:id/tasks", idempotent, taskController.CreateTask)
+	r.POST("/users/:id/tasks/batch", idempotent, taskController.BatchTasks)
 	r.GET("/users/:id/tasks", taskController.FindUserTasks)
 	r.GET("/users/:id/tasks/:task_id", taskController.FindTaskByID)
 	r.PUT("/users/:id/tasks/:task_id", taskController.UpdateTask)
-	// r.PATCH("/tasks/:id", taskController.UpdateTaskFields)
-	// r.DELETE("/tasks/:id", taskController.DeleteTask)
+	r.PATCH("/users/:id/tasks/:task_id", taskController.PatchTask)
+	r.DELETE("/users/:id/tasks/:task_id", taskController.DeleteTask)
+
+	r.POST("/users/:id/tasks/:task_id/shares", idempotent, taskController.ShareTask)
+	r.GET("/users/:id/tasks/:task_id/shares", taskController.FindTaskShares)
+	r.DELETE("/users/:id/tasks/:task_id/shares/:user_id", taskController.UnshareTask)
+}
+
+// RegisterEventRoutes sets up the routes streaming the events of a user, as
+// server-sent events and over a WebSocket. Browsers cannot set headers on these
+// requests, so the access token and the workspace may also be sent as the
+// access_token and workspace query parameters. They need the scopes of the task
+// routes.
+func registerEventRoutes(r *gin.RouterGroup, container *app.AppContainer) {
+	eventController := controllers.NewEventController(container.EventBus, container.EventHeartbeat)
+
+	streams := r.Group("/",
+		middleware.StreamCredentials(),
+		middleware.Workspace(container.WorkspaceService),
+		middleware.Authenticate(container.AuthService, container.APIKeyService),
+		middleware.SelfOrAdmin("id"),
+		middleware.RequireScope(domain.ScopeTasksRead, domain.ScopeTasksWrite),
+	)
+
+	streams.GET("/users/:id/events", eventController.StreamEvents)
+	streams.GET("/users/:id/events/ws", eventController.StreamEventsWebSocket)
+}
+
+// RegisterWorkspaceRoutes sets up the routes managing workspaces and their members.
+// As for tasks, the "id" parameter identifies the user performing the operation.
+// Creating a workspace can be retried with an Idempotency-Key.
+func registerWorkspaceRoutes(r *gin.RouterGroup, container *app.AppContainer) {
+	workspaceController := controllers.NewWorkspaceController(container.WorkspaceService)
+	idempotent := middleware.Idempotency(container.IdempotencyService)
+
+	r.POST("/users/:id/workspaces", idempotent, workspaceController.CreateWorkspace)
+	r.GET("/users/:id/workspaces", workspaceController.FindUserWorkspaces)
+	r.GET("/users/:id/workspaces/:workspace_id/members", workspaceController.FindMembers)
+	r.PUT("/users/:id/workspaces/:workspace_id/members/:user_id", workspaceController.SaveMember)
+	r.DELETE("/users/:id/workspaces/:workspace_id/members/:user_id", workspaceController.RemoveMember)
+}
+
+// RegisterAPIKeyRoutes sets up the routes managing the personal API keys of a user.
+func registerAPIKeyRoutes(r *gin.RouterGroup, container *app.AppContainer) {
+	apiKeyController := controllers.NewAPIKeyController(container.APIKeyService)
+
+	r.POST("/users/:id/api-keys", apiKeyController.CreateAPIKey)
+	r.GET("/users/:id/api-keys", apiKeyController.FindUserAPIKeys)
+	r.DELETE("/users/:id/api-keys/:key_id", apiKeyController.RevokeAPIKey)
+}
+
+// RegisterTwoFactorRoutes sets up the routes enrolling, confirming and resetting the
+// TOTP second factor of a user.
+func registerTwoFactorRoutes(r *gin.RouterGroup, container *app.AppContainer) {
+	twoFactorController := controllers.NewTwoFactorController(container.TwoFactorService)
+
+	r.POST("/users/:id/2fa", twoFactorController.EnrollTwoFactor)
+	r.GET("/users/:id/2fa", twoFactorController.FindTwoFactor)
+	r.GET("/users/:id/2fa/qr.png", twoFactorController.TwoFactorQRCode)
+	r.POST("/users/:id/2fa/confirm", twoFactorController.ConfirmTwoFactor)
+	r.DELETE("/users/:id/2fa", twoFactorController.ResetTwoFactor)
 }
 
-// RegisterHealthRoutes sets up the health check route for the Gin HTTP server.
-// It registers a route to check the health of the application.
-func registerHealthRoutes(r *gin.Engine) {
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status": "ok",
-		})
-	})
+// RegisterHealthRoutes sets up the liveness and readiness probes of the Gin HTTP
+// server. They run outside any workspace and require no authentication.
+func registerHealthRoutes(r *gin.Engine, container *app.AppContainer) {
+	healthController := controllers.NewHealthController(container.HealthService)
+
+	r.GET("/livez", healthController.Livez)
+	r.GET("/readyz", healthController.Readyz)
+}
+
+// RegisterMetricsRoutes exposes the metrics of the application in the Prometheus
+// format on /metrics. Like the health check, it runs outside any workspace and
+// requires no authentication, so it should only be reachable from the network of
+// the monitoring system.
+func registerMetricsRoutes(r *gin.Engine, container *app.AppContainer) {
+	r.GET("/metrics", gin.WrapH(promhttp.HandlerFor(container.Metrics, promhttp.HandlerOpts{Registry: container.Metrics})))
+}
+
+// RegisterOpenAPIRoutes serves the OpenAPI document of the API and the page rendering
+// it. Like the probes, they run outside any workspace and require no authentication.
+func registerOpenAPIRoutes(r *gin.Engine) error {
+	openAPIController, err := controllers.NewOpenAPIController()
+	if err != nil {
+		return err
+	}
+
+	r.GET("/openapi.json", openAPIController.Document)
+	r.GET("/docs", openAPIController.Docs)
+
+	return nil
 }
 
 // SetTrustedProxies configures the trusted proxies for the Gin HTTP server.
@@ -67,6 +329,6 @@ func setTrustedProxies(r *gin.Engine) {
 	trustedProxies := []string{"127.0.0.1", "::1", "192.168.0.0/16", "172.16.0.0/8"}
 
 	if err := r.SetTrustedProxies(trustedProxies); err != nil {
-		log.Printf("Failed to set trusted proxies: %v", err)
+		slog.Error("failed to set trusted proxies", "error", err)
 	}
 }
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/core/services"
+	"github.com/fabianoflorentino/gotostudy/internal/app"
+	"github.com/fabianoflorentino/gotostudy/internal/config"
+	"github.com/google/uuid"
+)
+
+func TestServe(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	health := services.NewHealthService(time.Second)
+	started, release := make(chan struct{}), make(chan struct{})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	})
+
+	cfg := config.Default().Server
+	cfg.ShutdownDelay = 50 * time.Millisecond
+	cfg.ShutdownTimeout = 5 * time.Second
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- serve(ctx, &http.Server{Handler: mux}, ln, &app.AppContainer{HealthService: health}, cfg)
+	}()
+
+	responses := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if err != nil {
+			responses <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		responses <- string(body)
+	}()
+
+	<-started
+	cancel()
+
+	t.Run("FailsReadiness", func(t *testing.T) {
+		deadline := time.Now().Add(time.Second)
+		for !health.Ready(context.Background()).ShuttingDown {
+			if time.Now().After(deadline) {
+				t.Fatal("Expected the readiness probe to fail during shutdown")
+			}
+			time.Sleep(time.Millisecond)
+		}
+	})
+
+	close(release)
+
+	t.Run("DrainsInFlightRequests", func(t *testing.T) {
+		if got := <-responses; got != "done" {
+			t.Errorf("Expected the in-flight request to complete, got %q", got)
+		}
+		if err := <-served; err != nil {
+			t.Errorf("Expected a clean shutdown, got: %v", err)
+		}
+	})
+}
+
+func TestTaskBatchRoute(t *testing.T) {
+	r := newTestRouter(t)
+	user := "/api/v1/users/" + uuid.NewString()
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"operations":[]}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := serve(user + "/tasks/batch"); w.Code == http.StatusNotFound {
+		t.Errorf("POST /tasks/batch status = %d, want it routed", w.Code)
+	}
+
+	for _, suffix := range []string{"/tasks:batch", "/tasks:other", "/tasksbatch", "/tasks/other"} {
+		t.Run(suffix, func(t *testing.T) {
+			if w := serve(user + suffix); w.Code != http.StatusNotFound {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+			}
+		})
+	}
+}
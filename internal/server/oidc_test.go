@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/adapters/outbound/oidc"
+	"github.com/fabianoflorentino/gotostudy/adapters/outbound/security"
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+	"github.com/fabianoflorentino/gotostudy/core/services"
+	"github.com/fabianoflorentino/gotostudy/internal/app"
+	"github.com/fabianoflorentino/gotostudy/internal/oidctest"
+	"github.com/google/uuid"
+)
+
+// provisioningUserRepository has no users and accepts the provisioned ones.
+type provisioningUserRepository struct {
+	ports.UserRepository
+	saved []*domain.User
+}
+
+func (r *provisioningUserRepository) FindByEmail(context.Context, string) (*domain.User, error) {
+	return nil, core.ErrUserNotFound
+}
+
+func (r *provisioningUserRepository) FindByEmailInAnyWorkspace(context.Context, string) (*domain.User, error) {
+	return nil, core.ErrUserNotFound
+}
+
+func (r *provisioningUserRepository) Save(_ context.Context, user *domain.User) error {
+	r.saved = append(r.saved, user)
+	return nil
+}
+
+// noTwoFactorRepository has no second factor enabled.
+type noTwoFactorRepository struct {
+	ports.TwoFactorRepository
+}
+
+func (noTwoFactorRepository) FindByUserID(context.Context, uuid.UUID) (*domain.TwoFactor, error) {
+	return nil, core.ErrTwoFactorNotFound
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) UserRegistered(context.Context, string) {}
+func (noopMetrics) TaskCreated(context.Context)            {}
+func (noopMetrics) TaskCompleted(context.Context)          {}
+
+type inlineTransactor struct{}
+
+func (inlineTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type discardOutbox struct {
+	ports.Outbox
+}
+
+func (discardOutbox) Append(context.Context, ...domain.DomainEvent) error { return nil }
+
+// TestOIDCLogin runs the single sign-on of each version of the API against the
+// provider of oidctest, with the cookies kept as a browser would.
+func TestOIDCLogin(t *testing.T) {
+	const host = "http://gotostudy.test"
+
+	idp := oidctest.NewProvider("gotostudy", "secret")
+	defer idp.Close()
+
+	for _, prefix := range []string{v1Prefix, ""} {
+		t.Run("prefix="+prefix, func(t *testing.T) {
+			provider, err := oidc.NewProvider(context.Background(), oidc.Config{
+				Issuer:       idp.Issuer(),
+				ClientID:     "gotostudy",
+				ClientSecret: "secret",
+				RedirectURL:  host + prefix + "/auth/oidc/callback",
+			})
+			if err != nil {
+				t.Fatalf("failed to discover the provider: %v", err)
+			}
+
+			users := &provisioningUserRepository{}
+			tokens := security.NewJWTIssuer([]byte("secret"), time.Minute, time.Hour)
+			infra := services.Infrastructure{Metrics: noopMetrics{}, Tx: inlineTransactor{}, Outbox: discardOutbox{}}
+			twoFactor := services.NewTwoFactorService(noTwoFactorRepository{}, users, nil, nil)
+
+			r := newTestRouter(t, func(container *app.AppContainer) {
+				container.OIDCService = services.NewOIDCService(provider, users, tokens, tokens, twoFactor, infra)
+			})
+
+			jar, err := cookiejar.New(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			get := func(target string) *httptest.ResponseRecorder {
+				u, _ := url.Parse(target)
+				req := httptest.NewRequest(http.MethodGet, target, nil)
+				for _, cookie := range jar.Cookies(u) {
+					req.AddCookie(cookie)
+				}
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				jar.SetCookies(u, w.Result().Cookies())
+				return w
+			}
+
+			login := get(host + prefix + "/auth/oidc/login")
+			if login.Code != http.StatusFound {
+				t.Fatalf("login status = %d, want %d: %s", login.Code, http.StatusFound, login.Body)
+			}
+
+			client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			}}
+			resp, err := client.Get(login.Header().Get("Location"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			callback := get(resp.Header.Get("Location"))
+			if callback.Code != http.StatusOK {
+				t.Fatalf("callback status = %d, want %d: %s", callback.Code, http.StatusOK, callback.Body)
+			}
+
+			var body struct {
+				AccessToken string `json:"access_token"`
+			}
+			if err := json.Unmarshal(callback.Body.Bytes(), &body); err != nil || body.AccessToken == "" {
+				t.Errorf("callback body = %s, want the tokens", callback.Body)
+			}
+			if len(users.saved) != 1 {
+				t.Errorf("provisioned %d users, want 1", len(users.saved))
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/openapi"
+	"github.com/fabianoflorentino/gotostudy/adapters/inbound/http/problems"
+	"github.com/fabianoflorentino/gotostudy/adapters/outbound/persistence/memory"
+	"github.com/fabianoflorentino/gotostudy/core"
+	"github.com/fabianoflorentino/gotostudy/core/domain"
+	"github.com/fabianoflorentino/gotostudy/core/ports"
+	"github.com/fabianoflorentino/gotostudy/core/services"
+	"github.com/fabianoflorentino/gotostudy/internal/app"
+	"github.com/fabianoflorentino/gotostudy/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// defaultWorkspaceRepository only finds the default workspace, for the requests
+// resolving the workspace of the request.
+type defaultWorkspaceRepository struct {
+	ports.WorkspaceRepository
+}
+
+// defaultWorkspaceID is the ID of the default workspace of defaultWorkspaceRepository.
+var defaultWorkspaceID = uuid.New()
+
+func (defaultWorkspaceRepository) FindBySlug(_ context.Context, slug string) (*domain.Workspace, error) {
+	if slug != domain.DefaultWorkspaceSlug {
+		return nil, core.ErrWorkspaceNotFound
+	}
+
+	return &domain.Workspace{ID: defaultWorkspaceID, Name: "Default", Slug: slug}, nil
+}
+
+// newTestRouter builds the router of the API on services without dependencies, for
+// the requests that do not reach them, after configure changed the container. The
+// requests and the responses are validated against the OpenAPI document.
+func newTestRouter(t *testing.T, configure ...func(*app.AppContainer)) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	container := &app.AppContainer{
+		Metrics:            prometheus.NewRegistry(),
+		HealthService:      services.NewHealthService(time.Second),
+		OIDCService:        &services.OIDCService{},
+		WorkspaceService:   services.NewWorkspaceService(defaultWorkspaceRepository{}, nil),
+		IdempotencyService: services.NewIdempotencyService(memory.NewIdempotencyStore(), time.Hour, time.Minute),
+	}
+	for _, fn := range configure {
+		fn(container)
+	}
+
+	cfg := config.Default().Server
+	cfg.ValidateRequests = true
+
+	r, err := newRouter(container, cfg)
+	if err != nil {
+		t.Fatalf("newRouter() error = %v", err)
+	}
+
+	return r
+}
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	param := regexp.MustCompile(`/:(\w+)`)
+	routes := map[string]bool{}
+	for _, route := range newTestRouter(t).Routes() {
+		path := param.ReplaceAllString(route.Path, "/{$1}")
+		routes[route.Method+" "+path] = true
+
+		// The deprecated aliases of the routes of /api/v1 are not documented.
+		if item := doc.Paths.Find(v1Prefix + path); item != nil && item.GetOperation(route.Method) != nil {
+			continue
+		}
+
+		item := doc.Paths.Find(path)
+		if item == nil || item.GetOperation(route.Method) == nil {
+			t.Errorf("%s %s is not documented in openapi.yaml", route.Method, path)
+		}
+	}
+
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			if !routes[method+" "+path] {
+				t.Errorf("openapi.yaml documents %s %s, which is not routed", method, path)
+			}
+		}
+	}
+}
+
+func TestOpenAPIValidation(t *testing.T) {
+	r := newTestRouter(t)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("documented responses", func(t *testing.T) {
+		for _, path := range []string{"/livez", "/readyz", "/metrics", "/openapi.json", "/docs"} {
+			if w := serve(http.MethodGet, path, ""); w.Code != http.StatusOK {
+				t.Errorf("GET %s = %d, want 200: %s", path, w.Code, w.Body)
+			}
+		}
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		w := serve(http.MethodPost, "/api/v1/users", `{"username":"ana","password":"short","id":"42"}`)
+
+		var p problems.Problem
+		json.Unmarshal(w.Body.Bytes(), &p)
+
+		if w.Code != http.StatusUnprocessableEntity || p.Type != problems.TypeBaseURI+"validation-failed" {
+			t.Fatalf("status = %d, body = %s, want 422 validation-failed", w.Code, w.Body)
+		}
+
+		got := map[string]string{}
+		for _, fe := range p.Errors {
+			got[fe.Field] = fe.Code
+		}
+		for field, code := range map[string]string{"email": "required", "password": "min", "id": "unknown"} {
+			if got[field] != code {
+				t.Errorf("field errors = %v, want %s %s", got, field, code)
+			}
+		}
+	})
+
+	t.Run("invalid batch", func(t *testing.T) {
+		w := serve(http.MethodPost, "/api/v1/users/"+uuid.NewString()+"/tasks/batch", `{"operations":[{"op":"archive"}]}`)
+
+		var p problems.Problem
+		json.Unmarshal(w.Body.Bytes(), &p)
+
+		if w.Code != http.StatusUnprocessableEntity || len(p.Errors) != 1 || p.Errors[0].Field != "operations[0].op" {
+			t.Errorf("status = %d, body = %s, want 422 for operations[0].op", w.Code, w.Body)
+		}
+	})
+
+	t.Run("unsupported media type", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPatch, "/api/v1/users/"+uuid.NewString(), strings.NewReader("username=ana"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnsupportedMediaType || !strings.Contains(w.Body.String(), "unsupported-media-type") {
+			t.Errorf("status = %d, body = %s, want 415 unsupported-media-type", w.Code, w.Body)
+		}
+	})
+
+	t.Run("invalid parameter", func(t *testing.T) {
+		w := serve(http.MethodGet, "/api/v1/users/42/tasks?scope=everything", "")
+
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "invalid-request") {
+			t.Errorf("status = %d, body = %s, want 400 invalid-request", w.Code, w.Body)
+		}
+	})
+}
+
+func TestLegacyRoutes(t *testing.T) {
+	r := newTestRouter(t)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("deprecated alias", func(t *testing.T) {
+		w := serve("/users")
+
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("status = %d, want 422 as on /api/v1: %s", w.Code, w.Body)
+		}
+		if w.Header().Get("Deprecation") == "" || w.Header().Get("Sunset") == "" {
+			t.Errorf("headers = %v, want Deprecation and Sunset", w.Header())
+		}
+		if got, want := w.Header().Get("Link"), `</api/v1/users>; rel="successor-version"`; got != want {
+			t.Errorf("Link = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("current route", func(t *testing.T) {
+		w := serve("/api/v1/users")
+
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("status = %d, want 422: %s", w.Code, w.Body)
+		}
+		if w.Header().Get("Deprecation") != "" || w.Header().Get("Sunset") != "" {
+			t.Errorf("headers = %v, want no deprecation", w.Header())
+		}
+	})
+}
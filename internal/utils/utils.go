@@ -16,11 +16,12 @@ type EmailChecker interface {
 }
 
 // isEmailInUse checks if the given email is already in use by another user in the repository.
+// Emails are unique across workspaces, so users of every workspace are considered.
 // It excludes the user with the specified excludeID from the check.
 // Returns true if the email is in use by a different user, false otherwise.
 // Returns an error if there is a problem accessing the repository.
 func IsEmailInUse(ports ports.UserRepository, ctx context.Context, email string, excludeID uuid.UUID) (bool, error) {
-	existingUser, err := ports.FindByEmail(ctx, email)
+	existingUser, err := ports.FindByEmailInAnyWorkspace(ctx, email)
 	if err != nil {
 		if errors.Is(err, core.ErrEmailAlreadyExists) {
 			return false, nil